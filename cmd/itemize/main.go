@@ -9,8 +9,10 @@ import (
 
 	"github.com/eshaffer321/itemize/internal/adapters/clients"
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	amazonprovider "github.com/eshaffer321/itemize/internal/adapters/providers/amazon"
 	"github.com/eshaffer321/itemize/internal/application/sync"
 	"github.com/eshaffer321/itemize/internal/cli"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/infrastructure/config"
 	"github.com/eshaffer321/itemize/internal/infrastructure/logging"
 	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
@@ -46,6 +48,39 @@ func main() {
 		return
 	}
 
+	// Handle eval command separately (no provider or Monarch access needed)
+	if command == "eval" {
+		os.Args = append([]string{os.Args[0]}, os.Args[2:]...)
+		cfg := config.LoadOrEnv()
+		flags := cli.ParseEvalFlags()
+		if err := cli.RunEval(cfg, flags); err != nil {
+			log.Fatalf("Eval failed: %v", err)
+		}
+		return
+	}
+
+	// Handle tag-report command separately (reads the local database only)
+	if command == "tag-report" {
+		os.Args = append([]string{os.Args[0]}, os.Args[2:]...)
+		cfg := config.LoadOrEnv()
+		flags := cli.ParseTagReportFlags()
+		if err := cli.RunTagReport(cfg, flags); err != nil {
+			log.Fatalf("Tag report failed: %v", err)
+		}
+		return
+	}
+
+	// Handle reconcile-charges command separately (database and Monarch only)
+	if command == "reconcile-charges" {
+		os.Args = append([]string{os.Args[0]}, os.Args[2:]...)
+		cfg := config.LoadOrEnv()
+		flags := cli.ParseReconcileChargesFlags()
+		if err := cli.RunReconcileCharges(cfg, flags); err != nil {
+			log.Fatalf("Charge reconciliation failed: %v", err)
+		}
+		return
+	}
+
 	flush := telemetry.Init()
 	defer flush()
 
@@ -75,9 +110,24 @@ func main() {
 	if providerName != "amazon" && len(flags.ExtraArgs) > 0 {
 		log.Fatalf("Unexpected positional arguments are only supported for the amazon provider")
 	}
+	if providerName != "walmart" && (flags.Receipt != "" || flags.TCNumber != "" || flags.StoreID != "" || flags.ReceiptDate != "") {
+		log.Fatalf("-receipt, -tc, -store and -receipt-date are only supported for the walmart provider")
+	}
+
+	if flags.AllAccounts {
+		if providerName != "amazon" {
+			log.Fatalf("-all-accounts is only supported for the amazon provider")
+		}
+		if amazonSetup || flags.ListAccounts || flags.ImportBrowserProfile != "" {
+			log.Fatalf("-all-accounts only applies to syncs and returns")
+		}
+		if flags.Account != "" || flags.CookieFile != "" || len(flags.ExtraArgs) > 0 {
+			log.Fatalf("-all-accounts reads every saved account; omit -account, -cookie-file and account arguments")
+		}
+	}
 
 	amazonAccount := ""
-	if providerName == "amazon" {
+	if providerName == "amazon" && !flags.AllAccounts {
 		if amazonSetup {
 			amazonAccount, err = cli.ResolveAmazonSetupAccount(flags.Account, flags.ExtraArgs)
 		} else {
@@ -131,7 +181,15 @@ func main() {
 	}
 
 	if amazonReturns {
-		provider, createErr := cli.NewAmazonProvider(cfg, flags.Verbose, amazonAccount)
+		var provider interface {
+			FetchReturns(ctx context.Context) ([]amazonprovider.ReturnRecord, error)
+		}
+		var createErr error
+		if flags.AllAccounts {
+			provider, createErr = cli.NewAmazonAccountsProvider(cfg, flags.Verbose)
+		} else {
+			provider, createErr = cli.NewAmazonProvider(cfg, flags.Verbose, amazonAccount)
+		}
 		if createErr != nil {
 			log.Fatalf("Failed to create Amazon provider: %v", createErr)
 		}
@@ -179,6 +237,11 @@ func main() {
 	}
 	defer func() { _ = store.Close() }()
 
+	if err := cli.EnableHistoryCategorizer(cfg, serviceClients, store, logging.NewLoggerWithSystem(cfg.Observability.Logging, "categorizer")); err != nil {
+		telemetry.CaptureError(err, providerName, "init")
+		log.Fatalf("Failed to initialize history categorizer: %v", err)
+	}
+
 	ctx := context.Background()
 
 	// Create provider based on subcommand
@@ -187,9 +250,17 @@ func main() {
 	case "costco":
 		provider, err = cli.NewCostcoProvider(cfg, flags.Verbose)
 	case "walmart":
-		provider, err = cli.NewWalmartProvider(cfg, flags.Verbose)
+		if flags.Receipt != "" || flags.TCNumber != "" || flags.StoreID != "" || flags.ReceiptDate != "" {
+			provider, err = cli.NewWalmartReceiptProvider(cfg, flags)
+		} else {
+			provider, err = cli.NewWalmartProvider(cfg, flags.Verbose)
+		}
 	case "amazon":
-		provider, err = cli.NewAmazonProvider(cfg, flags.Verbose, amazonAccount)
+		if flags.AllAccounts {
+			provider, err = cli.NewAmazonAccountsProvider(cfg, flags.Verbose)
+		} else {
+			provider, err = cli.NewAmazonProvider(cfg, flags.Verbose, amazonAccount)
+		}
 	default:
 		fmt.Printf("Unknown provider: %s\n", providerName)
 		printUsage()
@@ -210,7 +281,9 @@ func main() {
 	// Print configuration (shows the resolved Amazon account, if any, so it's
 	// obvious which profile is in use without digging through env vars)
 	resolvedAccount := ""
-	if providerName == "amazon" {
+	if accounts, ok := provider.(*amazonprovider.MultiAccountProvider); ok {
+		resolvedAccount = strings.Join(accounts.Accounts(), ", ")
+	} else if providerName == "amazon" {
 		resolvedAccount = amazonAccount
 		if resolvedAccount == "" {
 			resolvedAccount = "default"
@@ -220,6 +293,14 @@ func main() {
 
 	// Create orchestrator with sync-scoped logger and run
 	opts := flags.ToSyncOptions()
+	opts.MinConfidence = cfg.Categorizer.MinConfidence
+	opts.FallbackCategory = cfg.Categorizer.FallbackCategory
+	opts.CategoryGroups = categorizer.GroupFilter{Allow: cfg.Categorizer.Groups.Allow, Deny: cfg.Categorizer.Groups.Deny}
+	opts.MultiChargeMode = cfg.Providers.MultiChargeMode(providerName)
+	merchantFilter := cfg.Providers.MerchantFilter(providerName)
+	opts.MerchantAliases = merchantFilter.MerchantAliases
+	opts.MonarchAccounts = merchantFilter.MonarchAccounts
+	opts.CardAccounts = cfg.Monarch.CardAccounts
 	loggingCfg := cfg.Observability.Logging
 	if flags.Verbose {
 		loggingCfg.Level = "debug"
@@ -250,12 +331,44 @@ func printUsage() {
 	fmt.Println("              Read Amazon's return/refund ledger as JSON")
 	fmt.Println("  costco      Sync Costco orders")
 	fmt.Println("  walmart     Sync Walmart orders")
+	fmt.Println("  eval        Measure categorization accuracy on a labeled dataset")
+	fmt.Println("  tag-report  List tagged items (default HSA/FSA) with order proof")
+	fmt.Println("  reconcile-charges")
+	fmt.Println("              Retry matching unmatched ledger charges and report ones that never posted")
 	fmt.Println("  version     Print version, commit, and build date (also: -version, --version)")
 	fmt.Println()
 	fmt.Println("Serve Flags:")
 	fmt.Println("  -port int        Port to listen on (default 8080)")
 	fmt.Println("  -verbose         Verbose output")
 	fmt.Println()
+	fmt.Println("Eval Flags:")
+	fmt.Println("  -dataset string  Labeled YAML dataset (item -> expected category)")
+	fmt.Println("  -export string   Export a dataset from categorized orders in the database and exit")
+	fmt.Println("  -backend string  LLM backend: openai or anthropic")
+	fmt.Println("  -model string    Model to evaluate")
+	fmt.Println("  -prompt string   Prompt template file to evaluate")
+	fmt.Println("  -label string    Note stored with the run")
+	fmt.Println("  -input-price, -output-price float")
+	fmt.Println("                  USD per million tokens, for cost estimates")
+	fmt.Println("  -history         List stored eval runs and exit")
+	fmt.Println()
+	fmt.Println("Tag Report Flags:")
+	fmt.Println("  -tag string      Tag to report on (default HSA/FSA)")
+	fmt.Println("  -year int        Calendar year to report")
+	fmt.Println("  -from, -to string")
+	fmt.Println("                  Order date range (YYYY-MM-DD, inclusive)")
+	fmt.Println("  -csv             Write CSV instead of a table")
+	fmt.Println("  -out string      Write the report to a file")
+	fmt.Println()
+	fmt.Println("Reconcile Charges Flags:")
+	fmt.Println("  -provider string Only reconcile this provider's charges")
+	fmt.Println("  -stale-days int  Only retry charges at least this many days old (default 3)")
+	fmt.Println("  -never-posted-days int")
+	fmt.Println("                  Report charges unmatched after this many days as never posted (default 14)")
+	fmt.Println("  -limit int       Maximum charges to check (default 500)")
+	fmt.Println("  -dry-run         Report matches without recording them")
+	fmt.Println("  -verbose         Verbose output")
+	fmt.Println()
 	fmt.Println("Sync Flags:")
 	fmt.Println("  -dry-run         Run without making changes")
 	fmt.Println("  -days int        Number of days to look back (default 14)")
@@ -267,6 +380,10 @@ func printUsage() {
 	fmt.Println("  -cookie-file string")
 	fmt.Println("                  Explicit Amazon cookie file (amazon only)")
 	fmt.Println("  -list-accounts   List saved Amazon cookie accounts and exit (amazon only)")
+	fmt.Println("  -all-accounts    Sync every saved Amazon cookie account in one run (amazon only)")
+	fmt.Println("  -receipt string  Sync in-store receipts from a file or directory (walmart only)")
+	fmt.Println("  -tc, -store, -receipt-date string")
+	fmt.Println("                  Pick the receipt with this TC number, store and date (walmart only)")
 	fmt.Println()
 	fmt.Println("Advanced Amazon Authentication:")
 	fmt.Println("  -import-browser-profile string")
@@ -111,6 +111,10 @@ type messagesResponse struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
 }
 
 // buildMessagesRequest translates a ChatCompletionRequest into Anthropic's
@@ -172,9 +176,16 @@ func parseMessagesResponse(body []byte, prefilled bool) (*categorizer.ChatComple
 		text = "{" + text
 	}
 
-	return &categorizer.ChatCompletionResponse{
+	out := &categorizer.ChatCompletionResponse{
 		Choices: []categorizer.Choice{
 			{Message: categorizer.Message{Role: "assistant", Content: text}},
 		},
-	}, nil
+	}
+	if resp.Usage != nil {
+		out.Usage = &categorizer.Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+		}
+	}
+	return out, nil
 }
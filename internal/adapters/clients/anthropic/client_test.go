@@ -92,7 +92,7 @@ func TestCreateChatCompletion_Success(t *testing.T) {
 		assert.Equal(t, defaultMaxTokens, body.MaxTokens)
 
 		w.Header().Set("Content-Type", "application/json")
-		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"hello back"}]}`)
+		_, _ = io.WriteString(w, `{"content":[{"type":"text","text":"hello back"}],"usage":{"input_tokens":12,"output_tokens":3}}`)
 	}))
 	defer srv.Close()
 
@@ -106,6 +106,9 @@ func TestCreateChatCompletion_Success(t *testing.T) {
 	require.NotNil(t, resp)
 	require.Len(t, resp.Choices, 1)
 	assert.Equal(t, "hello back", resp.Choices[0].Message.Content)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 12, resp.Usage.PromptTokens)
+	assert.Equal(t, 3, resp.Usage.CompletionTokens)
 }
 
 func TestCreateChatCompletion_PrefillsAndReassemblesJSON(t *testing.T) {
@@ -3,12 +3,14 @@ package clients
 import (
 	"fmt"
 	"log/slog"
+	"os"
 	"strings"
 
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 	anthropicclient "github.com/eshaffer321/itemize/internal/adapters/clients/anthropic"
 	openaiclient "github.com/eshaffer321/itemize/internal/adapters/clients/openai"
 	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
 	"github.com/eshaffer321/itemize/internal/infrastructure/config"
 )
 
@@ -20,7 +22,14 @@ const (
 
 type Clients struct {
 	Monarch     *monarch.Client
-	Categorizer *categorizer.Categorizer
+	Categorizer *categorizer.Categorizer // LLM categorizer (nil in history-only mode)
+
+	// ItemCategorizer, when set, is used for splitting instead of Categorizer
+	// (e.g. a history-first StagedCategorizer).
+	ItemCategorizer categorizer.ItemCategorizer
+
+	// Tagger assigns Monarch tags to items (nil when no tags are configured)
+	Tagger *tagger.Tagger
 }
 
 func NewClients(cfg *config.Config) (*Clients, error) {
@@ -31,18 +40,110 @@ func NewClients(cfg *config.Config) (*Clients, error) {
 		return nil, err
 	}
 
-	chatClient, model, err := newChatClient(cfg, slog.Default())
+	itemTagger, err := tagger.New(TagRules(cfg))
 	if err != nil {
 		return nil, err
 	}
-	cat := categorizer.NewCategorizer(chatClient, categorizer.NewMemoryCache(), model)
+
+	// History-only mode runs offline, so an LLM key is optional there
+	var cat *categorizer.Categorizer
+	chatClient, model, err := newChatClient(cfg, slog.Default())
+	switch {
+	case err == nil:
+		prompt, promptErr := NewPrompt(cfg)
+		if promptErr != nil {
+			return nil, promptErr
+		}
+		cat = categorizer.NewCategorizer(chatClient, categorizer.NewMemoryCache(), model)
+		cat.SetPrompt(prompt)
+	case !cfg.Categorizer.History.Standalone():
+		return nil, err
+	}
 
 	return &Clients{
 		Monarch:     mClient,
 		Categorizer: cat,
+		Tagger:      itemTagger,
 	}, nil
 }
 
+// NewChatClient returns the configured LLM backend and model without the
+// Monarch client, for commands that only need the categorizer (e.g. eval).
+func NewChatClient(cfg *config.Config) (categorizer.ChatClient, string, error) {
+	return newChatClient(cfg, slog.Default())
+}
+
+// NewPrompt builds the categorization prompt from cfg.Categorizer.Prompt,
+// reading the template file when one is configured.
+func NewPrompt(cfg *config.Config) (*categorizer.Prompt, error) {
+	promptCfg := cfg.Categorizer.Prompt
+
+	template := promptCfg.Template
+	if promptCfg.TemplateFile != "" {
+		data, err := os.ReadFile(promptCfg.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("read prompt template: %w", err)
+		}
+		template = string(data)
+	}
+
+	categories := make(map[string]categorizer.CategoryGuidance, len(promptCfg.Categories))
+	for id, category := range promptCfg.Categories {
+		categories[id] = categorizer.CategoryGuidance{
+			Description: category.Description,
+			Examples:    category.Examples,
+		}
+	}
+
+	// Only tags with a description are offered to the LLM
+	var tags []categorizer.TagGuidance
+	for _, rule := range cfg.Tags {
+		if strings.TrimSpace(rule.Name) != "" && strings.TrimSpace(rule.Description) != "" {
+			tags = append(tags, categorizer.TagGuidance{Name: strings.TrimSpace(rule.Name), Description: rule.Description})
+		}
+	}
+
+	// Unset guidance keeps the built-in rules; an explicit empty one drops them
+	guidance := categorizer.DefaultGuidance
+	if promptCfg.Guidance != nil {
+		guidance = *promptCfg.Guidance
+	}
+
+	return categorizer.NewPrompt(categorizer.PromptConfig{
+		Template:   template,
+		Guidance:   guidance,
+		Categories: categories,
+		Tags:       tags,
+	})
+}
+
+// TagRules converts the configured tags into tagger rules.
+func TagRules(cfg *config.Config) []tagger.Rule {
+	rules := make([]tagger.Rule, 0, len(cfg.Tags))
+	for _, tag := range cfg.Tags {
+		rules = append(rules, tagger.Rule{
+			Tag:         tag.Name,
+			Description: tag.Description,
+			Match:       tag.Match,
+			Pattern:     tag.Pattern,
+			Categories:  tag.Categories,
+		})
+	}
+	return rules
+}
+
+// BackendName reports which LLM backend a ChatClient talks to.
+func BackendName(client categorizer.ChatClient) string {
+	switch client.(type) {
+	case *openaiclient.Client:
+		return providerOpenAI
+	case *anthropicclient.Client:
+		return providerAnthropic
+	default:
+		return "unknown"
+	}
+}
+
 // newChatClient picks the configured LLM backend and returns a ChatClient plus
 // the model string to hand to the categorizer.
 //
@@ -3,6 +3,8 @@ package clients
 import (
 	"io"
 	"log/slog"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +12,7 @@ import (
 
 	anthropicclient "github.com/eshaffer321/itemize/internal/adapters/clients/anthropic"
 	openaiclient "github.com/eshaffer321/itemize/internal/adapters/clients/openai"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/infrastructure/config"
 )
 
@@ -190,3 +193,76 @@ func TestNewChatClient_CaseInsensitiveProvider(t *testing.T) {
 	_, ok := client.(*anthropicclient.Client)
 	assert.True(t, ok)
 }
+
+func TestNewPrompt_FromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prompt.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte(`{{.Guidance}}{{range .Categories}}|{{.Name}}={{.Description}}{{end}}`), 0o600))
+
+	guidance := "Pet food is Pets"
+	cfg := &config.Config{Categorizer: config.CategorizerConfig{Prompt: config.CategorizerPromptConfig{
+		TemplateFile: path,
+		Guidance:     &guidance,
+		Categories: map[string]config.CategoryPromptConfig{
+			"cat_pets": {Description: "dog and cat supplies"},
+		},
+	}}}
+
+	prompt, err := NewPrompt(cfg)
+	require.NoError(t, err)
+
+	text, err := prompt.Render(nil, []categorizer.Category{{ID: "cat_pets", Name: "Pets"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Pet food is Pets|Pets=dog and cat supplies", text)
+	assert.NotEqual(t, categorizer.DefaultPrompt().Hash(), prompt.Hash())
+}
+
+func TestNewPrompt_EmptyGuidanceStaysEmpty(t *testing.T) {
+	unset, err := NewPrompt(&config.Config{})
+	require.NoError(t, err)
+	assert.Equal(t, categorizer.DefaultPrompt().Hash(), unset.Hash(), "no prompt config uses the built-in prompt")
+
+	empty := ""
+	cfg := &config.Config{Categorizer: config.CategorizerConfig{Prompt: config.CategorizerPromptConfig{
+		Guidance: &empty,
+	}}}
+	prompt, err := NewPrompt(cfg)
+	require.NoError(t, err)
+
+	text, err := prompt.Render([]categorizer.Item{{Name: "Kibble", Price: 54}}, []categorizer.Category{{ID: "cat_pets", Name: "Pets"}})
+	require.NoError(t, err)
+	assert.NotContains(t, text, "Household guidance")
+	assert.NotContains(t, text, categorizer.DefaultGuidance)
+	assert.NotEqual(t, categorizer.DefaultPrompt().Hash(), prompt.Hash())
+}
+
+func TestNewPrompt_MissingTemplateFile(t *testing.T) {
+	cfg := &config.Config{Categorizer: config.CategorizerConfig{Prompt: config.CategorizerPromptConfig{
+		TemplateFile: filepath.Join(t.TempDir(), "missing.tmpl"),
+	}}}
+
+	_, err := NewPrompt(cfg)
+	assert.Error(t, err)
+}
+
+func TestNewPrompt_OffersDescribedTags(t *testing.T) {
+	cfg := &config.Config{
+		Categorizer: config.CategorizerConfig{Prompt: config.CategorizerPromptConfig{
+			Template: `{{range .Tags}}{{.Name}}={{.Description}};{{end}}`,
+		}},
+		Tags: []config.TagRuleConfig{
+			{Name: "HSA/FSA", Description: "Eligible medical expenses", Categories: []string{"Medical"}},
+			{Name: "Reimbursable", Match: []string{"toner"}}, // rules only, not offered
+		},
+	}
+
+	prompt, err := NewPrompt(cfg)
+	require.NoError(t, err)
+	text, err := prompt.Render(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "HSA/FSA=Eligible medical expenses;", text)
+
+	rules := TagRules(cfg)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "Reimbursable", rules[1].Tag)
+	assert.Equal(t, []string{"toner"}, rules[1].Match)
+}
@@ -0,0 +1,147 @@
+package amazon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+)
+
+// MultiAccountProvider presents several Amazon cookie accounts as a single
+// provider, so one sync matches every account's orders against one set of
+// Monarch transactions instead of each account claiming charges on its own.
+type MultiAccountProvider struct {
+	logger   *slog.Logger
+	accounts []*Provider
+}
+
+// NewMultiAccountProvider creates a provider that reads each of accounts in turn.
+func NewMultiAccountProvider(logger *slog.Logger, accounts []*Provider) *MultiAccountProvider {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &MultiAccountProvider{
+		logger:   logger.With(slog.String("provider", "amazon")),
+		accounts: accounts,
+	}
+}
+
+// Name returns the provider identifier.
+func (m *MultiAccountProvider) Name() string {
+	return "amazon"
+}
+
+// DisplayName returns the human-readable provider name.
+func (m *MultiAccountProvider) DisplayName() string {
+	return "Amazon"
+}
+
+// Accounts returns the names of the cookie accounts read by this provider.
+func (m *MultiAccountProvider) Accounts() []string {
+	names := make([]string, 0, len(m.accounts))
+	for _, account := range m.accounts {
+		names = append(names, account.profile)
+	}
+	return names
+}
+
+// FetchOrders fetches orders from every account and merges them oldest first.
+// Each order reports its account through GetAccount. An order seen under more
+// than one account is kept once, under the first account that returned it.
+// MaxOrders applies to each account separately.
+func (m *MultiAccountProvider) FetchOrders(ctx context.Context, opts providers.FetchOptions) ([]providers.Order, error) {
+	var orders []providers.Order
+	seen := make(map[string]string)
+	for _, account := range m.accounts {
+		accountOrders, err := account.FetchOrders(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("amazon account %q: %w", account.profile, err)
+		}
+		for _, order := range accountOrders {
+			if first, ok := seen[order.GetID()]; ok {
+				m.logger.Warn("order returned by more than one account; keeping the first",
+					slog.String("order_id", order.GetID()),
+					slog.String("account", first),
+					slog.String("duplicate_account", account.profile))
+				continue
+			}
+			seen[order.GetID()] = account.profile
+			orders = append(orders, order)
+		}
+		m.logger.Info("fetched account orders",
+			slog.String("account", account.profile),
+			slog.Int("count", len(accountOrders)))
+	}
+
+	sort.SliceStable(orders, func(i, j int) bool {
+		return orders[i].GetDate().Before(orders[j].GetDate())
+	})
+	return orders, nil
+}
+
+// GetOrderDetails looks the order up in each account until one returns it.
+func (m *MultiAccountProvider) GetOrderDetails(ctx context.Context, orderID string) (providers.Order, error) {
+	if len(m.accounts) == 0 {
+		return nil, fmt.Errorf("amazon order %q not found: no accounts configured", orderID)
+	}
+	var lastErr error
+	for _, account := range m.accounts {
+		order, err := account.GetOrderDetails(ctx, orderID)
+		if err == nil {
+			return order, nil
+		}
+		lastErr = fmt.Errorf("amazon account %q: %w", account.profile, err)
+	}
+	return nil, lastErr
+}
+
+// FetchReturns reads the Return Center of every account.
+func (m *MultiAccountProvider) FetchReturns(ctx context.Context) ([]ReturnRecord, error) {
+	var returns []ReturnRecord
+	for _, account := range m.accounts {
+		accountReturns, err := account.FetchReturns(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("amazon account %q: %w", account.profile, err)
+		}
+		returns = append(returns, accountReturns...)
+	}
+	return returns, nil
+}
+
+// MerchantSearchTerms returns the merchant names to search for in Monarch.
+func (m *MultiAccountProvider) MerchantSearchTerms() []string {
+	return MerchantSearchTerms()
+}
+
+// SupportsDeliveryTips returns whether Amazon supports delivery tips.
+func (m *MultiAccountProvider) SupportsDeliveryTips() bool {
+	return false
+}
+
+// SupportsRefunds returns whether Amazon supports refund tracking.
+func (m *MultiAccountProvider) SupportsRefunds() bool {
+	return true
+}
+
+// SupportsBulkFetch returns whether Amazon supports bulk order fetching.
+func (m *MultiAccountProvider) SupportsBulkFetch() bool {
+	return true
+}
+
+// GetRateLimit returns the rate limit for API requests.
+func (m *MultiAccountProvider) GetRateLimit() time.Duration {
+	return time.Second
+}
+
+// HealthCheck verifies that every account can authenticate.
+func (m *MultiAccountProvider) HealthCheck(ctx context.Context) error {
+	for _, account := range m.accounts {
+		if err := account.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("amazon account %q: %w", account.profile, err)
+		}
+	}
+	return nil
+}
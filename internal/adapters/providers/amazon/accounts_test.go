@@ -0,0 +1,75 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amazongo "github.com/eshaffer321/amazon-go"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiAccountProvider_FetchOrdersMergesAccounts(t *testing.T) {
+	var _ providers.OrderProvider = (*MultiAccountProvider)(nil)
+
+	day := time.Date(2026, 6, 10, 0, 0, 0, 0, time.UTC)
+	mine := NewProviderWithClient(nil, &ProviderConfig{Profile: "mine"}, &fakeAmazonClient{
+		orders: []*amazongo.Order{
+			{ID: "111-0000000-0000003", Date: day.AddDate(0, 0, 2), Total: 30},
+			{ID: "111-0000000-0000001", Date: day, Total: 10},
+		},
+	})
+	wife := NewProviderWithClient(nil, &ProviderConfig{Profile: "wife"}, &fakeAmazonClient{
+		orders: []*amazongo.Order{
+			{ID: "111-0000000-0000002", Date: day.AddDate(0, 0, 1), Total: 20},
+			{ID: "111-0000000-0000001", Date: day, Total: 10},
+		},
+	})
+	provider := NewMultiAccountProvider(nil, []*Provider{mine, wife})
+
+	orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{IncludeDetails: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mine", "wife"}, provider.Accounts())
+	require.Len(t, orders, 3, "an order seen by two accounts is kept once")
+	ids := make([]string, len(orders))
+	accounts := make([]string, len(orders))
+	for i, order := range orders {
+		ids[i] = order.GetID()
+		accounts[i] = providers.OrderAccount(order)
+	}
+	assert.Equal(t, []string{"111-0000000-0000001", "111-0000000-0000002", "111-0000000-0000003"}, ids)
+	assert.Equal(t, []string{"mine", "wife", "mine"}, accounts)
+}
+
+func TestMultiAccountProvider_FetchOrdersNamesFailingAccount(t *testing.T) {
+	mine := NewProviderWithClient(nil, &ProviderConfig{Profile: "mine"}, &fakeAmazonClient{})
+	wife := NewProviderWithClient(nil, &ProviderConfig{Profile: "wife"}, &fakeAmazonClient{
+		healthErr: errors.New("missing essential cookies"),
+	})
+	provider := NewMultiAccountProvider(nil, []*Provider{mine, wife})
+
+	_, err := provider.FetchOrders(context.Background(), providers.FetchOptions{})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `amazon account "wife"`)
+	assert.Contains(t, err.Error(), "itemize amazon setup -account wife")
+}
+
+func TestMultiAccountProvider_GetOrderDetailsTriesEachAccount(t *testing.T) {
+	mine := NewProviderWithClient(nil, &ProviderConfig{Profile: "mine"}, &fakeAmazonClient{
+		fetchOrderWithTxErr: errors.New("order not found"),
+	})
+	wife := NewProviderWithClient(nil, &ProviderConfig{Profile: "wife"}, &fakeAmazonClient{
+		detailOrder: &amazongo.Order{ID: "111-0000000-0000009", Date: time.Now(), Total: 9},
+	})
+	provider := NewMultiAccountProvider(nil, []*Provider{mine, wife})
+
+	order, err := provider.GetOrderDetails(context.Background(), "111-0000000-0000009")
+
+	require.NoError(t, err)
+	assert.Equal(t, "wife", providers.OrderAccount(order))
+}
@@ -0,0 +1,497 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	amazongo "github.com/eshaffer321/amazon-go"
+)
+
+// Digital orders (Kindle books, Prime Video, apps, music, Audible) are kept in
+// a separate order history from physical orders, and memberships and
+// subscriptions (Prime, Kindle Unlimited, channels) have no order at all:
+// they only appear in the payments history. digitalHistoryClient reads both
+// so they can be synced as ordinary Orders.
+
+const (
+	amazonDigitalOrdersURL  = "https://www.amazon.com/gp/css/order-history?digitalOrders=1&unifiedOrders=0"
+	amazonDigitalSummaryURL = "https://www.amazon.com/gp/digital/your-account/order-summary.html"
+	amazonPaymentsURL       = "https://www.amazon.com/cpe/yourpayments/transactions"
+
+	// SubscriptionOrderPrefix starts the synthetic order ID of a membership
+	// or subscription charge.
+	SubscriptionOrderPrefix = "amazon-subscription:"
+
+	orderHistoryPageSize = 10
+	orderHistoryMaxPages = 20
+)
+
+var (
+	digitalOrderIDPattern     = regexp.MustCompile(`\bD01-\d{7}-\d{7}\b`)
+	digitalOrderPlacedPattern = regexp.MustCompile(`(?i)order placed:?\s+([A-Z][a-z]+\.?\s+\d{1,2},\s+\d{4})`)
+	digitalSubtotalPattern    = regexp.MustCompile(`(?i)item\(s\) subtotal:?\s*\$([\d,]+\.\d{2})`)
+	digitalTaxPattern         = regexp.MustCompile(`(?i)(before\s+)?tax(?: collected)?:?\s*\$([\d,]+\.\d{2})`)
+	digitalTotalPattern       = regexp.MustCompile(`(?i)grand total:?\s*\$([\d,]+\.\d{2})`)
+	digitalASINPattern        = regexp.MustCompile(`/(?:dp|gp/product)/([A-Z0-9]{10})`)
+	digitalSlugPattern        = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// DigitalKind classifies a digital purchase or a recurring Amazon charge. The
+// values are the keys of the Amazon digital_categories setting.
+type DigitalKind string
+
+const (
+	DigitalKindle          DigitalKind = "kindle"
+	DigitalVideo           DigitalKind = "video"
+	DigitalApp             DigitalKind = "app"
+	DigitalMusic           DigitalKind = "music"
+	DigitalAudible         DigitalKind = "audible"
+	DigitalOther           DigitalKind = "digital"
+	DigitalPrimeMembership DigitalKind = "prime_membership"
+	DigitalSubscription    DigitalKind = "subscription"
+)
+
+// digitalKinds lists every kind with the label used in item names, so tag
+// rules can match e.g. "Kindle eBook" or "Amazon subscription".
+var digitalKinds = []struct {
+	kind  DigitalKind
+	label string
+}{
+	{DigitalKindle, "Kindle eBook"},
+	{DigitalVideo, "Prime Video"},
+	{DigitalApp, "Amazon Appstore"},
+	{DigitalMusic, "Digital Music"},
+	{DigitalAudible, "Audible"},
+	{DigitalOther, "Amazon Digital"},
+	{DigitalPrimeMembership, "Amazon Prime membership"},
+	{DigitalSubscription, "Amazon subscription"},
+}
+
+// Label returns the human-readable kind, or "" for physical items.
+func (k DigitalKind) Label() string {
+	for _, known := range digitalKinds {
+		if known.kind == k {
+			return known.label
+		}
+	}
+	return ""
+}
+
+// ParseDigitalKind parses a digital_categories key.
+func ParseDigitalKind(value string) (DigitalKind, error) {
+	kind := DigitalKind(strings.ToLower(strings.TrimSpace(value)))
+	if kind.Label() == "" {
+		names := make([]string, len(digitalKinds))
+		for i, known := range digitalKinds {
+			names[i] = string(known.kind)
+		}
+		return "", fmt.Errorf("unknown Amazon digital kind %q (expected one of %s)", value, strings.Join(names, ", "))
+	}
+	return kind, nil
+}
+
+// digitalItemPhrases map text on a digital order row to the item's kind,
+// checked in order.
+var digitalItemPhrases = []struct {
+	phrase string
+	kind   DigitalKind
+}{
+	{"kindle edition", DigitalKindle},
+	{"kindle ebook", DigitalKindle},
+	{"kindle store", DigitalKindle},
+	{"audible", DigitalAudible},
+	{"audiobook", DigitalAudible},
+	{"prime video", DigitalVideo},
+	{"amazon video", DigitalVideo},
+	{"video on demand", DigitalVideo},
+	{"appstore", DigitalApp},
+	{"amazon coins", DigitalApp},
+	{"digital music", DigitalMusic},
+	{"amazon music", DigitalMusic},
+	{"mp3", DigitalMusic},
+}
+
+// subscriptionPhrases identify membership and subscription charges by the
+// merchant shown in the payments history, checked in order so channel and
+// add-on subscriptions aren't mistaken for Prime itself.
+var subscriptionPhrases = []struct {
+	phrase string
+	kind   DigitalKind
+}{
+	{"prime video channels", DigitalSubscription},
+	{"kindle unlimited", DigitalSubscription},
+	{"music unlimited", DigitalSubscription},
+	{"audible", DigitalSubscription},
+	{"amazon kids", DigitalSubscription},
+	{"subscription", DigitalSubscription},
+	{"prime membership", DigitalPrimeMembership},
+	{"amazon prime", DigitalPrimeMembership},
+}
+
+func classifyDigitalItem(text string) DigitalKind {
+	lower := strings.ToLower(text)
+	for _, candidate := range digitalItemPhrases {
+		if strings.Contains(lower, candidate.phrase) {
+			return candidate.kind
+		}
+	}
+	return DigitalOther
+}
+
+func classifySubscription(merchant string) (DigitalKind, bool) {
+	lower := strings.ToLower(merchant)
+	for _, candidate := range subscriptionPhrases {
+		if strings.Contains(lower, candidate.phrase) {
+			return candidate.kind, true
+		}
+	}
+	return "", false
+}
+
+// digitalItemName prefixes a title with its kind, e.g. "Kindle eBook: Dune".
+func digitalItemName(kind DigitalKind, title string) string {
+	title = normalizedReturnText(title)
+	if kind == DigitalPrimeMembership || title == "" {
+		return kind.Label()
+	}
+	return kind.Label() + ": " + title
+}
+
+// historySource fetches orders the amazon-go client can't read, such as
+// digital orders and Whole Foods receipts; tests substitute it for the
+// page-reading clients.
+type historySource interface {
+	Fetch(ctx context.Context, start, end time.Time) ([]*ParsedOrder, error)
+}
+
+// digitalHistoryClient reads the digital order history and the payments
+// history with the saved Amazon cookies. It borrows the Return Center
+// client's cookie handling and request headers.
+type digitalHistoryClient struct {
+	session     *returnHistoryClient
+	ordersURL   string
+	summaryURL  string
+	paymentsURL string
+	logger      *slog.Logger
+}
+
+func newDigitalHistoryClient(cookieFile string, fingerprint *amazongo.BrowserFingerprint, logger *slog.Logger) *digitalHistoryClient {
+	return &digitalHistoryClient{
+		session:     newReturnHistoryClient(cookieFile, fingerprint),
+		ordersURL:   amazonDigitalOrdersURL,
+		summaryURL:  amazonDigitalSummaryURL,
+		paymentsURL: amazonPaymentsURL,
+		logger:      logger,
+	}
+}
+
+// Fetch returns the digital orders placed, and the membership and
+// subscription charges made, between start and end. Digital orders carry no
+// transactions; subscription charges carry the charge itself. Only the first
+// page of the payments history can be read (later pages are loaded by a form
+// post), so a window reaching back further than that page is logged as not
+// fully covered.
+func (c *digitalHistoryClient) Fetch(ctx context.Context, start, end time.Time) ([]*ParsedOrder, error) {
+	cookies, err := c.session.loadCookies()
+	if err != nil {
+		return nil, err
+	}
+	orders, err := c.fetchDigitalOrders(ctx, cookies, start, end)
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := c.fetchSubscriptionCharges(ctx, cookies, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return append(orders, subscriptions...), nil
+}
+
+func (c *digitalHistoryClient) fetchDigitalOrders(ctx context.Context, cookies []*http.Cookie, start, end time.Time) ([]*ParsedOrder, error) {
+	var orders []*ParsedOrder
+	listURL := func(year, startIndex int) string {
+		return fmt.Sprintf("%s&orderFilter=year-%d&startIndex=%d", c.ordersURL, year, startIndex)
+	}
+	err := walkOrderHistory(ctx, c.session, cookies, "digital order history", listURL, parseDigitalOrderList, start, end, func(link historyOrderLink) error {
+		detailURL := c.summaryURL + "?orderID=" + url.QueryEscape(link.id)
+		detail, err := c.session.fetchSignedIn(ctx, detailURL, cookies, "digital order "+link.id)
+		if err != nil {
+			return err
+		}
+		order, err := parseDigitalOrderSummary(detail, link.id)
+		if err != nil {
+			return err
+		}
+		if order.Date.IsZero() {
+			order.Date = link.date
+		}
+		if withinOrderWindow(order.Date, start, end) {
+			orders = append(orders, order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// historyOrderLink is an order linked from an order history page, with the
+// order date when the page shows one.
+type historyOrderLink struct {
+	id   string
+	date time.Time
+}
+
+// walkOrderHistory pages through an order history list, from the year of end
+// back to the year of start, and calls visit once for each linked order in
+// the window (or with no date on the list). Paging through a year stops at a
+// short page or at a page of orders placed before start.
+func walkOrderHistory(ctx context.Context, session *returnHistoryClient, cookies []*http.Cookie, what string, listURL func(year, startIndex int) string, parse func([]byte) []historyOrderLink, start, end time.Time, visit func(historyOrderLink) error) error {
+	if end.IsZero() {
+		end = time.Now()
+	}
+	startYear := end.Year()
+	if !start.IsZero() {
+		startYear = start.Year()
+	}
+
+	seen := make(map[string]bool)
+	for year := end.Year(); year >= startYear; year-- {
+		for page := 0; page < orderHistoryMaxPages; page++ {
+			body, err := session.fetchSignedIn(ctx, listURL(year, page*orderHistoryPageSize), cookies, what)
+			if err != nil {
+				return err
+			}
+			added := 0
+			olderThanStart := true
+			for _, link := range parse(body) {
+				if seen[link.id] {
+					continue
+				}
+				seen[link.id] = true
+				added++
+				if link.date.IsZero() || !link.date.Before(start) {
+					olderThanStart = false
+				}
+				if !link.date.IsZero() && !withinOrderWindow(link.date, start, end) {
+					continue
+				}
+				if err := visit(link); err != nil {
+					return err
+				}
+			}
+			if added < orderHistoryPageSize || olderThanStart {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (c *digitalHistoryClient) fetchSubscriptionCharges(ctx context.Context, cookies []*http.Cookie, start, end time.Time) ([]*ParsedOrder, error) {
+	body, err := c.session.fetchSignedIn(ctx, c.paymentsURL, cookies, "payments history")
+	if err != nil {
+		return nil, err
+	}
+	orders, oldest, err := parseSubscriptionCharges(body, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if !paymentsHistoryCovers(oldest, start) && c.logger != nil {
+		c.logger.Warn("Amazon payments history does not reach the start of the sync window; older subscription charges are not synced",
+			slog.String("oldest_entry", oldest.Format("2006-01-02")),
+			slog.String("start_date", start.Format("2006-01-02")))
+	}
+	return orders, nil
+}
+
+// paymentsHistoryCovers reports whether a payments history page whose oldest
+// entry is dated oldest reaches back to start. A page with no dated entries
+// covers any window: the account has no older payments.
+func paymentsHistoryCovers(oldest, start time.Time) bool {
+	return start.IsZero() || oldest.IsZero() || oldest.Before(start.Truncate(24*time.Hour))
+}
+
+// parseDigitalOrderList returns the digital orders linked from an order
+// history page, with the order date when the page shows one.
+func parseDigitalOrderList(body []byte) []historyOrderLink {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var links []historyOrderLink
+	seen := make(map[string]bool)
+	doc.Find(`a[href*="orderID=D01-"]`).Each(func(_ int, anchor *goquery.Selection) {
+		href, _ := anchor.Attr("href")
+		id := digitalOrderIDPattern.FindString(href)
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		link := historyOrderLink{id: id}
+		card := anchor.Closest(".order-card, .a-box-group, .order")
+		if match := digitalOrderPlacedPattern.FindStringSubmatch(normalizedReturnText(card.Text())); len(match) == 2 {
+			link.date, _ = parseDigitalDate(match[1])
+		}
+		links = append(links, link)
+	})
+	return links
+}
+
+// parseDigitalOrderSummary reads a digital order summary page: one table row
+// per item (title, kind hints such as "Kindle Edition", price) followed by
+// the subtotal, tax and grand total.
+func parseDigitalOrderSummary(body []byte, orderID string) (*ParsedOrder, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Amazon digital order %s: %w", orderID, err)
+	}
+	text := normalizedReturnText(doc.Text())
+
+	order := &ParsedOrder{ID: orderID}
+	if match := digitalOrderPlacedPattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Date, _ = parseDigitalDate(match[1])
+	}
+
+	doc.Find("tr").Each(func(_ int, row *goquery.Selection) {
+		if row.Find("tr").Length() > 0 {
+			return
+		}
+		rowText := normalizedReturnText(row.Text())
+		lower := strings.ToLower(rowText)
+		if strings.Contains(lower, "subtotal") || strings.Contains(lower, "total") || strings.Contains(lower, "tax") {
+			return
+		}
+		prices := returnPricePattern.FindAllStringSubmatch(rowText, -1)
+		if len(prices) == 0 {
+			return
+		}
+		title := normalizedReturnText(row.Find("b, a").First().Text())
+		if title == "" {
+			return
+		}
+		price, err := parseReturnAmount(prices[len(prices)-1][1])
+		if err != nil {
+			return
+		}
+
+		kind := classifyDigitalItem(rowText)
+		item := &ParsedOrderItem{
+			Name:     digitalItemName(kind, title),
+			Price:    price,
+			Quantity: 1,
+			Kind:     kind,
+		}
+		row.Find("a[href]").EachWithBreak(func(_ int, link *goquery.Selection) bool {
+			href, _ := link.Attr("href")
+			if match := digitalASINPattern.FindStringSubmatch(href); len(match) == 2 {
+				item.ASIN = match[1]
+				return false
+			}
+			return true
+		})
+		order.Items = append(order.Items, item)
+	})
+	if len(order.Items) == 0 {
+		return nil, fmt.Errorf("amazon digital order %s has no items", orderID)
+	}
+
+	for _, item := range order.Items {
+		order.Subtotal += item.Price
+	}
+	if match := digitalSubtotalPattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Subtotal, _ = parseReturnAmount(match[1])
+	}
+	for _, match := range digitalTaxPattern.FindAllStringSubmatch(text, -1) {
+		// "Total Before Tax" is a subtotal, not the tax
+		if match[1] == "" {
+			order.Tax, _ = parseReturnAmount(match[2])
+			break
+		}
+	}
+	order.Total = order.Subtotal + order.Tax
+	if match := digitalTotalPattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Total, _ = parseReturnAmount(match[1])
+	}
+	return order, nil
+}
+
+// parseSubscriptionCharges turns the payments-history charges that belong to
+// no order but name a membership or subscription into single-item orders. It
+// also returns the date of the oldest entry on the page.
+func parseSubscriptionCharges(body []byte, start, end time.Time) ([]*ParsedOrder, time.Time, error) {
+	transactions, err := amazongo.NewParser().ParseTransactions(bytes.NewReader(body))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to parse Amazon payments history: %w", err)
+	}
+
+	var orders []*ParsedOrder
+	var oldest time.Time
+	for _, tx := range transactions {
+		if tx != nil && !tx.Date.IsZero() && (oldest.IsZero() || tx.Date.Before(oldest)) {
+			oldest = tx.Date
+		}
+		if tx == nil || tx.OrderID != "" || tx.Amount <= 0 {
+			continue
+		}
+		kind, ok := classifySubscription(tx.Merchant)
+		if !ok || !withinOrderWindow(tx.Date, start, end) {
+			continue
+		}
+		parsedTx := convertGoTransaction(tx)
+		if parsedTx.Type != "charge" {
+			continue
+		}
+		orders = append(orders, &ParsedOrder{
+			ID:       subscriptionOrderID(tx.Merchant, tx.Date, tx.Amount),
+			Date:     tx.Date,
+			Total:    tx.Amount,
+			Subtotal: tx.Amount,
+			Items: []*ParsedOrderItem{{
+				Name:     digitalItemName(kind, tx.Merchant),
+				Price:    tx.Amount,
+				Quantity: 1,
+				Kind:     kind,
+			}},
+			Transactions: []*ParsedTransaction{parsedTx},
+		})
+	}
+	return orders, oldest, nil
+}
+
+// subscriptionOrderID identifies a recurring charge by date, merchant and
+// amount, e.g. "amazon-subscription:2026-05-09:kindle-unlimited:11.99", so a
+// charge keeps its ID across syncs.
+func subscriptionOrderID(merchant string, date time.Time, amount float64) string {
+	slug := strings.Trim(digitalSlugPattern.ReplaceAllString(strings.ToLower(merchant), "-"), "-")
+	return SubscriptionOrderPrefix + date.Format("2006-01-02") + ":" + slug + ":" + strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+func parseDigitalDate(value string) (time.Time, error) {
+	value = strings.Replace(normalizedReturnText(value), ".", "", 1)
+	for _, layout := range []string{"January 2, 2006", "Jan 2, 2006"} {
+		if date, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid Amazon date %q", value)
+}
+
+func withinOrderWindow(date, start, end time.Time) bool {
+	if !start.IsZero() && date.Before(start.Truncate(24*time.Hour)) {
+		return false
+	}
+	return end.IsZero() || !date.After(end)
+}
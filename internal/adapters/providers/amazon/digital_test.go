@@ -0,0 +1,233 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amazongo "github.com/eshaffer321/amazon-go"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const digitalOrderListFixture = `<!doctype html>
+<html><body>
+  <div class="order-card">
+    <div>Order placed May 9, 2026</div>
+    <a href="/gp/digital/your-account/order-summary.html?orderID=D01-1111111-2222222&amp;ref=ppx">View order details</a>
+    <a href="/gp/your-account/order-details?orderID=D01-1111111-2222222">Invoice</a>
+  </div>
+  <div class="order-card">
+    <div>Order placed Apr 30, 2026</div>
+    <a href="/gp/digital/your-account/order-summary.html?orderID=D01-3333333-4444444">View order details</a>
+  </div>
+</body></html>`
+
+const digitalOrderSummaryFixture = `<!doctype html>
+<html><body>
+  <div>Digital Order: May 9, 2026</div>
+  <div>Order placed: May 9, 2026</div>
+  <table>
+    <tr><th>Items Ordered</th><th>Price</th></tr>
+    <tr>
+      <td><b><a href="/dp/B00EXAMPLE">Dune</a></b><br>Kindle Edition<br>Sold By: Amazon.com Services LLC</td>
+      <td>$9.99</td>
+    </tr>
+    <tr>
+      <td><b>The Expanse: Season 1, Episode 1</b><br>Prime Video</td>
+      <td>$1.99</td>
+    </tr>
+    <tr><td>Item(s) Subtotal: $11.98</td></tr>
+    <tr><td>Total Before Tax: $11.98</td></tr>
+    <tr><td>Tax Collected: $0.84</td></tr>
+    <tr><td>Grand Total: $12.82</td></tr>
+  </table>
+</body></html>`
+
+const paymentsHistoryFixture = `<!doctype html>
+<html><body>
+  <div class="apx-transactions-sleeve-header-container"><span class="a-text-bold">Completed</span></div>
+  <div class="apx-transaction-date-container"><span>May 9, 2026</span></div>
+  <div class="apx-transactions-line-item-component-container">
+    <div data-pmts-component-id="row-1">
+      <div class="a-column a-span9"><span class="a-text-bold">Prime Visa ****1211</span></div>
+      <div class="a-column a-span3"><span class="a-text-bold">-$11.99</span></div>
+      <div class="a-column a-span12"><span class="a-size-base">Kindle Unlimited</span></div>
+    </div>
+  </div>
+  <div class="apx-transactions-line-item-component-container">
+    <div data-pmts-component-id="row-2">
+      <div class="a-column a-span9"><span class="a-text-bold">Prime Visa ****1211</span></div>
+      <div class="a-column a-span3"><span class="a-text-bold">-$14.99</span></div>
+      <div class="a-column a-span12"><span class="a-size-base">Amazon Prime</span></div>
+    </div>
+  </div>
+  <div class="apx-transactions-line-item-component-container">
+    <div data-pmts-component-id="row-3">
+      <div class="a-column a-span9"><span class="a-text-bold">Prime Visa ****1211</span></div>
+      <div class="a-column a-span3"><span class="a-text-bold">-$42.10</span></div>
+      <div class="a-column a-span12"><a href="/gp/your-account/order-details?orderID=112-1111111-2222222">Order #112-1111111-2222222</a></div>
+    </div>
+  </div>
+  <div class="apx-transactions-line-item-component-container">
+    <div data-pmts-component-id="row-4">
+      <div class="a-column a-span9"><span class="a-text-bold">Prime Visa ****1211</span></div>
+      <div class="a-column a-span3"><span class="a-text-bold">-$5.00</span></div>
+      <div class="a-column a-span12"><span class="a-size-base">AMZN Mktp US</span></div>
+    </div>
+  </div>
+</body></html>`
+
+// fakeHistorySource returns canned orders
+type fakeHistorySource struct {
+	orders     []*ParsedOrder
+	err        error
+	start, end time.Time
+}
+
+func (s *fakeHistorySource) Fetch(ctx context.Context, start, end time.Time) ([]*ParsedOrder, error) {
+	s.start, s.end = start, end
+	return s.orders, s.err
+}
+
+func TestParseDigitalKind(t *testing.T) {
+	kind, err := ParseDigitalKind(" Kindle ")
+	require.NoError(t, err)
+	assert.Equal(t, DigitalKindle, kind)
+	assert.Equal(t, "Kindle eBook", kind.Label())
+
+	_, err = ParseDigitalKind("books")
+	assert.ErrorContains(t, err, `unknown Amazon digital kind "books"`)
+}
+
+func TestParseDigitalOrderList(t *testing.T) {
+	links := parseDigitalOrderList([]byte(digitalOrderListFixture))
+
+	require.Len(t, links, 2, "an order linked twice is listed once")
+	assert.Equal(t, "D01-1111111-2222222", links[0].id)
+	assert.Equal(t, time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC), links[0].date)
+	assert.Equal(t, "D01-3333333-4444444", links[1].id)
+	assert.Equal(t, time.Date(2026, 4, 30, 0, 0, 0, 0, time.UTC), links[1].date)
+}
+
+func TestParseDigitalOrderSummary(t *testing.T) {
+	order, err := parseDigitalOrderSummary([]byte(digitalOrderSummaryFixture), "D01-1111111-2222222")
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC), order.Date)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, "Kindle eBook: Dune", order.Items[0].Name)
+	assert.Equal(t, DigitalKindle, order.Items[0].Kind)
+	assert.Equal(t, "B00EXAMPLE", order.Items[0].ASIN)
+	assert.InDelta(t, 9.99, order.Items[0].Price, 0.001)
+	assert.Equal(t, "Prime Video: The Expanse: Season 1, Episode 1", order.Items[1].Name)
+	assert.Equal(t, DigitalVideo, order.Items[1].Kind)
+	assert.InDelta(t, 11.98, order.Subtotal, 0.001)
+	assert.InDelta(t, 0.84, order.Tax, 0.001, "Total Before Tax is not the tax")
+	assert.InDelta(t, 12.82, order.Total, 0.001)
+
+	_, err = parseDigitalOrderSummary([]byte(`<html><body>Sign in</body></html>`), "D01-0")
+	assert.ErrorContains(t, err, "has no items")
+}
+
+func TestParseSubscriptionCharges(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 31, 0, 0, 0, 0, time.UTC)
+
+	orders, oldest, err := parseSubscriptionCharges([]byte(paymentsHistoryFixture), start, end)
+
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC), oldest.UTC())
+	require.Len(t, orders, 2, "order charges and unknown merchants are skipped")
+	assert.Equal(t, "amazon-subscription:2026-05-09:kindle-unlimited:11.99", orders[0].ID)
+	assert.Equal(t, "Amazon subscription: Kindle Unlimited", orders[0].Items[0].Name)
+	assert.Equal(t, DigitalSubscription, orders[0].Items[0].Kind)
+	require.Len(t, orders[0].Transactions, 1)
+	assert.Equal(t, "1211", orders[0].Transactions[0].Last4)
+	assert.InDelta(t, 11.99, orders[0].Transactions[0].Amount, 0.001)
+
+	assert.Equal(t, "Amazon Prime membership", orders[1].Items[0].Name)
+	assert.Equal(t, DigitalPrimeMembership, orders[1].Items[0].Kind)
+	assert.InDelta(t, 14.99, orders[1].Total, 0.001)
+
+	outside, _, err := parseSubscriptionCharges([]byte(paymentsHistoryFixture), end.AddDate(0, 0, 1), time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, outside)
+}
+
+func TestPaymentsHistoryCovers(t *testing.T) {
+	oldest := time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC)
+
+	assert.False(t, paymentsHistoryCovers(oldest, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)), "the page stops after the window starts")
+	assert.True(t, paymentsHistoryCovers(oldest, time.Date(2026, 5, 10, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, paymentsHistoryCovers(time.Time{}, time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)), "an empty history has nothing older")
+	assert.True(t, paymentsHistoryCovers(oldest, time.Time{}))
+}
+
+func TestProvider_FetchOrdersIncludesDigitalOrders(t *testing.T) {
+	day := time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC)
+	client := &fakeAmazonClient{
+		orders: []*amazongo.Order{{ID: "112-0000000-0000001", Date: day, Total: 20}},
+		transactionsByOrderID: map[string][]*amazongo.Transaction{
+			"D01-1111111-2222222": {{Date: day, Amount: 12.82, Status: "Completed", LastFour: "1211"}},
+		},
+	}
+	provider := NewProviderWithClient(nil, &ProviderConfig{Profile: "mine"}, client)
+	require.NoError(t, provider.SetDigitalOrders(true, map[string]string{"kindle": "Books"}))
+	source := &fakeHistorySource{orders: []*ParsedOrder{
+		{
+			ID:    "D01-1111111-2222222",
+			Date:  day,
+			Total: 12.82,
+			Items: []*ParsedOrderItem{
+				{Name: "Kindle eBook: Dune", Price: 9.99, Quantity: 1, Kind: DigitalKindle, ASIN: "B00EXAMPLE"},
+				{Name: "Prime Video: The Expanse", Price: 1.99, Quantity: 1, Kind: DigitalVideo},
+			},
+		},
+	}}
+	provider.digitalSource = source
+
+	opts := providers.FetchOptions{StartDate: day.AddDate(0, 0, -7), EndDate: day, IncludeDetails: true}
+	orders, err := provider.FetchOrders(context.Background(), opts)
+
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	assert.Equal(t, opts.StartDate, source.start)
+	digital := orders[1]
+	assert.Equal(t, "D01-1111111-2222222", digital.GetID())
+	assert.Equal(t, "mine", providers.OrderAccount(digital))
+	charges, err := digital.(*Order).GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{12.82}, charges)
+
+	items := digital.GetItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, "B00EXAMPLE", items[0].GetSKU())
+	assert.Equal(t, "Kindle eBook", items[0].GetCategory())
+	assert.Equal(t, "Books", providers.PinnedCategory(items[0]))
+	assert.Equal(t, "", providers.PinnedCategory(items[1]), "unconfigured kinds are categorized as usual")
+}
+
+func TestProvider_FetchOrdersKeepsPhysicalOrdersWhenDigitalFetchFails(t *testing.T) {
+	client := &fakeAmazonClient{
+		orders: []*amazongo.Order{{ID: "112-0000000-0000001", Date: time.Now(), Total: 20}},
+	}
+	provider := NewProviderWithClient(nil, &ProviderConfig{}, client)
+	require.NoError(t, provider.SetDigitalOrders(true, nil))
+	provider.digitalSource = &fakeHistorySource{err: errors.New("sign-in required")}
+
+	orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+}
+
+func TestProvider_SetDigitalOrdersRejectsUnknownKind(t *testing.T) {
+	provider := NewProviderWithClient(nil, &ProviderConfig{}, &fakeAmazonClient{})
+
+	err := provider.SetDigitalOrders(true, map[string]string{"comics": "Books"})
+
+	assert.ErrorContains(t, err, `unknown Amazon digital kind "comics"`)
+}
@@ -0,0 +1,333 @@
+package amazon
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	amazongo "github.com/eshaffer321/amazon-go"
+)
+
+// Amazon Fresh and Whole Foods Market orders have their own receipt pages:
+// delivery orders are listed in the regular order history but the amazon-go
+// parser can't read their items, and in-store Whole Foods purchases (paid with
+// the Prime code) are listed only under in-store orders. groceryHistoryClient
+// reads the itemized receipts of both, including items sold by weight, the
+// delivery tip and grocery fees.
+
+const (
+	amazonOrderHistoryURL   = "https://www.amazon.com/gp/css/order-history?unifiedOrders=0"
+	amazonInStoreOrdersURL  = "https://www.amazon.com/gp/css/order-history?instoreOrders=1&unifiedOrders=0"
+	amazonGroceryReceiptURL = "https://www.amazon.com/uff/your-account/order-details"
+
+	// StoreWholeFoods and StoreAmazonFresh name the store of a grocery order.
+	StoreWholeFoods  = "Whole Foods Market"
+	StoreAmazonFresh = "Amazon Fresh"
+)
+
+var (
+	groceryDatePattern   = regexp.MustCompile(`([A-Z][a-z]+\.?\s+\d{1,2},\s+\d{4})`)
+	groceryPricePattern  = regexp.MustCompile(`(-)?\$([\d,]+\.\d{2})`)
+	groceryWeightPattern = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*lbs?\s*@\s*\$([\d,]+\.\d{2})\s*/\s*lb`)
+	groceryQtyPattern    = regexp.MustCompile(`(?i)\bqty:?\s*(\d+)\b`)
+	groceryCardPattern   = regexp.MustCompile(`(?i)(?:ending in|\*{4})\s*(\d{4})\b`)
+
+	// grocerySummaryPattern recognizes the receipt rows below the items by
+	// their label.
+	grocerySummaryPattern = regexp.MustCompile(`(?i)^((?:total )?savings|item\(s\) subtotal|subtotal|estimated tax|tax|(?:driver|delivery|courier|shopper)?\s*tip|delivery fee|service fee|bag fee|order total|grand total|total)\b`)
+)
+
+// groceryHistoryClient reads Amazon Fresh and Whole Foods receipts with the
+// saved Amazon cookies.
+type groceryHistoryClient struct {
+	session    *returnHistoryClient
+	listURLs   []string
+	receiptURL string
+}
+
+func newGroceryHistoryClient(cookieFile string, fingerprint *amazongo.BrowserFingerprint) *groceryHistoryClient {
+	return &groceryHistoryClient{
+		session:    newReturnHistoryClient(cookieFile, fingerprint),
+		listURLs:   []string{amazonOrderHistoryURL, amazonInStoreOrdersURL},
+		receiptURL: amazonGroceryReceiptURL,
+	}
+}
+
+// Fetch returns the Amazon Fresh and Whole Foods orders placed between start
+// and end. Orders whose receipt names the card carry that charge; the others
+// carry no transactions.
+func (c *groceryHistoryClient) Fetch(ctx context.Context, start, end time.Time) ([]*ParsedOrder, error) {
+	cookies, err := c.session.loadCookies()
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []*ParsedOrder
+	seen := make(map[string]bool)
+	for _, baseURL := range c.listURLs {
+		listURL := func(year, startIndex int) string {
+			return fmt.Sprintf("%s&orderFilter=year-%d&startIndex=%d", baseURL, year, startIndex)
+		}
+		err := walkOrderHistory(ctx, c.session, cookies, "grocery order history", listURL, parseGroceryOrderList, start, end, func(link historyOrderLink) error {
+			if seen[link.id] {
+				return nil
+			}
+			seen[link.id] = true
+
+			receiptURL := c.receiptURL + "?orderID=" + url.QueryEscape(link.id)
+			body, err := c.session.fetchSignedIn(ctx, receiptURL, cookies, "grocery receipt "+link.id)
+			if err != nil {
+				return err
+			}
+			order, err := parseGroceryReceipt(body, link.id)
+			if err != nil {
+				return err
+			}
+			if order.Date.IsZero() {
+				order.Date = link.date
+			}
+			if withinOrderWindow(order.Date, start, end) {
+				orders = append(orders, order)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+// parseGroceryOrderList returns the orders on an order history page that
+// link to a grocery receipt, with the order date when the page shows one.
+func parseGroceryOrderList(body []byte) []historyOrderLink {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil
+	}
+
+	var links []historyOrderLink
+	seen := make(map[string]bool)
+	doc.Find(`a[href*="/uff/"], a[href*="instore"]`).Each(func(_ int, anchor *goquery.Selection) {
+		href, _ := anchor.Attr("href")
+		id := groceryOrderID(href)
+		if id == "" || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		link := historyOrderLink{id: id}
+		card := anchor.Closest(".order-card, .a-box-group, .order")
+		if match := digitalOrderPlacedPattern.FindStringSubmatch(normalizedReturnText(card.Text())); len(match) == 2 {
+			link.date, _ = parseDigitalDate(match[1])
+		}
+		links = append(links, link)
+	})
+	return links
+}
+
+// groceryOrderID reads the order ID from a receipt link, which Amazon spells
+// either orderID or orderId.
+func groceryOrderID(href string) string {
+	parsed, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	query := parsed.Query()
+	return strings.TrimSpace(firstNonEmpty(query.Get("orderID"), query.Get("orderId")))
+}
+
+// parseGroceryReceipt reads an Amazon Fresh or Whole Foods receipt: one table
+// row per item, where items sold by weight show e.g. "1.32 lb @ $3.99/lb" and
+// savings show as a negative amount under the item they reduce, followed by
+// the subtotal, fees, tax, tip and total.
+func parseGroceryReceipt(body []byte, orderID string) (*ParsedOrder, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Amazon grocery receipt %s: %w", orderID, err)
+	}
+	text := normalizedReturnText(doc.Text())
+
+	order := &ParsedOrder{ID: orderID, Store: groceryStore(text)}
+	if order.Store == "" {
+		return nil, fmt.Errorf("amazon order %s is not an Amazon Fresh or Whole Foods order", orderID)
+	}
+	if match := digitalOrderPlacedPattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Date, _ = parseDigitalDate(match[1])
+	} else if match := groceryDatePattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Date, _ = parseDigitalDate(match[1])
+	}
+
+	var subtotal float64
+	var last *ParsedOrderItem
+	doc.Find("tr").Each(func(_ int, row *goquery.Selection) {
+		if row.Find("tr").Length() > 0 {
+			return
+		}
+		cells := row.Find("td")
+		if cells.Length() == 0 {
+			return
+		}
+		rowText := normalizedReturnText(row.Text())
+		prices := groceryPricePattern.FindAllStringSubmatch(rowText, -1)
+		if len(prices) == 0 {
+			return
+		}
+		price, err := parseReturnAmount(prices[len(prices)-1][2])
+		if err != nil {
+			return
+		}
+		negative := prices[len(prices)-1][1] == "-"
+
+		label := normalizedReturnText(cells.First().Text())
+		if match := grocerySummaryPattern.FindStringSubmatch(label); match != nil {
+			applyGrocerySummary(order, &subtotal, strings.ToLower(match[1]), price)
+			return
+		}
+		if negative {
+			// Prime member deals and other savings print under their item
+			if last != nil {
+				last.Price = roundCents(last.Price - price)
+			}
+			return
+		}
+
+		name := normalizedReturnText(cells.First().Find("b, a, .item-name").First().Text())
+		if name == "" {
+			name = groceryItemName(label)
+		}
+		if name == "" {
+			return
+		}
+		item := &ParsedOrderItem{Name: name, Price: price, Quantity: 1}
+		if match := groceryWeightPattern.FindStringSubmatch(rowText); match != nil {
+			item.Weight, _ = strconv.ParseFloat(match[1], 64)
+			item.UnitPrice, _ = parseReturnAmount(match[2])
+		} else if match := groceryQtyPattern.FindStringSubmatch(rowText); match != nil {
+			if quantity, err := strconv.Atoi(match[1]); err == nil && quantity > 0 {
+				item.Quantity = quantity
+			}
+		}
+		row.Find("a[href]").EachWithBreak(func(_ int, link *goquery.Selection) bool {
+			href, _ := link.Attr("href")
+			if match := digitalASINPattern.FindStringSubmatch(href); len(match) == 2 {
+				item.ASIN = match[1]
+				return false
+			}
+			return true
+		})
+		order.Items = append(order.Items, item)
+		last = item
+	})
+	if len(order.Items) == 0 {
+		return nil, fmt.Errorf("amazon grocery receipt %s has no items", orderID)
+	}
+
+	if subtotal == 0 {
+		for _, item := range order.Items {
+			subtotal += item.Price
+		}
+	}
+	order.Subtotal = roundCents(subtotal)
+	if order.Total == 0 {
+		order.Total = roundCents(order.Subtotal + order.Tax + order.Tip + order.Shipping)
+	}
+	if match := groceryCardPattern.FindStringSubmatch(text); len(match) == 2 {
+		order.Transactions = []*ParsedTransaction{{
+			Date:        order.Date,
+			Amount:      order.Total,
+			Type:        "charge",
+			Last4:       match[1],
+			Description: order.Store,
+		}}
+	}
+	return order, nil
+}
+
+// applyGrocerySummary records a receipt summary row on the order. Fees are
+// added together; the first subtotal and total rows win. Savings are already
+// taken off the items they apply to.
+func applyGrocerySummary(order *ParsedOrder, subtotal *float64, label string, amount float64) {
+	switch {
+	case strings.Contains(label, "savings"):
+	case strings.Contains(label, "subtotal"):
+		if *subtotal == 0 {
+			*subtotal = amount
+		}
+	case strings.Contains(label, "tax"):
+		order.Tax = roundCents(order.Tax + amount)
+	case strings.HasSuffix(label, "tip"):
+		order.Tip = roundCents(order.Tip + amount)
+	case strings.HasSuffix(label, "fee"):
+		order.Shipping = roundCents(order.Shipping + amount)
+	case strings.Contains(label, "total"):
+		if order.Total == 0 {
+			order.Total = amount
+		}
+	}
+}
+
+// groceryStore names the store a receipt is from, or "" for other orders.
+func groceryStore(text string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "whole foods"):
+		return StoreWholeFoods
+	case strings.Contains(lower, "amazon fresh"):
+		return StoreAmazonFresh
+	}
+	return ""
+}
+
+// groceryItemName drops the weight, quantity and prices from an item cell
+// that has no separate name element.
+func groceryItemName(label string) string {
+	label = groceryWeightPattern.ReplaceAllString(label, "")
+	label = groceryQtyPattern.ReplaceAllString(label, "")
+	label = groceryPricePattern.ReplaceAllString(label, "")
+	return normalizedReturnText(label)
+}
+
+// mergeGroceryOrders replaces regular orders with their itemized grocery
+// receipts and appends in-store purchases. A receipt that names no card keeps
+// the payment transactions of the regular order it replaces.
+func mergeGroceryOrders(orders []*ParsedOrder, grocery []*ParsedOrder) ([]*ParsedOrder, []*ParsedOrder) {
+	byID := make(map[string]int, len(orders))
+	for i, order := range orders {
+		byID[order.ID] = i
+	}
+
+	var added []*ParsedOrder
+	for _, receipt := range grocery {
+		i, ok := byID[receipt.ID]
+		if !ok {
+			added = append(added, receipt)
+			continue
+		}
+		if len(receipt.Transactions) == 0 {
+			receipt.Transactions = orders[i].Transactions
+		}
+		orders[i] = receipt
+	}
+	return orders, added
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
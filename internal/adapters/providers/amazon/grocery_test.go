@@ -0,0 +1,217 @@
+package amazon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amazongo "github.com/eshaffer321/amazon-go"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const groceryOrderListFixture = `<!doctype html>
+<html><body>
+  <div class="order-card">
+    <div>Order placed June 12, 2026</div>
+    <a href="/gp/your-account/order-details?orderID=112-0000000-0000001">View order details</a>
+  </div>
+  <div class="order-card">
+    <div>Order placed June 13, 2026</div>
+    <a href="/uff/your-account/order-details?orderID=113-5555555-6666666&amp;ref=ppx_yo">View order details</a>
+  </div>
+  <div class="order-card">
+    <div>Order placed June 14, 2026</div>
+    <a href="/gp/css/instore/receipt?orderId=WFM-0123-4567">View receipt</a>
+  </div>
+</body></html>`
+
+const wholeFoodsDeliveryFixture = `<!doctype html>
+<html><body>
+  <h1>Whole Foods Market delivery</h1>
+  <div>Order placed June 13, 2026</div>
+  <table>
+    <tr><th>Item</th><th>Price</th></tr>
+    <tr>
+      <td><span class="item-name">Organic Bananas</span> 2.18 lb @ $0.79/lb</td>
+      <td>$1.72</td>
+    </tr>
+    <tr>
+      <td><a href="/dp/B07EXAMPLE"><b>365 Whole Milk, 1 gal</b></a> Qty: 2</td>
+      <td>$9.58</td>
+    </tr>
+    <tr><td>Prime member deal</td><td>-$1.00</td></tr>
+    <tr>
+      <td><span class="item-name">Wild Salmon Fillet</span> 1.25 lb @ $13.99/lb</td>
+      <td>$17.49</td>
+    </tr>
+    <tr><td>Item(s) Subtotal</td><td>$27.79</td></tr>
+    <tr><td>Total savings</td><td>-$1.00</td></tr>
+    <tr><td>Service fee</td><td>$9.95</td></tr>
+    <tr><td>Bag fee</td><td>$0.10</td></tr>
+    <tr><td>Estimated tax</td><td>$1.12</td></tr>
+    <tr><td>Driver tip</td><td>$5.00</td></tr>
+    <tr><td>Order total</td><td>$43.96</td></tr>
+  </table>
+  <div>Payment method: Prime Visa ending in 1211</div>
+</body></html>`
+
+const freshReceiptFixture = `<!doctype html>
+<html><body>
+  <h1>Amazon Fresh</h1>
+  <div>June 14, 2026</div>
+  <table>
+    <tr><td>Amazon Fresh Large Eggs</td><td>$3.49</td></tr>
+    <tr><td>Honeycrisp Apples 1.5 lb @ $2.99/lb</td><td>$4.49</td></tr>
+    <tr><td>Total</td><td>$7.98</td></tr>
+  </table>
+</body></html>`
+
+func TestParseGroceryOrderList(t *testing.T) {
+	links := parseGroceryOrderList([]byte(groceryOrderListFixture))
+
+	require.Len(t, links, 2, "regular orders are not grocery receipts")
+	assert.Equal(t, "113-5555555-6666666", links[0].id)
+	assert.Equal(t, time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC), links[0].date)
+	assert.Equal(t, "WFM-0123-4567", links[1].id)
+	assert.Equal(t, time.Date(2026, 6, 14, 0, 0, 0, 0, time.UTC), links[1].date)
+}
+
+func TestParseGroceryReceipt_WholeFoodsDelivery(t *testing.T) {
+	order, err := parseGroceryReceipt([]byte(wholeFoodsDeliveryFixture), "113-5555555-6666666")
+
+	require.NoError(t, err)
+	assert.Equal(t, StoreWholeFoods, order.Store)
+	assert.Equal(t, time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC), order.Date)
+	require.Len(t, order.Items, 3)
+
+	bananas := order.Items[0]
+	assert.Equal(t, "Organic Bananas", bananas.Name)
+	assert.InDelta(t, 2.18, bananas.Weight, 0.001)
+	assert.InDelta(t, 0.79, bananas.UnitPrice, 0.001)
+	assert.InDelta(t, 1.72, bananas.Price, 0.001)
+
+	milk := order.Items[1]
+	assert.Equal(t, "365 Whole Milk, 1 gal", milk.Name)
+	assert.Equal(t, 2, milk.Quantity)
+	assert.Equal(t, "B07EXAMPLE", milk.ASIN)
+	assert.InDelta(t, 8.58, milk.Price, 0.001, "the Prime member deal reduces the item above it")
+
+	assert.InDelta(t, 17.49, order.Items[2].Price, 0.001)
+	assert.InDelta(t, 27.79, order.Subtotal, 0.001)
+	assert.InDelta(t, 10.05, order.Shipping, 0.001, "service and bag fees")
+	assert.InDelta(t, 1.12, order.Tax, 0.001)
+	assert.InDelta(t, 5.00, order.Tip, 0.001)
+	assert.InDelta(t, 43.96, order.Total, 0.001)
+
+	require.Len(t, order.Transactions, 1)
+	assert.Equal(t, "1211", order.Transactions[0].Last4)
+	assert.Equal(t, "charge", order.Transactions[0].Type)
+	assert.InDelta(t, 43.96, order.Transactions[0].Amount, 0.001)
+}
+
+func TestParseGroceryReceipt_FreshWithoutNameElements(t *testing.T) {
+	order, err := parseGroceryReceipt([]byte(freshReceiptFixture), "WFM-0123-4567")
+
+	require.NoError(t, err)
+	assert.Equal(t, StoreAmazonFresh, order.Store)
+	assert.Equal(t, time.Date(2026, 6, 14, 0, 0, 0, 0, time.UTC), order.Date)
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, "Amazon Fresh Large Eggs", order.Items[0].Name)
+	assert.Equal(t, "Honeycrisp Apples", order.Items[1].Name)
+	assert.InDelta(t, 1.5, order.Items[1].Weight, 0.001)
+	assert.InDelta(t, 7.98, order.Subtotal, 0.001)
+	assert.InDelta(t, 7.98, order.Total, 0.001)
+	assert.Empty(t, order.Transactions, "no card on the receipt")
+}
+
+func TestParseGroceryReceipt_RejectsOtherOrders(t *testing.T) {
+	_, err := parseGroceryReceipt([]byte(`<html><body><table><tr><td>Cable</td><td>$5.00</td></tr></table></body></html>`), "112-0")
+
+	assert.ErrorContains(t, err, "not an Amazon Fresh or Whole Foods order")
+}
+
+func TestOrderItem_WeightPricedItem(t *testing.T) {
+	item := &OrderItem{parsedItem: &ParsedOrderItem{Name: "Organic Bananas", Price: 1.72, Quantity: 1, Weight: 2.18, UnitPrice: 0.79}}
+
+	assert.InDelta(t, 2.18, item.GetQuantity(), 0.001)
+	assert.InDelta(t, 0.79, item.GetUnitPrice(), 0.001)
+}
+
+func TestProvider_FetchOrdersUsesGroceryReceipts(t *testing.T) {
+	day := time.Date(2026, 6, 13, 0, 0, 0, 0, time.UTC)
+	client := &fakeAmazonClient{
+		orders: []*amazongo.Order{
+			{ID: "112-0000000-0000001", Date: day, Total: 20},
+			{ID: "113-5555555-6666666", Date: day, Total: 43.96},
+		},
+		transactionsByOrderID: map[string][]*amazongo.Transaction{
+			"113-5555555-6666666": {{Date: day, Amount: 43.96, Status: "Completed", LastFour: "1211"}},
+			"WFM-0123-4567":       {{Date: day, Amount: 7.98, Status: "Completed", LastFour: "4242"}},
+		},
+	}
+	provider := NewProviderWithClient(nil, &ProviderConfig{}, client)
+	provider.SetGroceryOrders(true)
+	provider.grocerySource = &fakeHistorySource{orders: []*ParsedOrder{
+		{
+			ID:    "113-5555555-6666666",
+			Date:  day,
+			Store: StoreWholeFoods,
+			Total: 43.96,
+			Tip:   5,
+			Items: []*ParsedOrderItem{{Name: "Organic Bananas", Price: 1.72, Quantity: 1, Weight: 2.18, UnitPrice: 0.79}},
+		},
+		{
+			ID:    "WFM-0123-4567",
+			Date:  day.AddDate(0, 0, 1),
+			Store: StoreWholeFoods,
+			Total: 7.98,
+			Items: []*ParsedOrderItem{{Name: "Large Eggs", Price: 7.98, Quantity: 1}},
+		},
+	}}
+
+	orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{IncludeDetails: true})
+
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	assert.Equal(t, "112-0000000-0000001", orders[0].GetID())
+
+	delivery := orders[1]
+	assert.Equal(t, "113-5555555-6666666", delivery.GetID())
+	assert.InDelta(t, 5.00, delivery.GetTip(), 0.001)
+	require.Len(t, delivery.GetItems(), 1, "the receipt replaces the regular order")
+	charges, err := delivery.(*Order).GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{43.96}, charges, "the regular order's transactions are kept")
+
+	inStore := orders[2]
+	assert.Equal(t, "WFM-0123-4567", inStore.GetID())
+	charges, err = inStore.(*Order).GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{7.98}, charges)
+}
+
+func TestProvider_FetchOrdersKeepsRegularOrdersWhenGroceryFetchFails(t *testing.T) {
+	client := &fakeAmazonClient{
+		orders: []*amazongo.Order{{ID: "113-5555555-6666666", Date: time.Now(), Total: 43.96}},
+	}
+	provider := NewProviderWithClient(nil, &ProviderConfig{}, client)
+	provider.SetGroceryOrders(true)
+	provider.grocerySource = &fakeHistorySource{err: errors.New("sign-in required")}
+
+	orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{})
+
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, "113-5555555-6666666", orders[0].GetID())
+}
+
+func TestProvider_MerchantSearchTermsIncludeGroceryStores(t *testing.T) {
+	terms := NewProvider(nil, nil).MerchantSearchTerms()
+
+	assert.Contains(t, terms, "Amazon Fresh")
+	assert.Contains(t, terms, "Whole Foods")
+	assert.Equal(t, terms, NewMultiAccountProvider(nil, nil).MerchantSearchTerms())
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log/slog"
 	"math"
+	"strings"
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
@@ -21,6 +22,7 @@ type Order struct {
 	parsedOrder *ParsedOrder
 	items       []providers.OrderItem
 	logger      *slog.Logger
+	account     string
 }
 
 // NewOrder creates a new Order adapter from a ParsedOrder
@@ -61,9 +63,9 @@ func (o *Order) GetTax() float64 {
 	return o.parsedOrder.Tax
 }
 
-// GetTip returns the tip amount (Amazon doesn't support tips)
+// GetTip returns the delivery tip of an Amazon Fresh or Whole Foods order
 func (o *Order) GetTip() float64 {
-	return 0
+	return o.parsedOrder.Tip
 }
 
 // GetFees returns shipping and handling fees
@@ -81,6 +83,12 @@ func (o *Order) GetProviderName() string {
 	return "Amazon"
 }
 
+// GetAccount returns the Amazon cookie account the order was fetched from,
+// or "" for the default account
+func (o *Order) GetAccount() string {
+	return o.account
+}
+
 // GetRawData returns the underlying parsed order
 func (o *Order) GetRawData() interface{} {
 	return o.parsedOrder
@@ -253,6 +261,22 @@ func (o *Order) GetTransactionDates() []time.Time {
 	return dates
 }
 
+// GetPaymentCards returns the cards the order's charges were made on
+func (o *Order) GetPaymentCards() []providers.PaymentCard {
+	var cards []providers.PaymentCard
+	seen := make(map[string]bool)
+	for _, tx := range o.parsedOrder.Transactions {
+		if tx.Type != "charge" || tx.Last4 == "" || seen[tx.Last4] {
+			continue
+		}
+		seen[tx.Last4] = true
+		// "Prime Visa ****1211" -> "Prime Visa"
+		cardType := strings.TrimRight(strings.TrimSuffix(tx.Description, tx.Last4), "* ")
+		cards = append(cards, providers.PaymentCard{Type: cardType, LastFour: tx.Last4})
+	}
+	return cards
+}
+
 // OrderItem wraps a ParsedOrderItem to implement the providers.OrderItem interface
 type OrderItem struct {
 	parsedItem *ParsedOrderItem
@@ -268,13 +292,21 @@ func (i *OrderItem) GetPrice() float64 {
 	return i.parsedItem.Price
 }
 
-// GetQuantity returns the quantity of this item
+// GetQuantity returns the quantity of this item, or its weight in pounds for
+// items sold by weight
 func (i *OrderItem) GetQuantity() float64 {
+	if i.parsedItem.Weight > 0 {
+		return i.parsedItem.Weight
+	}
 	return float64(i.parsedItem.Quantity)
 }
 
-// GetUnitPrice returns the unit price of this item
+// GetUnitPrice returns the unit price of this item (per pound for items sold
+// by weight)
 func (i *OrderItem) GetUnitPrice() float64 {
+	if i.parsedItem.UnitPrice > 0 {
+		return i.parsedItem.UnitPrice
+	}
 	if i.parsedItem.Quantity > 0 {
 		return i.parsedItem.Price / float64(i.parsedItem.Quantity)
 	}
@@ -286,14 +318,21 @@ func (i *OrderItem) GetDescription() string {
 	return i.parsedItem.Name
 }
 
-// GetSKU returns the SKU (not available from CLI output)
+// GetSKU returns the item's ASIN, when Amazon reported one
 func (i *OrderItem) GetSKU() string {
-	return ""
+	return i.parsedItem.ASIN
 }
 
-// GetCategory returns the item category (not available from CLI output)
+// GetCategory returns the kind of digital purchase or recurring charge (e.g.
+// "Kindle eBook"); physical items have no provider category
 func (i *OrderItem) GetCategory() string {
-	return ""
+	return i.parsedItem.Kind.Label()
+}
+
+// PinnedCategory implements providers.PinnedCategoryItem for digital items
+// whose kind has a configured Monarch category
+func (i *OrderItem) PinnedCategory() string {
+	return i.parsedItem.PinnedCategory
 }
 
 // Ensure interfaces are implemented at compile time
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -370,3 +371,17 @@ func TestOrder_GetItemsForChargeUsesLineTotalsForShipmentMatching(t *testing.T)
 	assert.Equal(t, 80.0, items[0].GetPrice())
 	assert.Equal(t, 2.0, items[0].GetQuantity())
 }
+
+func TestOrder_GetPaymentCards(t *testing.T) {
+	order := NewOrder(&ParsedOrder{
+		ID: "112-4559127-2161020",
+		Transactions: []*ParsedTransaction{
+			{Amount: 52.55, Description: "Prime Visa ****1211", Last4: "1211", Type: "charge"},
+			{Amount: 50.72, Description: "Prime Visa ****1211", Last4: "1211", Type: "charge"},
+			{Amount: 8.03, Description: "Amazon Visa points", Type: "charge"},
+			{Amount: 12.00, Description: "Amex ****3005", Last4: "3005", Type: "refund"},
+		},
+	}, nil)
+
+	assert.Equal(t, []providers.PaymentCard{{Type: "Prime Visa", LastFour: "1211"}}, order.GetPaymentCards())
+}
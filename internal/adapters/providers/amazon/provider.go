@@ -44,6 +44,17 @@ type Provider struct {
 	cookieFile  string
 	fingerprint *amazongo.BrowserFingerprint
 	client      amazonClient
+
+	// digital also fetches digital orders and subscription charges (see
+	// SetDigitalOrders); digitalCategories pins their items by kind.
+	digital           bool
+	digitalCategories map[DigitalKind]string
+	digitalSource     historySource
+
+	// grocery also reads Amazon Fresh and Whole Foods receipts (see
+	// SetGroceryOrders).
+	grocery       bool
+	grocerySource historySource
 }
 
 // ProviderConfig holds configuration for the Amazon provider.
@@ -129,6 +140,14 @@ func (p *Provider) DisplayName() string {
 	return "Amazon"
 }
 
+// Accounts returns the cookie account this provider reads, if one was named.
+func (p *Provider) Accounts() []string {
+	if p.profile == "" {
+		return nil
+	}
+	return []string{p.profile}
+}
+
 // FetchOrders fetches orders from Amazon within the specified date range.
 func (p *Provider) FetchOrders(ctx context.Context, opts providers.FetchOptions) ([]providers.Order, error) {
 	p.logger.Info("fetching orders",
@@ -155,7 +174,7 @@ func (p *Provider) FetchOrders(ctx context.Context, opts providers.FetchOptions)
 		return nil, fmt.Errorf("failed to fetch Amazon orders: %w", err)
 	}
 
-	orders := make([]providers.Order, 0, len(amazonOrders))
+	parsedOrders := make([]*ParsedOrder, 0, len(amazonOrders))
 	for _, amazonOrder := range amazonOrders {
 		if amazonOrder == nil {
 			continue
@@ -167,9 +186,35 @@ func (p *Provider) FetchOrders(ctx context.Context, opts providers.FetchOptions)
 				slog.String("order_id", amazonOrder.ID),
 				slog.String("error", err.Error()))
 		}
+		parsedOrders = append(parsedOrders, convertGoOrder(amazonOrder, transactions))
+	}
+
+	if p.grocery {
+		groceryOrders, err := p.fetchGroceryOrders(ctx, client, opts, parsedOrders)
+		if err != nil {
+			// Fresh and Whole Foods delivery orders are still synced from
+			// the regular history, without their itemized receipts.
+			p.logger.Warn("failed to fetch Amazon Fresh and Whole Foods orders", slog.String("error", err.Error()))
+		} else {
+			parsedOrders = groceryOrders
+		}
+	}
 
-		parsedOrder := convertGoOrder(amazonOrder, transactions)
-		orders = append(orders, NewOrder(parsedOrder, p.logger))
+	orders := make([]providers.Order, 0, len(parsedOrders))
+	for _, parsed := range parsedOrders {
+		order := NewOrder(parsed, p.logger)
+		order.account = p.profile
+		orders = append(orders, order)
+	}
+
+	if p.digital {
+		digitalOrders, err := p.fetchDigitalOrders(ctx, client, opts)
+		if err != nil {
+			// Physical orders are still worth syncing; digital charges stay
+			// uncategorized until the next run.
+			p.logger.Warn("failed to fetch Amazon digital orders", slog.String("error", err.Error()))
+		}
+		orders = append(orders, digitalOrders...)
 	}
 
 	p.logger.Info("processed orders", slog.Int("count", len(orders)))
@@ -178,6 +223,127 @@ func (p *Provider) FetchOrders(ctx context.Context, opts providers.FetchOptions)
 	return orders, nil
 }
 
+// SetDigitalOrders enables fetching digital orders (Kindle, Prime Video,
+// apps, music, Audible) and membership and subscription charges alongside
+// physical orders. categories maps a DigitalKind (e.g. "kindle") to the
+// Monarch category, ID or name, its items are assigned without
+// categorization.
+func (p *Provider) SetDigitalOrders(enabled bool, categories map[string]string) error {
+	pinned := make(map[DigitalKind]string, len(categories))
+	for key, category := range categories {
+		kind, err := ParseDigitalKind(key)
+		if err != nil {
+			return err
+		}
+		if category = strings.TrimSpace(category); category != "" {
+			pinned[kind] = category
+		}
+	}
+	p.digital = enabled
+	p.digitalCategories = pinned
+	return nil
+}
+
+// SetGroceryOrders enables reading the itemized receipts of Amazon Fresh and
+// Whole Foods Market orders, both delivery and in-store purchases paid with
+// the Prime code.
+func (p *Provider) SetGroceryOrders(enabled bool) {
+	p.grocery = enabled
+}
+
+// fetchGroceryOrders reads the Amazon Fresh and Whole Foods receipts in the
+// window. It returns orders with each grocery delivery order replaced by its
+// receipt, followed by in-store purchases.
+func (p *Provider) fetchGroceryOrders(ctx context.Context, client amazonClient, opts providers.FetchOptions, orders []*ParsedOrder) ([]*ParsedOrder, error) {
+	source := p.grocerySource
+	if source == nil {
+		cookieFile, fingerprint, err := p.historyCredentials()
+		if err != nil {
+			return nil, err
+		}
+		source = newGroceryHistoryClient(cookieFile, fingerprint)
+	}
+
+	receipts, err := source.Fetch(ctx, opts.StartDate, opts.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	merged, inStore := mergeGroceryOrders(append([]*ParsedOrder(nil), orders...), receipts)
+	for _, parsed := range inStore {
+		p.attachTransactions(ctx, client, parsed)
+	}
+	p.logger.Info("processed grocery orders",
+		slog.Int("receipts", len(receipts)),
+		slog.Int("in_store", len(inStore)))
+	return append(merged, inStore...), nil
+}
+
+// fetchDigitalOrders fetches digital orders and subscription charges in the
+// window and looks up the payment transactions of digital orders.
+func (p *Provider) fetchDigitalOrders(ctx context.Context, client amazonClient, opts providers.FetchOptions) ([]providers.Order, error) {
+	source := p.digitalSource
+	if source == nil {
+		cookieFile, fingerprint, err := p.historyCredentials()
+		if err != nil {
+			return nil, err
+		}
+		source = newDigitalHistoryClient(cookieFile, fingerprint, p.logger)
+	}
+
+	parsedOrders, err := source.Fetch(ctx, opts.StartDate, opts.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]providers.Order, 0, len(parsedOrders))
+	for _, parsed := range parsedOrders {
+		p.attachTransactions(ctx, client, parsed)
+		for _, item := range parsed.Items {
+			item.PinnedCategory = p.digitalCategories[item.Kind]
+		}
+
+		order := NewOrder(parsed, p.logger)
+		order.account = p.profile
+		orders = append(orders, order)
+	}
+	p.logger.Info("processed digital orders", slog.Int("count", len(orders)))
+	return orders, nil
+}
+
+// historyCredentials returns the cookie file and browser fingerprint used to
+// read account pages the amazon-go client doesn't cover.
+func (p *Provider) historyCredentials() (string, *amazongo.BrowserFingerprint, error) {
+	cookieFile, err := p.resolvedCookieFile()
+	if err != nil {
+		return "", nil, err
+	}
+	fingerprint, err := p.resolveBrowserFingerprint(cookieFile)
+	if err != nil {
+		return "", nil, err
+	}
+	return cookieFile, fingerprint, nil
+}
+
+// attachTransactions looks up the payment transactions of an order read from
+// a page other than the regular order history, unless it already has some.
+func (p *Provider) attachTransactions(ctx context.Context, client amazonClient, parsed *ParsedOrder) {
+	if len(parsed.Transactions) > 0 {
+		return
+	}
+	transactions, err := client.FetchTransactions(ctx, parsed.ID)
+	if err != nil {
+		p.logger.Warn("failed to fetch Amazon transactions",
+			slog.String("order_id", parsed.ID),
+			slog.String("error", err.Error()))
+	}
+	for _, tx := range transactions {
+		if tx != nil {
+			parsed.Transactions = append(parsed.Transactions, convertGoTransaction(tx))
+		}
+	}
+}
+
 // GetOrderDetails fetches details for a specific order.
 func (p *Provider) GetOrderDetails(ctx context.Context, orderID string) (providers.Order, error) {
 	client, err := p.getClient()
@@ -194,7 +360,9 @@ func (p *Provider) GetOrderDetails(ctx context.Context, orderID string) (provide
 	}
 	p.saveCookies(client)
 
-	return NewOrder(convertGoOrder(amazonOrder, transactions), p.logger), nil
+	order := NewOrder(convertGoOrder(amazonOrder, transactions), p.logger)
+	order.account = p.profile
+	return order, nil
 }
 
 // SupportsDeliveryTips returns whether Amazon supports delivery tips.
@@ -235,18 +403,32 @@ func (p *Provider) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// merchantSearchTerms are the Monarch merchant names Amazon charges post
+// under, including Amazon Fresh and Whole Foods Market.
+var merchantSearchTerms = []string{
+	"Amazon",
+	"AMZN",
+	"Amzn Mktp",
+	"AMZN Mktp US",
+	"Amazon.com",
+	"Amazon Prime",
+	"Prime Video",
+	"Kindle",
+	"Audible",
+	"Amazon Fresh",
+	"Whole Foods",
+	"WholeFds",
+}
+
 // MerchantSearchTerms returns the merchant names to search for in Monarch.
 func (p *Provider) MerchantSearchTerms() []string {
-	return []string{
-		"Amazon",
-		"AMZN",
-		"Amzn Mktp",
-		"AMZN Mktp US",
-		"Amazon.com",
-		"Amazon Prime",
-		"Prime Video",
-		"Whole Foods",
-	}
+	return MerchantSearchTerms()
+}
+
+// MerchantSearchTerms returns the Monarch merchant names Amazon charges post
+// under, for callers without a Provider.
+func MerchantSearchTerms() []string {
+	return append([]string(nil), merchantSearchTerms...)
 }
 
 func (p *Provider) getClient() (amazonClient, error) {
@@ -366,6 +548,7 @@ func convertGoOrder(order *amazongo.Order, transactions []*amazongo.Transaction)
 			Name:     item.Name,
 			Price:    price,
 			Quantity: quantity,
+			ASIN:     item.ASIN,
 		})
 	}
 
@@ -44,6 +44,7 @@ type ReturnRecord struct {
 	RefundIssuedAt *time.Time     `json:"refund_issued_at,omitempty"`
 	Status         string         `json:"status"`
 	Items          []ReturnedItem `json:"items"`
+	Account        string         `json:"account,omitempty"`
 	statusURL      string
 }
 
@@ -80,6 +81,7 @@ func (o *RefundOrder) GetFees() float64        { return 0 }
 func (o *RefundOrder) GetProviderName() string { return "Amazon" }
 func (o *RefundOrder) GetRawData() interface{} { return o.record }
 func (o *RefundOrder) Record() ReturnRecord    { return o.record }
+func (o *RefundOrder) GetAccount() string      { return o.record.Account }
 func (o *RefundOrder) GetItems() []providers.OrderItem {
 	items := make([]providers.OrderItem, 0, len(o.record.Items))
 	for _, item := range o.record.Items {
@@ -140,6 +142,9 @@ func (p *Provider) FetchReturns(ctx context.Context) ([]ReturnRecord, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Amazon returns: %w", err)
 	}
+	for i := range returns {
+		returns[i].Account = p.profile
+	}
 	return returns, nil
 }
 
@@ -162,17 +167,9 @@ func (p *Provider) resolvedCookieFile() (string, error) {
 }
 
 func (c *returnHistoryClient) Fetch(ctx context.Context) ([]ReturnRecord, error) {
-	store, err := amazongo.NewCookieStore(c.cookieFile)
+	cookies, err := c.loadCookies()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load Amazon cookies: %w", err)
-	}
-
-	cookies := store.ToHTTPCookies()
-	for _, cookie := range cookies {
-		normalizeReturnCookieValue(cookie)
-	}
-	if c.fingerprint == nil {
-		c.fingerprint = store.BrowserFingerprint()
+		return nil, err
 	}
 	body, finalURL, err := c.fetchPage(ctx, c.historyURL, cookies)
 	if err != nil {
@@ -208,6 +205,24 @@ func (c *returnHistoryClient) Fetch(ctx context.Context) ([]ReturnRecord, error)
 	return returns, nil
 }
 
+// loadCookies reads the saved cookie file without modifying it, adopting the
+// browser fingerprint saved with the cookies when none was configured.
+func (c *returnHistoryClient) loadCookies() ([]*http.Cookie, error) {
+	store, err := amazongo.NewCookieStore(c.cookieFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Amazon cookies: %w", err)
+	}
+
+	cookies := store.ToHTTPCookies()
+	for _, cookie := range cookies {
+		normalizeReturnCookieValue(cookie)
+	}
+	if c.fingerprint == nil {
+		c.fingerprint = store.BrowserFingerprint()
+	}
+	return cookies, nil
+}
+
 func (c *returnHistoryClient) fetchPage(ctx context.Context, target string, cookies []*http.Cookie) ([]byte, *url.URL, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
 	if err != nil {
@@ -252,6 +267,19 @@ func (c *returnHistoryClient) fetchPage(ctx context.Context, target string, cook
 	return body, responseURL(resp), nil
 }
 
+// fetchSignedIn fetches an account page, failing when Amazon answers with
+// its sign-in page instead. what names the page in errors.
+func (c *returnHistoryClient) fetchSignedIn(ctx context.Context, target string, cookies []*http.Cookie, what string) ([]byte, error) {
+	body, finalURL, err := c.fetchPage(ctx, target, cookies)
+	if err != nil {
+		return nil, fmt.Errorf("amazon %s request failed: %w", what, err)
+	}
+	if isReturnSignInPage(finalURL, body) {
+		return nil, fmt.Errorf("amazon %s authentication required: sign in again with the account browser profile", what)
+	}
+	return body, nil
+}
+
 func responseURL(resp *http.Response) *url.URL {
 	if resp != nil && resp.Request != nil {
 		return resp.Request.URL
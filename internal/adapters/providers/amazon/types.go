@@ -17,9 +17,13 @@ type ParsedOrder struct {
 	Subtotal     float64
 	Tax          float64
 	Shipping     float64
+	Tip          float64 // Amazon Fresh and Whole Foods delivery tip
 	Items        []*ParsedOrderItem
 	Shipments    []*ParsedShipment
 	Transactions []*ParsedTransaction
+
+	// Store is StoreWholeFoods or StoreAmazonFresh for grocery orders.
+	Store string
 }
 
 // ParsedOrderItem is the internal representation of an order item.
@@ -27,6 +31,18 @@ type ParsedOrderItem struct {
 	Name     string
 	Price    float64
 	Quantity int
+	ASIN     string
+
+	// Weight is the weight in pounds of an item sold by weight, and
+	// UnitPrice its price per pound.
+	Weight    float64
+	UnitPrice float64
+
+	// Kind is set for digital purchases and recurring charges (see
+	// DigitalKind); PinnedCategory is the Monarch category configured for
+	// that kind, if any.
+	Kind           DigitalKind
+	PinnedCategory string
 }
 
 // ParsedTransaction is the internal representation of a transaction.
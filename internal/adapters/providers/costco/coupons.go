@@ -0,0 +1,113 @@
+package costco
+
+import (
+	"fmt"
+	"strings"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+)
+
+// foldedItems is the result of folding a receipt's credit lines into the
+// items they reduce.
+type foldedItems struct {
+	items    []costcogo.ReceiptItem // Purchased items with coupons and credits applied
+	orphaned []costcogo.ReceiptItem // "/"-coupons whose item isn't on the receipt
+	credits  []costcogo.ReceiptItem // Receipt-wide credits spread across all items
+}
+
+// foldCoupons folds coupon and credit lines into the purchased items so that
+// every remaining item is something that was bought, at the price paid.
+//
+// Instant Savings coupons normally reference their item as "/1553261" and are
+// netted by costcogo.NetDiscounts. Some coupons carry the reference after a
+// prefix ("TPD/1553261"); those are normalized first. Negative lines with no
+// reference at all, such as an Executive Member 2% reward redeemed at the
+// register, discount the whole receipt and are spread across the items in
+// proportion to their price.
+func foldCoupons(lines []costcogo.ReceiptItem) foldedItems {
+	var result foldedItems
+	normalized := make([]costcogo.ReceiptItem, 0, len(lines))
+	for _, line := range lines {
+		if line.Amount >= 0 || line.IsDiscount() {
+			normalized = append(normalized, line)
+			continue
+		}
+		if ref := couponReference(line.ItemDescription01); ref != "" {
+			line.ItemDescription01 = "/" + ref
+			if line.Unit >= 0 {
+				line.Unit = -1
+			}
+			normalized = append(normalized, line)
+			continue
+		}
+		result.credits = append(result.credits, line)
+	}
+
+	result.items, result.orphaned = costcogo.NetDiscounts(normalized)
+	spreadCredits(result.items, result.credits)
+	return result
+}
+
+// couponReference returns the item reference of a coupon described as
+// "PREFIX/ref", or "" when the description has no reference.
+func couponReference(description string) string {
+	idx := strings.LastIndex(description, "/")
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(description[idx+1:])
+}
+
+// spreadCredits applies receipt-wide credits to items pro-rata by price. The
+// last item absorbs rounding so the items sum to the discounted total.
+func spreadCredits(items []costcogo.ReceiptItem, credits []costcogo.ReceiptItem) {
+	credit := 0.0
+	for _, line := range credits {
+		credit += line.Amount
+	}
+	if credit == 0 || len(items) == 0 {
+		return
+	}
+
+	base := 0.0
+	for _, item := range items {
+		base += item.Amount
+	}
+	if base <= 0 {
+		return
+	}
+
+	applied := 0.0
+	for i := range items {
+		share := roundCurrency(credit * items[i].Amount / base)
+		if i == len(items)-1 {
+			share = roundCurrency(credit - applied)
+		}
+		items[i].Amount = roundCurrency(items[i].Amount + share)
+		applied += share
+	}
+}
+
+// isFuelReceipt reports whether a receipt is from a Costco gas station.
+func isFuelReceipt(receipt *costcogo.Receipt) bool {
+	docType := strings.ToUpper(receipt.DocumentType)
+	receiptType := strings.ToUpper(receipt.ReceiptType)
+	return strings.Contains(docType, "FUEL") || strings.Contains(receiptType, "GAS")
+}
+
+// isFuelItem reports whether a receipt line is a fuel sale.
+func isFuelItem(item costcogo.ReceiptItem) bool {
+	return item.FuelUnitQuantity != 0 || item.FuelGradeCode != "" || item.FuelGradeDescription != ""
+}
+
+// fuelItemName names a fuel line by its grade, e.g. "Gas (Regular)".
+func fuelItemName(item costcogo.ReceiptItem) string {
+	grade := strings.TrimSpace(item.FuelGradeDescription)
+	if grade == "" {
+		grade = strings.TrimSpace(item.ItemDescription01)
+	}
+	if grade == "" {
+		return "Gas"
+	}
+	return fmt.Sprintf("Gas (%s)", grade)
+}
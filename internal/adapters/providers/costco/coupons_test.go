@@ -0,0 +1,182 @@
+package costco
+
+import (
+	"log/slog"
+	"testing"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertReceipt_InstantSavings(t *testing.T) {
+	provider := NewProvider(nil, slog.Default())
+
+	t.Run("prefixed coupon reference is folded into its item", func(t *testing.T) {
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "TPD001",
+			TransactionDate:    "2026-05-09",
+			Total:              19.99,
+			SubTotal:           19.99,
+			ItemArray: []costcogo.ReceiptItem{
+				{ItemNumber: "1553261", ItemDescription01: "KS OLIVE OIL", Amount: 24.99, Unit: 1, ItemUnitPriceAmount: 24.99},
+				{ItemNumber: "348920", ItemDescription01: "TPD/1553261", Amount: -5.00, Unit: 1},
+			},
+		}
+
+		items := provider.convertReceipt(receipt, true).GetItems()
+
+		require.Len(t, items, 1, "coupon should not become its own item")
+		assert.Equal(t, "KS OLIVE OIL", items[0].GetName())
+		assert.InDelta(t, 19.99, items[0].GetPrice(), 0.001)
+	})
+
+	t.Run("executive reward line is spread across items", func(t *testing.T) {
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "EXEC001",
+			TransactionDate:    "2026-05-09",
+			Total:              90.00,
+			SubTotal:           90.00,
+			ItemArray: []costcogo.ReceiptItem{
+				{ItemNumber: "111111", ItemDescription01: "PAPER TOWEL", Amount: 25.00, Unit: 1},
+				{ItemNumber: "222222", ItemDescription01: "COFFEE", Amount: 75.00, Unit: 1},
+				{ItemNumber: "999999", ItemDescription01: "EXEC 2% REWARD", Amount: -10.00, Unit: 1},
+			},
+			TenderArray: []costcogo.Tender{
+				{TenderDescription: "VISA", AmountTender: 90.00},
+			},
+		}
+
+		order := provider.convertReceipt(receipt, true)
+		items := order.GetItems()
+
+		require.Len(t, items, 2, "reward should not become a negative item")
+		assert.InDelta(t, 22.50, items[0].GetPrice(), 0.001)
+		assert.InDelta(t, 67.50, items[1].GetPrice(), 0.001)
+		assert.InDelta(t, 90.00, order.GetTotal(), 0.001)
+	})
+
+	t.Run("credit spread absorbs rounding in the last item", func(t *testing.T) {
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "ROUND001",
+			TransactionDate:    "2026-05-09",
+			Total:              9.00,
+			SubTotal:           9.00,
+			ItemArray: []costcogo.ReceiptItem{
+				{ItemNumber: "1", ItemDescription01: "A", Amount: 3.33, Unit: 1},
+				{ItemNumber: "2", ItemDescription01: "B", Amount: 3.33, Unit: 1},
+				{ItemNumber: "3", ItemDescription01: "C", Amount: 3.34, Unit: 1},
+				{ItemNumber: "4", ItemDescription01: "INSTANT SAVINGS", Amount: -1.00, Unit: 1},
+			},
+		}
+
+		items := provider.convertReceipt(receipt, true).GetItems()
+
+		require.Len(t, items, 3)
+		total := 0.0
+		for _, item := range items {
+			total += item.GetPrice()
+		}
+		assert.InDelta(t, 9.00, total, 0.001)
+	})
+
+	t.Run("executive reward tender is not a bank charge", func(t *testing.T) {
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "EXEC002",
+			TransactionDate:    "2026-05-09",
+			Total:              100.00,
+			SubTotal:           100.00,
+			ItemArray: []costcogo.ReceiptItem{
+				{ItemNumber: "111111", ItemDescription01: "TV", Amount: 100.00, Unit: 1},
+			},
+			TenderArray: []costcogo.Tender{
+				{TenderDescription: "EXECUTIVE REWARD", TenderTypeName: "VISA", AmountTender: 40.00},
+				{TenderDescription: "COSTCO VISA", AmountTender: 60.00},
+			},
+		}
+
+		order := provider.convertReceipt(receipt, true)
+
+		assert.InDelta(t, 60.00, order.GetTotal(), 0.001)
+		require.Len(t, order.GetItems(), 1)
+		assert.InDelta(t, 60.00, order.GetItems()[0].GetPrice(), 0.001)
+	})
+}
+
+func TestConvertReceipt_Gas(t *testing.T) {
+	gasReceipt := func() *costcogo.Receipt {
+		return &costcogo.Receipt{
+			TransactionBarcode: "GAS001",
+			TransactionDate:    "2026-05-09",
+			ReceiptType:        "Gas Station",
+			DocumentType:       "FuelReceipts",
+			Total:              52.37,
+			SubTotal:           52.37,
+			ItemArray: []costcogo.ReceiptItem{
+				{
+					ItemNumber:           "300",
+					ItemDescription01:    "REG",
+					Amount:               52.37,
+					Unit:                 13,
+					ItemUnitPriceAmount:  3.899,
+					FuelUnitQuantity:     13.432,
+					FuelGradeCode:        "001",
+					FuelGradeDescription: "Regular",
+				},
+			},
+			TenderArray: []costcogo.Tender{
+				{TenderDescription: "COSTCO VISA", AmountTender: 52.37},
+			},
+		}
+	}
+
+	t.Run("fuel uses fractional gallons and the default gas category", func(t *testing.T) {
+		provider := NewProvider(nil, slog.Default())
+
+		order := provider.convertReceipt(gasReceipt(), true)
+
+		require.Len(t, order.GetItems(), 1)
+		item := order.GetItems()[0]
+		assert.Equal(t, "Gas (Regular)", item.GetName())
+		assert.InDelta(t, 13.432, item.GetQuantity(), 0.0001)
+		assert.InDelta(t, 52.37, item.GetPrice(), 0.001)
+		assert.Equal(t, DefaultGasCategory, providers.PinnedCategory(item))
+	})
+
+	t.Run("configured gas category is pinned", func(t *testing.T) {
+		provider := NewProvider(nil, slog.Default())
+		provider.SetGasCategory("Auto & Transport")
+
+		item := provider.convertReceipt(gasReceipt(), true).GetItems()[0]
+
+		assert.Equal(t, "Auto & Transport", providers.PinnedCategory(item))
+	})
+
+	t.Run("empty gas category restores the default", func(t *testing.T) {
+		provider := NewProvider(nil, slog.Default())
+		provider.SetGasCategory("  ")
+
+		item := provider.convertReceipt(gasReceipt(), true).GetItems()[0]
+
+		assert.Equal(t, DefaultGasCategory, providers.PinnedCategory(item))
+	})
+
+	t.Run("warehouse items are not pinned", func(t *testing.T) {
+		provider := NewProvider(nil, slog.Default())
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "WH001",
+			TransactionDate:    "2026-05-09",
+			Total:              5.00,
+			SubTotal:           5.00,
+			ItemArray: []costcogo.ReceiptItem{
+				{ItemNumber: "1", ItemDescription01: "MILK", Amount: 5.00, Unit: 1},
+			},
+		}
+
+		item := provider.convertReceipt(receipt, true).GetItems()[0]
+
+		assert.Equal(t, "MILK", item.GetName())
+		assert.Empty(t, providers.PinnedCategory(item))
+	})
+}
@@ -14,11 +14,16 @@ import (
 
 // Provider implements the OrderProvider interface for Costco
 type Provider struct {
-	client    *costcogo.Client
-	logger    *slog.Logger
-	rateLimit time.Duration
+	client      *costcogo.Client
+	logger      *slog.Logger
+	rateLimit   time.Duration
+	gasCategory string // Monarch category (ID or name) for gas station fuel
 }
 
+// DefaultGasCategory is the Monarch category fuel is assigned to when no
+// other category is configured.
+const DefaultGasCategory = "Gas"
+
 // NewProvider creates a new Costco provider
 // Note: Caller is responsible for adding any scoping attributes (e.g., system="costco")
 func NewProvider(client *costcogo.Client, logger *slog.Logger) *Provider {
@@ -26,10 +31,22 @@ func NewProvider(client *costcogo.Client, logger *slog.Logger) *Provider {
 		logger = slog.Default()
 	}
 	return &Provider{
-		client:    client,
-		logger:    logger,
-		rateLimit: 3 * time.Second, // Conservative rate limit for Costco
+		client:      client,
+		logger:      logger,
+		rateLimit:   3 * time.Second, // Conservative rate limit for Costco
+		gasCategory: DefaultGasCategory,
+	}
+}
+
+// SetGasCategory sets the Monarch category (ID or name) that fuel from Costco
+// gas stations is assigned to without consulting the categorizer. An empty
+// value restores the default.
+func (p *Provider) SetGasCategory(category string) {
+	category = strings.TrimSpace(category)
+	if category == "" {
+		category = DefaultGasCategory
 	}
+	p.gasCategory = category
 }
 
 // Name returns the provider identifier
@@ -99,6 +116,10 @@ func (p *Provider) fetchOnlineOrders(ctx context.Context, opts providers.FetchOp
 	for _, onlineOrder := range response.BCOrders {
 		order := p.convertOnlineOrder(&onlineOrder, opts.IncludeDetails)
 		orders = append(orders, order)
+		// A returned order was still charged; its refund is a separate credit.
+		if refund := NewOnlineRefundOrder(&onlineOrder); refund != nil {
+			orders = append(orders, refund)
+		}
 	}
 
 	return orders, nil
@@ -132,16 +153,13 @@ func (p *Provider) fetchReceipts(ctx context.Context, opts providers.FetchOption
 					slog.String("error", err.Error()),
 				)
 				// Fall back to the summary data
-				order := p.convertReceipt(&receipt, false)
-				orders = append(orders, order)
+				orders = append(orders, p.convertAnyReceipt(&receipt, false))
 			} else {
-				order := p.convertReceipt(fullReceipt, true)
-				orders = append(orders, order)
+				orders = append(orders, p.convertAnyReceipt(fullReceipt, true))
 			}
 		} else {
 			// Just use the summary data
-			order := p.convertReceipt(&receipt, false)
-			orders = append(orders, order)
+			orders = append(orders, p.convertAnyReceipt(&receipt, false))
 		}
 	}
 
@@ -154,13 +172,16 @@ func (p *Provider) GetOrderDetails(ctx context.Context, orderID string) (provide
 	// For now, we'll need to determine if this is a receipt barcode or online order ID
 	// This is a simplified implementation
 
+	// Refunds are looked up by the receipt they came from
+	orderID = strings.TrimPrefix(orderID, "costco-refund:")
+
 	// Assume it's a receipt barcode if it looks like one
 	if len(orderID) > 10 {
 		receipt, err := p.client.GetReceiptDetail(ctx, orderID, "warehouse")
 		if err != nil {
 			return nil, fmt.Errorf("failed to get receipt details: %w", err)
 		}
-		return p.convertReceipt(receipt, true), nil
+		return p.convertAnyReceipt(receipt, true), nil
 	}
 
 	// Otherwise treat as online order (would need to implement proper lookup)
@@ -172,9 +193,11 @@ func (p *Provider) SupportsDeliveryTips() bool {
 	return false // Costco doesn't have delivery tips in the same way as other services
 }
 
-// SupportsRefunds indicates whether this provider can handle refunds
+// SupportsRefunds indicates whether this provider can handle refunds.
+// Refund receipts and returned online orders are returned by FetchOrders as
+// *RefundOrder values.
 func (p *Provider) SupportsRefunds() bool {
-	return false // Not implemented yet
+	return true
 }
 
 // SupportsBulkFetch indicates whether this provider can fetch multiple orders at once
@@ -227,50 +250,62 @@ func (p *Provider) convertOnlineOrder(order *costcogo.OnlineOrder, includeDetail
 	}
 }
 
-// convertReceipt converts a Costco receipt to the generic Order interface
-func (p *Provider) convertReceipt(receipt *costcogo.Receipt, hasDetails bool) providers.Order {
-	// Try different date formats
-	var receiptDate time.Time
-	var err error
-
-	// Try ISO date format first
-	receiptDate, err = time.Parse("2006-01-02", receipt.TransactionDate)
-	if err != nil {
-		// Try datetime format
-		receiptDate, err = time.Parse("2006-01-02T15:04:05", receipt.TransactionDateTime)
-		if err != nil {
-			// Default to current time if parsing fails
-			receiptDate = time.Now()
-		}
+// convertAnyReceipt converts a purchase receipt to a CostcoOrder and a refund
+// receipt to a RefundOrder
+func (p *Provider) convertAnyReceipt(receipt *costcogo.Receipt, hasDetails bool) providers.Order {
+	if IsRefundReceipt(receipt) {
+		return p.convertRefundReceipt(receipt, hasDetails)
 	}
+	return p.convertReceipt(receipt, hasDetails)
+}
 
+// convertReceipt converts a Costco receipt to the generic Order interface
+func (p *Provider) convertReceipt(receipt *costcogo.Receipt, hasDetails bool) providers.Order {
 	chargeTotal, allocationRatio := receiptChargeTotal(receipt)
 
 	var items []providers.OrderItem
 	if hasDetails {
-		netted, orphaned := costcogo.NetDiscounts(receipt.ItemArray)
-		for _, disc := range orphaned {
+		folded := foldCoupons(receipt.ItemArray)
+		for _, disc := range folded.orphaned {
 			p.logger.Warn("found orphaned discount with no matching parent item",
 				"discount_item", disc.ItemNumber,
 				"parent_item_ref", disc.GetParentItemNumber(),
 				"discount_amount", disc.Amount,
 			)
 		}
-		for _, item := range netted {
+		for _, credit := range folded.credits {
+			p.logger.Debug("spreading receipt credit across items",
+				"receipt", receipt.TransactionBarcode,
+				"description", credit.ItemDescription01,
+				"amount", credit.Amount,
+			)
+		}
+		fuelReceipt := isFuelReceipt(receipt)
+		for _, item := range folded.items {
 			itemAmount := roundCurrency(item.Amount * allocationRatio)
 			unitPrice := item.ItemUnitPriceAmount
 			if unitPrice != 0 {
 				unitPrice = roundCurrency(unitPrice * allocationRatio)
 			}
 
-			items = append(items, &CostcoOrderItem{
+			orderItem := &CostcoOrderItem{
 				name:        item.ItemDescription01,
 				price:       itemAmount,
 				quantity:    float64(item.Unit),
 				unitPrice:   unitPrice,
 				sku:         item.ItemNumber,
 				description: fmt.Sprintf("%s %s", item.ItemDescription01, item.ItemDescription02),
-			})
+			}
+			if isFuelItem(item) || (fuelReceipt && len(folded.items) == 1) {
+				// Gas is sold by the fractional gallon; the Unit field is
+				// rounded (or zero) and the line name is just the grade
+				orderItem.name = fuelItemName(item)
+				if item.FuelUnitQuantity != 0 {
+					orderItem.quantity = item.FuelUnitQuantity
+				}
+				orderItem.pinnedCategory = p.gasCategory
+			}
+			items = append(items, orderItem)
 		}
 	}
 
@@ -279,7 +314,7 @@ func (p *Provider) convertReceipt(receipt *costcogo.Receipt, hasDetails bool) pr
 
 	return &CostcoOrder{
 		id:           receipt.TransactionBarcode,
-		date:         receiptDate,
+		date:         receiptDate(receipt),
 		total:        chargeTotal,
 		subtotal:     subtotal,
 		tax:          tax,
@@ -290,6 +325,18 @@ func (p *Provider) convertReceipt(receipt *costcogo.Receipt, hasDetails bool) pr
 	}
 }
 
+// receiptDate parses the receipt's transaction date, trying the ISO date
+// first and then the datetime. Defaults to the current time if neither parses.
+func receiptDate(receipt *costcogo.Receipt) time.Time {
+	if date, err := time.Parse("2006-01-02", receipt.TransactionDate); err == nil {
+		return date
+	}
+	if date, err := time.Parse("2006-01-02T15:04:05", receipt.TransactionDateTime); err == nil {
+		return date
+	}
+	return time.Now()
+}
+
 func receiptChargeTotal(receipt *costcogo.Receipt) (float64, float64) {
 	total := roundCurrency(receipt.Total)
 	cardTotal := 0.0
@@ -315,19 +362,28 @@ func receiptChargeTotal(receipt *costcogo.Receipt) (float64, float64) {
 }
 
 func isBankCardTender(tender costcogo.Tender) bool {
+	if isNonBankTender(tender) {
+		return false
+	}
+
 	description := strings.ToUpper(strings.TrimSpace(tender.TenderDescription))
 	typeName := strings.ToUpper(strings.TrimSpace(tender.TenderTypeName))
 	value := description + " " + typeName
 
-	excluded := []string{"CASH", "CHANGE", "REBATE", "SHOP CARD", "GIFT CARD"}
-	for _, term := range excluded {
+	cardTerms := []string{"VISA", "MASTERCARD", "DEBIT", "DISCOVER", "AMEX", "AMERICAN EXPRESS"}
+	for _, term := range cardTerms {
 		if strings.Contains(value, term) {
-			return false
+			return true
 		}
 	}
+	return false
+}
 
-	cardTerms := []string{"VISA", "MASTERCARD", "DEBIT", "DISCOVER", "AMEX", "AMERICAN EXPRESS"}
-	for _, term := range cardTerms {
+// isNonBankTender reports whether a tender is paid with something other than a
+// bank card, such as cash, a shop card or an executive reward.
+func isNonBankTender(tender costcogo.Tender) bool {
+	value := strings.ToUpper(strings.TrimSpace(tender.TenderDescription) + " " + strings.TrimSpace(tender.TenderTypeName))
+	for _, term := range []string{"CASH", "CHANGE", "REBATE", "REWARD", "SHOP CARD", "GIFT CARD"} {
 		if strings.Contains(value, term) {
 			return true
 		}
@@ -365,6 +421,31 @@ func (o *CostcoOrder) GetItems() []providers.OrderItem { return o.items }
 func (o *CostcoOrder) GetProviderName() string         { return o.providerName }
 func (o *CostcoOrder) GetRawData() interface{}         { return o.rawData }
 
+// GetPaymentBreakdown splits a warehouse receipt's total into its bank card
+// tenders and its non-bank tenders. Tenders that are neither are left out, so
+// the card charges won't add up. Online orders and receipts without tenders
+// have no breakdown.
+func (o *CostcoOrder) GetPaymentBreakdown() (*providers.PaymentBreakdown, error) {
+	receipt, ok := o.rawData.(*costcogo.Receipt)
+	if !ok || len(receipt.TenderArray) == 0 {
+		return nil, nil
+	}
+
+	breakdown := &providers.PaymentBreakdown{Total: roundCurrency(receipt.Total)}
+	for _, tender := range receipt.TenderArray {
+		switch {
+		case isBankCardTender(tender):
+			breakdown.BankCharges = append(breakdown.BankCharges, roundCurrency(tender.AmountTender))
+		case isNonBankTender(tender):
+			breakdown.NonBank = append(breakdown.NonBank, providers.NonBankPayment{
+				Method: strings.TrimSpace(tender.TenderDescription),
+				Amount: roundCurrency(tender.AmountTender),
+			})
+		}
+	}
+	return breakdown, nil
+}
+
 // CostcoOrderItem implements the OrderItem interface for Costco
 type CostcoOrderItem struct {
 	name        string
@@ -374,6 +455,8 @@ type CostcoOrderItem struct {
 	description string
 	sku         string
 	category    string
+
+	pinnedCategory string // Category assigned without categorization (fuel)
 }
 
 func (i *CostcoOrderItem) GetName() string        { return i.name }
@@ -383,3 +466,7 @@ func (i *CostcoOrderItem) GetUnitPrice() float64  { return i.unitPrice }
 func (i *CostcoOrderItem) GetDescription() string { return i.description }
 func (i *CostcoOrderItem) GetSKU() string         { return i.sku }
 func (i *CostcoOrderItem) GetCategory() string    { return i.category }
+
+// PinnedCategory implements providers.PinnedCategoryItem. It is set for fuel
+// and empty for everything else.
+func (i *CostcoOrderItem) PinnedCategory() string { return i.pinnedCategory }
@@ -32,7 +32,7 @@ func TestProvider_Capabilities(t *testing.T) {
 	}
 
 	assert.False(t, provider.SupportsDeliveryTips())
-	assert.False(t, provider.SupportsRefunds())
+	assert.True(t, provider.SupportsRefunds())
 	assert.True(t, provider.SupportsBulkFetch())
 	assert.Equal(t, 3*time.Second, provider.GetRateLimit())
 }
@@ -581,3 +581,39 @@ func TestConvertReceipt_MixedTenderUsesCardAmount(t *testing.T) {
 	assert.InDelta(t, 46.67, items[1].GetPrice(), 0.001)
 	assert.InDelta(t, 46.67, items[1].GetUnitPrice(), 0.001)
 }
+
+func TestCostcoOrder_GetPaymentBreakdown(t *testing.T) {
+	provider := NewProvider(nil, slog.Default())
+
+	t.Run("shop card and reward are non-bank tenders", func(t *testing.T) {
+		receipt := &costcogo.Receipt{
+			TransactionBarcode: "MIXED123",
+			Total:              150.00,
+			TenderArray: []costcogo.Tender{
+				{TenderDescription: "COSTCO VISA", AmountTender: 100.00},
+				{TenderDescription: "SHOP CARD", AmountTender: 30.00},
+				{TenderDescription: "EXECUTIVE REWARD", AmountTender: 15.00},
+				{TenderDescription: "STORE CREDIT", AmountTender: 5.00},
+			},
+		}
+
+		order := provider.convertReceipt(receipt, true).(*CostcoOrder)
+		breakdown, err := order.GetPaymentBreakdown()
+		require.NoError(t, err)
+		assert.Equal(t, &providers.PaymentBreakdown{
+			Total:       150.00,
+			BankCharges: []float64{100.00},
+			NonBank: []providers.NonBankPayment{
+				{Method: "SHOP CARD", Amount: 30.00},
+				{Method: "EXECUTIVE REWARD", Amount: 15.00},
+			},
+		}, breakdown, "unrecognized tenders are left out")
+	})
+
+	t.Run("no tenders", func(t *testing.T) {
+		order := provider.convertReceipt(&costcogo.Receipt{TransactionBarcode: "BARE", Total: 10.00}, false).(*CostcoOrder)
+		breakdown, err := order.GetPaymentBreakdown()
+		require.NoError(t, err)
+		assert.Nil(t, breakdown)
+	})
+}
@@ -0,0 +1,181 @@
+package costco
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+)
+
+// RefundOrder adapts a Costco return (a refund receipt from the warehouse or a
+// returned online order) to the generic order interface used by
+// categorization and matching. Amounts are positive; GetTotal is negative so
+// the matcher looks for a credit.
+type RefundOrder struct {
+	sourceID  string // Receipt barcode or online order number
+	date      time.Time
+	amount    float64
+	subtotal  float64
+	tax       float64
+	hasAmount bool // False when Costco reports no refund total (partial online returns)
+	priced    bool // False when the returned items carry no prices
+	items     []providers.OrderItem
+	orderType string // "receipt" or "online"
+	rawData   interface{}
+}
+
+func (o *RefundOrder) GetID() string                   { return "costco-refund:" + o.sourceID }
+func (o *RefundOrder) GetDate() time.Time              { return o.date }
+func (o *RefundOrder) GetTotal() float64               { return -o.amount }
+func (o *RefundOrder) GetSubtotal() float64            { return o.subtotal }
+func (o *RefundOrder) GetTax() float64                 { return o.tax }
+func (o *RefundOrder) GetTip() float64                 { return 0 }
+func (o *RefundOrder) GetFees() float64                { return 0 }
+func (o *RefundOrder) GetItems() []providers.OrderItem { return o.items }
+func (o *RefundOrder) GetProviderName() string         { return "Costco" }
+func (o *RefundOrder) GetRawData() interface{}         { return o.rawData }
+
+// SourceID is the receipt barcode or online order number the refund came from.
+func (o *RefundOrder) SourceID() string { return o.sourceID }
+
+// RefundAmount is the amount credited back to the bank card.
+func (o *RefundOrder) RefundAmount() float64 { return o.amount }
+
+// HasRefundAmount reports whether Costco reported how much was refunded.
+func (o *RefundOrder) HasRefundAmount() bool { return o.hasAmount }
+
+// HasItemPrices reports whether the returned items carry their refunded
+// prices, which is required to split a refund across categories.
+func (o *RefundOrder) HasItemPrices() bool { return o.priced }
+
+// IsOnline reports whether the refund is for a returned online order. Costco
+// does not report when an online refund was issued, so GetDate is the order
+// date and the credit is expected on or after it.
+func (o *RefundOrder) IsOnline() bool { return o.orderType == "online" }
+
+// IsRefundReceipt reports whether a warehouse receipt records a return rather
+// than a purchase.
+func IsRefundReceipt(receipt *costcogo.Receipt) bool {
+	return receipt.Total < 0 || strings.EqualFold(strings.TrimSpace(receipt.TransactionType), "Refund")
+}
+
+// NewRefundOrder creates a refund view of a detailed refund receipt.
+func NewRefundOrder(receipt *costcogo.Receipt) *RefundOrder {
+	refund, _ := refundFromReceipt(receipt, true)
+	return refund
+}
+
+// convertRefundReceipt converts a refund receipt to a RefundOrder
+func (p *Provider) convertRefundReceipt(receipt *costcogo.Receipt, hasDetails bool) *RefundOrder {
+	refund, orphaned := refundFromReceipt(receipt, hasDetails)
+	for _, disc := range orphaned {
+		p.logger.Warn("found orphaned coupon on refund receipt with no matching returned item",
+			"discount_item", disc.ItemNumber,
+			"parent_item_ref", disc.GetParentItemNumber(),
+			"discount_amount", disc.Amount,
+		)
+	}
+	return refund
+}
+
+// refundFromReceipt builds a RefundOrder from a refund receipt. Returned items
+// are listed with negative amounts and units; coupons given back on the return
+// are positive lines, so the signs are flipped before netting them into their
+// items the same way as a purchase. Coupons with no returned item are
+// returned separately.
+func refundFromReceipt(receipt *costcogo.Receipt, hasDetails bool) (*RefundOrder, []costcogo.ReceiptItem) {
+	chargeTotal, allocationRatio := receiptChargeTotal(receipt)
+	amount := math.Abs(chargeTotal)
+
+	refund := &RefundOrder{
+		sourceID:  receipt.TransactionBarcode,
+		date:      receiptDate(receipt),
+		amount:    amount,
+		subtotal:  roundCurrency(math.Abs(receipt.SubTotal) * allocationRatio),
+		hasAmount: amount > 0,
+		priced:    hasDetails,
+		orderType: "receipt",
+		rawData:   receipt,
+	}
+	refund.tax = roundCurrency(refund.amount - refund.subtotal)
+
+	if !hasDetails {
+		return refund, nil
+	}
+
+	flipped := make([]costcogo.ReceiptItem, len(receipt.ItemArray))
+	for i, item := range receipt.ItemArray {
+		item.Amount = -item.Amount
+		item.Unit = -item.Unit
+		item.ItemUnitPriceAmount = -item.ItemUnitPriceAmount
+		flipped[i] = item
+	}
+	netted, orphaned := costcogo.NetDiscounts(flipped)
+	for _, item := range netted {
+		unitPrice := item.ItemUnitPriceAmount
+		if unitPrice != 0 {
+			unitPrice = roundCurrency(unitPrice * allocationRatio)
+		}
+		refund.items = append(refund.items, &CostcoOrderItem{
+			name:        item.ItemDescription01,
+			price:       roundCurrency(item.Amount * allocationRatio),
+			quantity:    math.Abs(float64(item.Unit)),
+			unitPrice:   unitPrice,
+			sku:         item.ItemNumber,
+			description: strings.TrimSpace(item.ItemDescription01 + " " + item.ItemDescription02),
+		})
+	}
+	return refund, orphaned
+}
+
+// NewOnlineRefundOrder returns a RefundOrder for the returned part of an online
+// order, or nil when nothing was returned. Costco only reports a refund total
+// when the whole order is returned, and never reports line prices, so a
+// single returned item is priced at the order total and anything else is left
+// unpriced.
+func NewOnlineRefundOrder(order *costcogo.OnlineOrder) *RefundOrder {
+	wholeOrder := isReturnedStatus(order.Status)
+
+	var items []providers.OrderItem
+	for _, line := range order.OrderLineItems {
+		if !wholeOrder && !isReturnedStatus(line.Status) && !isReturnedStatus(line.OrderStatus) {
+			continue
+		}
+		items = append(items, &CostcoOrderItem{
+			name:        line.ItemDescription,
+			quantity:    1,
+			sku:         line.ItemNumber,
+			description: line.ItemDescription,
+		})
+	}
+	if !wholeOrder && len(items) == 0 {
+		return nil
+	}
+
+	orderDate, _ := time.Parse("2006-01-02T15:04:05", order.OrderPlacedDate)
+	refund := &RefundOrder{
+		sourceID:  order.OrderNumber,
+		date:      orderDate,
+		items:     items,
+		orderType: "online",
+		rawData:   order,
+	}
+	if wholeOrder && order.OrderTotal > 0 {
+		refund.amount = roundCurrency(order.OrderTotal)
+		refund.subtotal = refund.amount
+		refund.hasAmount = true
+		if len(items) == 1 {
+			items[0].(*CostcoOrderItem).price = refund.amount
+			items[0].(*CostcoOrderItem).unitPrice = refund.amount
+			refund.priced = true
+		}
+	}
+	return refund
+}
+
+func isReturnedStatus(status string) bool {
+	upper := strings.ToUpper(status)
+	return strings.Contains(upper, "RETURN") || strings.Contains(upper, "REFUND")
+}
@@ -0,0 +1,108 @@
+package costco
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T, name string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, v))
+}
+
+func TestRefundOrder_Interface(t *testing.T) {
+	var _ providers.Order = (*RefundOrder)(nil)
+}
+
+func TestConvertAnyReceipt_RefundReceipt(t *testing.T) {
+	var receipt costcogo.Receipt
+	loadFixture(t, "refund_receipt.json", &receipt)
+	require.True(t, IsRefundReceipt(&receipt))
+
+	order := NewProvider(nil, nil).convertAnyReceipt(&receipt, true)
+	refund, ok := order.(*RefundOrder)
+	require.True(t, ok, "refund receipts become RefundOrders")
+
+	assert.Equal(t, "costco-refund:21000100500112603141142", refund.GetID())
+	assert.Equal(t, "21000100500112603141142", refund.SourceID())
+	assert.Equal(t, time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC), refund.GetDate())
+	assert.InDelta(t, -52.97, refund.GetTotal(), 0.001, "negative total so the matcher looks for a credit")
+	assert.InDelta(t, 52.97, refund.RefundAmount(), 0.001)
+	assert.InDelta(t, 49.98, refund.GetSubtotal(), 0.001)
+	assert.InDelta(t, 2.99, refund.GetTax(), 0.001)
+	assert.True(t, refund.HasRefundAmount())
+	assert.True(t, refund.HasItemPrices())
+	assert.False(t, refund.IsOnline())
+	assert.Equal(t, "Costco", refund.GetProviderName())
+
+	items := refund.GetItems()
+	require.Len(t, items, 2, "the returned coupon is netted into its item")
+	assert.Equal(t, "KS PAPER TOWEL", items[0].GetName())
+	assert.InDelta(t, 24.99, items[0].GetPrice(), 0.001)
+	assert.Equal(t, 1.0, items[0].GetQuantity())
+	assert.Equal(t, "DURACELL AAA", items[1].GetName())
+	assert.InDelta(t, 24.99, items[1].GetPrice(), 0.001)
+	assert.Equal(t, "1234567", items[1].GetSKU())
+}
+
+func TestConvertAnyReceipt_PurchaseReceiptUnchanged(t *testing.T) {
+	receipt := &costcogo.Receipt{
+		TransactionBarcode: "PURCHASE1",
+		TransactionDate:    "2026-03-10",
+		TransactionType:    "Sales",
+		Total:              10.00,
+		SubTotal:           10.00,
+	}
+	assert.False(t, IsRefundReceipt(receipt))
+	_, ok := NewProvider(nil, nil).convertAnyReceipt(receipt, false).(*CostcoOrder)
+	assert.True(t, ok)
+}
+
+func TestNewOnlineRefundOrder_WholeOrder(t *testing.T) {
+	var order costcogo.OnlineOrder
+	loadFixture(t, "returned_online_order.json", &order)
+
+	refund := NewOnlineRefundOrder(&order)
+	require.NotNil(t, refund)
+	assert.Equal(t, "costco-refund:1100223344", refund.GetID())
+	assert.True(t, refund.IsOnline())
+	assert.True(t, refund.HasRefundAmount())
+	assert.True(t, refund.HasItemPrices(), "a single returned item is priced at the order total")
+	assert.InDelta(t, -89.99, refund.GetTotal(), 0.001)
+	require.Len(t, refund.GetItems(), 1)
+	assert.Equal(t, "Sunbeam Heated Throw", refund.GetItems()[0].GetName())
+	assert.InDelta(t, 89.99, refund.GetItems()[0].GetPrice(), 0.001)
+}
+
+func TestNewOnlineRefundOrder_PartialReturnHasNoAmount(t *testing.T) {
+	var order costcogo.OnlineOrder
+	loadFixture(t, "partially_returned_online_order.json", &order)
+
+	refund := NewOnlineRefundOrder(&order)
+	require.NotNil(t, refund)
+	assert.False(t, refund.HasRefundAmount(), "Costco doesn't report what a partial return refunded")
+	require.Len(t, refund.GetItems(), 1)
+	assert.Equal(t, "Kirkland Signature Hand Towel", refund.GetItems()[0].GetName())
+}
+
+func TestNewOnlineRefundOrder_NotReturned(t *testing.T) {
+	order := &costcogo.OnlineOrder{
+		OrderNumber: "1100223366",
+		Status:      "Delivered",
+		OrderTotal:  20,
+		OrderLineItems: []costcogo.OrderLineItem{
+			{ItemDescription: "Coffee", Status: "Delivered"},
+		},
+	}
+	assert.Nil(t, NewOnlineRefundOrder(order))
+}
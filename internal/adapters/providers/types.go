@@ -30,6 +30,79 @@ type OrderItem interface {
 	GetCategory() string // Provider's category if available
 }
 
+// PinnedCategoryItem is implemented by items whose Monarch category is known
+// without categorization, such as fuel bought at a gas station. The value is a
+// Monarch category ID or name.
+type PinnedCategoryItem interface {
+	PinnedCategory() string
+}
+
+// PinnedCategory returns the category pinned on an item, or "" when the item
+// should be categorized normally.
+func PinnedCategory(item OrderItem) string {
+	if pinned, ok := item.(PinnedCategoryItem); ok {
+		return pinned.PinnedCategory()
+	}
+	return ""
+}
+
+// AccountOrder is implemented by orders fetched from one of several accounts
+// with the same provider, such as a household's Amazon logins.
+type AccountOrder interface {
+	GetAccount() string
+}
+
+// OrderAccount returns the provider account an order was fetched from, or ""
+// when the provider has a single account.
+func OrderAccount(order Order) string {
+	if withAccount, ok := order.(AccountOrder); ok {
+		return withAccount.GetAccount()
+	}
+	return ""
+}
+
+// PaymentCard is a bank card an order was charged to
+type PaymentCard struct {
+	Type     string // e.g. "VISA" or "Prime Visa" (may be empty)
+	LastFour string
+}
+
+// PaymentCardOrder is implemented by orders that report the cards their
+// bank charges were made on.
+type PaymentCardOrder interface {
+	GetPaymentCards() []PaymentCard
+}
+
+// OrderPaymentCards returns the cards an order was charged to, or nil when
+// the provider doesn't report them.
+func OrderPaymentCards(order Order) []PaymentCard {
+	if withCards, ok := order.(PaymentCardOrder); ok {
+		return withCards.GetPaymentCards()
+	}
+	return nil
+}
+
+// NonBankPayment is a payment that never reaches the bank, such as a gift
+// card, store credit or rewards
+type NonBankPayment struct {
+	Method string // as the provider reports it, e.g. "GIFTCARD" or "Shop Card"
+	Amount float64
+}
+
+// PaymentBreakdown splits an order's total into the bank charges that should
+// appear in Monarch and the payments that won't.
+type PaymentBreakdown struct {
+	Total       float64 // Order total before any payment is applied
+	BankCharges []float64
+	NonBank     []NonBankPayment
+}
+
+// PaymentBreakdownOrder is implemented by orders that report how they were
+// paid. A nil breakdown means the order has no payment detail to check.
+type PaymentBreakdownOrder interface {
+	GetPaymentBreakdown() (*PaymentBreakdown, error)
+}
+
 // FetchOptions configures how orders are fetched
 type FetchOptions struct {
 	StartDate      time.Time
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net"
 	"time"
 
@@ -12,6 +13,14 @@ import (
 	walmartclient "github.com/eshaffer321/walmart-client-go/v2"
 )
 
+var (
+	// ErrPaymentPending indicates an order has no ledger charges yet
+	ErrPaymentPending = errors.New("order not yet charged (payment pending)")
+	// ErrNoBankCharges indicates an order was charged, but not to a credit
+	// card: it was fully refunded or paid entirely with gift cards
+	ErrNoBankCharges = errors.New("no positive charges found (order may be fully refunded or paid entirely with gift card)")
+)
+
 // Order wraps a Walmart order and implements providers.Order interface
 type Order struct {
 	walmartOrder *walmartclient.Order
@@ -132,6 +141,60 @@ func (o *Order) GetProviderName() string {
 	return "walmart"
 }
 
+// GetItemsForCharge returns only the items of the delivery matching the given
+// charge amount. Each delivery group is charged separately, so the group whose
+// total (or estimated total, from its items and the order's tax rate) is
+// closest to the charge is used. Falls back to all items when the order has a
+// single group or the matching group has no items.
+func (o *Order) GetItemsForCharge(chargeAmount float64) []providers.OrderItem {
+	if len(o.walmartOrder.Groups) <= 1 {
+		return o.GetItems()
+	}
+
+	taxRate := 0.0
+	if subtotal := o.GetSubtotal(); subtotal > 0 {
+		taxRate = o.GetTax() / subtotal
+	}
+
+	bestIdx := -1
+	bestDiff := math.MaxFloat64
+	for i, group := range o.walmartOrder.Groups {
+		diff := math.Abs(groupTotal(group, taxRate) - chargeAmount)
+		if diff < bestDiff {
+			bestDiff = diff
+			bestIdx = i
+		}
+	}
+
+	if bestIdx < 0 || len(o.walmartOrder.Groups[bestIdx].Items) == 0 {
+		return o.GetItems()
+	}
+
+	group := o.walmartOrder.Groups[bestIdx]
+	items := make([]providers.OrderItem, 0, len(group.Items))
+	for _, item := range group.Items {
+		items = append(items, &OrderItem{item: item})
+	}
+	return items
+}
+
+// groupTotal returns what a delivery group was charged: its grand total when
+// Walmart reports one, otherwise its item subtotal plus the order's tax rate.
+func groupTotal(group walmartclient.OrderGroup, taxRate float64) float64 {
+	if group.PriceDetails != nil && group.PriceDetails.GrandTotal != nil && group.PriceDetails.GrandTotal.Value > 0 {
+		return group.PriceDetails.GrandTotal.Value
+	}
+	if group.TotalPrice != nil && group.TotalPrice.Total.Value > 0 {
+		return group.TotalPrice.Total.Value
+	}
+
+	subtotal := 0.0
+	for _, item := range group.Items {
+		subtotal += (&OrderItem{item: item}).GetPrice()
+	}
+	return subtotal * (1 + taxRate)
+}
+
 // GetRawData returns the raw Walmart order data
 func (o *Order) GetRawData() interface{} {
 	return o.walmartOrder
@@ -212,7 +275,7 @@ func (o *Order) GetFinalCharges() ([]float64, error) {
 			}
 			return []float64{charge}, nil
 		}
-		return nil, fmt.Errorf("order not yet charged (payment pending)")
+		return nil, ErrPaymentPending
 	}
 
 	// Collect positive charges from credit card payment methods only
@@ -241,7 +304,7 @@ func (o *Order) GetFinalCharges() ([]float64, error) {
 	}
 
 	if len(positiveCharges) == 0 {
-		return nil, fmt.Errorf("no positive charges found (order may be fully refunded or paid entirely with gift card)")
+		return nil, ErrNoBankCharges
 	}
 
 	return positiveCharges, nil
@@ -273,7 +336,7 @@ func (o *Order) GetRefundCharges() ([]float64, error) {
 	}
 
 	if len(ledger.PaymentMethods) == 0 {
-		return nil, fmt.Errorf("order not yet charged (payment pending)")
+		return nil, ErrPaymentPending
 	}
 
 	var refunds []float64
@@ -302,6 +365,58 @@ func (o *Order) GetRefundItems() ([]providers.OrderItem, error) {
 	return items, nil
 }
 
+// Return is one Walmart return: the items sent back under one return ID and
+// what they should refund, with tax at the order's rate.
+type Return struct {
+	ID       string
+	Items    []providers.OrderItem
+	Subtotal float64
+	Tax      float64
+}
+
+// Total returns the expected refund for the return, tax included.
+func (r Return) Total() float64 {
+	return roundCurrency(r.Subtotal + r.Tax)
+}
+
+// GetReturns groups the items Walmart marked as returned by return ID, in the
+// order Walmart lists them. Each item keeps the quantity and line price that
+// was returned.
+func (o *Order) GetReturns() ([]Return, error) {
+	taxRate := 0.0
+	if subtotal := o.GetSubtotal(); subtotal > 0 {
+		taxRate = o.GetTax() / subtotal
+	}
+
+	var returns []Return
+	index := make(map[string]int)
+	for _, item := range o.walmartOrder.GetRefundedItems() {
+		i, ok := index[item.ReturnID]
+		if !ok {
+			i = len(returns)
+			index[item.ReturnID] = i
+			returns = append(returns, Return{ID: item.ReturnID})
+		}
+		returned := &OrderItem{item: item}
+		returns[i].Items = append(returns[i].Items, returned)
+		returns[i].Subtotal += returnedItemPrice(returned)
+	}
+	for i := range returns {
+		returns[i].Subtotal = roundCurrency(returns[i].Subtotal)
+		returns[i].Tax = roundCurrency(returns[i].Subtotal * taxRate)
+	}
+	return returns, nil
+}
+
+// returnedItemPrice is the line price of a returned item, or its unit price
+// times the quantity returned when Walmart leaves the line price out.
+func returnedItemPrice(item *OrderItem) float64 {
+	if price := item.GetPrice(); price != 0 {
+		return math.Abs(price)
+	}
+	return math.Abs(item.GetUnitPrice() * item.GetQuantity())
+}
+
 func (o *Order) getLedger() (*walmartclient.OrderLedger, error) {
 	if o.ledgerCache != nil {
 		return o.ledgerCache, nil
@@ -359,6 +474,79 @@ func (o *Order) IsMultiDelivery() (bool, error) {
 	return len(charges) > 1, nil
 }
 
+// GetPaymentCards returns the credit cards in the order's ledger. It reads
+// the ledger fetched for the order's charges and reports no cards when that
+// fails.
+func (o *Order) GetPaymentCards() []providers.PaymentCard {
+	ledger, err := o.getLedger()
+	if err != nil {
+		return nil
+	}
+	var cards []providers.PaymentCard
+	seen := make(map[string]bool)
+	for _, pm := range ledger.PaymentMethods {
+		if pm.PaymentType != "CREDITCARD" || pm.LastFour == "" || seen[pm.LastFour] {
+			continue
+		}
+		seen[pm.LastFour] = true
+		cards = append(cards, providers.PaymentCard{Type: pm.CardType, LastFour: pm.LastFour})
+	}
+	return cards
+}
+
+// GetPaymentBreakdown splits the order total into its credit card charges and
+// the gift card, Walmart Cash and other payments that never reach the bank.
+// Completed in-store receipts without a ledger are charged their total to one
+// card. Cash isn't in the ledger (the tendered amount includes change), so an
+// in-store order paid partly in cash counts the remainder as cash.
+func (o *Order) GetPaymentBreakdown() (*providers.PaymentBreakdown, error) {
+	ledger, err := o.getLedger()
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := &providers.PaymentBreakdown{Total: o.GetTotal()}
+	if len(ledger.PaymentMethods) == 0 {
+		if charge, ok := o.inStoreCreditCardCharge(); ok {
+			breakdown.BankCharges = []float64{charge}
+			return breakdown, nil
+		}
+		return nil, ErrPaymentPending
+	}
+
+	paid := 0.0
+	for _, pm := range ledger.PaymentMethods {
+		for _, charge := range pm.FinalCharges {
+			if charge <= 0 {
+				continue
+			}
+			if pm.PaymentType == "CREDITCARD" {
+				breakdown.BankCharges = append(breakdown.BankCharges, charge)
+			} else {
+				breakdown.NonBank = append(breakdown.NonBank, providers.NonBankPayment{Method: pm.PaymentType, Amount: charge})
+			}
+			paid += charge
+		}
+	}
+
+	if cash := roundCurrency(breakdown.Total - paid); cash > 0 && o.paidInStoreWithCash() {
+		breakdown.NonBank = append(breakdown.NonBank, providers.NonBankPayment{Method: "CASH", Amount: cash})
+	}
+	return breakdown, nil
+}
+
+func (o *Order) paidInStoreWithCash() bool {
+	if o.walmartOrder.Type != "IN_STORE" {
+		return false
+	}
+	for _, pm := range o.walmartOrder.PaymentMethods {
+		if pm.PaymentType == "CASH" {
+			return true
+		}
+	}
+	return false
+}
+
 // GetRawLedger returns the cached ledger data for persistence
 // Returns nil if ledger hasn't been fetched yet
 func (o *Order) GetRawLedger() *walmartclient.OrderLedger {
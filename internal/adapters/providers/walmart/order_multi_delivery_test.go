@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"testing"
 
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	walmartclient "github.com/eshaffer321/walmart-client-go/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -48,6 +49,60 @@ func TestOrder_GetRefundItems_UsesClientReturnIDMetadata(t *testing.T) {
 	assert.Equal(t, "sku-1", items[0].GetSKU())
 }
 
+func TestOrder_GetReturns(t *testing.T) {
+	returned := func(id, returnID, name string, quantity, linePrice, unitPrice float64) walmartclient.OrderItem {
+		item := walmartclient.OrderItem{
+			ID:          id,
+			ReturnID:    returnID,
+			Quantity:    quantity,
+			ProductInfo: &walmartclient.ProductInfo{Name: name},
+			PriceInfo:   &walmartclient.ItemPrice{UnitPrice: &walmartclient.Price{Value: unitPrice}},
+		}
+		if linePrice != 0 {
+			item.PriceInfo.LinePrice = &walmartclient.Price{Value: linePrice}
+		}
+		return item
+	}
+	order := &Order{walmartOrder: &walmartclient.Order{
+		ID: "RETURNS",
+		PriceDetails: &walmartclient.OrderPriceDetails{
+			SubTotal: &walmartclient.PriceLineItem{Value: 100.00},
+			TaxTotal: &walmartclient.PriceLineItem{Value: 8.00},
+		},
+		Groups: []walmartclient.OrderGroup{{
+			Categories: []walmartclient.OrderCategory{{
+				Items: []walmartclient.OrderItem{
+					returned("item-1", "return-1", "Towels", 2, 20.00, 10.00),
+					returned("item-2", "return-2", "Lamp", 1, 0, 35.00),
+					returned("item-3", "return-1", "Bath mat", 1, 5.00, 5.00),
+					{ID: "item-4", Quantity: 1, ProductInfo: &walmartclient.ProductInfo{Name: "Kept"}},
+				},
+			}},
+			SubGroups: []walmartclient.OrderSubGroup{{
+				Categories: []walmartclient.OrderCategory{{
+					Items: []walmartclient.OrderItem{returned("item-1", "return-1", "Towels", 2, 20.00, 10.00)},
+				}},
+			}},
+		}},
+	}}
+
+	returns, err := order.GetReturns()
+	require.NoError(t, err)
+	require.Len(t, returns, 2)
+
+	assert.Equal(t, "return-1", returns[0].ID)
+	require.Len(t, returns[0].Items, 2, "views of the same item are listed once")
+	assert.Equal(t, "Towels", returns[0].Items[0].GetName())
+	assert.Equal(t, 2.0, returns[0].Items[0].GetQuantity())
+	assert.Equal(t, 25.00, returns[0].Subtotal)
+	assert.Equal(t, 2.00, returns[0].Tax)
+	assert.Equal(t, 27.00, returns[0].Total())
+
+	assert.Equal(t, "return-2", returns[1].ID)
+	assert.Equal(t, 35.00, returns[1].Subtotal, "unit price times quantity without a line price")
+	assert.Equal(t, 37.80, returns[1].Total())
+}
+
 // TestOrder_GetFinalCharges tests retrieving final charges from order ledger
 func TestOrder_GetFinalCharges(t *testing.T) {
 	t.Run("retries a transient ledger timeout", func(t *testing.T) {
@@ -154,7 +209,7 @@ func TestOrder_GetFinalCharges(t *testing.T) {
 
 		_, err := order.GetFinalCharges()
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "payment pending")
+		assert.ErrorIs(t, err, ErrPaymentPending)
 	})
 
 	t.Run("completed in-store order falls back to its credit-card total when ledger is empty", func(t *testing.T) {
@@ -264,7 +319,7 @@ func TestOrder_GetFinalCharges(t *testing.T) {
 
 		_, err := order.GetFinalCharges()
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "no positive charges found")
+		assert.ErrorIs(t, err, ErrNoBankCharges)
 	})
 
 	t.Run("filters negative charge amounts (refunds)", func(t *testing.T) {
@@ -381,7 +436,7 @@ func TestOrder_GetFinalCharges(t *testing.T) {
 
 		_, err := order.GetFinalCharges()
 		require.Error(t, err)
-		assert.Contains(t, err.Error(), "no positive charges found")
+		assert.ErrorIs(t, err, ErrNoBankCharges)
 	})
 
 	t.Run("filters gift card payments (only returns credit card)", func(t *testing.T) {
@@ -488,3 +543,122 @@ func TestOrder_IsMultiDelivery(t *testing.T) {
 		assert.Contains(t, err.Error(), "client not available")
 	})
 }
+
+func TestOrder_GetItemsForCharge(t *testing.T) {
+	item := func(name string, price float64) walmartclient.OrderItem {
+		return walmartclient.OrderItem{
+			Quantity:    1,
+			ProductInfo: &walmartclient.ProductInfo{Name: name},
+			PriceInfo:   &walmartclient.ItemPrice{LinePrice: &walmartclient.Price{Value: price}},
+		}
+	}
+	order := &Order{walmartOrder: &walmartclient.Order{
+		ID: "MULTI-GROUP",
+		PriceDetails: &walmartclient.OrderPriceDetails{
+			SubTotal: &walmartclient.PriceLineItem{Value: 30.00},
+			TaxTotal: &walmartclient.PriceLineItem{Value: 3.00},
+		},
+		Groups: []walmartclient.OrderGroup{
+			{
+				ID:           "delivery",
+				Items:        []walmartclient.OrderItem{item("Milk", 4.00), item("Bread", 6.00)},
+				PriceDetails: &walmartclient.PriceDetails{GrandTotal: &walmartclient.Money{Value: 11.00}},
+			},
+			{
+				ID:    "shipping",
+				Items: []walmartclient.OrderItem{item("Blender", 20.00)},
+			},
+		},
+	}}
+
+	t.Run("group grand total picks its delivery", func(t *testing.T) {
+		items := order.GetItemsForCharge(11.00)
+		require.Len(t, items, 2)
+		assert.Equal(t, "Milk", items[0].GetName())
+		assert.Equal(t, "Bread", items[1].GetName())
+	})
+
+	t.Run("group without totals is estimated with the order tax rate", func(t *testing.T) {
+		items := order.GetItemsForCharge(22.00)
+		require.Len(t, items, 1)
+		assert.Equal(t, "Blender", items[0].GetName())
+	})
+
+	t.Run("single group returns every item", func(t *testing.T) {
+		single := &Order{walmartOrder: &walmartclient.Order{
+			ID:     "ONE-GROUP",
+			Groups: []walmartclient.OrderGroup{{Items: []walmartclient.OrderItem{item("Milk", 4.00), item("Eggs", 3.00)}}},
+		}}
+		assert.Len(t, single.GetItemsForCharge(4.00), 2)
+	})
+}
+
+func TestOrder_GetPaymentCards(t *testing.T) {
+	order := &Order{
+		walmartOrder: &walmartclient.Order{ID: "CARDS"},
+		ledgerCache: &walmartclient.OrderLedger{
+			OrderID: "CARDS",
+			PaymentMethods: []walmartclient.PaymentMethodCharges{
+				{PaymentType: "CREDITCARD", CardType: "VISA", LastFour: "1234", FinalCharges: []float64{10.00}},
+				{PaymentType: "GIFTCARD", LastFour: "9999", FinalCharges: []float64{5.00}},
+				{PaymentType: "CREDITCARD", CardType: "VISA", LastFour: "1234", FinalCharges: []float64{2.00}},
+			},
+		},
+	}
+
+	assert.Equal(t, []providers.PaymentCard{{Type: "VISA", LastFour: "1234"}}, order.GetPaymentCards())
+}
+
+func TestOrder_GetPaymentBreakdown(t *testing.T) {
+	t.Run("gift card and Walmart Cash are non-bank payments", func(t *testing.T) {
+		order := &Order{
+			walmartOrder: &walmartclient.Order{
+				ID:           "MIXED",
+				PriceDetails: &walmartclient.OrderPriceDetails{GrandTotal: &walmartclient.PriceLineItem{Value: 60.00}},
+			},
+			ledgerCache: &walmartclient.OrderLedger{
+				OrderID: "MIXED",
+				PaymentMethods: []walmartclient.PaymentMethodCharges{
+					{PaymentType: "CREDITCARD", LastFour: "1234", FinalCharges: []float64{30.00, 12.50, -5.00}},
+					{PaymentType: "GIFTCARD", LastFour: "9999", FinalCharges: []float64{15.00}},
+					{PaymentType: "WALMART_CASH", FinalCharges: []float64{2.50}},
+				},
+			},
+		}
+
+		breakdown, err := order.GetPaymentBreakdown()
+		require.NoError(t, err)
+		assert.Equal(t, &providers.PaymentBreakdown{
+			Total:       60.00,
+			BankCharges: []float64{30.00, 12.50},
+			NonBank:     []providers.NonBankPayment{{Method: "GIFTCARD", Amount: 15.00}, {Method: "WALMART_CASH", Amount: 2.50}},
+		}, breakdown)
+	})
+
+	t.Run("in-store receipt without a ledger", func(t *testing.T) {
+		order := &Order{
+			walmartOrder: &walmartclient.Order{
+				ID:             "IN-STORE",
+				Type:           "IN_STORE",
+				PriceDetails:   &walmartclient.OrderPriceDetails{GrandTotal: &walmartclient.PriceLineItem{Value: 9.88}},
+				PaymentMethods: []walmartclient.OrderPaymentMethod{{PaymentType: "CREDITCARD"}},
+			},
+			ledgerCache: &walmartclient.OrderLedger{OrderID: "IN-STORE"},
+		}
+
+		breakdown, err := order.GetPaymentBreakdown()
+		require.NoError(t, err)
+		assert.Equal(t, []float64{9.88}, breakdown.BankCharges)
+		assert.Empty(t, breakdown.NonBank)
+	})
+
+	t.Run("payment pending", func(t *testing.T) {
+		order := &Order{
+			walmartOrder: &walmartclient.Order{ID: "PENDING"},
+			ledgerCache:  &walmartclient.OrderLedger{OrderID: "PENDING"},
+		}
+
+		_, err := order.GetPaymentBreakdown()
+		assert.ErrorContains(t, err, "payment pending")
+	})
+}
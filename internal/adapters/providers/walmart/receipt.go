@@ -0,0 +1,439 @@
+package walmart
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	walmartclient "github.com/eshaffer321/walmart-client-go/v2"
+)
+
+// Receipt is a Walmart in-store receipt read from a file rather than from a
+// linked Walmart account: a saved order payload from walmart.com, or the text
+// of a printed or emailed receipt.
+type Receipt struct {
+	TCNumber string // Transaction code ("TC#"), digits only
+	StoreID  string
+	Date     time.Time
+	Items    []ReceiptItem
+	Subtotal float64
+	Tax      float64
+	Total    float64
+	Tenders  []ReceiptTender
+
+	order *walmartclient.Order // Set when the file was a walmart.com order payload
+}
+
+// ReceiptItem is one line item on a receipt.
+type ReceiptItem struct {
+	Name      string
+	UPC       string
+	Quantity  float64 // Fractional for items sold by weight
+	UnitPrice float64
+	Price     float64 // Line total after any coupons printed under the item
+}
+
+// ReceiptTender is one payment on a receipt.
+type ReceiptTender struct {
+	PaymentType string // "CREDITCARD", "GIFTCARD", "CASH" or "EBT"
+	Description string // As printed, e.g. "VISA"
+	LastFour    string
+	Amount      float64
+}
+
+// ReceiptQuery selects receipts by the details printed on them. Empty fields
+// match any receipt.
+type ReceiptQuery struct {
+	TCNumber string
+	StoreID  string
+	Date     time.Time
+}
+
+// IsZero reports whether the query selects every receipt.
+func (q ReceiptQuery) IsZero() bool {
+	return q.TCNumber == "" && q.StoreID == "" && q.Date.IsZero()
+}
+
+// Matches reports whether a receipt has the query's TC number, store and
+// date. TC numbers are compared by digits and store numbers without leading
+// zeros, since both are printed with varying spacing and padding.
+func (q ReceiptQuery) Matches(r *Receipt) bool {
+	if q.TCNumber != "" && digitsOnly(q.TCNumber) != r.TCNumber {
+		return false
+	}
+	if q.StoreID != "" && normalizeStoreID(q.StoreID) != normalizeStoreID(r.StoreID) {
+		return false
+	}
+	if !q.Date.IsZero() && q.Date.Format("2006-01-02") != r.Date.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
+
+// String describes the query for messages, e.g. "TC# 1234, store 5678".
+func (q ReceiptQuery) String() string {
+	var parts []string
+	if q.TCNumber != "" {
+		parts = append(parts, "TC# "+q.TCNumber)
+	}
+	if q.StoreID != "" {
+		parts = append(parts, "store "+q.StoreID)
+	}
+	if !q.Date.IsZero() {
+		parts = append(parts, q.Date.Format("2006-01-02"))
+	}
+	if len(parts) == 0 {
+		return "any receipt"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// LoadReceiptFile reads a receipt from a file. See ParseReceipt.
+func LoadReceiptFile(path string) (*Receipt, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt file: %w", err)
+	}
+	receipt, err := ParseReceipt(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return receipt, nil
+}
+
+// ParseReceipt reads a receipt in either supported format: the JSON order
+// payload walmart.com returns for an in-store purchase (with or without the
+// GraphQL "data" envelope), or receipt text as printed or emailed.
+func ParseReceipt(r io.Reader) (*Receipt, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseReceiptJSON(trimmed)
+	}
+	return parseReceiptText(data)
+}
+
+func parseReceiptJSON(data []byte) (*Receipt, error) {
+	var envelope walmartclient.OrderResponse
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid receipt JSON: %w", err)
+	}
+	order := envelope.Data.Order
+	if order == nil {
+		order = &walmartclient.Order{}
+		if err := json.Unmarshal(data, order); err != nil {
+			return nil, fmt.Errorf("invalid receipt JSON: %w", err)
+		}
+	}
+	if order.ID == "" {
+		return nil, fmt.Errorf("receipt JSON has no order id")
+	}
+
+	receipt := &Receipt{TCNumber: digitsOnly(order.ID), order: order}
+	if receipt.TCNumber == "" {
+		receipt.TCNumber = order.ID
+	}
+	wrapped := &Order{walmartOrder: order}
+	receipt.Date = wrapped.GetDate()
+	receipt.Subtotal = wrapped.GetSubtotal()
+	receipt.Tax = wrapped.GetTax()
+	receipt.Total = wrapped.GetTotal()
+	for _, group := range order.Groups {
+		if group.Store != nil && receipt.StoreID == "" {
+			receipt.StoreID = group.Store.ID
+		}
+	}
+	for _, item := range wrapped.GetItems() {
+		receipt.Items = append(receipt.Items, ReceiptItem{
+			Name:      item.GetName(),
+			UPC:       item.GetSKU(),
+			Quantity:  item.GetQuantity(),
+			UnitPrice: item.GetUnitPrice(),
+			Price:     item.GetPrice(),
+		})
+	}
+	return receipt, nil
+}
+
+var (
+	receiptItemPattern     = regexp.MustCompile(`^\s*(.+?)\s+(\d{11,13})\s*[A-Z]{0,2}\s+(\d+\.\d{2})(-?)\s*[A-Z]{0,2}\s*$`)
+	receiptMultiplePattern = regexp.MustCompile(`^\s*(\d+)\s+AT\s+\d+\s+FOR\s+(\d+\.\d{2})\s*$`)
+	receiptWeightPattern   = regexp.MustCompile(`(?i)^\s*(\d+\.\d+)\s+lb\s+@\s+\d+\s+lb\s*/\s*(\d+\.\d{2})\s*$`)
+	receiptSubtotalPattern = regexp.MustCompile(`^\s*SUBTOTAL\s+(\d+\.\d{2})\s*$`)
+	receiptTaxPattern      = regexp.MustCompile(`^\s*TAX\s+\d+\s+[\d.]+\s*%\s+(\d+\.\d{2})\s*$`)
+	receiptTotalPattern    = regexp.MustCompile(`^\s*TOTAL\s+(\d+\.\d{2})\s*$`)
+	receiptTenderPattern   = regexp.MustCompile(`^\s*(.+?)\s+TEND\s+(\d+\.\d{2})\s*$`)
+	receiptCardPattern     = regexp.MustCompile(`\*{4}\s*(\d{4})`)
+	receiptStorePattern    = regexp.MustCompile(`ST#\s*(\d+)`)
+	receiptTCPattern       = regexp.MustCompile(`TC#\s*([\d ]+)`)
+	receiptDatePattern     = regexp.MustCompile(`(\d{2}/\d{2}/\d{2,4})\s+(\d{2}:\d{2}(?::\d{2})?)`)
+)
+
+// parseReceiptText parses the text of a Walmart register receipt. Only the
+// lines needed to itemize the purchase are recognized; everything else
+// (address, approval codes, survey links) is ignored.
+func parseReceiptText(data []byte) (*Receipt, error) {
+	receipt := &Receipt{}
+	var last *ReceiptItem
+	var pending *ReceiptItem // "N AT 1 FOR x" printed before its item
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if m := receiptItemPattern.FindStringSubmatch(line); m != nil {
+			price, _ := strconv.ParseFloat(m[3], 64)
+			if m[4] == "-" {
+				// Coupons and price adjustments print under the item they reduce
+				if last != nil {
+					last.Price = roundCurrency(last.Price - price)
+				}
+				continue
+			}
+			item := ReceiptItem{
+				Name:      strings.TrimSpace(m[1]),
+				UPC:       m[2],
+				Quantity:  1,
+				UnitPrice: price,
+				Price:     price,
+			}
+			if pending != nil && pending.covers(price) {
+				item.Quantity, item.UnitPrice = pending.Quantity, pending.UnitPrice
+			}
+			pending = nil
+			receipt.Items = append(receipt.Items, item)
+			last = &receipt.Items[len(receipt.Items)-1]
+			continue
+		}
+		if m := receiptMultiplePattern.FindStringSubmatch(line); m != nil {
+			// Registers print the multiple either above or below its item;
+			// it belongs to whichever one it prices correctly
+			multiple := &ReceiptItem{}
+			multiple.Quantity, _ = strconv.ParseFloat(m[1], 64)
+			multiple.UnitPrice, _ = strconv.ParseFloat(m[2], 64)
+			if last != nil && last.Quantity == 1 && multiple.covers(last.Price) {
+				last.Quantity, last.UnitPrice = multiple.Quantity, multiple.UnitPrice
+			} else {
+				pending = multiple
+			}
+			continue
+		}
+		if m := receiptWeightPattern.FindStringSubmatch(line); m != nil && last != nil {
+			last.Quantity, _ = strconv.ParseFloat(m[1], 64)
+			last.UnitPrice, _ = strconv.ParseFloat(m[2], 64)
+			continue
+		}
+		if m := receiptSubtotalPattern.FindStringSubmatch(line); m != nil {
+			receipt.Subtotal, _ = strconv.ParseFloat(m[1], 64)
+			continue
+		}
+		if m := receiptTaxPattern.FindStringSubmatch(line); m != nil {
+			tax, _ := strconv.ParseFloat(m[1], 64)
+			receipt.Tax = roundCurrency(receipt.Tax + tax)
+			continue
+		}
+		if m := receiptTotalPattern.FindStringSubmatch(line); m != nil {
+			receipt.Total, _ = strconv.ParseFloat(m[1], 64)
+			continue
+		}
+		if m := receiptTenderPattern.FindStringSubmatch(line); m != nil {
+			amount, _ := strconv.ParseFloat(m[2], 64)
+			description := strings.TrimSpace(m[1])
+			receipt.Tenders = append(receipt.Tenders, ReceiptTender{
+				PaymentType: tenderPaymentType(description),
+				Description: description,
+				Amount:      amount,
+			})
+			continue
+		}
+		if m := receiptCardPattern.FindStringSubmatch(line); m != nil {
+			// The masked card number prints after its tender line
+			for i := len(receipt.Tenders) - 1; i >= 0; i-- {
+				if receipt.Tenders[i].LastFour == "" && receipt.Tenders[i].PaymentType != "CASH" {
+					receipt.Tenders[i].LastFour = m[1]
+					break
+				}
+			}
+			continue
+		}
+		if m := receiptStorePattern.FindStringSubmatch(line); m != nil && receipt.StoreID == "" {
+			receipt.StoreID = m[1]
+		}
+		if m := receiptTCPattern.FindStringSubmatch(line); m != nil {
+			receipt.TCNumber = digitsOnly(m[1])
+			continue
+		}
+		if m := receiptDatePattern.FindStringSubmatch(line); m != nil && receipt.Date.IsZero() {
+			receipt.Date = parseReceiptDate(m[1], m[2])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read receipt: %w", err)
+	}
+
+	switch {
+	case receipt.TCNumber == "":
+		return nil, fmt.Errorf("receipt has no TC number")
+	case receipt.Date.IsZero():
+		return nil, fmt.Errorf("receipt has no date")
+	case len(receipt.Items) == 0:
+		return nil, fmt.Errorf("receipt has no items")
+	case receipt.Total == 0:
+		return nil, fmt.Errorf("receipt has no total")
+	}
+	if receipt.Subtotal == 0 {
+		receipt.Subtotal = roundCurrency(receipt.Total - receipt.Tax)
+	}
+	return receipt, nil
+}
+
+// covers reports whether a quantity line prices a line total.
+func (i *ReceiptItem) covers(lineTotal float64) bool {
+	return math.Abs(i.Quantity*i.UnitPrice-lineTotal) < 0.015
+}
+
+// tenderPaymentType maps a printed tender to Walmart's ledger payment types.
+// Debit cards settle through the bank like credit cards and are reported as
+// CREDITCARD, the same as Walmart's order ledger does.
+func tenderPaymentType(description string) string {
+	upper := strings.ToUpper(description)
+	switch {
+	case strings.Contains(upper, "CASH"):
+		return "CASH"
+	case strings.Contains(upper, "GIFT"):
+		return "GIFTCARD"
+	case strings.Contains(upper, "EBT"):
+		return "EBT"
+	default:
+		return "CREDITCARD"
+	}
+}
+
+func parseReceiptDate(date, clock string) time.Time {
+	layouts := []string{"01/02/06 15:04:05", "01/02/06 15:04", "01/02/2006 15:04:05", "01/02/2006 15:04"}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, date+" "+clock, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ToOrder converts a receipt to a Walmart order that goes through the same
+// handling as in-store purchases fetched from a linked account. The TC number
+// is the order ID, which is also how Walmart identifies in-store purchases,
+// so a receipt synced later from an account is recognized as processed.
+func (r *Receipt) ToOrder() *Order {
+	order := r.order
+	if order == nil {
+		order = r.buildOrder()
+	}
+	return &Order{
+		walmartOrder: order,
+		ledgerCache:  r.ledger(order.ID),
+	}
+}
+
+func (r *Receipt) buildOrder() *walmartclient.Order {
+	items := make([]walmartclient.OrderItem, 0, len(r.Items))
+	for i, item := range r.Items {
+		items = append(items, walmartclient.OrderItem{
+			ID:       fmt.Sprintf("%s-%d", r.TCNumber, i+1),
+			Quantity: item.Quantity,
+			ProductInfo: &walmartclient.ProductInfo{
+				Name:     item.Name,
+				USItemID: item.UPC,
+			},
+			PriceInfo: &walmartclient.ItemPrice{
+				LinePrice: &walmartclient.Price{Value: item.Price},
+				UnitPrice: &walmartclient.Price{Value: item.UnitPrice},
+			},
+		})
+	}
+
+	var paymentMethods []walmartclient.OrderPaymentMethod
+	for _, tender := range r.Tenders {
+		paymentMethods = append(paymentMethods, walmartclient.OrderPaymentMethod{
+			Description: tender.Description,
+			CardType:    tender.Description,
+			PaymentType: tender.PaymentType,
+		})
+	}
+
+	return &walmartclient.Order{
+		ID:        r.TCNumber,
+		Type:      "IN_STORE",
+		OrderDate: r.Date.Format(time.RFC3339),
+		Groups: []walmartclient.OrderGroup{{
+			ID:              r.TCNumber,
+			ItemCount:       len(items),
+			Items:           items,
+			FulfillmentType: "IN_STORE",
+			Store:           &walmartclient.Store{ID: r.StoreID},
+		}},
+		PriceDetails: &walmartclient.OrderPriceDetails{
+			SubTotal:   &walmartclient.PriceLineItem{Label: "Subtotal", Value: r.Subtotal},
+			TaxTotal:   &walmartclient.PriceLineItem{Label: "Tax", Value: r.Tax},
+			GrandTotal: &walmartclient.PriceLineItem{Label: "Total", Value: r.Total},
+		},
+		PaymentMethods: paymentMethods,
+	}
+}
+
+// ledger returns the payment ledger for the receipt. Walmart's ledger is
+// empty for in-store purchases, and the order falls back to its single card
+// payment (see inStoreCreditCardCharge); the receipt mirrors that. Receipts
+// paid with several tenders carry each tender as a charge instead, so only the
+// card portion is matched to the bank.
+func (r *Receipt) ledger(orderID string) *walmartclient.OrderLedger {
+	ledger := &walmartclient.OrderLedger{OrderID: orderID, PaymentMethods: []walmartclient.PaymentMethodCharges{}}
+	if len(r.Tenders) <= 1 {
+		return ledger
+	}
+	for _, tender := range r.Tenders {
+		if tender.PaymentType == "CASH" || tender.Amount <= 0 {
+			continue
+		}
+		ledger.PaymentMethods = append(ledger.PaymentMethods, walmartclient.PaymentMethodCharges{
+			PaymentType:  tender.PaymentType,
+			CardType:     tender.Description,
+			LastFour:     tender.LastFour,
+			FinalCharges: []float64{tender.Amount},
+			ChargedDates: []time.Time{r.Date},
+			TotalCharged: tender.Amount,
+		})
+	}
+	return ledger
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+func normalizeStoreID(id string) string {
+	id = strings.TrimLeft(strings.TrimSpace(id), "0")
+	if id == "" {
+		return "0"
+	}
+	return id
+}
+
+func roundCurrency(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
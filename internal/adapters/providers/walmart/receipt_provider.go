@@ -0,0 +1,195 @@
+package walmart
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+)
+
+// ReceiptProvider supplies Walmart in-store purchases from receipt files, for
+// purchases that aren't on a linked Walmart account. Its orders are ordinary
+// Walmart orders and are processed by the Walmart handler.
+type ReceiptProvider struct {
+	paths  []string // Receipt files, or directories of .json/.txt receipts
+	query  ReceiptQuery
+	logger *slog.Logger
+}
+
+// NewReceiptProvider creates a provider over receipt files. Each path is a
+// receipt file or a directory of them; a non-zero query keeps only the
+// receipts with that TC number, store and date.
+func NewReceiptProvider(paths []string, query ReceiptQuery, logger *slog.Logger) *ReceiptProvider {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ReceiptProvider{paths: paths, query: query, logger: logger}
+}
+
+// Name returns the provider identifier
+func (p *ReceiptProvider) Name() string {
+	return "walmart"
+}
+
+// DisplayName returns the human-readable name
+func (p *ReceiptProvider) DisplayName() string {
+	return "Walmart"
+}
+
+// FetchOrders loads the receipts and returns them as Walmart orders. Receipts
+// are limited to the lookback window unless they were picked out by a query,
+// since a receipt looked up by its TC number is wanted whatever its age.
+func (p *ReceiptProvider) FetchOrders(ctx context.Context, opts providers.FetchOptions) ([]providers.Order, error) {
+	receipts, err := p.loadReceipts()
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []providers.Order
+	for _, receipt := range receipts {
+		if err := ctx.Err(); err != nil {
+			return orders, err
+		}
+		if !p.query.Matches(receipt) {
+			continue
+		}
+		if p.query.IsZero() && !inRange(receipt.Date, opts.StartDate, opts.EndDate) {
+			p.logger.Debug("skipping receipt outside the lookback window",
+				slog.String("tc_number", receipt.TCNumber),
+				slog.Time("date", receipt.Date))
+			continue
+		}
+
+		order := receipt.ToOrder()
+		order.logger = p.logger
+		order.ctx = ctx
+		orders = append(orders, order)
+		if opts.MaxOrders > 0 && len(orders) >= opts.MaxOrders {
+			break
+		}
+	}
+
+	if len(orders) == 0 && !p.query.IsZero() {
+		return nil, fmt.Errorf("no receipt found for %s", p.query)
+	}
+	p.logger.Info("loaded receipts", slog.Int("total", len(orders)))
+	return orders, nil
+}
+
+// loadReceipts reads every receipt under the configured paths, oldest first.
+// Unreadable files in a directory are logged and skipped; a file named
+// explicitly must parse.
+func (p *ReceiptProvider) loadReceipts() ([]*Receipt, error) {
+	var receipts []*Receipt
+	seen := make(map[string]bool)
+	add := func(receipt *Receipt) {
+		if seen[receipt.TCNumber] {
+			return
+		}
+		seen[receipt.TCNumber] = true
+		receipts = append(receipts, receipt)
+	}
+
+	for _, path := range p.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read receipts: %w", err)
+		}
+		if !info.IsDir() {
+			receipt, err := LoadReceiptFile(path)
+			if err != nil {
+				return nil, err
+			}
+			add(receipt)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read receipts: %w", err)
+		}
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if entry.IsDir() || (ext != ".json" && ext != ".txt") {
+				continue
+			}
+			receipt, err := LoadReceiptFile(filepath.Join(path, entry.Name()))
+			if err != nil {
+				p.logger.Warn("skipping unreadable receipt", slog.String("error", err.Error()))
+				continue
+			}
+			add(receipt)
+		}
+	}
+
+	sort.SliceStable(receipts, func(i, j int) bool {
+		return receipts[i].Date.Before(receipts[j].Date)
+	})
+	return receipts, nil
+}
+
+// GetOrderDetails returns the receipt with the given TC number
+func (p *ReceiptProvider) GetOrderDetails(ctx context.Context, orderID string) (providers.Order, error) {
+	receipts, err := p.loadReceipts()
+	if err != nil {
+		return nil, err
+	}
+	for _, receipt := range receipts {
+		if receipt.TCNumber == digitsOnly(orderID) || receipt.TCNumber == orderID {
+			order := receipt.ToOrder()
+			order.logger = p.logger
+			order.ctx = ctx
+			return order, nil
+		}
+	}
+	return nil, fmt.Errorf("no receipt found for order %s", orderID)
+}
+
+// SupportsDeliveryTips returns false; receipts are in-store purchases
+func (p *ReceiptProvider) SupportsDeliveryTips() bool {
+	return false
+}
+
+// SupportsRefunds returns false; receipts only record the purchase
+func (p *ReceiptProvider) SupportsRefunds() bool {
+	return false
+}
+
+// SupportsBulkFetch returns true if provider supports bulk fetching
+func (p *ReceiptProvider) SupportsBulkFetch() bool {
+	return true
+}
+
+// GetRateLimit returns zero; receipts are read from disk
+func (p *ReceiptProvider) GetRateLimit() time.Duration {
+	return 0
+}
+
+// HealthCheck verifies the receipt paths exist
+func (p *ReceiptProvider) HealthCheck(ctx context.Context) error {
+	if len(p.paths) == 0 {
+		return fmt.Errorf("no receipt files configured")
+	}
+	for _, path := range p.paths {
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("walmart receipts unavailable: %w", err)
+		}
+	}
+	return nil
+}
+
+func inRange(date, start, end time.Time) bool {
+	if !start.IsZero() && date.Before(start) {
+		return false
+	}
+	if !end.IsZero() && date.After(end) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,184 @@
+package walmart
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReceipt_Text(t *testing.T) {
+	receipt, err := LoadReceiptFile(filepath.Join("testdata", "receipt.txt"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "12345678901234567890", receipt.TCNumber)
+	assert.Equal(t, "00100", receipt.StoreID)
+	assert.Equal(t, time.Date(2026, 5, 9, 14, 32, 11, 0, time.Local), receipt.Date)
+	assert.InDelta(t, 15.63, receipt.Subtotal, 0.001)
+	assert.InDelta(t, 0.63, receipt.Tax, 0.001)
+	assert.InDelta(t, 16.26, receipt.Total, 0.001)
+
+	require.Len(t, receipt.Items, 4)
+	assert.Equal(t, ReceiptItem{Name: "GV 2% MILK", UPC: "007874235186", Quantity: 1, UnitPrice: 3.48, Price: 3.48}, receipt.Items[0])
+	assert.InDelta(t, 2.48, receipt.Items[1].Quantity, 0.001, "weighed items keep fractional pounds")
+	assert.InDelta(t, 0.49, receipt.Items[1].UnitPrice, 0.001)
+	assert.Equal(t, 2.0, receipt.Items[2].Quantity, "multiple printed above its item")
+	assert.InDelta(t, 0.98, receipt.Items[2].UnitPrice, 0.001)
+	assert.InDelta(t, 8.97, receipt.Items[3].Price, 0.001, "coupon folded into its item")
+
+	require.Len(t, receipt.Tenders, 1)
+	assert.Equal(t, ReceiptTender{PaymentType: "CREDITCARD", Description: "VISA", LastFour: "1234", Amount: 16.26}, receipt.Tenders[0])
+}
+
+func TestParseReceipt_RejectsIncompleteText(t *testing.T) {
+	_, err := ParseReceipt(strings.NewReader("GV 2% MILK    007874235186 F   3.48 N\n  TOTAL  3.48\n"))
+	assert.ErrorContains(t, err, "no TC number")
+}
+
+func TestParseReceipt_OrderJSON(t *testing.T) {
+	receipt, err := LoadReceiptFile(filepath.Join("testdata", "receipt_order.json"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "20005678901234567890", receipt.TCNumber)
+	assert.Equal(t, "100", receipt.StoreID)
+	assert.InDelta(t, 4.41, receipt.Total, 0.001)
+	require.Len(t, receipt.Items, 1)
+	assert.Equal(t, "Great Value Eggs", receipt.Items[0].Name)
+}
+
+func TestReceipt_ToOrder_SingleCardUsesInStoreCharge(t *testing.T) {
+	receipt, err := LoadReceiptFile(filepath.Join("testdata", "receipt.txt"))
+	require.NoError(t, err)
+
+	order := receipt.ToOrder()
+
+	assert.Equal(t, "12345678901234567890", order.GetID())
+	assert.Equal(t, "walmart", order.GetProviderName())
+	assert.InDelta(t, 16.26, order.GetTotal(), 0.001)
+	assert.InDelta(t, 0.63, order.GetTax(), 0.001)
+	assert.Len(t, order.GetItems(), 4)
+
+	charges, err := order.GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{16.26}, charges)
+	require.NotNil(t, order.GetRawLedger())
+	assert.Empty(t, order.GetRawLedger().PaymentMethods, "in-store ledgers are empty, as from Walmart")
+}
+
+func TestReceipt_ToOrder_SplitTenderChargesOnlyTheCard(t *testing.T) {
+	receipt, err := LoadReceiptFile(filepath.Join("testdata", "receipt_split_tender.txt"))
+	require.NoError(t, err)
+
+	order := receipt.ToOrder()
+
+	charges, err := order.GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{221.00}, charges, "gift card portion never reaches the bank")
+	assert.InDelta(t, 321.00, order.GetTotal(), 0.001)
+
+	breakdown, err := order.GetPaymentBreakdown()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{221.00}, breakdown.BankCharges)
+	assert.Equal(t, []providers.NonBankPayment{{Method: "GIFTCARD", Amount: 100.00}}, breakdown.NonBank)
+}
+
+func TestReceipt_ToOrder_CashRemainderIsNonBank(t *testing.T) {
+	receipt := &Receipt{
+		TCNumber: "111", Date: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), Total: 50.00,
+		Tenders: []ReceiptTender{
+			{PaymentType: "CREDITCARD", Description: "VISA", LastFour: "1234", Amount: 30.00},
+			{PaymentType: "CASH", Description: "CASH", Amount: 40.00},
+		},
+	}
+
+	breakdown, err := receipt.ToOrder().GetPaymentBreakdown()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{30.00}, breakdown.BankCharges)
+	assert.Equal(t, []providers.NonBankPayment{{Method: "CASH", Amount: 20.00}}, breakdown.NonBank, "cash tendered includes change")
+}
+
+func TestReceipt_ToOrder_OrderJSONUsesInStoreCharge(t *testing.T) {
+	receipt, err := LoadReceiptFile(filepath.Join("testdata", "receipt_order.json"))
+	require.NoError(t, err)
+
+	charges, err := receipt.ToOrder().GetFinalCharges()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{4.41}, charges)
+}
+
+func TestReceiptQuery_Matches(t *testing.T) {
+	receipt := &Receipt{
+		TCNumber: "12345678901234567890",
+		StoreID:  "00100",
+		Date:     time.Date(2026, 5, 9, 14, 32, 11, 0, time.Local),
+	}
+
+	assert.True(t, ReceiptQuery{}.Matches(receipt))
+	assert.True(t, ReceiptQuery{TCNumber: "1234 5678 9012 3456 7890"}.Matches(receipt))
+	assert.True(t, ReceiptQuery{StoreID: "100", Date: time.Date(2026, 5, 9, 0, 0, 0, 0, time.Local)}.Matches(receipt))
+	assert.False(t, ReceiptQuery{StoreID: "101"}.Matches(receipt))
+	assert.False(t, ReceiptQuery{Date: time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local)}.Matches(receipt))
+}
+
+func TestReceiptProvider_FetchOrders(t *testing.T) {
+	var _ providers.OrderProvider = (*ReceiptProvider)(nil)
+
+	window := providers.FetchOptions{
+		StartDate: time.Date(2026, 5, 1, 0, 0, 0, 0, time.Local),
+		EndDate:   time.Date(2026, 5, 31, 0, 0, 0, 0, time.Local),
+	}
+
+	t.Run("directory yields every receipt in the window, oldest first", func(t *testing.T) {
+		provider := NewReceiptProvider([]string{"testdata"}, ReceiptQuery{}, nil)
+
+		orders, err := provider.FetchOrders(context.Background(), window)
+		require.NoError(t, err)
+		require.Len(t, orders, 3)
+		assert.Equal(t, "12345678901234567890", orders[0].GetID())
+		assert.Equal(t, "99990000111122223333", orders[1].GetID())
+		assert.Equal(t, "20005678901234567890", orders[2].GetID())
+		_, ok := orders[0].(*Order)
+		assert.True(t, ok, "receipts are ordinary Walmart orders")
+	})
+
+	t.Run("lookback window excludes older receipts", func(t *testing.T) {
+		provider := NewReceiptProvider([]string{"testdata"}, ReceiptQuery{}, nil)
+
+		orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{
+			StartDate: time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local),
+		})
+		require.NoError(t, err)
+		assert.Len(t, orders, 2)
+	})
+
+	t.Run("TC lookup ignores the lookback window", func(t *testing.T) {
+		provider := NewReceiptProvider([]string{"testdata"}, ReceiptQuery{TCNumber: "1234 5678 9012 3456 7890"}, nil)
+
+		orders, err := provider.FetchOrders(context.Background(), providers.FetchOptions{
+			StartDate: time.Date(2026, 6, 1, 0, 0, 0, 0, time.Local),
+		})
+		require.NoError(t, err)
+		require.Len(t, orders, 1)
+		assert.Equal(t, "12345678901234567890", orders[0].GetID())
+	})
+
+	t.Run("unknown TC number is an error", func(t *testing.T) {
+		provider := NewReceiptProvider([]string{"testdata"}, ReceiptQuery{TCNumber: "1"}, nil)
+
+		_, err := provider.FetchOrders(context.Background(), window)
+		assert.ErrorContains(t, err, "no receipt found for TC# 1")
+	})
+
+	t.Run("order details by TC number", func(t *testing.T) {
+		provider := NewReceiptProvider([]string{"testdata"}, ReceiptQuery{}, nil)
+
+		order, err := provider.GetOrderDetails(context.Background(), "99990000111122223333")
+		require.NoError(t, err)
+		assert.InDelta(t, 321.00, order.GetTotal(), 0.001)
+	})
+}
@@ -26,17 +26,32 @@ type OrderResponse struct {
 	SplitCount        int             `json:"split_count"`
 	MatchConfidence   float64         `json:"match_confidence"`
 	DryRun            bool            `json:"dry_run"`
+	PromptHash        string          `json:"prompt_hash,omitempty"`
+	Account           string          `json:"account,omitempty"`
 	Items             []ItemResponse  `json:"items,omitempty"`
 	Splits            []SplitResponse `json:"splits,omitempty"`
+
+	LowConfidenceItems []LowConfidenceItemResponse `json:"low_confidence_items,omitempty"`
+}
+
+// LowConfidenceItemResponse is an item categorized below the confidence threshold.
+type LowConfidenceItemResponse struct {
+	Name             string  `json:"name"`
+	CategoryID       string  `json:"category_id"`
+	CategoryName     string  `json:"category_name"`
+	Confidence       float64 `json:"confidence"`
+	FallbackCategory string  `json:"fallback_category,omitempty"`
+	FlaggedForReview bool    `json:"flagged_for_review"`
 }
 
 // ItemResponse represents an item within an order.
 type ItemResponse struct {
-	Name       string  `json:"name"`
-	Quantity   float64 `json:"quantity"`
-	UnitPrice  float64 `json:"unit_price"`
-	TotalPrice float64 `json:"total_price"`
-	Category   string  `json:"category,omitempty"`
+	Name       string   `json:"name"`
+	Quantity   float64  `json:"quantity"`
+	UnitPrice  float64  `json:"unit_price"`
+	TotalPrice float64  `json:"total_price"`
+	Category   string   `json:"category,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
 }
 
 // SplitResponse represents a transaction split.
@@ -46,6 +61,7 @@ type SplitResponse struct {
 	Amount       float64        `json:"amount"`
 	Items        []ItemResponse `json:"items,omitempty"`
 	Notes        string         `json:"notes,omitempty"`
+	Tags         []string       `json:"tags,omitempty"`
 }
 
 // OrderListResponse is returned when listing orders.
@@ -75,17 +91,19 @@ type ItemSearchResponse struct {
 
 // SyncRunResponse represents a sync run in API responses.
 type SyncRunResponse struct {
-	ID              int64  `json:"id"`
-	Provider        string `json:"provider"`
-	StartedAt       string `json:"started_at"`
-	CompletedAt     string `json:"completed_at,omitempty"`
-	LookbackDays    int    `json:"lookback_days"`
-	DryRun          bool   `json:"dry_run"`
-	OrdersFound     int    `json:"orders_found"`
-	OrdersProcessed int    `json:"orders_processed"`
-	OrdersSkipped   int    `json:"orders_skipped"`
-	OrdersErrored   int    `json:"orders_errored"`
-	Status          string `json:"status"`
+	ID              int64    `json:"id"`
+	Provider        string   `json:"provider"`
+	StartedAt       string   `json:"started_at"`
+	CompletedAt     string   `json:"completed_at,omitempty"`
+	LookbackDays    int      `json:"lookback_days"`
+	DryRun          bool     `json:"dry_run"`
+	OrdersFound     int      `json:"orders_found"`
+	OrdersProcessed int      `json:"orders_processed"`
+	OrdersSkipped   int      `json:"orders_skipped"`
+	OrdersErrored   int      `json:"orders_errored"`
+	Status          string   `json:"status"`
+	Account         string   `json:"account,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
 }
 
 // SyncRunListResponse is returned when listing sync runs.
@@ -157,6 +175,46 @@ type ChargeResponse struct {
 	SplitCount           int     `json:"split_count,omitempty"`
 }
 
+// UnmatchedChargeResponse is a payment charge that hasn't been matched to a
+// Monarch transaction, with the order it belongs to.
+type UnmatchedChargeResponse struct {
+	ChargeResponse
+	OrderID       string `json:"order_id"`
+	OrderLedgerID int64  `json:"order_ledger_id"`
+	Provider      string `json:"provider"`
+}
+
+// UnmatchedChargeListResponse is returned when listing unmatched charges.
+type UnmatchedChargeListResponse struct {
+	Charges     []UnmatchedChargeResponse `json:"charges"`
+	Count       int                       `json:"count"`
+	TotalAmount float64                   `json:"total_amount"`
+}
+
+// LedgerEventResponse is a change between two ledger snapshots of an order.
+type LedgerEventResponse struct {
+	ID               int64   `json:"id"`
+	OrderLedgerID    int64   `json:"order_ledger_id"`
+	PreviousLedgerID int64   `json:"previous_ledger_id,omitempty"`
+	SyncRunID        int64   `json:"sync_run_id,omitempty"`
+	EventType        string  `json:"event_type"`
+	ChargeAmount     float64 `json:"charge_amount,omitempty"`
+	ChargeType       string  `json:"charge_type,omitempty"`
+	PaymentMethod    string  `json:"payment_method,omitempty"`
+	CardType         string  `json:"card_type,omitempty"`
+	CardLastFour     string  `json:"card_last_four,omitempty"`
+	PreviousState    string  `json:"previous_state,omitempty"`
+	NewState         string  `json:"new_state,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+// LedgerEventListResponse is returned when listing an order's ledger events.
+type LedgerEventListResponse struct {
+	OrderID string                `json:"order_id"`
+	Events  []LedgerEventResponse `json:"events"`
+	Count   int                   `json:"count"`
+}
+
 // LedgerListResponse is returned when listing ledgers.
 type LedgerListResponse struct {
 	Ledgers    []LedgerResponse `json:"ledgers"`
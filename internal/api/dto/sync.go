@@ -5,6 +5,7 @@ type StartSyncRequest struct {
 	Provider     string `json:"provider"`      // "walmart", "costco", "amazon"
 	DryRun       bool   `json:"dry_run"`       // Preview mode
 	LookbackDays int    `json:"lookback_days"` // How many days to look back (default 14)
+	SinceLast    bool   `json:"since_last"`    // Look back to where the last sync left off
 	MaxOrders    int    `json:"max_orders"`    // Max orders to process (0 = all)
 	Force        bool   `json:"force"`         // Force reprocess already processed orders
 	Verbose      bool   `json:"verbose"`       // Verbose logging
@@ -33,12 +34,14 @@ type SyncJobResponse struct {
 
 // SyncProgressResponse represents real-time progress.
 type SyncProgressResponse struct {
-	CurrentPhase    string `json:"current_phase"`
-	TotalOrders     int    `json:"total_orders"`
-	ProcessedOrders int    `json:"processed_orders"`
-	SkippedOrders   int    `json:"skipped_orders"`
-	ErroredOrders   int    `json:"errored_orders"`
-	LastUpdate      string `json:"last_update"`
+	CurrentPhase     string `json:"current_phase"`
+	TotalOrders      int    `json:"total_orders"`
+	ProcessedOrders  int    `json:"processed_orders"`
+	SkippedOrders    int    `json:"skipped_orders"`
+	ErroredOrders    int    `json:"errored_orders"`
+	TotalItems       int    `json:"total_items"`
+	CategorizedItems int    `json:"categorized_items"`
+	LastUpdate       string `json:"last_update"`
 }
 
 // SyncResultResponse represents the final result.
@@ -85,6 +85,32 @@ func (h *LedgersHandler) Get(w http.ResponseWriter, r *http.Request) {
 	h.WriteJSON(w, http.StatusOK, response)
 }
 
+// Unmatched handles GET /api/ledgers/unmatched - returns payment charges in
+// each order's latest ledger that haven't been matched to a Monarch transaction.
+func (h *LedgersHandler) Unmatched(w http.ResponseWriter, r *http.Request) {
+	charges, err := h.repo.GetUnmatchedCharges(r.URL.Query().Get("provider"), ParseIntParam(r, "limit", 100))
+	if err != nil {
+		h.WriteError(w, http.StatusInternalServerError, dto.InternalError())
+		return
+	}
+
+	response := dto.UnmatchedChargeListResponse{
+		Charges: make([]dto.UnmatchedChargeResponse, 0, len(charges)),
+		Count:   len(charges),
+	}
+	for _, charge := range charges {
+		response.Charges = append(response.Charges, dto.UnmatchedChargeResponse{
+			ChargeResponse: toChargeResponse(charge),
+			OrderID:        charge.OrderID,
+			OrderLedgerID:  charge.OrderLedgerID,
+			Provider:       charge.Provider,
+		})
+		response.TotalAmount += charge.ChargeAmount
+	}
+
+	h.WriteJSON(w, http.StatusOK, response)
+}
+
 // GetByOrderID handles GET /api/orders/{orderID}/ledger - returns the latest ledger for an order.
 func (h *LedgersHandler) GetByOrderID(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderID")
@@ -136,6 +162,47 @@ func (h *LedgersHandler) GetHistoryByOrderID(w http.ResponseWriter, r *http.Requ
 	h.WriteJSON(w, http.StatusOK, response)
 }
 
+// GetEventsByOrderID handles GET /api/orders/{orderID}/ledger/events - returns
+// what changed between an order's ledger snapshots, oldest first.
+func (h *LedgersHandler) GetEventsByOrderID(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderID")
+	if orderID == "" {
+		h.WriteError(w, http.StatusBadRequest, dto.BadRequestError("order ID is required"))
+		return
+	}
+
+	events, err := h.repo.GetLedgerEvents(orderID)
+	if err != nil {
+		h.WriteError(w, http.StatusInternalServerError, dto.InternalError())
+		return
+	}
+
+	response := dto.LedgerEventListResponse{
+		OrderID: orderID,
+		Events:  make([]dto.LedgerEventResponse, 0, len(events)),
+		Count:   len(events),
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, dto.LedgerEventResponse{
+			ID:               event.ID,
+			OrderLedgerID:    event.OrderLedgerID,
+			PreviousLedgerID: event.PreviousLedgerID,
+			SyncRunID:        event.SyncRunID,
+			EventType:        string(event.EventType),
+			ChargeAmount:     event.ChargeAmount,
+			ChargeType:       event.ChargeType,
+			PaymentMethod:    event.PaymentMethod,
+			CardType:         event.CardType,
+			CardLastFour:     event.CardLastFour,
+			PreviousState:    string(event.PreviousState),
+			NewState:         string(event.NewState),
+			CreatedAt:        event.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+
+	h.WriteJSON(w, http.StatusOK, response)
+}
+
 // toLedgerResponse converts a storage OrderLedger to an API response.
 func toLedgerResponse(ledger *storage.OrderLedger) dto.LedgerResponse {
 	response := dto.LedgerResponse{
@@ -156,25 +223,30 @@ func toLedgerResponse(ledger *storage.OrderLedger) dto.LedgerResponse {
 	}
 
 	for _, charge := range ledger.Charges {
-		chargeResp := dto.ChargeResponse{
-			ID:                   charge.ID,
-			ChargeSequence:       charge.ChargeSequence,
-			ChargeAmount:         charge.ChargeAmount,
-			ChargeType:           charge.ChargeType,
-			PaymentMethod:        charge.PaymentMethod,
-			CardType:             charge.CardType,
-			CardLastFour:         charge.CardLastFour,
-			MonarchTransactionID: charge.MonarchTransactionID,
-			IsMatched:            charge.IsMatched,
-			MatchConfidence:      charge.MatchConfidence,
-			SplitCount:           charge.SplitCount,
-		}
-		// Only include charged_at if it's not zero
-		if !charge.ChargedAt.IsZero() {
-			chargeResp.ChargedAt = charge.ChargedAt.Format("2006-01-02T15:04:05Z07:00")
-		}
-		response.Charges = append(response.Charges, chargeResp)
+		response.Charges = append(response.Charges, toChargeResponse(charge))
 	}
 
 	return response
 }
+
+// toChargeResponse converts a storage LedgerCharge to an API response.
+func toChargeResponse(charge storage.LedgerCharge) dto.ChargeResponse {
+	response := dto.ChargeResponse{
+		ID:                   charge.ID,
+		ChargeSequence:       charge.ChargeSequence,
+		ChargeAmount:         charge.ChargeAmount,
+		ChargeType:           charge.ChargeType,
+		PaymentMethod:        charge.PaymentMethod,
+		CardType:             charge.CardType,
+		CardLastFour:         charge.CardLastFour,
+		MonarchTransactionID: charge.MonarchTransactionID,
+		IsMatched:            charge.IsMatched,
+		MatchConfidence:      charge.MatchConfidence,
+		SplitCount:           charge.SplitCount,
+	}
+	// Only include charged_at if it's not zero
+	if !charge.ChargedAt.IsZero() {
+		response.ChargedAt = charge.ChargedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return response
+}
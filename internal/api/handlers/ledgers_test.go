@@ -0,0 +1,125 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/eshaffer321/itemize/internal/api/dto"
+	"github.com/eshaffer321/itemize/internal/api/handlers"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+func TestLedgersHandler_Unmatched(t *testing.T) {
+	t.Run("returns empty list when every charge is matched", func(t *testing.T) {
+		repo := storage.NewMockRepository()
+		handler := handlers.NewLedgersHandler(repo)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/ledgers/unmatched", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Unmatched(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.UnmatchedChargeListResponse
+		err := json.NewDecoder(rec.Body).Decode(&response)
+		require.NoError(t, err)
+
+		assert.Empty(t, response.Charges)
+		assert.Equal(t, 0, response.Count)
+	})
+
+	t.Run("returns unmatched charges filtered by provider", func(t *testing.T) {
+		repo := storage.NewMockRepository()
+		chargedAt := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+		require.NoError(t, repo.SaveLedger(&storage.OrderLedger{
+			OrderID:     "walmart-order",
+			Provider:    "walmart",
+			LedgerState: storage.LedgerStateCharged,
+			Charges: []storage.LedgerCharge{
+				{ChargeSequence: 1, ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234", ChargedAt: chargedAt},
+				{ChargeSequence: 2, ChargeAmount: 12.50, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234"},
+				{ChargeSequence: 3, ChargeAmount: 5.00, ChargeType: "payment", PaymentMethod: "GIFTCARD"},
+			},
+		}))
+		require.NoError(t, repo.SaveLedger(&storage.OrderLedger{
+			OrderID:     "costco-order",
+			Provider:    "costco",
+			LedgerState: storage.LedgerStateCharged,
+			Charges: []storage.LedgerCharge{
+				{ChargeSequence: 1, ChargeAmount: 99.00, ChargeType: "payment", PaymentMethod: "CREDITCARD"},
+			},
+		}))
+		ledger, err := repo.GetLatestLedger("walmart-order")
+		require.NoError(t, err)
+		require.NoError(t, repo.UpdateChargeMatch(ledger.Charges[1].ID, "txn-1", 1.0, 1))
+
+		handler := handlers.NewLedgersHandler(repo)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/ledgers/unmatched?provider=walmart", nil)
+		rec := httptest.NewRecorder()
+
+		handler.Unmatched(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var response dto.UnmatchedChargeListResponse
+		err = json.NewDecoder(rec.Body).Decode(&response)
+		require.NoError(t, err)
+
+		require.Len(t, response.Charges, 1)
+		charge := response.Charges[0]
+		assert.Equal(t, "walmart-order", charge.OrderID)
+		assert.Equal(t, ledger.ID, charge.OrderLedgerID)
+		assert.Equal(t, "walmart", charge.Provider)
+		assert.Equal(t, 40.00, charge.ChargeAmount)
+		assert.Equal(t, "1234", charge.CardLastFour)
+		assert.Equal(t, "2025-10-10T00:00:00Z", charge.ChargedAt)
+		assert.False(t, charge.IsMatched)
+		assert.Equal(t, 40.00, response.TotalAmount)
+	})
+}
+
+func TestLedgersHandler_GetEventsByOrderID(t *testing.T) {
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveLedger(&storage.OrderLedger{
+		OrderID: "order-1", Provider: "walmart", LedgerState: storage.LedgerStateCharged,
+		Charges: []storage.LedgerCharge{{ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234"}},
+	}))
+	require.NoError(t, repo.SaveLedger(&storage.OrderLedger{
+		OrderID: "order-1", Provider: "walmart", LedgerState: storage.LedgerStatePartialRefund,
+		Charges: []storage.LedgerCharge{
+			{ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234"},
+			{ChargeAmount: -5.58, ChargeType: "refund", PaymentMethod: "CREDITCARD", CardLastFour: "1234"},
+		},
+	}))
+
+	handler := handlers.NewLedgersHandler(repo)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/orders/order-1/ledger/events", nil)
+	req = req.WithContext(setChiURLParam(req.Context(), "orderID", "order-1"))
+	rec := httptest.NewRecorder()
+
+	handler.GetEventsByOrderID(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response dto.LedgerEventListResponse
+	err := json.NewDecoder(rec.Body).Decode(&response)
+	require.NoError(t, err)
+
+	assert.Equal(t, "order-1", response.OrderID)
+	require.Equal(t, 3, response.Count)
+	assert.Equal(t, "charge_added", response.Events[0].EventType)
+	assert.Equal(t, "refund_issued", response.Events[1].EventType)
+	assert.Equal(t, -5.58, response.Events[1].ChargeAmount)
+	assert.Equal(t, "state_changed", response.Events[2].EventType)
+	assert.Equal(t, "charged", response.Events[2].PreviousState)
+	assert.Equal(t, "partial_refund", response.Events[2].NewState)
+}
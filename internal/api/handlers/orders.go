@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
@@ -99,6 +100,8 @@ func toOrderResponse(record *storage.ProcessingRecord) dto.OrderResponse {
 		SplitCount:        record.SplitCount,
 		MatchConfidence:   record.MatchConfidence,
 		DryRun:            record.DryRun,
+		PromptHash:        record.PromptHash,
+		Account:           record.Account,
 		Items:             make([]dto.ItemResponse, 0, len(record.Items)),
 		Splits:            make([]dto.SplitResponse, 0, len(record.Splits)),
 	}
@@ -110,6 +113,7 @@ func toOrderResponse(record *storage.ProcessingRecord) dto.OrderResponse {
 			UnitPrice:  item.UnitPrice,
 			TotalPrice: item.TotalPrice,
 			Category:   item.Category,
+			Tags:       item.Tags,
 		})
 	}
 
@@ -119,6 +123,7 @@ func toOrderResponse(record *storage.ProcessingRecord) dto.OrderResponse {
 			CategoryName: split.CategoryName,
 			Amount:       split.Amount,
 			Notes:        split.Notes,
+			Tags:         split.Tags,
 			Items:        make([]dto.ItemResponse, 0, len(split.Items)),
 		}
 		for _, item := range split.Items {
@@ -128,10 +133,27 @@ func toOrderResponse(record *storage.ProcessingRecord) dto.OrderResponse {
 				UnitPrice:  item.UnitPrice,
 				TotalPrice: item.TotalPrice,
 				Category:   item.Category,
+				Tags:       item.Tags,
 			})
 		}
 		response.Splits = append(response.Splits, splitResp)
 	}
 
+	if record.LowConfidenceJSON != "" {
+		var items []storage.LowConfidenceItem
+		if err := json.Unmarshal([]byte(record.LowConfidenceJSON), &items); err == nil {
+			for _, item := range items {
+				response.LowConfidenceItems = append(response.LowConfidenceItems, dto.LowConfidenceItemResponse{
+					Name:             item.Name,
+					CategoryID:       item.CategoryID,
+					CategoryName:     item.CategoryName,
+					Confidence:       item.Confidence,
+					FallbackCategory: item.FallbackCategory,
+					FlaggedForReview: item.FlaggedForReview,
+				})
+			}
+		}
+	}
+
 	return response
 }
@@ -87,5 +87,7 @@ func toSyncRunResponse(run storage.SyncRun) dto.SyncRunResponse {
 		OrdersSkipped:   run.OrdersSkipped,
 		OrdersErrored:   run.OrdersErrored,
 		Status:          run.Status,
+		Account:         run.Account,
+		Warnings:        run.Warnings,
 	}
 }
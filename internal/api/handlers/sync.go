@@ -49,6 +49,7 @@ func (h *SyncHandler) StartSync(w http.ResponseWriter, r *http.Request) {
 		Provider:     req.Provider,
 		DryRun:       req.DryRun,
 		LookbackDays: req.LookbackDays,
+		SinceLast:    req.SinceLast,
 		MaxOrders:    req.MaxOrders,
 		Force:        req.Force,
 		Verbose:      req.Verbose,
@@ -181,11 +182,13 @@ func toSyncJobResponse(job *service.SyncJob) dto.SyncJobResponse {
 // toProgressResponse converts progress to API response.
 func toProgressResponse(progress service.SyncProgress) dto.SyncProgressResponse {
 	return dto.SyncProgressResponse{
-		CurrentPhase:    progress.CurrentPhase,
-		TotalOrders:     progress.TotalOrders,
-		ProcessedOrders: progress.ProcessedOrders,
-		SkippedOrders:   progress.SkippedOrders,
-		ErroredOrders:   progress.ErroredOrders,
-		LastUpdate:      progress.LastUpdate.Format(time.RFC3339),
+		CurrentPhase:     progress.CurrentPhase,
+		TotalOrders:      progress.TotalOrders,
+		ProcessedOrders:  progress.ProcessedOrders,
+		SkippedOrders:    progress.SkippedOrders,
+		ErroredOrders:    progress.ErroredOrders,
+		TotalItems:       progress.TotalItems,
+		CategorizedItems: progress.CategorizedItems,
+		LastUpdate:       progress.LastUpdate.Format(time.RFC3339),
 	}
 }
@@ -107,8 +107,10 @@ func (s *Server) setupRoutes() {
 		// Ledgers
 		ledgersHandler := handlers.NewLedgersHandler(s.repo)
 		r.Get("/ledgers", ledgersHandler.List)
+		r.Get("/ledgers/unmatched", ledgersHandler.Unmatched)
 		r.Get("/ledgers/{id}", ledgersHandler.Get)
 		r.Get("/orders/{orderID}/ledger", ledgersHandler.GetByOrderID)
+		r.Get("/orders/{orderID}/ledger/events", ledgersHandler.GetEventsByOrderID)
 		r.Get("/orders/{orderID}/ledgers", ledgersHandler.GetHistoryByOrderID)
 
 		// Sync operations (live sync jobs)
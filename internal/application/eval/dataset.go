@@ -0,0 +1,166 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is a labeled set of items and the category each one should get.
+//
+// Categories is the list offered to the model. When omitted, the categories
+// referenced by the items are used; list the full Monarch category set to
+// make the evaluation as hard as a real sync.
+type Dataset struct {
+	Name       string                 `yaml:"name,omitempty"`
+	Categories []categorizer.Category `yaml:"categories,omitempty"`
+	Items      []Example              `yaml:"items"`
+}
+
+// Example is one labeled item. Either CategoryID or Category (the category
+// name) must be set; names are resolved against Dataset.Categories.
+type Example struct {
+	Name       string  `yaml:"name"`
+	Price      float64 `yaml:"price,omitempty"`
+	CategoryID string  `yaml:"category_id,omitempty"`
+	Category   string  `yaml:"category,omitempty"`
+}
+
+// LoadDataset reads and validates a YAML dataset. The dataset name defaults
+// to the file's base name so stored runs can be grouped by dataset.
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read dataset: %w", err)
+	}
+
+	var ds Dataset
+	if err := yaml.Unmarshal(data, &ds); err != nil {
+		return nil, fmt.Errorf("parse dataset %s: %w", path, err)
+	}
+	if ds.Name == "" {
+		ds.Name = filepath.Base(path)
+	}
+	if err := ds.normalize(); err != nil {
+		return nil, fmt.Errorf("dataset %s: %w", path, err)
+	}
+	return &ds, nil
+}
+
+// normalize resolves category names to IDs, fills in the category list when
+// it is missing and rejects items that can't be scored.
+func (d *Dataset) normalize() error {
+	if len(d.Items) == 0 {
+		return fmt.Errorf("no items")
+	}
+
+	byName := make(map[string]categorizer.Category, len(d.Categories))
+	byID := make(map[string]categorizer.Category, len(d.Categories))
+	for _, category := range d.Categories {
+		byName[strings.ToLower(category.Name)] = category
+		byID[category.ID] = category
+	}
+
+	derived := len(d.Categories) == 0
+	for i := range d.Items {
+		item := &d.Items[i]
+		if strings.TrimSpace(item.Name) == "" {
+			return fmt.Errorf("item %d has no name", i+1)
+		}
+
+		switch {
+		case item.CategoryID != "":
+			if category, ok := byID[item.CategoryID]; ok && item.Category == "" {
+				item.Category = category.Name
+			}
+		case item.Category != "":
+			category, ok := byName[strings.ToLower(item.Category)]
+			if !ok {
+				return fmt.Errorf("item %q: unknown category %q (add it to categories or use category_id)", item.Name, item.Category)
+			}
+			item.CategoryID = category.ID
+			item.Category = category.Name
+		default:
+			return fmt.Errorf("item %q has no expected category", item.Name)
+		}
+
+		if _, ok := byID[item.CategoryID]; !ok {
+			if !derived {
+				return fmt.Errorf("item %q: category_id %q is not in the categories list", item.Name, item.CategoryID)
+			}
+			category := categorizer.Category{ID: item.CategoryID, Name: item.Category}
+			byID[category.ID] = category
+			d.Categories = append(d.Categories, category)
+		}
+	}
+	return nil
+}
+
+// DatasetFromHistory builds a dataset from past categorizations (see
+// sync.LoadCategoryHistory). Items are deduplicated by name; an item seen
+// under several categories is labeled with the one it was given most often.
+func DatasetFromHistory(name string, examples []categorizer.LabeledItem) *Dataset {
+	type tally struct {
+		name   string
+		counts map[string]int
+		order  []string
+	}
+	tallies := make(map[string]*tally)
+	categoryNames := make(map[string]string)
+
+	for _, example := range examples {
+		key := strings.ToLower(strings.TrimSpace(example.Name))
+		if key == "" || example.CategoryID == "" {
+			continue
+		}
+		t := tallies[key]
+		if t == nil {
+			t = &tally{name: strings.TrimSpace(example.Name), counts: make(map[string]int)}
+			tallies[key] = t
+		}
+		if t.counts[example.CategoryID] == 0 {
+			t.order = append(t.order, example.CategoryID)
+		}
+		t.counts[example.CategoryID]++
+		if example.CategoryName != "" {
+			categoryNames[example.CategoryID] = example.CategoryName
+		}
+	}
+
+	ds := &Dataset{Name: name}
+	for _, t := range tallies {
+		best := t.order[0]
+		for _, id := range t.order[1:] {
+			if t.counts[id] > t.counts[best] {
+				best = id
+			}
+		}
+		ds.Items = append(ds.Items, Example{Name: t.name, CategoryID: best, Category: categoryNames[best]})
+	}
+	sort.Slice(ds.Items, func(i, j int) bool {
+		return strings.ToLower(ds.Items[i].Name) < strings.ToLower(ds.Items[j].Name)
+	})
+
+	for id, categoryName := range categoryNames {
+		ds.Categories = append(ds.Categories, categorizer.Category{ID: id, Name: categoryName})
+	}
+	sort.Slice(ds.Categories, func(i, j int) bool { return ds.Categories[i].Name < ds.Categories[j].Name })
+
+	return ds
+}
+
+// WriteDataset writes a dataset as YAML, the same format LoadDataset reads.
+func WriteDataset(w io.Writer, ds *Dataset) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(ds); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,99 @@
+package eval
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeDataset(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "groceries.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadDataset_ResolvesCategoryNames(t *testing.T) {
+	path := writeDataset(t, `
+categories:
+  - id: cat_1
+    name: Groceries
+  - id: cat_2
+    name: Home & Garden
+items:
+  - name: Milk
+    price: 3.99
+    category: groceries
+  - name: Trash Bags
+    category_id: cat_2
+`)
+
+	ds, err := LoadDataset(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "groceries.yaml", ds.Name)
+	assert.Equal(t, Example{Name: "Milk", Price: 3.99, CategoryID: "cat_1", Category: "Groceries"}, ds.Items[0])
+	assert.Equal(t, "Home & Garden", ds.Items[1].Category)
+}
+
+func TestLoadDataset_DerivesCategoriesFromItems(t *testing.T) {
+	path := writeDataset(t, `
+name: quick
+items:
+  - name: Milk
+    category_id: cat_1
+    category: Groceries
+  - name: Bread
+    category_id: cat_1
+`)
+
+	ds, err := LoadDataset(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, "quick", ds.Name)
+	assert.Equal(t, []categorizer.Category{{ID: "cat_1", Name: "Groceries"}}, ds.Categories)
+}
+
+func TestLoadDataset_Errors(t *testing.T) {
+	tests := map[string]string{
+		"no items":          "items: []\n",
+		"missing label":     "items:\n  - name: Milk\n",
+		"unknown name":      "categories:\n  - id: cat_1\n    name: Groceries\nitems:\n  - name: Milk\n    category: Dairy\n",
+		"id not in list":    "categories:\n  - id: cat_1\n    name: Groceries\nitems:\n  - name: Milk\n    category_id: cat_9\n",
+		"item without name": "items:\n  - category_id: cat_1\n",
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadDataset(writeDataset(t, content))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDatasetFromHistory_RoundTrip(t *testing.T) {
+	ds := DatasetFromHistory("history", []categorizer.LabeledItem{
+		{Name: "Milk", CategoryID: "cat_1", CategoryName: "Groceries"},
+		{Name: "milk", CategoryID: "cat_1", CategoryName: "Groceries"},
+		{Name: "Milk", CategoryID: "cat_2", CategoryName: "Home & Garden"},
+		{Name: "Trash Bags", CategoryID: "cat_2", CategoryName: "Home & Garden"},
+		{Name: "", CategoryID: "cat_1"},
+	})
+
+	require.Len(t, ds.Items, 2)
+	assert.Equal(t, Example{Name: "Milk", CategoryID: "cat_1", Category: "Groceries"}, ds.Items[0], "majority label wins")
+	assert.Equal(t, "Trash Bags", ds.Items[1].Name)
+	assert.Equal(t, []categorizer.Category{{ID: "cat_1", Name: "Groceries"}, {ID: "cat_2", Name: "Home & Garden"}}, ds.Categories)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteDataset(&buf, ds))
+	path := writeDataset(t, buf.String())
+
+	loaded, err := LoadDataset(path)
+	require.NoError(t, err)
+	assert.Equal(t, ds, loaded)
+}
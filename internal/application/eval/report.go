@@ -0,0 +1,223 @@
+package eval
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// NoAnswer is the confusion-matrix key for items the categorizer didn't
+// place in any valid category (unknown ID, missing answer or failed call).
+const NoAnswer = "(none)"
+
+// Report is the outcome of one evaluation run.
+type Report struct {
+	Dataset    string `json:"dataset"`
+	Backend    string `json:"backend"`
+	Model      string `json:"model"`
+	PromptHash string `json:"prompt_hash"`
+	Label      string `json:"label,omitempty"`
+
+	Items         int     `json:"items"`
+	Correct       int     `json:"correct"`
+	Unanswered    int     `json:"unanswered"`
+	Accuracy      float64 `json:"accuracy"`
+	FailedBatches int     `json:"failed_batches"`
+
+	Calls            int     `json:"calls"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	Latency          Latency `json:"latency"`
+
+	// Categories has one row per category that was expected or predicted,
+	// sorted by name.
+	Categories []CategoryStats `json:"categories"`
+
+	// Confusion counts expected category ID → predicted category ID
+	// (NoAnswer when nothing valid came back).
+	Confusion map[string]map[string]int `json:"confusion"`
+
+	Mistakes []Mistake `json:"mistakes,omitempty"`
+
+	names map[string]string
+}
+
+// Latency summarizes per-call LLM latency in milliseconds.
+type Latency struct {
+	TotalMs int64 `json:"total_ms"`
+	MeanMs  int64 `json:"mean_ms"`
+	P50Ms   int64 `json:"p50_ms"`
+	P95Ms   int64 `json:"p95_ms"`
+	MaxMs   int64 `json:"max_ms"`
+}
+
+// CategoryStats is the per-category precision/recall row.
+type CategoryStats struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Expected  int     `json:"expected"`
+	Predicted int     `json:"predicted"`
+	Correct   int     `json:"correct"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// Mistake is an item the categorizer got wrong.
+type Mistake struct {
+	Item       string  `json:"item"`
+	Expected   string  `json:"expected"`
+	Predicted  string  `json:"predicted"`
+	Confidence float64 `json:"confidence"`
+}
+
+func newReport(ds *Dataset, opts Options, promptHash string) *Report {
+	r := &Report{
+		Dataset:    ds.Name,
+		Backend:    opts.Backend,
+		Model:      opts.Model,
+		PromptHash: promptHash,
+		Label:      opts.Label,
+		Confusion:  make(map[string]map[string]int),
+		names:      map[string]string{NoAnswer: NoAnswer},
+	}
+	for _, category := range ds.Categories {
+		r.names[category.ID] = category.Name
+	}
+	return r
+}
+
+// CategoryName returns the display name for a category ID in this report.
+func (r *Report) CategoryName(id string) string {
+	if name, ok := r.names[id]; ok && name != "" {
+		return name
+	}
+	return id
+}
+
+func (r *Report) score(example Example, prediction categorizer.ItemCategorization) {
+	predicted := prediction.CategoryID
+	if predicted == "" {
+		predicted = NoAnswer
+		r.Unanswered++
+	}
+
+	r.Items++
+	if r.Confusion[example.CategoryID] == nil {
+		r.Confusion[example.CategoryID] = make(map[string]int)
+	}
+	r.Confusion[example.CategoryID][predicted]++
+
+	if predicted == example.CategoryID {
+		r.Correct++
+		return
+	}
+	r.Mistakes = append(r.Mistakes, Mistake{
+		Item:       example.Name,
+		Expected:   r.CategoryName(example.CategoryID),
+		Predicted:  r.CategoryName(predicted),
+		Confidence: prediction.Confidence,
+	})
+}
+
+func (r *Report) finish(calls []callStat, elapsed time.Duration, opts Options) {
+	if r.Items > 0 {
+		r.Accuracy = float64(r.Correct) / float64(r.Items)
+	}
+
+	r.Calls = len(calls)
+	durations := make([]int64, 0, len(calls))
+	for _, call := range calls {
+		r.PromptTokens += call.promptTokens
+		r.CompletionTokens += call.completionTokens
+		durations = append(durations, call.duration.Milliseconds())
+	}
+	r.CostUSD = (float64(r.PromptTokens)*opts.InputPricePerMillion +
+		float64(r.CompletionTokens)*opts.OutputPricePerMillion) / 1_000_000
+	r.Latency = summarizeLatency(durations, elapsed)
+
+	rows := make(map[string]*CategoryStats)
+	row := func(id string) *CategoryStats {
+		if rows[id] == nil {
+			rows[id] = &CategoryStats{ID: id, Name: r.CategoryName(id)}
+		}
+		return rows[id]
+	}
+	for expected, predictions := range r.Confusion {
+		for predicted, count := range predictions {
+			row(expected).Expected += count
+			if predicted != NoAnswer {
+				row(predicted).Predicted += count
+			}
+			if predicted == expected {
+				row(expected).Correct += count
+			}
+		}
+	}
+	for _, stats := range rows {
+		if stats.Predicted > 0 {
+			stats.Precision = float64(stats.Correct) / float64(stats.Predicted)
+		}
+		if stats.Expected > 0 {
+			stats.Recall = float64(stats.Correct) / float64(stats.Expected)
+		}
+		r.Categories = append(r.Categories, *stats)
+	}
+	sort.Slice(r.Categories, func(i, j int) bool {
+		if r.Categories[i].Name != r.Categories[j].Name {
+			return r.Categories[i].Name < r.Categories[j].Name
+		}
+		return r.Categories[i].ID < r.Categories[j].ID
+	})
+}
+
+func summarizeLatency(durations []int64, elapsed time.Duration) Latency {
+	latency := Latency{TotalMs: elapsed.Milliseconds()}
+	if len(durations) == 0 {
+		return latency
+	}
+
+	sorted := append([]int64(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var sum int64
+	for _, d := range sorted {
+		sum += d
+	}
+	percentile := func(p float64) int64 {
+		idx := int(p*float64(len(sorted))+0.5) - 1
+		return sorted[max(0, min(idx, len(sorted)-1))]
+	}
+
+	latency.MeanMs = sum / int64(len(sorted))
+	latency.P50Ms = percentile(0.50)
+	latency.P95Ms = percentile(0.95)
+	latency.MaxMs = sorted[len(sorted)-1]
+	return latency
+}
+
+// EvalRun converts the report into the row stored in SQLite.
+func (r *Report) EvalRun() (*storage.EvalRun, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.EvalRun{
+		Dataset:          r.Dataset,
+		Backend:          r.Backend,
+		Model:            r.Model,
+		PromptHash:       r.PromptHash,
+		Label:            r.Label,
+		ItemCount:        r.Items,
+		CorrectCount:     r.Correct,
+		Accuracy:         r.Accuracy,
+		PromptTokens:     r.PromptTokens,
+		CompletionTokens: r.CompletionTokens,
+		CostUSD:          r.CostUSD,
+		DurationMs:       r.Latency.TotalMs,
+		ReportJSON:       string(data),
+	}, nil
+}
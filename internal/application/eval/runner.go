@@ -0,0 +1,145 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+)
+
+// Options configures an evaluation run.
+type Options struct {
+	Backend   string // Reported only, e.g. "openai"
+	Model     string
+	Label     string // Free-form note stored with the run, e.g. "new grocery rules"
+	BatchSize int    // Items per LLM call (default categorizer.DefaultBatchChunkSize)
+
+	// Prompt overrides the built-in categorization prompt (nil = default).
+	Prompt *categorizer.Prompt
+
+	// Prices in USD per million tokens, used to estimate cost. Zero leaves
+	// cost at zero; the backends' prices change too often to hardcode.
+	InputPricePerMillion  float64
+	OutputPricePerMillion float64
+}
+
+// Run sends every distinct item in the dataset through a fresh categorizer
+// (no cache) backed by client and scores the answers against the labels.
+//
+// A failed LLM call does not abort the run: the items in that batch are
+// scored as unanswered and counted in FailedBatches.
+func Run(ctx context.Context, client categorizer.ChatClient, ds *Dataset, opts Options) (*Report, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = categorizer.DefaultBatchChunkSize
+	}
+
+	meter := &meteredClient{client: client}
+	cat := categorizer.NewCategorizer(meter, categorizer.NewMemoryCache(), opts.Model)
+	cat.SetPrompt(opts.Prompt)
+
+	// Each distinct name is asked once; duplicates share the answer
+	var unique []categorizer.Item
+	seen := make(map[string]bool)
+	for _, example := range ds.Items {
+		key := normalizeName(example.Name)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, categorizer.Item{Name: example.Name, Price: example.Price})
+	}
+
+	predictions := make(map[string]categorizer.ItemCategorization, len(unique))
+	failedBatches := 0
+	started := time.Now()
+	for start := 0; start < len(unique); start += batchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		batch := unique[start:min(start+batchSize, len(unique))]
+
+		result, err := cat.CategorizeItems(ctx, batch, ds.Categories)
+		if err != nil {
+			failedBatches++
+			continue
+		}
+		for name, prediction := range matchPredictions(batch, result.Categorizations) {
+			predictions[name] = prediction
+		}
+	}
+	elapsed := time.Since(started)
+
+	report := newReport(ds, opts, cat.PromptHash())
+	for _, example := range ds.Items {
+		report.score(example, predictions[normalizeName(example.Name)])
+	}
+	report.FailedBatches = failedBatches
+	report.finish(meter.snapshot(), elapsed, opts)
+	return report, nil
+}
+
+// matchPredictions pairs answers with the items that were asked about. The
+// LLM is asked to echo item names, so answers are matched by name first and
+// by position for any item whose name came back altered.
+func matchPredictions(items []categorizer.Item, answers []categorizer.ItemCategorization) map[string]categorizer.ItemCategorization {
+	byName := make(map[string]categorizer.ItemCategorization, len(answers))
+	for _, answer := range answers {
+		byName[normalizeName(answer.ItemName)] = answer
+	}
+
+	matched := make(map[string]categorizer.ItemCategorization, len(items))
+	for i, item := range items {
+		key := normalizeName(item.Name)
+		if answer, ok := byName[key]; ok {
+			matched[key] = answer
+		} else if i < len(answers) {
+			matched[key] = answers[i]
+		}
+	}
+	return matched
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// meteredClient wraps a ChatClient to record per-call latency and token usage.
+type meteredClient struct {
+	client categorizer.ChatClient
+
+	mu    sync.Mutex
+	calls []callStat
+}
+
+type callStat struct {
+	duration         time.Duration
+	promptTokens     int
+	completionTokens int
+	failed           bool
+}
+
+func (m *meteredClient) CreateChatCompletion(ctx context.Context, request categorizer.ChatCompletionRequest) (*categorizer.ChatCompletionResponse, error) {
+	started := time.Now()
+	response, err := m.client.CreateChatCompletion(ctx, request)
+
+	stat := callStat{duration: time.Since(started), failed: err != nil}
+	if response != nil && response.Usage != nil {
+		stat.promptTokens = response.Usage.PromptTokens
+		stat.completionTokens = response.Usage.CompletionTokens
+	}
+
+	m.mu.Lock()
+	m.calls = append(m.calls, stat)
+	m.mu.Unlock()
+
+	return response, err
+}
+
+func (m *meteredClient) snapshot() []callStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]callStat(nil), m.calls...)
+}
@@ -0,0 +1,152 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promptItemLine = regexp.MustCompile(`(?m)^\d+\. (.+) - \$`)
+
+// fakeChatClient answers from a fixed item → category table and reports a
+// fixed token usage per call.
+type fakeChatClient struct {
+	answers map[string]string // item name -> category ID ("bogus" for an invalid ID)
+	failOn  string
+	calls   int
+}
+
+func (f *fakeChatClient) CreateChatCompletion(_ context.Context, request categorizer.ChatCompletionRequest) (*categorizer.ChatCompletionResponse, error) {
+	f.calls++
+	result := categorizer.CategorizationResult{}
+	for _, match := range promptItemLine.FindAllStringSubmatch(request.Messages[1].Content, -1) {
+		name := match[1]
+		if name == f.failOn {
+			return nil, errors.New("bad request")
+		}
+		result.Categorizations = append(result.Categorizations, categorizer.ItemCategorization{
+			ItemName:   name,
+			CategoryID: f.answers[name],
+			Confidence: 0.9,
+		})
+	}
+	content, _ := json.Marshal(result)
+	return &categorizer.ChatCompletionResponse{
+		Choices: []categorizer.Choice{{Message: categorizer.Message{Content: string(content)}}},
+		Usage:   &categorizer.Usage{PromptTokens: 1000, CompletionTokens: 100},
+	}, nil
+}
+
+func testDataset() *Dataset {
+	return &Dataset{
+		Name: "test",
+		Categories: []categorizer.Category{
+			{ID: "groceries", Name: "Groceries"},
+			{ID: "home", Name: "Home & Garden"},
+			{ID: "personal", Name: "Personal Care"},
+		},
+		Items: []Example{
+			{Name: "Milk", Price: 3.99, CategoryID: "groceries"},
+			{Name: "Bread", Price: 2.49, CategoryID: "groceries"},
+			{Name: "Paper Towels", Price: 12.99, CategoryID: "home"},
+			{Name: "Shampoo", Price: 6.49, CategoryID: "personal"},
+			{Name: "milk", Price: 3.99, CategoryID: "groceries"}, // duplicate name
+		},
+	}
+}
+
+func TestRun_ScoresAccuracyAndConfusion(t *testing.T) {
+	client := &fakeChatClient{answers: map[string]string{
+		"Milk":         "groceries",
+		"Bread":        "groceries",
+		"Paper Towels": "groceries", // wrong
+		"Shampoo":      "bogus",     // invalid ID → no answer
+	}}
+
+	report, err := Run(context.Background(), client, testDataset(), Options{
+		Backend:               "openai",
+		Model:                 "gpt-5.4-nano",
+		BatchSize:             2,
+		InputPricePerMillion:  0.10,
+		OutputPricePerMillion: 0.40,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, client.calls, "4 distinct items in batches of 2")
+	assert.Equal(t, 5, report.Items)
+	assert.Equal(t, 3, report.Correct)
+	assert.Equal(t, 1, report.Unanswered)
+	assert.InDelta(t, 0.6, report.Accuracy, 1e-9)
+	assert.NotEmpty(t, report.PromptHash)
+
+	assert.Equal(t, map[string]map[string]int{
+		"groceries": {"groceries": 3},
+		"home":      {"groceries": 1},
+		"personal":  {NoAnswer: 1},
+	}, report.Confusion)
+
+	require.Len(t, report.Categories, 3)
+	groceries := report.Categories[0]
+	assert.Equal(t, "Groceries", groceries.Name)
+	assert.Equal(t, 3, groceries.Expected)
+	assert.Equal(t, 4, groceries.Predicted)
+	assert.InDelta(t, 0.75, groceries.Precision, 1e-9)
+	assert.InDelta(t, 1.0, groceries.Recall, 1e-9)
+
+	assert.ElementsMatch(t, []Mistake{
+		{Item: "Paper Towels", Expected: "Home & Garden", Predicted: "Groceries", Confidence: 0.9},
+		{Item: "Shampoo", Expected: "Personal Care", Predicted: NoAnswer, Confidence: 0.9},
+	}, report.Mistakes)
+
+	assert.Equal(t, 2, report.Calls)
+	assert.Equal(t, 2000, report.PromptTokens)
+	assert.Equal(t, 200, report.CompletionTokens)
+	assert.InDelta(t, 0.00028, report.CostUSD, 1e-12)
+}
+
+func TestRun_FailedBatchCountsAsUnanswered(t *testing.T) {
+	client := &fakeChatClient{
+		answers: map[string]string{"Milk": "groceries", "Bread": "groceries", "Paper Towels": "home", "Shampoo": "personal"},
+		failOn:  "Paper Towels",
+	}
+
+	report, err := Run(context.Background(), client, testDataset(), Options{BatchSize: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.FailedBatches)
+	assert.Equal(t, 3, report.Correct)
+	assert.Equal(t, 2, report.Unanswered)
+	assert.Equal(t, 0.0, report.CostUSD, "no prices configured")
+}
+
+func TestReport_EvalRun(t *testing.T) {
+	client := &fakeChatClient{answers: map[string]string{"Milk": "groceries", "Bread": "groceries", "Paper Towels": "home", "Shampoo": "personal"}}
+	report, err := Run(context.Background(), client, testDataset(), Options{Backend: "anthropic", Model: "claude-haiku-4-5-20251001", Label: "baseline"})
+	require.NoError(t, err)
+
+	run, err := report.EvalRun()
+	require.NoError(t, err)
+	assert.Equal(t, "test", run.Dataset)
+	assert.Equal(t, "anthropic", run.Backend)
+	assert.Equal(t, "baseline", run.Label)
+	assert.Equal(t, 5, run.CorrectCount)
+	assert.Equal(t, 1.0, run.Accuracy)
+
+	var stored Report
+	require.NoError(t, json.Unmarshal([]byte(run.ReportJSON), &stored))
+	assert.Equal(t, report.Confusion, stored.Confusion)
+}
+
+func TestSummarizeLatency(t *testing.T) {
+	latency := summarizeLatency([]int64{100, 300, 200, 400, 1000}, 0)
+	assert.Equal(t, int64(400), latency.MeanMs)
+	assert.Equal(t, int64(300), latency.P50Ms)
+	assert.Equal(t, int64(1000), latency.P95Ms)
+	assert.Equal(t, int64(1000), latency.MaxMs)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/eshaffer321/itemize/internal/adapters/clients"
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	appsync "github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/infrastructure/config"
 	"github.com/eshaffer321/itemize/internal/infrastructure/logging"
 	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
@@ -43,6 +44,7 @@ type SyncRequest struct {
 	Provider     string // "walmart", "costco", "amazon"
 	DryRun       bool
 	LookbackDays int
+	SinceLast    bool // Look back to the provider's sync watermark
 	MaxOrders    int
 	Force        bool
 	Verbose      bool
@@ -51,12 +53,14 @@ type SyncRequest struct {
 
 // SyncProgress holds real-time progress information.
 type SyncProgress struct {
-	CurrentPhase    string    // "pending", "initializing", "fetching_orders", "processing_orders", "completed", "failed"
-	TotalOrders     int
-	ProcessedOrders int
-	SkippedOrders   int
-	ErroredOrders   int
-	LastUpdate      time.Time
+	CurrentPhase     string // "pending", "initializing", "fetching_orders", "categorizing_items", "processing_orders", "completed", "failed"
+	TotalOrders      int
+	ProcessedOrders  int
+	SkippedOrders    int
+	ErroredOrders    int
+	TotalItems       int // Unique items in the batch categorization phase
+	CategorizedItems int
+	LastUpdate       time.Time
 }
 
 // SyncJob represents a running or completed sync job.
@@ -274,10 +278,22 @@ func (s *SyncService) runSyncJob(ctx context.Context, job *SyncJob) {
 	opts := appsync.Options{
 		DryRun:       job.Request.DryRun,
 		LookbackDays: job.Request.LookbackDays,
+		SinceLast:    job.Request.SinceLast,
 		MaxOrders:    job.Request.MaxOrders,
 		Force:        job.Request.Force,
 		Verbose:      job.Request.Verbose,
 		OrderID:      job.Request.OrderID,
+
+		MinConfidence:    s.cfg.Categorizer.MinConfidence,
+		FallbackCategory: s.cfg.Categorizer.FallbackCategory,
+		CategoryGroups: categorizer.GroupFilter{
+			Allow: s.cfg.Categorizer.Groups.Allow,
+			Deny:  s.cfg.Categorizer.Groups.Deny,
+		},
+		MultiChargeMode: s.cfg.Providers.MultiChargeMode(job.Request.Provider),
+		MerchantAliases: s.cfg.Providers.MerchantFilter(job.Request.Provider).MerchantAliases,
+		MonarchAccounts: s.cfg.Providers.MerchantFilter(job.Request.Provider).MonarchAccounts,
+		CardAccounts:    s.cfg.Monarch.CardAccounts,
 		ProgressCallback: func(update appsync.ProgressUpdate) {
 			s.updateJobProgress(job.ID, update)
 		},
@@ -321,6 +337,8 @@ func (s *SyncService) updateJobProgress(jobID string, update appsync.ProgressUpd
 		job.Progress.ProcessedOrders = update.ProcessedOrders
 		job.Progress.SkippedOrders = update.SkippedOrders
 		job.Progress.ErroredOrders = update.ErroredOrders
+		job.Progress.TotalItems = update.TotalItems
+		job.Progress.CategorizedItems = update.CategorizedItems
 		job.Progress.LastUpdate = time.Now()
 	}
 }
@@ -178,3 +178,101 @@ func TestProcessAmazonReturnsHonorsFiltersAndNilGuards(t *testing.T) {
 	assert.Zero(t, result.RefundSkippedCount)
 	assert.False(t, returnFallsWithinLookback(amazonprovider.ReturnRecord{}, 14, now))
 }
+
+func TestProcessAmazonReturnsSplitsRefundByPurchaseCategories(t *testing.T) {
+	now := time.Date(2026, time.July, 17, 15, 30, 0, 0, time.UTC)
+	issuedAt := time.Date(2026, time.July, 3, 0, 0, 0, 0, time.UTC)
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID:  "112-3333333-4444444",
+		Provider: "Amazon",
+		Status:   "success",
+		Items: []storage.OrderItem{
+			{Name: "Kids Cup", SKU: "B0CUP", TotalPrice: 10},
+			{Name: "USB-C Cable, 6ft", SKU: "B0USB", TotalPrice: 15},
+			{Name: "Paper Towels", SKU: "B0TOWEL", TotalPrice: 20},
+		},
+		Splits: []storage.SplitDetail{
+			{CategoryID: "kid-needs", Amount: -10.80, Notes: "Kid Needs:\n- Kids Cup $10.00"},
+			{CategoryID: "electronics", Amount: -16.20, Notes: "Electronics:\n- USB-C Cable, 6ft $15.00"},
+			{CategoryID: "household", Amount: -21.60, Notes: "Household:\n- Paper Towels $20.00"},
+		},
+	}))
+	records := []amazonprovider.ReturnRecord{{
+		OrderID:        "112-3333333-4444444",
+		RMAID:          "DpurchaseRRMA",
+		RefundAmount:   27.00,
+		HasRefundTotal: true,
+		RefundIssuedAt: &issuedAt,
+		Items: []amazonprovider.ReturnedItem{
+			{ASIN: "B0CUP", Name: "Kids Cup"},
+			{ASIN: "B0USB", Name: "USB-C Cable 6 ft", Price: 15},
+		},
+	}}
+	temporary := &monarch.TransactionCategory{ID: "temp-amazon", Name: "[TEMP] Amazon"}
+	transactions := []*monarch.Transaction{{ID: "refund-credit", Amount: 27.00, Date: toMonarchDate(issuedAt), Category: temporary}}
+	fallback := &countingCategorizer{mockCategorizer: mockCategorizer{categoryID: "household", categoryName: "Household"}}
+	handler := handlers.NewAmazonHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}), nil,
+		&mockSplitterAdapter{splitter: splitter.NewSplitter(fallback)}, &processOrderTestMonarch{}, slog.Default(),
+	)
+	handler.SetPurchaseHistory(&purchaseHistory{repo: repo})
+	orchestrator := &Orchestrator{amazonHandler: handler, storage: repo, logger: slog.Default()}
+	categories := []categorizer.Category{{ID: "kid-needs", Name: "Kid Needs"}, {ID: "electronics", Name: "Electronics"}, {ID: "household", Name: "Household"}}
+	result := &Result{}
+
+	orchestrator.processAmazonReturns(context.Background(), records, transactions, map[string]bool{}, categories, nil, Options{LookbackDays: 14}, now, result)
+
+	require.Equal(t, 1, result.RefundProcessedCount)
+	assert.Empty(t, fallback.items, "returned items should not be re-categorized")
+	record, err := repo.GetRecord("amazon-refund:DpurchaseRRMA")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	amounts := make(map[string]float64)
+	for _, split := range record.Splits {
+		amounts[split.CategoryID] = split.Amount
+	}
+	assert.Equal(t, map[string]float64{"kid-needs": 10.80, "electronics": 16.20}, amounts)
+}
+
+func TestProcessAmazonReturnsCategorizesRefundWithoutProcessedPurchase(t *testing.T) {
+	now := time.Date(2026, time.July, 17, 15, 30, 0, 0, time.UTC)
+	issuedAt := time.Date(2026, time.July, 3, 0, 0, 0, 0, time.UTC)
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID:    "112-5555555-6666666",
+		Provider:   "Amazon",
+		Status:     "dry-run",
+		DryRun:     true,
+		CategoryID: "electronics",
+		Items:      []storage.OrderItem{{Name: "Kids Cup", SKU: "B0CUP", TotalPrice: 10}},
+	}))
+	records := []amazonprovider.ReturnRecord{{
+		OrderID:        "112-5555555-6666666",
+		RMAID:          "DfallbackRRMA",
+		RefundAmount:   10.80,
+		HasRefundTotal: true,
+		RefundIssuedAt: &issuedAt,
+		Items:          []amazonprovider.ReturnedItem{{ASIN: "B0CUP", Name: "Kids Cup", Price: 10}},
+	}}
+	temporary := &monarch.TransactionCategory{ID: "temp-amazon", Name: "[TEMP] Amazon"}
+	transactions := []*monarch.Transaction{{ID: "refund-credit", Amount: 10.80, Date: toMonarchDate(issuedAt), Category: temporary}}
+	fallback := &countingCategorizer{mockCategorizer: mockCategorizer{categoryID: "kid-needs", categoryName: "Kid Needs"}}
+	handler := handlers.NewAmazonHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}), nil,
+		&mockSplitterAdapter{splitter: splitter.NewSplitter(fallback)}, &processOrderTestMonarch{}, slog.Default(),
+	)
+	handler.SetPurchaseHistory(&purchaseHistory{repo: repo})
+	orchestrator := &Orchestrator{amazonHandler: handler, storage: repo, logger: slog.Default()}
+	categories := []categorizer.Category{{ID: "kid-needs", Name: "Kid Needs"}, {ID: "electronics", Name: "Electronics"}}
+	result := &Result{}
+
+	orchestrator.processAmazonReturns(context.Background(), records, transactions, map[string]bool{}, categories, nil, Options{LookbackDays: 14}, now, result)
+
+	require.Equal(t, 1, result.RefundProcessedCount)
+	assert.Equal(t, []string{"Kids Cup"}, fallback.items)
+	record, err := repo.GetRecord("amazon-refund:DfallbackRRMA")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "kid-needs", record.CategoryID)
+}
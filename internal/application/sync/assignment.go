@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// assignTransactions assigns the run's Monarch transactions to the charges
+// of every order that will be processed, before any handler runs, so the
+// order in which orders were fetched no longer decides which of two
+// same-amount orders gets which charge. Handlers receive their assigned
+// transactions through the shared matcher.
+func (o *Orchestrator) assignTransactions(
+	orders []providers.Order,
+	transactions []*monarch.Transaction,
+	usedTransactionIDs map[string]bool,
+	opts Options,
+) *matcher.Assignment {
+	var charges []matcher.OrderCharges
+	for _, order := range orders {
+		if opts.OrderID != "" && order.GetID() != opts.OrderID {
+			continue
+		}
+		if !opts.Force && o.storage != nil && o.storage.IsProcessed(order.GetID()) {
+			continue
+		}
+		if amounts := expectedCharges(order); len(amounts) > 0 {
+			charges = append(charges, matcher.OrderCharges{Order: order, Charges: amounts})
+		}
+	}
+
+	assignment := o.matcher.Assign(charges, transactions, usedTransactionIDs)
+	o.matcher.SetAssignment(assignment)
+
+	contested := 0
+	for _, order := range charges {
+		if assignment.Diagnostics(order.Order.GetID()) != nil {
+			contested++
+		}
+	}
+	o.logger.Debug("Assigned transactions to orders",
+		"orders", len(charges),
+		"contested_orders", contested)
+	return assignment
+}
+
+// expectedCharges returns the bank charges an order is expected to produce,
+// the same amounts its handler matches: the provider's final charges when it
+// reports them, otherwise the order total. Orders whose payment is pending
+// or not made by card expect none.
+func expectedCharges(order providers.Order) []float64 {
+	if amazonOrder, ok := handlers.AsAmazonOrder(order); ok {
+		charges, err := amazonOrder.GetFinalCharges()
+		if err != nil {
+			return nil
+		}
+		return charges
+	}
+	if walmartOrder, ok := handlers.AsWalmartOrder(order); ok {
+		charges, err := walmartOrder.GetFinalCharges()
+		switch {
+		case err == nil:
+			return charges
+		case errors.Is(err, handlers.ErrWalmartPaymentPending),
+			errors.Is(err, handlers.ErrWalmartNoBankCharges):
+			return nil
+		}
+	}
+	if order.GetTotal() == 0 {
+		return nil
+	}
+	return []float64{order.GetTotal()}
+}
+
+// withAssignmentDiagnostics adds the candidates an order lost in the
+// assignment to the handler's match diagnostics.
+func (o *Orchestrator) withAssignmentDiagnostics(orderID, diagnosticsJSON string) string {
+	assignment := o.assignment.Diagnostics(orderID)
+	if assignment == nil {
+		return diagnosticsJSON
+	}
+
+	diagnostics := map[string]any{}
+	if diagnosticsJSON != "" {
+		if err := json.Unmarshal([]byte(diagnosticsJSON), &diagnostics); err != nil {
+			return diagnosticsJSON
+		}
+	}
+	diagnostics["assignment"] = assignment
+
+	data, err := json.Marshal(diagnostics)
+	if err != nil {
+		return diagnosticsJSON
+	}
+	return string(data)
+}
@@ -0,0 +1,109 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrchestrator_SameAmountOrdersGetTheirAssignedCharges(t *testing.T) {
+	orch := createTestOrchestrator(t)
+	store := storage.NewMockRepository()
+	orch.storage = store
+
+	// Fetched first, the earlier order would take the Oct 11 charge and leave
+	// the later one nothing within five days.
+	orderDate := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	earlier := &mockSimpleOrder{
+		id: "EARLIER", date: orderDate, total: 25.00, subtotal: 25.00, providerName: "Costco",
+		items: []providers.OrderItem{&mockOrderItem{name: "Milk", price: 25.00, quantity: 1}},
+	}
+	later := &mockSimpleOrder{
+		id: "LATER", date: orderDate.AddDate(0, 0, 5), total: 25.00, subtotal: 25.00, providerName: "Costco",
+		items: []providers.OrderItem{&mockOrderItem{name: "Bread", price: 25.00, quantity: 1}},
+	}
+	transactions := []*monarch.Transaction{
+		{ID: "txn-oct11", Amount: -25.00, Date: toMonarchDate(orderDate.AddDate(0, 0, 1))},
+		{ID: "txn-oct7", Amount: -25.00, Date: toMonarchDate(orderDate.AddDate(0, 0, -3))},
+	}
+	orders := []providers.Order{earlier, later}
+	catCategories := []categorizer.Category{{ID: "cat-1", Name: "Groceries"}}
+	monarchCategories := []*monarch.TransactionCategory{{ID: "cat-1", Name: "Groceries"}}
+	opts := Options{}
+
+	used := make(map[string]bool)
+	orch.assignment = orch.assignTransactions(orders, transactions, used, opts)
+	for _, order := range orders {
+		processed, _, err := orch.processOrder(context.Background(), order, transactions, used, catCategories, monarchCategories, opts)
+		require.NoError(t, err)
+		assert.True(t, processed, order.GetID())
+	}
+
+	earlierRecord, err := store.GetRecord("EARLIER")
+	require.NoError(t, err)
+	require.NotNil(t, earlierRecord)
+	assert.Equal(t, "txn-oct7", earlierRecord.TransactionID)
+
+	laterRecord, err := store.GetRecord("LATER")
+	require.NoError(t, err)
+	require.NotNil(t, laterRecord)
+	assert.Equal(t, "txn-oct11", laterRecord.TransactionID)
+
+	var diagnostics struct {
+		Assignment struct {
+			Charges []struct {
+				TransactionID string `json:"transaction_id"`
+				Losing        []struct {
+					TransactionID string `json:"transaction_id"`
+					Reason        string `json:"reason"`
+					WonBy         string `json:"won_by"`
+				} `json:"losing_candidates"`
+			} `json:"charges"`
+		} `json:"assignment"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(earlierRecord.MatchDiagnosticsJSON), &diagnostics))
+	require.Len(t, diagnostics.Assignment.Charges, 1)
+	assert.Equal(t, "txn-oct7", diagnostics.Assignment.Charges[0].TransactionID)
+	require.Len(t, diagnostics.Assignment.Charges[0].Losing, 1)
+	assert.Equal(t, "txn-oct11", diagnostics.Assignment.Charges[0].Losing[0].TransactionID)
+	assert.Equal(t, "assigned_to_other_order", diagnostics.Assignment.Charges[0].Losing[0].Reason)
+	assert.Equal(t, "LATER", diagnostics.Assignment.Charges[0].Losing[0].WonBy)
+
+	assert.Empty(t, laterRecord.MatchDiagnosticsJSON, "the later order had no competing candidate")
+}
+
+func TestOrchestrator_AssignmentKeepsHandlerDiagnostics(t *testing.T) {
+	orch := createTestOrchestrator(t)
+	orderDate := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	order := &mockSimpleOrder{id: "ORDER", date: orderDate.AddDate(0, 0, 4), total: 25.00, providerName: "Costco"}
+	other := &mockSimpleOrder{id: "OTHER", date: orderDate, total: 25.00, providerName: "Costco"}
+	transactions := []*monarch.Transaction{
+		{ID: "txn-1", Amount: -25.00, Date: toMonarchDate(orderDate.AddDate(0, 0, 1))},
+	}
+	orch.assignment = orch.assignTransactions([]providers.Order{order, other}, transactions, map[string]bool{}, Options{})
+
+	// The order that lost its only candidate keeps the handler's no-match
+	// diagnostics and learns which order took the charge
+	merged := orch.withAssignmentDiagnostics("ORDER", `{"order_id":"ORDER","candidate_count":1}`)
+
+	var diagnostics map[string]any
+	require.NoError(t, json.Unmarshal([]byte(merged), &diagnostics))
+	assert.Equal(t, "ORDER", diagnostics["order_id"])
+	assert.Contains(t, diagnostics, "assignment")
+	assert.Contains(t, merged, `"won_by":"OTHER"`)
+	assert.Equal(t, `{"x":1}`, orch.withAssignmentDiagnostics("UNKNOWN", `{"x":1}`))
+}
+
+func TestExpectedCharges(t *testing.T) {
+	assert.Equal(t, []float64{25.00}, expectedCharges(&mockSimpleOrder{total: 25.00}))
+	assert.Equal(t, []float64{-12.50}, expectedCharges(&mockSimpleOrder{total: -12.50}), "returns match refunds")
+	assert.Nil(t, expectedCharges(&mockSimpleOrder{}))
+}
@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"log/slog"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// learnedCardAccountMinMatches is how many distinct matched orders must pair
+// a card with a Monarch account before the pairing is trusted to prefer that
+// account's transactions.
+const learnedCardAccountMinMatches = 3
+
+// setCardAccounts gives the matcher the configured and learned card accounts
+// for a sync.
+func (o *Orchestrator) setCardAccounts(opts Options) {
+	setCardAccounts(o.matcher, o.storage, opts.CardAccounts, o.logger)
+}
+
+func setCardAccounts(m *matcher.Matcher, repo storage.CardAccountRepository, configured map[string][]string, logger *slog.Logger) {
+	configuredAccounts, learned := loadCardAccounts(repo, configured, logger)
+	m.SetCardAccounts(configuredAccounts)
+	m.SetLearnedCardAccounts(learned)
+}
+
+// loadCardAccounts returns the configured card-to-account mapping, which
+// filters candidates, and the pairings learned from past matches, which only
+// score them. A configured card replaces whatever was learned for it.
+func loadCardAccounts(repo storage.CardAccountRepository, configured map[string][]string, logger *slog.Logger) (configuredAccounts, learned matcher.CardAccounts) {
+	for card, names := range configured {
+		if len(names) == 0 {
+			continue
+		}
+		if configuredAccounts == nil {
+			configuredAccounts = make(matcher.CardAccounts)
+		}
+		configuredAccounts[card] = names
+	}
+
+	if repo != nil {
+		pairings, err := repo.ListCardAccounts()
+		if err != nil {
+			logger.Warn("Failed to load learned card accounts", "error", err)
+		}
+		for _, pairing := range pairings {
+			if pairing.MatchCount < learnedCardAccountMinMatches {
+				continue
+			}
+			if _, ok := configuredAccounts[pairing.CardLastFour]; ok {
+				continue
+			}
+			if learned == nil {
+				learned = make(matcher.CardAccounts)
+			}
+			learned[pairing.CardLastFour] = append(learned[pairing.CardLastFour], pairing.MonarchAccountID)
+		}
+	}
+
+	if len(configuredAccounts) > 0 || len(learned) > 0 {
+		logger.Debug("Loaded card accounts", "configured", len(configuredAccounts), "learned", len(learned))
+	}
+	return configuredAccounts, learned
+}
+
+// learnCardAccount records the Monarch accounts an order's charges posted
+// to. Only orders paid with a single card are learned from, so every charge
+// belongs to that card; each order counts once however often it is processed.
+func (o *Orchestrator) learnCardAccount(order providers.Order, result *handlers.ProcessResult, dryRun bool) {
+	if o.storage == nil || dryRun || result == nil || !result.Processed {
+		return
+	}
+	cards := providers.OrderPaymentCards(order)
+	if len(cards) != 1 || cards[0].LastFour == "" {
+		return
+	}
+
+	transactions := []*monarch.Transaction{result.Transaction}
+	for _, charge := range result.Charges {
+		transactions = append(transactions, charge.Transaction)
+	}
+	learned := make(map[string]bool)
+	for _, tx := range transactions {
+		if tx == nil || tx.Account == nil || tx.Account.ID == "" || learned[tx.Account.ID] {
+			continue
+		}
+		learned[tx.Account.ID] = true
+		if err := o.storage.RecordCardAccount(&storage.CardAccount{
+			CardLastFour:       cards[0].LastFour,
+			MonarchAccountID:   tx.Account.ID,
+			MonarchAccountName: tx.Account.DisplayName,
+			CardType:           cards[0].Type,
+		}, order.GetID()); err != nil {
+			o.logger.Warn("Failed to record card account",
+				"order_id", order.GetID(),
+				"card", cards[0].LastFour,
+				"error", err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCardOrder is a simple order paid with the given cards
+type mockCardOrder struct {
+	mockSimpleOrder
+	cards []providers.PaymentCard
+}
+
+func (m *mockCardOrder) GetPaymentCards() []providers.PaymentCard { return m.cards }
+
+func TestOrchestrator_CardAccountsFilterOtherCards(t *testing.T) {
+	orch := createTestOrchestrator(t)
+	store := storage.NewMockRepository()
+	orch.storage = store
+
+	orderDate := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	order := &mockCardOrder{
+		mockSimpleOrder: mockSimpleOrder{
+			id: "ORDER", date: orderDate, total: 42.17, subtotal: 42.17, providerName: "Costco",
+			items: []providers.OrderItem{&mockOrderItem{name: "Milk", price: 42.17, quantity: 1}},
+		},
+		cards: []providers.PaymentCard{{Type: "VISA", LastFour: "1211"}},
+	}
+	transactions := []*monarch.Transaction{
+		{ID: "txn-amex", Amount: -42.17, Date: toMonarchDate(orderDate), Account: &monarch.Account{ID: "acc-amex", DisplayName: "Amex Gold"}},
+		{ID: "txn-prime", Amount: -42.17, Date: toMonarchDate(orderDate.AddDate(0, 0, 2)), Account: &monarch.Account{ID: "acc-prime", DisplayName: "Chase Prime Visa"}},
+	}
+	opts := Options{CardAccounts: map[string][]string{"1211": {"Chase Prime Visa"}}}
+
+	orch.setCardAccounts(opts)
+	defer orch.matcher.SetCardAccounts(nil)
+	used := make(map[string]bool)
+	orch.assignment = orch.assignTransactions([]providers.Order{order}, transactions, used, opts)
+	processed, _, err := orch.processOrder(context.Background(), order, transactions, used,
+		[]categorizer.Category{{ID: "cat-1", Name: "Groceries"}},
+		[]*monarch.TransactionCategory{{ID: "cat-1", Name: "Groceries"}}, opts)
+	require.NoError(t, err)
+	require.True(t, processed)
+
+	record, err := store.GetRecord("ORDER")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "txn-prime", record.TransactionID)
+	assert.Contains(t, record.MatchDiagnosticsJSON, `"reason":"card_mismatch"`)
+	assert.Contains(t, record.MatchDiagnosticsJSON, `"account":"Amex Gold"`)
+
+	learned, err := store.ListCardAccounts()
+	require.NoError(t, err)
+	require.Len(t, learned, 1)
+	assert.Equal(t, storage.CardAccount{
+		CardLastFour: "1211", MonarchAccountID: "acc-prime", MonarchAccountName: "Chase Prime Visa",
+		CardType: "VISA", MatchCount: 1,
+	}, learned[0])
+}
+
+func TestOrchestrator_LoadCardAccounts(t *testing.T) {
+	orch := createTestOrchestrator(t)
+	store := storage.NewMockRepository()
+	orch.storage = store
+
+	paidWith := func(id string) *mockCardOrder {
+		return &mockCardOrder{mockSimpleOrder: mockSimpleOrder{id: id}, cards: []providers.PaymentCard{{LastFour: "1211"}}}
+	}
+	result := &handlers.ProcessResult{
+		Processed:   true,
+		Transaction: &monarch.Transaction{ID: "txn", Account: &monarch.Account{ID: "acc-prime"}},
+	}
+
+	// Re-processing one order (e.g. with -force) counts it once
+	for i := 0; i < learnedCardAccountMinMatches; i++ {
+		orch.learnCardAccount(paidWith("ORDER-1"), result, false)
+	}
+	for i := 2; i < learnedCardAccountMinMatches; i++ {
+		orch.learnCardAccount(paidWith(fmt.Sprintf("ORDER-%d", i)), result, false)
+	}
+	orch.learnCardAccount(paidWith("ORDER-DRY"), result, true)
+	configured, learned := loadCardAccounts(store, nil, orch.logger)
+	assert.Nil(t, configured)
+	assert.Nil(t, learned, "too few orders to trust")
+
+	orch.learnCardAccount(paidWith("ORDER-LAST"), result, false)
+	configured, learned = loadCardAccounts(store, nil, orch.logger)
+	assert.Nil(t, configured, "learned pairings never filter candidates")
+	assert.Equal(t, matcher.CardAccounts{"1211": {"acc-prime"}}, learned)
+
+	configured, learned = loadCardAccounts(store, map[string][]string{"1211": {"Chase Prime Visa"}, "3005": {"Amex Gold"}}, orch.logger)
+	assert.Equal(t, matcher.CardAccounts{
+		"1211": {"Chase Prime Visa"},
+		"3005": {"Amex Gold"},
+	}, configured)
+	assert.Nil(t, learned, "configured cards replace learned ones")
+
+	// Orders paid with several cards don't say which card a charge was on
+	twoCards := &mockCardOrder{mockSimpleOrder: mockSimpleOrder{id: "ORDER-TWO"}, cards: []providers.PaymentCard{{LastFour: "1211"}, {LastFour: "3005"}}}
+	orch.learnCardAccount(twoCards, result, false)
+	pairings, err := store.ListCardAccounts()
+	require.NoError(t, err)
+	require.Len(t, pairings, 1)
+	assert.Equal(t, learnedCardAccountMinMatches, pairings[0].MatchCount)
+}
@@ -0,0 +1,391 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// Outcomes of reconciling an unmatched ledger charge
+const (
+	ChargeMatched     = "matched"      // matched to a Monarch transaction
+	ChargePending     = "pending"      // not in Monarch yet; may still post
+	ChargeNeverPosted = "never_posted" // not in Monarch long after it was charged
+)
+
+const (
+	defaultChargeStaleAfter       = 3 * 24 * time.Hour
+	defaultChargeNeverPostedAfter = 14 * 24 * time.Hour
+
+	// chargeSearchMaxAge bounds how far back Monarch is searched: charges
+	// older than this are no longer looked for there and stay never posted,
+	// so one forgotten charge can't make every run list years of
+	// transactions.
+	chargeSearchMaxAge = 60 * 24 * time.Hour
+)
+
+// TransactionSource lists the Monarch transactions dated between start and end.
+type TransactionSource func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error)
+
+// MonarchTransactions returns a TransactionSource that pages through every
+// Monarch transaction in the range.
+func MonarchTransactions(client *monarch.Client) TransactionSource {
+	pages := MonarchTransactionPages(client)
+	return func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error) {
+		pager := newTransactionPager(pages)
+		pager.maxPages = 0
+		transactions, _, err := pager.fetch(ctx, start, end, "")
+		return transactions, err
+	}
+}
+
+// ChargeReconcileOptions configures a charge reconciliation run.
+type ChargeReconcileOptions struct {
+	Provider         string        // Only reconcile this provider's charges ("" for all)
+	StaleAfter       time.Duration // Skip charges younger than this (default 3 days)
+	NeverPostedAfter time.Duration // Report charges still unmatched after this as never posted (default 14 days)
+	Limit            int           // Max charges to check (0 = storage default)
+	DryRun           bool          // Report matches without recording them
+
+	// MerchantSearchTerms are the Monarch merchant names each provider's
+	// charges post under, for providers with more than one (the provider
+	// name otherwise)
+	MerchantSearchTerms map[string][]string
+	// MerchantAliases are extra Monarch merchant names per provider, as in
+	// Options.MerchantAliases
+	MerchantAliases map[string][]string
+	// CardAccounts maps card last four digits to Monarch accounts, as in
+	// Options.CardAccounts
+	CardAccounts map[string][]string
+}
+
+// ChargeOutcome is what became of one unmatched charge.
+type ChargeOutcome struct {
+	Charge        storage.LedgerCharge
+	ChargedAt     time.Time // The ledger's fetch time when the provider reported none
+	Status        string
+	TransactionID string
+}
+
+// ChargeReconcileReport summarizes a charge reconciliation run.
+type ChargeReconcileReport struct {
+	Outcomes []ChargeOutcome
+}
+
+// Count returns how many charges ended with the given status.
+func (r *ChargeReconcileReport) Count(status string) int {
+	count := 0
+	for _, outcome := range r.Outcomes {
+		if outcome.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// ChargeReconciler retries matching ledger charges that no sync could tie to
+// a Monarch transaction, e.g. because the bank posted them after the order
+// was processed.
+type ChargeReconciler struct {
+	repo         storage.Repository
+	transactions TransactionSource
+	matcher      *matcher.Matcher
+	logger       *slog.Logger
+	now          func() time.Time
+}
+
+// NewChargeReconciler creates a reconciler that looks for charges in the
+// transactions listed by source.
+func NewChargeReconciler(repo storage.Repository, source TransactionSource, logger *slog.Logger) *ChargeReconciler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &ChargeReconciler{
+		repo:         repo,
+		transactions: source,
+		matcher:      matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+// Run checks the unmatched charges older than opts.StaleAfter. A charge is
+// first matched against the transactions its order was already matched to,
+// which covers charges that were consolidated into one transaction, and then
+// against fresh Monarch transactions not claimed by another order.
+func (r *ChargeReconciler) Run(ctx context.Context, opts ChargeReconcileOptions) (*ChargeReconcileReport, error) {
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = defaultChargeStaleAfter
+	}
+	if opts.NeverPostedAfter <= 0 {
+		opts.NeverPostedAfter = defaultChargeNeverPostedAfter
+	}
+	now := r.now()
+
+	charges, err := r.repo.GetUnmatchedCharges(opts.Provider, opts.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unmatched charges: %w", err)
+	}
+
+	report := &ChargeReconcileReport{}
+	ledgers := make(map[int64]*storage.OrderLedger)
+	for _, charge := range charges {
+		chargedAt := charge.ChargedAt
+		if chargedAt.IsZero() {
+			chargedAt = r.ledgerFetchedAt(charge.OrderLedgerID, ledgers)
+		}
+		if now.Sub(chargedAt) < opts.StaleAfter {
+			continue
+		}
+		report.Outcomes = append(report.Outcomes, ChargeOutcome{Charge: charge, ChargedAt: chargedAt, Status: ChargePending})
+	}
+	if len(report.Outcomes) == 0 {
+		return report, nil
+	}
+
+	used := make(map[string]bool)
+	if err := r.matchRecorded(report.Outcomes, used); err != nil {
+		return nil, err
+	}
+	if err := r.matchMonarch(ctx, report.Outcomes, used, opts, now); err != nil {
+		return nil, err
+	}
+
+	for i := range report.Outcomes {
+		outcome := &report.Outcomes[i]
+		switch {
+		case outcome.Status == ChargeMatched:
+			r.logger.Info("Matched ledger charge",
+				"order_id", outcome.Charge.OrderID,
+				"amount", outcome.Charge.ChargeAmount,
+				"transaction_id", outcome.TransactionID)
+			if opts.DryRun {
+				continue
+			}
+			if err := r.repo.UpdateChargeMatch(outcome.Charge.ID, outcome.TransactionID, 1.0, 0); err != nil {
+				return nil, fmt.Errorf("record match for charge %d: %w", outcome.Charge.ID, err)
+			}
+		case now.Sub(outcome.ChargedAt) >= opts.NeverPostedAfter:
+			outcome.Status = ChargeNeverPosted
+			r.logger.Warn("Ledger charge never posted to Monarch",
+				"order_id", outcome.Charge.OrderID,
+				"amount", outcome.Charge.ChargeAmount,
+				"charged_at", outcome.ChargedAt.Format("2006-01-02"))
+		}
+	}
+	return report, nil
+}
+
+// ledgerFetchedAt returns when a ledger was fetched, standing in for the
+// charge date of providers that don't report one.
+func (r *ChargeReconciler) ledgerFetchedAt(id int64, cache map[int64]*storage.OrderLedger) time.Time {
+	ledger, ok := cache[id]
+	if !ok {
+		var err error
+		if ledger, err = r.repo.GetLedgerByID(id); err != nil {
+			r.logger.Warn("Failed to load ledger", "ledger_id", id, "error", err)
+		}
+		cache[id] = ledger
+	}
+	if ledger == nil {
+		return time.Time{}
+	}
+	return ledger.FetchedAt
+}
+
+// matchRecorded matches charges to the transactions their order was already
+// matched to. Charges left over that add up to the order's transaction were
+// consolidated into it.
+func (r *ChargeReconciler) matchRecorded(outcomes []ChargeOutcome, used map[string]bool) error {
+	byOrder := make(map[string][]*ChargeOutcome)
+	var orderIDs []string
+	for i := range outcomes {
+		orderID := outcomes[i].Charge.OrderID
+		if _, ok := byOrder[orderID]; !ok {
+			orderIDs = append(orderIDs, orderID)
+		}
+		byOrder[orderID] = append(byOrder[orderID], &outcomes[i])
+	}
+
+	for _, orderID := range orderIDs {
+		record, err := r.repo.GetRecord(orderID)
+		if err != nil {
+			return fmt.Errorf("get record for order %s: %w", orderID, err)
+		}
+		if record == nil || record.DryRun || (record.Status != "success" && record.Status != provisionalStatus) {
+			continue
+		}
+		candidates, err := r.repo.GetOrderTransactions(orderID)
+		if err != nil {
+			return fmt.Errorf("get transactions for order %s: %w", orderID, err)
+		}
+		if record.TransactionID != "" {
+			candidates = append(candidates, storage.OrderTransaction{TransactionID: record.TransactionID, Amount: record.TransactionAmount})
+		}
+
+		var leftover []*ChargeOutcome
+		leftoverTotal := 0.0
+		for _, outcome := range byOrder[orderID] {
+			for _, candidate := range candidates {
+				if candidate.Role == "refund" || used[candidate.TransactionID] ||
+					math.Abs(math.Abs(candidate.Amount)-outcome.Charge.ChargeAmount) > 0.01 {
+					continue
+				}
+				used[candidate.TransactionID] = true
+				outcome.Status = ChargeMatched
+				outcome.TransactionID = candidate.TransactionID
+				break
+			}
+			if outcome.Status != ChargeMatched {
+				leftover = append(leftover, outcome)
+				leftoverTotal += outcome.Charge.ChargeAmount
+			}
+		}
+		if len(leftover) > 1 && record.TransactionID != "" &&
+			math.Abs(leftoverTotal-math.Abs(record.TransactionAmount)) <= 0.01 {
+			for _, outcome := range leftover {
+				outcome.Status = ChargeMatched
+				outcome.TransactionID = record.TransactionID
+			}
+		}
+	}
+	return nil
+}
+
+// matchMonarch looks for the charges still unmatched, and charged within
+// chargeSearchMaxAge, among Monarch transactions dated from a week before the
+// earliest such charge until now. Each charge only takes a transaction that
+// is its unique best match.
+func (r *ChargeReconciler) matchMonarch(ctx context.Context, outcomes []ChargeOutcome, used map[string]bool, opts ChargeReconcileOptions, now time.Time) error {
+	var pending []*ChargeOutcome
+	earliest := now
+	for i := range outcomes {
+		if outcomes[i].Status == ChargeMatched || now.Sub(outcomes[i].ChargedAt) > chargeSearchMaxAge {
+			continue
+		}
+		pending = append(pending, &outcomes[i])
+		if outcomes[i].ChargedAt.Before(earliest) {
+			earliest = outcomes[i].ChargedAt
+		}
+	}
+	if len(pending) == 0 || r.transactions == nil {
+		return nil
+	}
+
+	transactions, err := r.transactions(ctx, earliest.AddDate(0, 0, -7), now)
+	if err != nil {
+		return err
+	}
+	claimed, err := r.claimedTransactions()
+	if err != nil {
+		return err
+	}
+	setCardAccounts(r.matcher, r.repo, opts.CardAccounts, r.logger)
+
+	filters := make(map[string]*merchantFilter)
+	for _, outcome := range pending {
+		provider := outcome.Charge.Provider
+		filter, ok := filters[provider]
+		if !ok {
+			filter, err = newProviderMerchantFilter(provider, opts.MerchantSearchTerms[provider], opts.MerchantAliases[provider], nil)
+			if err != nil {
+				return err
+			}
+			filters[provider] = filter
+		}
+
+		var eligible []*monarch.Transaction
+		candidates, _ := filter.apply(transactions)
+		for _, tx := range candidates {
+			if owner := claimed[tx.ID]; owner == "" || owner == outcome.Charge.OrderID {
+				eligible = append(eligible, tx)
+			}
+		}
+		match, err := r.matcher.FindUniqueMatch(&chargeOrder{charge: outcome.Charge, date: outcome.ChargedAt}, eligible, used)
+		if err != nil {
+			r.logger.Debug("No unique transaction for ledger charge",
+				"order_id", outcome.Charge.OrderID,
+				"amount", outcome.Charge.ChargeAmount,
+				"error", err)
+			continue
+		}
+		if match == nil {
+			continue
+		}
+		used[match.Transaction.ID] = true
+		outcome.Status = ChargeMatched
+		outcome.TransactionID = match.Transaction.ID
+	}
+	return nil
+}
+
+// claimedTransactions maps the transactions of applied processing records to
+// the order that claimed them: the record's transaction and every charge
+// saved with the order.
+func (r *ChargeReconciler) claimedTransactions() (map[string]string, error) {
+	claimed := make(map[string]string)
+	for _, status := range []string{"success", provisionalStatus} {
+		for offset := 0; ; offset += historyPageSize {
+			page, err := r.repo.ListOrders(storage.OrderFilters{
+				Status: status,
+				Limit:  historyPageSize,
+				Offset: offset,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list %s orders: %w", status, err)
+			}
+			for _, record := range page.Orders {
+				if record.DryRun {
+					continue
+				}
+				if record.TransactionID != "" {
+					claimed[record.TransactionID] = record.OrderID
+				}
+				transactions, err := r.repo.GetOrderTransactions(record.OrderID)
+				if err != nil {
+					return nil, fmt.Errorf("get transactions for order %s: %w", record.OrderID, err)
+				}
+				for _, transaction := range transactions {
+					if transaction.TransactionID != "" {
+						claimed[transaction.TransactionID] = record.OrderID
+					}
+				}
+			}
+			if len(page.Orders) < historyPageSize {
+				break
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// chargeOrder presents a single ledger charge as an order for the matcher.
+type chargeOrder struct {
+	charge storage.LedgerCharge
+	date   time.Time
+}
+
+func (c *chargeOrder) GetID() string                   { return c.charge.OrderID }
+func (c *chargeOrder) GetDate() time.Time              { return c.date }
+func (c *chargeOrder) GetTotal() float64               { return c.charge.ChargeAmount }
+func (c *chargeOrder) GetSubtotal() float64            { return c.charge.ChargeAmount }
+func (c *chargeOrder) GetTax() float64                 { return 0 }
+func (c *chargeOrder) GetTip() float64                 { return 0 }
+func (c *chargeOrder) GetFees() float64                { return 0 }
+func (c *chargeOrder) GetItems() []providers.OrderItem { return nil }
+func (c *chargeOrder) GetProviderName() string         { return c.charge.Provider }
+func (c *chargeOrder) GetRawData() interface{}         { return nil }
+
+func (c *chargeOrder) GetPaymentCards() []providers.PaymentCard {
+	if c.charge.CardLastFour == "" {
+		return nil
+	}
+	return []providers.PaymentCard{{Type: c.charge.CardType, LastFour: c.charge.CardLastFour}}
+}
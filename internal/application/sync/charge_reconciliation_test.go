@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func saveChargeLedger(t *testing.T, repo *storage.MockRepository, orderID string, chargedAt time.Time, amounts ...float64) {
+	t.Helper()
+	ledger := &storage.OrderLedger{OrderID: orderID, Provider: "walmart", FetchedAt: chargedAt, LedgerState: storage.LedgerStateCharged}
+	for i, amount := range amounts {
+		ledger.Charges = append(ledger.Charges, storage.LedgerCharge{
+			ChargeSequence: i + 1, ChargeAmount: amount, ChargeType: "payment",
+			PaymentMethod: "CREDITCARD", CardLastFour: "1234", ChargedAt: chargedAt,
+		})
+	}
+	require.NoError(t, repo.SaveLedger(ledger))
+}
+
+func walmartTransaction(id string, amount float64, date time.Time) *monarch.Transaction {
+	return &monarch.Transaction{ID: id, Amount: amount, Date: toMonarchDate(date), Merchant: &monarch.Merchant{Name: "Walmart"}}
+}
+
+func TestChargeReconciler_Run(t *testing.T) {
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -5)
+
+	repo := storage.NewMockRepository()
+	// Consolidated into one transaction when the order was processed
+	saveChargeLedger(t, repo, "consolidated", recent, 30.00, 20.00)
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "consolidated", Provider: "walmart", Status: "success",
+		TransactionID: "txn-consolidated", TransactionAmount: -50.00,
+	}))
+	// Posted after the order was processed
+	saveChargeLedger(t, repo, "late", recent, 42.17)
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "other", Provider: "walmart", Status: "success", TransactionID: "txn-other",
+	}))
+	saveChargeLedger(t, repo, "missing", now.AddDate(0, 0, -20), 10.00)
+	saveChargeLedger(t, repo, "waiting", recent, 12.00)
+	saveChargeLedger(t, repo, "fresh", now.AddDate(0, 0, -1), 15.00)
+
+	var fetchedFrom time.Time
+	source := func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error) {
+		fetchedFrom = start
+		return []*monarch.Transaction{
+			walmartTransaction("txn-other", -42.17, recent),
+			walmartTransaction("txn-late", -42.17, recent.AddDate(0, 0, 2)),
+			{ID: "txn-target", Amount: -12.00, Date: toMonarchDate(recent), Merchant: &monarch.Merchant{Name: "Target"}},
+		}, nil
+	}
+	reconciler := NewChargeReconciler(repo, source, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	reconciler.now = func() time.Time { return now }
+
+	report, err := reconciler.Run(context.Background(), ChargeReconcileOptions{Provider: "walmart"})
+	require.NoError(t, err)
+
+	statuses := make(map[string][]string)
+	for _, outcome := range report.Outcomes {
+		statuses[outcome.Charge.OrderID] = append(statuses[outcome.Charge.OrderID], outcome.Status+":"+outcome.TransactionID)
+	}
+	assert.Equal(t, map[string][]string{
+		"consolidated": {"matched:txn-consolidated", "matched:txn-consolidated"},
+		"late":         {"matched:txn-late"},
+		"missing":      {"never_posted:"},
+		"waiting":      {"pending:"},
+	}, statuses, "charges from the last 3 days aren't checked")
+	assert.Equal(t, 3, report.Count(ChargeMatched))
+	assert.Equal(t, now.AddDate(0, 0, -27), fetchedFrom, "a week before the oldest charge")
+
+	unmatched, err := repo.GetUnmatchedCharges("walmart", 0)
+	require.NoError(t, err)
+	assert.Len(t, unmatched, 3)
+	latest, err := repo.GetLatestLedger("late")
+	require.NoError(t, err)
+	assert.Equal(t, "txn-late", latest.Charges[0].MonarchTransactionID)
+}
+
+func TestChargeReconciler_DoesNotSearchMonarchForOldCharges(t *testing.T) {
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	repo := storage.NewMockRepository()
+	saveChargeLedger(t, repo, "forgotten", now.AddDate(-2, 0, 0), 10.00)
+	saveChargeLedger(t, repo, "late", now.AddDate(0, 0, -5), 42.17)
+
+	var fetchedFrom time.Time
+	source := func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error) {
+		fetchedFrom = start
+		return []*monarch.Transaction{
+			walmartTransaction("txn-old", -10.00, now.AddDate(-2, 0, 1)),
+			walmartTransaction("txn-late", -42.17, now.AddDate(0, 0, -4)),
+		}, nil
+	}
+	reconciler := NewChargeReconciler(repo, source, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	reconciler.now = func() time.Time { return now }
+
+	report, err := reconciler.Run(context.Background(), ChargeReconcileOptions{DryRun: true})
+	require.NoError(t, err)
+
+	statuses := make(map[string]string)
+	for _, outcome := range report.Outcomes {
+		statuses[outcome.Charge.OrderID] = outcome.Status + ":" + outcome.TransactionID
+	}
+	assert.Equal(t, map[string]string{"forgotten": "never_posted:", "late": "matched:txn-late"}, statuses)
+	assert.Equal(t, now.AddDate(0, 0, -12), fetchedFrom, "the window starts a week before the oldest charge still searched")
+
+	fetchedFrom = time.Time{}
+	repo = storage.NewMockRepository()
+	saveChargeLedger(t, repo, "forgotten", now.AddDate(-2, 0, 0), 10.00)
+	reconciler.repo = repo
+	report, err = reconciler.Run(context.Background(), ChargeReconcileOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Count(ChargeNeverPosted))
+	assert.True(t, fetchedFrom.IsZero(), "Monarch isn't listed when every charge is too old to search")
+}
+
+func TestChargeReconciler_DryRun(t *testing.T) {
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	repo := storage.NewMockRepository()
+	saveChargeLedger(t, repo, "late", now.AddDate(0, 0, -5), 42.17)
+
+	source := func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error) {
+		return []*monarch.Transaction{walmartTransaction("txn-late", -42.17, now.AddDate(0, 0, -4))}, nil
+	}
+	reconciler := NewChargeReconciler(repo, source, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	reconciler.now = func() time.Time { return now }
+
+	report, err := reconciler.Run(context.Background(), ChargeReconcileOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Count(ChargeMatched))
+
+	unmatched, err := repo.GetUnmatchedCharges("", 0)
+	require.NoError(t, err)
+	assert.Len(t, unmatched, 1, "dry runs don't record matches")
+}
+
+func TestChargeReconciler_SkipsSiblingChargesAndSearchesMerchantTerms(t *testing.T) {
+	now := time.Date(2025, 10, 30, 12, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, 0, -5)
+
+	repo := storage.NewMockRepository()
+	saveChargeLedger(t, repo, "late", recent, 42.17)
+	// Another order was split across two charges; the second is saved with
+	// the order but isn't the record's transaction
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "split", Provider: "walmart", Status: "success", TransactionID: "txn-split-1",
+	}))
+	require.NoError(t, repo.SaveOrderTransaction(&storage.OrderTransaction{
+		OrderID: "split", TransactionID: "txn-split-2", Role: "charge", Amount: -42.17,
+	}))
+
+	source := func(ctx context.Context, start, end time.Time) ([]*monarch.Transaction, error) {
+		return []*monarch.Transaction{
+			walmartTransaction("txn-split-2", -42.17, recent),
+			{ID: "txn-late", Amount: -42.17, Date: toMonarchDate(recent.AddDate(0, 0, 2)), Merchant: &monarch.Merchant{Name: "WM Supercenter"}},
+		}, nil
+	}
+	reconciler := NewChargeReconciler(repo, source, slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError})))
+	reconciler.now = func() time.Time { return now }
+
+	report, err := reconciler.Run(context.Background(), ChargeReconcileOptions{
+		MerchantSearchTerms: map[string][]string{"walmart": {"Walmart", "WM Supercenter"}},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Outcomes, 1)
+	assert.Equal(t, ChargeMatched, report.Outcomes[0].Status)
+	assert.Equal(t, "txn-late", report.Outcomes[0].TransactionID, "the sibling charge belongs to another order")
+}
@@ -0,0 +1,115 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// reviewClient is the Monarch operation needed to flag transactions for
+// review.
+type reviewClient interface {
+	UpdateTransaction(ctx context.Context, id string, params *monarch.UpdateTransactionParams) error
+}
+
+// configureConfidencePolicy applies the run's low-confidence settings to the
+// splitter. A fallback category that doesn't exist in Monarch is reported
+// once here; affected orders are flagged for review instead.
+func (o *Orchestrator) configureConfidencePolicy(catCategories []categorizer.Category, opts Options) {
+	if o.splitter == nil {
+		return
+	}
+
+	o.splitter.SetConfidencePolicy(splitter.ConfidencePolicy{
+		MinConfidence:    opts.MinConfidence,
+		FallbackCategory: opts.FallbackCategory,
+	})
+
+	if opts.FallbackCategory != "" && splitter.ResolveCategory(opts.FallbackCategory, catCategories) == nil {
+		o.logger.Warn("Fallback category not found in Monarch; low-confidence orders will be flagged for review and uncategorized items left alone instead",
+			"fallback_category", opts.FallbackCategory)
+	}
+}
+
+// reviewLowConfidence handles the low-confidence items of a processed order.
+// If any item kept an uncertain category (no fallback available), the Monarch
+// transaction is flagged as needing review (every charge's transaction, when
+// the charges were split separately). The items are attached to the result so
+// the processing record shows up under status=low_confidence.
+func (o *Orchestrator) reviewLowConfidence(ctx context.Context, order providers.Order, result *handlers.ProcessResult, dryRun bool) {
+	if o.splitter == nil || result == nil || !result.Processed {
+		return
+	}
+	report := o.splitter.ConfidenceReport(order.GetID())
+	if report == nil || len(report.Items) == 0 {
+		return
+	}
+
+	flagged := false
+	if report.NeedsReview && !dryRun && o.reviewClient != nil {
+		for _, transaction := range appliedTransactions(result) {
+			needsReview := true
+			if err := o.reviewClient.UpdateTransaction(ctx, transaction.ID, &monarch.UpdateTransactionParams{
+				NeedsReview: &needsReview,
+			}); err != nil {
+				o.logger.Warn("Failed to flag low-confidence transaction for review",
+					"order_id", order.GetID(),
+					"transaction_id", transaction.ID,
+					"error", err)
+			} else {
+				flagged = true
+			}
+		}
+	}
+
+	items := make([]storage.LowConfidenceItem, 0, len(report.Items))
+	for _, item := range report.Items {
+		stored := storage.LowConfidenceItem{
+			Name:         item.Original.ItemName,
+			CategoryID:   item.Original.CategoryID,
+			CategoryName: item.Original.CategoryName,
+			Confidence:   item.Original.Confidence,
+		}
+		if item.Fallback != nil {
+			stored.FallbackCategory = item.Fallback.Name
+		} else {
+			stored.FlaggedForReview = flagged
+		}
+		items = append(items, stored)
+	}
+
+	if data, err := json.Marshal(items); err == nil {
+		result.LowConfidenceJSON = string(data)
+	}
+
+	o.logger.Info("Low-confidence categorization",
+		"order_id", order.GetID(),
+		"item_count", len(items),
+		"flagged_for_review", flagged,
+		"dry_run", dryRun)
+}
+
+// appliedTransactions returns the transactions a handler categorized for an
+// order: each charge's transaction when they were split separately,
+// otherwise the matched transaction.
+func appliedTransactions(result *handlers.ProcessResult) []*monarch.Transaction {
+	if len(result.Charges) == 0 {
+		if result.Transaction == nil {
+			return nil
+		}
+		return []*monarch.Transaction{result.Transaction}
+	}
+	transactions := make([]*monarch.Transaction, 0, len(result.Charges))
+	for _, charge := range result.Charges {
+		if charge.Transaction != nil {
+			transactions = append(transactions, charge.Transaction)
+		}
+	}
+	return transactions
+}
@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scoredCategorizer returns fixed categorizations with per-item confidence
+type scoredCategorizer struct {
+	result *categorizer.CategorizationResult
+}
+
+func (s *scoredCategorizer) CategorizeItems(context.Context, []categorizer.Item, []categorizer.Category) (*categorizer.CategorizationResult, error) {
+	return s.result, nil
+}
+
+func TestReviewLowConfidence_RecordsItemsOnResult(t *testing.T) {
+	spl := splitter.NewSplitter(&scoredCategorizer{result: &categorizer.CategorizationResult{
+		Categorizations: []categorizer.ItemCategorization{
+			{ItemName: "Milk", CategoryID: "groceries", CategoryName: "Groceries", Confidence: 0.9},
+			{ItemName: "Widget", CategoryID: "shopping", CategoryName: "Shopping", Confidence: 0.3},
+		},
+	}})
+	o := &Orchestrator{splitter: spl, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	categories := []categorizer.Category{{ID: "groceries", Name: "Groceries"}, {ID: "shopping", Name: "Shopping"}}
+	o.configureConfidencePolicy(categories, Options{MinConfidence: 0.6})
+
+	order := &mockSimpleOrder{id: "ORDER-1", date: time.Now(), total: 10, subtotal: 10, items: []providers.OrderItem{
+		&mockOrderItem{name: "Milk", price: 4, quantity: 1},
+		&mockOrderItem{name: "Widget", price: 6, quantity: 1},
+	}}
+	txn := &monarch.Transaction{ID: "TXN-1", Amount: -10}
+	_, err := spl.CreateSplits(context.Background(), order, txn, categories, nil)
+	require.NoError(t, err)
+
+	result := &handlers.ProcessResult{Processed: true, Transaction: txn}
+	o.reviewLowConfidence(context.Background(), order, result, true)
+
+	var items []storage.LowConfidenceItem
+	require.NoError(t, json.Unmarshal([]byte(result.LowConfidenceJSON), &items))
+	require.Len(t, items, 1)
+	assert.Equal(t, "Widget", items[0].Name)
+	assert.Equal(t, "shopping", items[0].CategoryID)
+	assert.Equal(t, 0.3, items[0].Confidence)
+	assert.False(t, items[0].FlaggedForReview, "dry run does not touch Monarch")
+}
+
+func TestReviewLowConfidence_NoReportLeavesResultUntouched(t *testing.T) {
+	spl := splitter.NewSplitter(&scoredCategorizer{})
+	o := &Orchestrator{splitter: spl, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+
+	result := &handlers.ProcessResult{Processed: true}
+	o.reviewLowConfidence(context.Background(), &mockSimpleOrder{id: "ORDER-2"}, result, false)
+
+	assert.Empty(t, result.LowConfidenceJSON)
+}
+
+// fakeReviewClient records which transactions were flagged for review
+type fakeReviewClient struct {
+	flagged []string
+}
+
+func (f *fakeReviewClient) UpdateTransaction(_ context.Context, id string, params *monarch.UpdateTransactionParams) error {
+	if params.NeedsReview != nil && *params.NeedsReview {
+		f.flagged = append(f.flagged, id)
+	}
+	return nil
+}
+
+func TestReviewLowConfidence_FlagsEveryCharge(t *testing.T) {
+	spl := splitter.NewSplitter(&scoredCategorizer{result: &categorizer.CategorizationResult{
+		Categorizations: []categorizer.ItemCategorization{
+			{ItemName: "Milk", CategoryID: "groceries", CategoryName: "Groceries", Confidence: 0.9},
+			{ItemName: "Widget", CategoryID: "shopping", CategoryName: "Shopping", Confidence: 0.3},
+		},
+	}})
+	client := &fakeReviewClient{}
+	o := &Orchestrator{splitter: spl, reviewClient: client, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	categories := []categorizer.Category{{ID: "groceries", Name: "Groceries"}, {ID: "shopping", Name: "Shopping"}}
+	o.configureConfidencePolicy(categories, Options{MinConfidence: 0.6})
+
+	order := &mockSimpleOrder{id: "ORDER-3", date: time.Now(), total: 10, subtotal: 10, items: []providers.OrderItem{
+		&mockOrderItem{name: "Milk", price: 4, quantity: 1},
+		&mockOrderItem{name: "Widget", price: 6, quantity: 1},
+	}}
+	_, err := spl.CategorizeOrder(context.Background(), order, categories)
+	require.NoError(t, err)
+
+	first := &monarch.Transaction{ID: "TXN-A", Amount: -4}
+	sibling := &monarch.Transaction{ID: "TXN-B", Amount: -6}
+	result := &handlers.ProcessResult{
+		Processed:   true,
+		Transaction: first,
+		Charges: []handlers.ChargeProcessResult{
+			{Amount: 4, Transaction: first, CategoryID: "groceries"},
+			{Amount: 6, Transaction: sibling, CategoryID: "shopping"},
+		},
+	}
+	o.reviewLowConfidence(context.Background(), order, result, false)
+
+	assert.Equal(t, []string{"TXN-A", "TXN-B"}, client.flagged, "the sibling charge is flagged too")
+	var items []storage.LowConfidenceItem
+	require.NoError(t, json.Unmarshal([]byte(result.LowConfidenceJSON), &items))
+	require.Len(t, items, 1)
+	assert.True(t, items[0].FlaggedForReview)
+}
@@ -0,0 +1,69 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	costcoprovider "github.com/eshaffer321/itemize/internal/adapters/providers/costco"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// splitCostcoRefunds separates Costco refunds from the purchases returned by
+// the provider so they can be matched to credits after the purchases.
+func splitCostcoRefunds(orders []providers.Order) ([]providers.Order, []*costcoprovider.RefundOrder) {
+	purchases := make([]providers.Order, 0, len(orders))
+	var refunds []*costcoprovider.RefundOrder
+	for _, order := range orders {
+		if refund, ok := order.(*costcoprovider.RefundOrder); ok {
+			refunds = append(refunds, refund)
+			continue
+		}
+		purchases = append(purchases, order)
+	}
+	return purchases, refunds
+}
+
+func (o *Orchestrator) processCostcoRefunds(
+	ctx context.Context,
+	refunds []*costcoprovider.RefundOrder,
+	transactions []*monarch.Transaction,
+	usedTxnIDs map[string]bool,
+	catCategories []categorizer.Category,
+	monarchCategories []*monarch.TransactionCategory,
+	opts Options,
+	result *Result,
+) {
+	if o.costcoRefundHandler == nil || result == nil {
+		return
+	}
+	for _, refund := range refunds {
+		if opts.OrderID != "" && refund.GetID() != opts.OrderID && refund.SourceID() != opts.OrderID {
+			continue
+		}
+		if !opts.Force && o.storage != nil && o.storage.IsProcessed(refund.GetID()) {
+			o.logger.Debug("Skipping already processed Costco refund", "refund_id", refund.GetID())
+			continue
+		}
+
+		refundCtx := withAuditContext(ctx, refund.GetID(), opts.DryRun)
+		processed, err := o.costcoRefundHandler.ProcessRefund(refundCtx, refund, transactions, usedTxnIDs, catCategories, monarchCategories, opts.DryRun)
+		if err != nil {
+			result.ErrorCount++
+			result.Errors = append(result.Errors, fmt.Errorf("costco refund %s ($%.2f): %w", refund.SourceID(), refund.RefundAmount(), err))
+			o.logger.Error("Costco refund processing failed", "refund_id", refund.GetID(), "error", err)
+			o.recordError(refund, err.Error(), nil)
+			continue
+		}
+		if processed.Skipped {
+			result.RefundSkippedCount++
+			o.logger.Warn("Costco refund left untouched", "refund_id", refund.GetID(), "reason", processed.SkipReason)
+			continue
+		}
+		if processed.Processed {
+			result.RefundProcessedCount++
+			o.recordSuccessWithResult(refund, processed.Transaction, processed.Splits, 0, opts.DryRun, processed, nil)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	costcoprovider "github.com/eshaffer321/itemize/internal/adapters/providers/costco"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingCategorizer records how many items reach the fallback categorizer
+type countingCategorizer struct {
+	mockCategorizer
+	items []string
+}
+
+func (c *countingCategorizer) CategorizeItems(ctx context.Context, items []categorizer.Item, categories []categorizer.Category) (*categorizer.CategorizationResult, error) {
+	for _, item := range items {
+		c.items = append(c.items, item.Name)
+	}
+	return c.mockCategorizer.CategorizeItems(ctx, items, categories)
+}
+
+var costcoRefundCategories = []categorizer.Category{
+	{ID: "household", Name: "Household"},
+	{ID: "electronics", Name: "Electronics"},
+	{ID: "groceries", Name: "Groceries"},
+}
+
+func loadCostcoRefundFixture(t *testing.T) *costcoprovider.RefundOrder {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "adapters", "providers", "costco", "testdata", "refund_receipt.json"))
+	require.NoError(t, err)
+	var receipt costcogo.Receipt
+	require.NoError(t, json.Unmarshal(data, &receipt))
+	return costcoprovider.NewRefundOrder(&receipt)
+}
+
+func newCostcoRefundOrchestrator(repo storage.Repository, fallback categorizer.ItemCategorizer) *Orchestrator {
+	handler := handlers.NewCostcoRefundHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		&mockSplitterAdapter{splitter: splitter.NewSplitter(fallback)},
+		&processOrderTestMonarch{},
+		slog.Default(),
+	)
+	handler.SetPurchaseHistory(&purchaseHistory{repo: repo})
+	return &Orchestrator{costcoRefundHandler: handler, storage: repo, logger: slog.Default()}
+}
+
+func TestSplitCostcoRefunds(t *testing.T) {
+	refund := loadCostcoRefundFixture(t)
+	purchase := &mockSimpleOrder{id: "PURCHASE"}
+
+	orders, refunds := splitCostcoRefunds([]providers.Order{purchase, refund})
+
+	assert.Equal(t, []providers.Order{purchase}, orders)
+	assert.Equal(t, []*costcoprovider.RefundOrder{refund}, refunds)
+}
+
+func TestProcessCostcoRefunds_OnlineRefundTakesPurchaseCategory(t *testing.T) {
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID:    "1100223344",
+		Provider:   "Costco",
+		OrderTotal: 89.99,
+		Status:     "success",
+		CategoryID: "household",
+		Items:      []storage.OrderItem{{Name: "Sunbeam Heated Throw", SKU: "100799", TotalPrice: 89.99}},
+	}))
+	fallback := &countingCategorizer{mockCategorizer: mockCategorizer{categoryID: "groceries", categoryName: "Groceries"}}
+	o := newCostcoRefundOrchestrator(repo, fallback)
+
+	refund := costcoprovider.NewOnlineRefundOrder(&costcogo.OnlineOrder{
+		OrderNumber:     "1100223344",
+		OrderPlacedDate: "2026-02-20T09:15:00",
+		OrderTotal:      89.99,
+		Status:          "Returned",
+		OrderLineItems:  []costcogo.OrderLineItem{{ItemNumber: "100799", ItemDescription: "Sunbeam Heated Throw"}},
+	})
+	credit := &monarch.Transaction{ID: "CREDIT-1", Amount: 89.99, Date: toMonarchDate(time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC))}
+	used := map[string]bool{}
+	result := &Result{}
+
+	o.processCostcoRefunds(context.Background(), []*costcoprovider.RefundOrder{refund}, []*monarch.Transaction{credit}, used, costcoRefundCategories, nil, Options{}, result)
+
+	assert.Equal(t, 1, result.RefundProcessedCount)
+	assert.Zero(t, result.ErrorCount)
+	assert.Empty(t, fallback.items, "items with a known purchase must not reach the LLM")
+	assert.True(t, used["CREDIT-1"])
+
+	record, err := repo.GetRecord(refund.GetID())
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "CREDIT-1", record.TransactionID)
+	assert.Equal(t, "household", record.CategoryID)
+}
+
+func TestProcessCostcoRefunds_WarehouseRefundTakesLatestPurchaseCategory(t *testing.T) {
+	repo := storage.NewMockRepository()
+	for _, record := range []*storage.ProcessingRecord{
+		{
+			OrderID: "21000100500112601100930", Provider: "Costco", Status: "success",
+			OrderDate: time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC), OrderTotal: 24.99,
+			CategoryID: "groceries", Items: []storage.OrderItem{{Name: "KS PAPER TOWEL", SKU: "1147521", TotalPrice: 24.99}},
+		},
+		{
+			OrderID: "21000100500112602281015", Provider: "Costco", Status: "success",
+			OrderDate: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), OrderTotal: 24.99,
+			CategoryID: "household", Items: []storage.OrderItem{{Name: "KS PAPER TOWEL", SKU: "1147521", TotalPrice: 24.99}},
+		},
+		{
+			OrderID: "21000100500112603201200", Provider: "Costco", Status: "success",
+			OrderDate: time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC), OrderTotal: 24.99,
+			CategoryID: "electronics", Items: []storage.OrderItem{{Name: "KS PAPER TOWEL", SKU: "1147521", TotalPrice: 24.99}},
+		},
+	} {
+		require.NoError(t, repo.SaveRecord(record))
+	}
+	fallback := &countingCategorizer{mockCategorizer: mockCategorizer{categoryID: "groceries", categoryName: "Groceries"}}
+	o := newCostcoRefundOrchestrator(repo, fallback)
+
+	refund := loadCostcoRefundFixture(t)
+	credit := &monarch.Transaction{ID: "CREDIT-1", Amount: 52.97, Date: toMonarchDate(time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC))}
+	result := &Result{}
+
+	o.processCostcoRefunds(context.Background(), []*costcoprovider.RefundOrder{refund}, []*monarch.Transaction{credit}, map[string]bool{}, costcoRefundCategories, nil, Options{}, result)
+
+	assert.Equal(t, 1, result.RefundProcessedCount)
+	assert.Zero(t, result.ErrorCount)
+	assert.Equal(t, []string{"DURACELL AAA"}, fallback.items, "only items never bought before reach the LLM")
+
+	record, err := repo.GetRecord(refund.GetID())
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	categories := map[string]string{}
+	for _, split := range record.Splits {
+		categories[split.CategoryID] = split.Notes
+	}
+	assert.Contains(t, categories["household"], "KS PAPER TOWEL", "the latest purchase before the refund wins")
+	assert.Contains(t, categories["groceries"], "DURACELL AAA")
+}
+
+func TestProcessCostcoRefunds_WarehouseRefundFallsBackToCategorizer(t *testing.T) {
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "21000100500112602281015", Provider: "Costco", Status: "success", DryRun: true,
+		OrderDate: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC), OrderTotal: 24.99,
+		CategoryID: "household", Items: []storage.OrderItem{{Name: "KS PAPER TOWEL", SKU: "1147521"}},
+	}))
+	fallback := &countingCategorizer{mockCategorizer: mockCategorizer{categoryID: "groceries", categoryName: "Groceries"}}
+	o := newCostcoRefundOrchestrator(repo, fallback)
+
+	refund := loadCostcoRefundFixture(t)
+	credit := &monarch.Transaction{ID: "CREDIT-1", Amount: 52.97, Date: toMonarchDate(time.Date(2026, time.March, 14, 0, 0, 0, 0, time.UTC))}
+	result := &Result{}
+
+	o.processCostcoRefunds(context.Background(), []*costcoprovider.RefundOrder{refund}, []*monarch.Transaction{credit}, map[string]bool{}, costcoRefundCategories, nil, Options{DryRun: true}, result)
+
+	assert.Equal(t, 1, result.RefundProcessedCount)
+	assert.Equal(t, []string{"KS PAPER TOWEL", "DURACELL AAA"}, fallback.items, "a dry-run purchase was never applied")
+}
+
+func TestProcessCostcoRefunds_SkipsWithoutCredit(t *testing.T) {
+	repo := storage.NewMockRepository()
+	o := newCostcoRefundOrchestrator(repo, nil)
+	result := &Result{}
+
+	o.processCostcoRefunds(context.Background(), []*costcoprovider.RefundOrder{loadCostcoRefundFixture(t)}, nil, map[string]bool{}, costcoRefundCategories, nil, Options{}, result)
+
+	assert.Equal(t, 1, result.RefundSkippedCount)
+	assert.Zero(t, result.ErrorCount)
+	assert.False(t, repo.IsProcessed("costco-refund:21000100500112603141142"))
+}
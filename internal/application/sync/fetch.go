@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
@@ -74,53 +73,136 @@ func (o *Orchestrator) fetchOrders(ctx context.Context, opts Options) ([]provide
 }
 
 // fetchMonarchTransactions fetches and filters transactions from Monarch
-func (o *Orchestrator) fetchMonarchTransactions(ctx context.Context, opts Options) ([]*monarch.Transaction, error) {
+func (o *Orchestrator) fetchMonarchTransactions(ctx context.Context, opts Options, merchants *merchantFilter) ([]*monarch.Transaction, error) {
 	o.logger.Debug("Fetching Monarch transactions")
 
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -opts.LookbackDays)
 
+	// Add buffer for date matching
+	fetchStart := startDate.AddDate(0, 0, -7)
+	// Search Monarch for the provider's merchants when the filter allows it;
+	// configured aliases need every transaction in the window
+	searches := merchants.searchTerms()
+	if len(searches) == 0 {
+		searches = []string{""}
+	}
 	request := map[string]any{
-		"start_date": startDate.AddDate(0, 0, -7).Format("2006-01-02"),
+		"start_date": fetchStart.Format("2006-01-02"),
 		"end_date":   endDate.Format("2006-01-02"),
-		"limit":      500,
+		"page_size":  monarchPageSize,
+		"searches":   searches,
 	}
 	started := time.Now()
-	txList, err := o.clients.Monarch.Transactions.Query().
-		Between(startDate.AddDate(0, 0, -7), endDate). // Add buffer for date matching
-		Limit(500).
-		Execute(ctx)
+	transactions, queries, err := o.fetchTransactionPages(ctx, fetchStart, endDate, searches)
 	if err != nil {
-		o.logProviderFetch("monarch_transactions", request, nil, err, time.Since(started), 0, 0)
-		return nil, fmt.Errorf("failed to fetch transactions: %w", err)
+		o.logProviderFetch("monarch_transactions", request, map[string]any{"queries": queries}, err, time.Since(started), 0, 0)
+		return nil, err
 	}
-
-	// Filter for provider transactions (excluding splits)
-	var providerTransactions []*monarch.Transaction
-	providerName := strings.ToLower(o.provider.DisplayName())
-	for _, tx := range txList.Transactions {
-		// Skip split transactions - only process parent transactions
-		if tx.IsSplitTransaction {
+	// Orders whose charges were left unread come out "no match"; the sync
+	// carries on and the run records why
+	truncated := false
+	for _, query := range queries {
+		if !query.Truncated {
 			continue
 		}
-		if tx.Merchant != nil && strings.Contains(strings.ToLower(tx.Merchant.Name), providerName) {
-			providerTransactions = append(providerTransactions, tx)
+		truncated = true
+		o.logger.Warn("Monarch transaction fetch truncated; older transactions in the window were not read",
+			"search", query.Search,
+			"fetched", query.Fetched,
+			"total_count", query.TotalCount,
+			"max_pages", o.transactionPager.maxPages)
+		if o.storage != nil && o.runID > 0 {
+			warning := fmt.Sprintf("monarch transaction fetch truncated: read %d of %d transactions between %s and %s",
+				query.Fetched, query.TotalCount, fetchStart.Format("2006-01-02"), endDate.Format("2006-01-02"))
+			if err := o.storage.AddSyncRunWarning(o.runID, warning); err != nil {
+				o.logger.Warn("Failed to record sync run warning", "error", err)
+			}
+		}
+	}
+
+	providerTransactions, excluded := merchants.apply(transactions)
+	excludedCounts := make(map[string]int)
+	var excludedByAccount []excludedTransactionSummary
+	for _, exclusion := range excluded {
+		excludedCounts[exclusion.Reason]++
+		tx := exclusion.Transaction
+		merchantName := ""
+		if tx.Merchant != nil {
+			merchantName = tx.Merchant.Name
+		}
+		o.logger.Debug("Excluded transaction",
+			"transaction_id", tx.ID,
+			"merchant", merchantName,
+			"plaid_name", tx.PlaidName,
+			"amount", tx.Amount,
+			"reason", exclusion.Reason)
+		if exclusion.Reason == excludedAccount {
+			// The provider's merchant on another card is worth a second look;
+			// the household's other spending is not
+			excludedByAccount = append(excludedByAccount, excludedTransactionSummary{
+				transactionFetchSummary: summarizeTransactionsForFetchLog([]*monarch.Transaction{tx})[0],
+				Account:                 accountName(tx.Account),
+				Reason:                  exclusion.Reason,
+			})
 		}
 	}
 
 	o.logger.Debug("Fetched transactions",
-		"total", len(txList.Transactions),
+		"total", len(transactions),
 		"provider_transactions", len(providerTransactions),
+		"excluded", excludedCounts,
 	)
+	if len(excludedByAccount) > 0 {
+		o.logger.Info("Excluded provider transactions outside the configured Monarch accounts",
+			"count", len(excludedByAccount),
+			"accounts", opts.MonarchAccounts)
+	}
 	o.logProviderFetch("monarch_transactions", request, map[string]any{
-		"total_count":           len(txList.Transactions),
+		"total_count":           len(transactions),
 		"provider_count":        len(providerTransactions),
+		"queries":               queries,
+		"truncated":             truncated,
 		"provider_transactions": summarizeTransactionsForFetchLog(providerTransactions),
+		"excluded_counts":       excludedCounts,
+		"excluded_by_account":   excludedByAccount,
 	}, nil, time.Since(started), 0, len(providerTransactions))
 
 	return providerTransactions, nil
 }
 
+// fetchTransactionPages runs one paged Monarch query per search and merges
+// the results, keeping each transaction once. Pages come from the run's
+// transactionPager, so a query repeated within the run is not refetched.
+func (o *Orchestrator) fetchTransactionPages(ctx context.Context, start, end time.Time, searches []string) ([]*monarch.Transaction, []transactionQueryStats, error) {
+	if o.transactionPager == nil {
+		source := o.transactionPages
+		if source == nil {
+			source = MonarchTransactionPages(o.clients.Monarch)
+		}
+		o.transactionPager = newTransactionPager(source)
+	}
+
+	var transactions []*monarch.Transaction
+	var queries []transactionQueryStats
+	seen := make(map[string]bool)
+	for _, search := range searches {
+		page, stats, err := o.transactionPager.fetch(ctx, start, end, search)
+		queries = append(queries, stats)
+		if err != nil {
+			return nil, queries, err
+		}
+		for _, tx := range page {
+			if seen[tx.ID] {
+				continue
+			}
+			seen[tx.ID] = true
+			transactions = append(transactions, tx)
+		}
+	}
+	return transactions, queries, nil
+}
+
 // fetchCategories fetches categories from Monarch and converts to categorizer format
 func (o *Orchestrator) fetchCategories(ctx context.Context) ([]categorizer.Category, []*monarch.TransactionCategory, error) {
 	o.logger.Debug("Loading Monarch categories")
@@ -136,18 +218,50 @@ func (o *Orchestrator) fetchCategories(ctx context.Context) ([]categorizer.Categ
 
 	o.logger.Debug("Loaded categories", "count", len(categories))
 
-	// Convert to categorizer format
-	catCategories := make([]categorizer.Category, len(categories))
-	for i, cat := range categories {
-		catCategories[i] = categorizer.Category{
-			ID:   cat.ID,
-			Name: cat.Name,
+	// Convert to categorizer format, keeping group info. Disabled categories
+	// can't be assigned in Monarch, so they are never offered.
+	catCategories := make([]categorizer.Category, 0, len(categories))
+	for _, cat := range categories {
+		if cat.IsDisabled {
+			continue
 		}
+		category := categorizer.Category{
+			ID:      cat.ID,
+			Name:    cat.Name,
+			GroupID: cat.GroupID,
+		}
+		if cat.Group != nil {
+			category.GroupID = cat.Group.ID
+			category.GroupName = cat.Group.Name
+			category.GroupType = cat.Group.Type
+		}
+		catCategories = append(catCategories, category)
 	}
 
 	return catCategories, categories, nil
 }
 
+// filterCategoryGroups applies the configured group allow/deny lists to the
+// categories offered for categorization. The unfiltered Monarch list is still
+// used for lookups, so existing assignments outside the allowed groups keep
+// resolving.
+func (o *Orchestrator) filterCategoryGroups(categories []categorizer.Category, filter categorizer.GroupFilter) []categorizer.Category {
+	if filter.IsZero() {
+		return categories
+	}
+	filtered := filter.Apply(categories)
+	o.logger.Debug("Filtered categories by group",
+		"offered", len(filtered),
+		"excluded", len(categories)-len(filtered),
+		"allow", filter.Allow,
+		"deny", filter.Deny)
+	if len(filtered) == 0 {
+		o.logger.Warn("Category group filter excludes every category; ignoring it", "allow", filter.Allow, "deny", filter.Deny)
+		return categories
+	}
+	return filtered
+}
+
 type orderFetchSummary struct {
 	ID           string  `json:"id"`
 	Date         string  `json:"date"`
@@ -171,6 +285,24 @@ type transactionFetchSummary struct {
 	IsSplitTransaction bool    `json:"is_split_transaction"`
 }
 
+// excludedTransactionSummary is a transaction left out by the merchant filter
+type excludedTransactionSummary struct {
+	transactionFetchSummary
+	Account string `json:"account,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// accountName returns a Monarch account's display name, or its ID
+func accountName(account *monarch.Account) string {
+	if account == nil {
+		return ""
+	}
+	if account.DisplayName != "" {
+		return account.DisplayName
+	}
+	return account.ID
+}
+
 func summarizeOrdersForFetchLog(orders []providers.Order) []orderFetchSummary {
 	summaries := make([]orderFetchSummary, 0, len(orders))
 	for _, order := range orders {
@@ -81,6 +81,17 @@ type PaymentMethodData struct {
 // LedgerStorage provides access to ledger persistence
 type LedgerStorage interface {
 	SaveLedger(ledger *LedgerData, syncRunID int64) error
+	// RecordChargeMatches links the charges of an order's latest ledger to
+	// the Monarch transactions they were matched to
+	RecordChargeMatches(orderID string, matches []ChargeMatch) error
+}
+
+// ChargeMatch is a ledger charge (negative for refunds) and the Monarch
+// transaction it ended up in
+type ChargeMatch struct {
+	Amount        float64
+	TransactionID string
+	SplitCount    int
 }
 
 // ProcessResult holds the result of processing an order
@@ -103,6 +114,30 @@ type ProcessResult struct {
 	// Debug/reconciliation audit fields
 	MatchDiagnosticsJSON   string
 	ReconciledTransactions []*monarch.Transaction
+
+	// Charges lists each bank charge of a multi-charge order categorized on
+	// its own transaction (MultiChargeSplit). Transaction, Splits and the
+	// audit fields above then describe the first charge.
+	Charges []ChargeProcessResult
+
+	// ChargeValidation is the check of the order's bank charges against its
+	// total less non-bank payments (nil when the order wasn't checked)
+	ChargeValidation *validator.ChargeValidation
+
+	// LowConfidenceJSON lists items categorized below the confidence threshold
+	// (set by the orchestrator after the handler returns)
+	LowConfidenceJSON string
+
+	// TaggedItems lists each categorized item with the Monarch tags assigned
+	// to it (set by the orchestrator after the handler returns)
+	TaggedItems []TaggedItem
+}
+
+// TaggedItem is an order item with the category and tags assigned to it.
+type TaggedItem struct {
+	Name       string
+	CategoryID string
+	Tags       []string
 }
 
 // RefundProcessResult describes a refund transaction categorized for an order.
@@ -114,11 +149,13 @@ type RefundProcessResult struct {
 
 // AmazonHandler processes Amazon orders with pro-rata allocation
 type AmazonHandler struct {
-	matcher      *matcher.Matcher
-	consolidator TransactionConsolidator
-	splitter     CategorySplitter
-	monarch      MonarchClient
-	logger       *slog.Logger
+	matcher         *matcher.Matcher
+	consolidator    TransactionConsolidator
+	splitter        CategorySplitter
+	monarch         MonarchClient
+	multiChargeMode MultiChargeMode
+	purchases       PurchaseHistory
+	logger          *slog.Logger
 }
 
 // NewAmazonHandler creates a new Amazon order handler
@@ -138,6 +175,13 @@ func NewAmazonHandler(
 	}
 }
 
+// SetMultiChargeMode selects how orders with several bank charges are
+// applied: consolidated into one transaction (the default) or split charge
+// by charge.
+func (h *AmazonHandler) SetMultiChargeMode(mode MultiChargeMode) {
+	h.multiChargeMode = mode
+}
+
 // ProcessOrder processes an Amazon order with pro-rata allocation
 func (h *AmazonHandler) ProcessOrder(
 	ctx context.Context,
@@ -190,6 +234,7 @@ func (h *AmazonHandler) ProcessOrder(
 
 	// Step 3: Validate charges
 	validation := validator.ValidateCharges(bankCharges, order.GetTotal(), nonBankAmount)
+	result.ChargeValidation = validation
 
 	// Step 4: Match to Monarch transactions
 	var matchedTxns []*monarch.Transaction
@@ -212,9 +257,16 @@ func (h *AmazonHandler) ProcessOrder(
 				"reason", validation.Reason,
 				"bank_sum", validation.BankChargesSum,
 				"expected", validation.ExpectedSum,
-				"difference", validation.Difference)
+				"difference", validation.Difference,
+				"discovery_error", discoverErr)
 			result.Skipped = true
 			result.SkipReason = validation.Reason
+			switch {
+			case errors.Is(discoverErr, matcher.ErrAmbiguousSubset):
+				result.SkipReason = "several combinations of Monarch transactions match the order total"
+			case errors.Is(discoverErr, matcher.ErrSubsetSearchLimit):
+				result.SkipReason = "too many Monarch transactions to search for a combination matching the order total"
+			}
 			return result, nil
 		}
 
@@ -304,7 +356,29 @@ func (h *AmazonHandler) ProcessOrder(
 		return result, nil
 	}
 
-	// Step 5: Consolidate multi-transaction matches
+	// Step 5: Consolidate multi-transaction matches, or leave each charge
+	// intact and split it by its shipment's items
+	if consolidatedTxn == nil && len(matchedTxns) > 1 && h.multiChargeMode == MultiChargeSplit {
+		charges := make([]orderCharge, len(matchedTxns))
+		for i, txn := range matchedTxns {
+			amount := math.Abs(txn.Amount)
+			if !monarchDiscovered && i < len(bankCharges) {
+				amount = bankCharges[i]
+			}
+			charges[i] = orderCharge{amount: amount, transaction: txn}
+		}
+		h.logInfo("Splitting each charge by shipment",
+			"order_id", order.GetID(),
+			"transaction_count", len(matchedTxns))
+		return splitChargesByShipment(ctx, chargeSplitRequest{
+			splitter:       h.splitter,
+			monarch:        h.monarch,
+			logger:         h.logger,
+			order:          order,
+			charges:        charges,
+			itemsForCharge: order.GetItemsForCharge,
+		}, catCategories, dryRun)
+	}
 	if consolidatedTxn == nil {
 		if len(matchedTxns) > 1 {
 			consolidationResult, err := h.consolidator.ConsolidateTransactions(ctx, matchedTxns, order, dryRun)
@@ -469,6 +543,11 @@ func (b *bankChargeOrder) GetTotal() float64 {
 	return b.bankCharge
 }
 
+// GetPaymentCards keeps the wrapped order's cards visible to the matcher
+func (b *bankChargeOrder) GetPaymentCards() []providers.PaymentCard {
+	return providers.OrderPaymentCards(b.Order)
+}
+
 // allocatedAmazonOrder wraps an Amazon order with allocated item prices
 type allocatedAmazonOrder struct {
 	providers.Order
@@ -7,6 +7,7 @@ import (
 	"math"
 	"strings"
 
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	amazonprovider "github.com/eshaffer321/itemize/internal/adapters/providers/amazon"
 	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/domain/matcher"
@@ -15,6 +16,13 @@ import (
 
 const temporaryAmazonCategory = "[TEMP] Amazon"
 
+// SetPurchaseHistory lets refunds take the categories their items were
+// charged to when the original order was processed. Without it, returned
+// items are categorized by the splitter like any other order.
+func (h *AmazonHandler) SetPurchaseHistory(history PurchaseHistory) {
+	h.purchases = history
+}
+
 // ProcessRefund categorizes one directly reported Amazon refund only when its
 // item and matching Monarch credit are both unambiguous.
 func (h *AmazonHandler) ProcessRefund(
@@ -46,7 +54,15 @@ func (h *AmazonHandler) ProcessRefund(
 		result.Skipped = true
 		result.SkipReason = "Amazon did not report an RMA ID"
 		return result, nil
-	case len(record.Items) != 1:
+	}
+
+	// Several returned items can only be split when the original purchase
+	// says what each was charged to and for how much
+	order, fromPurchase, err := h.withPurchaseCategories(refund)
+	if err != nil {
+		return nil, err
+	}
+	if len(record.Items) == 0 || (len(record.Items) > 1 && !fromPurchase) {
 		result.Skipped = true
 		result.SkipReason = "multiple returned items lack an authoritative item-level refund allocation"
 		return result, nil
@@ -70,13 +86,13 @@ func (h *AmazonHandler) ProcessRefund(
 	}
 
 	transaction := matchResult.Transaction
-	splits, err := h.splitter.CreateSplits(ctx, refund, transaction, catCategories, monarchCategories)
+	splits, err := h.splitter.CreateSplits(ctx, order, transaction, catCategories, monarchCategories)
 	if err != nil {
 		return nil, fmt.Errorf("amazon refund split creation error: %w", err)
 	}
 	result.Splits = splits
 	if splits == nil {
-		categoryID, notes, categoryErr := h.splitter.GetSingleCategoryInfo(ctx, refund, catCategories)
+		categoryID, notes, categoryErr := h.splitter.GetSingleCategoryInfo(ctx, order, catCategories)
 		if categoryErr != nil {
 			return nil, fmt.Errorf("amazon refund category error: %w", categoryErr)
 		}
@@ -115,6 +131,7 @@ func (h *AmazonHandler) ProcessRefund(
 		"transaction_id", transaction.ID,
 		"refund_amount", record.RefundAmount,
 		"item_asin", record.Items[0].ASIN,
+		"purchase_categories", fromPurchase,
 		"dry_run", dryRun)
 	return result, nil
 }
@@ -166,14 +183,18 @@ func (h *AmazonHandler) ProcessRefundGroup(
 	categoryID := ""
 	categoryName := ""
 	for _, refund := range refunds {
-		splits, err := h.splitter.CreateSplits(ctx, refund, candidates[0], catCategories, monarchCategories)
+		order, _, err := h.withPurchaseCategories(refund)
+		if err != nil {
+			return nil, "", err
+		}
+		splits, err := h.splitter.CreateSplits(ctx, order, candidates[0], catCategories, monarchCategories)
 		if err != nil {
 			return nil, "", fmt.Errorf("amazon refund group categorization failed: %w", err)
 		}
 		if splits != nil {
 			return nil, "returned item did not resolve to one category", nil
 		}
-		itemCategoryID, notes, err := h.splitter.GetSingleCategoryInfo(ctx, refund, catCategories)
+		itemCategoryID, notes, err := h.splitter.GetSingleCategoryInfo(ctx, order, catCategories)
 		if err != nil {
 			return nil, "", fmt.Errorf("amazon refund group category failed: %w", err)
 		}
@@ -235,6 +256,52 @@ func (h *AmazonHandler) ProcessRefundGroup(
 	return results, "", nil
 }
 
+// withPurchaseCategories pins each returned item to the category it was
+// charged to in the original order. When every item is found, the refund
+// credit is shared out by the items' purchase prices, so each category gets
+// back the same share of tax it was charged; fromPurchase reports this.
+// Items the original order doesn't account for are left to the splitter's
+// categorizer, as is the whole refund when the order wasn't processed.
+func (h *AmazonHandler) withPurchaseCategories(refund *amazonprovider.RefundOrder) (providers.Order, bool, error) {
+	record := refund.Record()
+	items := refund.GetItems()
+	pinned, purchases, err := pinPurchaseCategories(h.purchases, record.OrderID, items)
+	if err != nil {
+		return nil, false, fmt.Errorf("amazon refund purchase lookup error: %w", err)
+	}
+	found := countPurchased(purchases)
+	if found == 0 {
+		return refund, false, nil
+	}
+
+	fromPurchase := found == len(items) && len(items) > 0
+	if fromPurchase && len(items) > 1 {
+		prices := make([]float64, len(items))
+		priceTotal := 0.0
+		for i, purchase := range purchases {
+			prices[i] = purchase.Price
+			if prices[i] <= 0 && i < len(record.Items) {
+				prices[i] = record.Items[i].Price
+			}
+			if prices[i] <= 0 {
+				fromPurchase = false
+			}
+			priceTotal += prices[i]
+		}
+		if fromPurchase {
+			for i := range pinned {
+				pinned[i].(*purchasedRefundItem).price = record.RefundAmount * prices[i] / priceTotal
+			}
+		}
+	}
+	h.logDebug("Found original purchase categories for Amazon refund",
+		"order_id", record.OrderID,
+		"rma_id", record.RMAID,
+		"items_found", found,
+		"item_count", len(items))
+	return &purchasedRefundOrder{Order: refund, items: pinned}, fromPurchase, nil
+}
+
 func eligibleAmazonRefundTransactions(monarchTxns []*monarch.Transaction) []*monarch.Transaction {
 	eligible := make([]*monarch.Transaction, 0, len(monarchTxns))
 	for _, tx := range monarchTxns {
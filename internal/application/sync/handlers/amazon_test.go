@@ -246,6 +246,58 @@ func TestAmazonHandler_ProcessOrder_InvalidCharges(t *testing.T) {
 	assert.Contains(t, result.SkipReason, "less than expected")
 }
 
+func TestAmazonHandler_ProcessOrder_AmbiguousDiscovery(t *testing.T) {
+	// Two pairs of same-day charges both sum to the order total
+	orderDate := time.Now()
+	order := &mockAmazonOrder{
+		id:            "test-ambiguous",
+		date:          orderDate,
+		total:         25.00,
+		items:         []providers.OrderItem{&mockItem{name: "Item", price: 25.00}},
+		bankCharges:   []float64{10.00},
+		nonBankAmount: 0,
+	}
+	monarchTxns := []*monarch.Transaction{
+		{ID: "a", Amount: -10.00, Date: monarch.Date{Time: orderDate}},
+		{ID: "b", Amount: -15.00, Date: monarch.Date{Time: orderDate}},
+		{ID: "c", Amount: -12.00, Date: monarch.Date{Time: orderDate}},
+		{ID: "d", Amount: -13.00, Date: monarch.Date{Time: orderDate}},
+	}
+
+	handler := NewAmazonHandler(matcher.NewMatcher(matcher.DefaultConfig()), nil, nil, nil, nil)
+
+	result, err := handler.ProcessOrder(context.Background(), order, monarchTxns, make(map[string]bool), nil, nil, false)
+
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Equal(t, "several combinations of Monarch transactions match the order total", result.SkipReason)
+}
+
+func TestAmazonHandler_ProcessOrder_SubsetSearchLimitSkipsOrder(t *testing.T) {
+	orderDate := time.Now()
+	order := &mockAmazonOrder{
+		id:          "test-search-limit",
+		date:        orderDate,
+		total:       25.00,
+		items:       []providers.OrderItem{&mockItem{name: "Item", price: 25.00}},
+		bankCharges: []float64{10.00},
+	}
+	monarchTxns := []*monarch.Transaction{
+		{ID: "a", Amount: -10.00, Date: monarch.Date{Time: orderDate}},
+		{ID: "b", Amount: -15.00, Date: monarch.Date{Time: orderDate}},
+	}
+	config := matcher.DefaultConfig()
+	config.SubsetMaxStates = 1
+
+	handler := NewAmazonHandler(matcher.NewMatcher(config), nil, nil, nil, nil)
+
+	result, err := handler.ProcessOrder(context.Background(), order, monarchTxns, make(map[string]bool), nil, nil, false)
+
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Equal(t, "too many Monarch transactions to search for a combination matching the order total", result.SkipReason)
+}
+
 func TestAmazonHandler_ProcessOrder_MissingTransactions(t *testing.T) {
 	order := &mockAmazonOrder{
 		id:            "test-order",
@@ -580,6 +632,66 @@ func TestAmazonHandler_ProcessRefundSkipsMultipleItemsWithoutAllocation(t *testi
 	assert.Contains(t, result.SkipReason, "multiple returned items")
 }
 
+// stubPurchaseHistory implements PurchaseHistory
+type stubPurchaseHistory struct {
+	items    []PurchasedItem
+	bySKU    map[string]PurchasedItem
+	err      error
+	orderIDs []string
+	skus     []string
+}
+
+func (s *stubPurchaseHistory) PurchasedItems(orderID string) ([]PurchasedItem, error) {
+	s.orderIDs = append(s.orderIDs, orderID)
+	return s.items, s.err
+}
+
+func (s *stubPurchaseHistory) LatestPurchases(_ string, skus []string, _ time.Time) (map[string]PurchasedItem, error) {
+	s.skus = append(s.skus, skus...)
+	return s.bySKU, s.err
+}
+
+func TestAmazonHandler_ProcessRefundPinsPurchaseCategories(t *testing.T) {
+	issuedAt := time.Date(2026, time.July, 3, 0, 0, 0, 0, time.UTC)
+	refund := amazonprovider.NewRefundOrder(amazonprovider.ReturnRecord{
+		OrderID:        "112-1111111-2222222",
+		RMAID:          "DpinnedRRMA",
+		RefundAmount:   30.00,
+		HasRefundTotal: true,
+		RefundIssuedAt: &issuedAt,
+		Items: []amazonprovider.ReturnedItem{
+			{ASIN: "B0EXAMPLE1", Name: "Item one", Price: 10},
+			{ASIN: "B0EXAMPLE2", Name: "Item two"},
+		},
+	})
+	temporary := &monarch.TransactionCategory{ID: "temp-amazon", Name: "[TEMP] Amazon"}
+	transactions := []*monarch.Transaction{{ID: "credit", Amount: 30, Date: toMonarchDate(issuedAt), Category: temporary}}
+	splitter := &mockSplitter{splits: []*monarch.TransactionSplit{{Amount: 12}, {Amount: 18}}}
+	handler := NewAmazonHandler(matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}), nil, splitter, &mockMonarch{}, nil)
+	handler.SetPurchaseHistory(&stubPurchaseHistory{items: []PurchasedItem{
+		{SKU: "B0EXAMPLE1", Name: "Item one", Price: 10, CategoryID: "kid-needs"},
+		{SKU: "B0EXAMPLE2", Name: "Item two", Price: 15, CategoryID: "electronics"},
+	}})
+
+	result, err := handler.ProcessRefund(context.Background(), refund, transactions, map[string]bool{}, nil, nil, true)
+
+	require.NoError(t, err)
+	assert.True(t, result.Processed)
+	require.NotNil(t, splitter.lastOrder)
+	items := splitter.lastOrder.GetItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, "kid-needs", providers.PinnedCategory(items[0]))
+	assert.Equal(t, "electronics", providers.PinnedCategory(items[1]))
+	assert.InDelta(t, 12.00, items[0].GetPrice(), 0.001)
+	assert.InDelta(t, 18.00, items[1].GetPrice(), 0.001)
+
+	handler.SetPurchaseHistory(&stubPurchaseHistory{err: errors.New("database locked")})
+	result, err = handler.ProcessRefund(context.Background(), refund, transactions, map[string]bool{}, nil, nil, true)
+	assert.Nil(t, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "purchase lookup")
+}
+
 func TestAmazonHandler_ProcessRefundValidatesAuthoritativeFields(t *testing.T) {
 	issuedAt := time.Date(2026, time.July, 3, 0, 0, 0, 0, time.UTC)
 	tests := []struct {
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/validator"
+)
+
+// paymentBreakdown returns how an order was paid, or nil when the order
+// doesn't report it.
+func paymentBreakdown(order providers.Order) (*providers.PaymentBreakdown, error) {
+	withBreakdown, ok := order.(providers.PaymentBreakdownOrder)
+	if !ok {
+		return nil, nil
+	}
+	return withBreakdown.GetPaymentBreakdown()
+}
+
+// validateBreakdown checks the bank charges of a payment breakdown against
+// its total less its non-bank payments.
+func validateBreakdown(breakdown *providers.PaymentBreakdown) *validator.ChargeValidation {
+	nonBank := make([]validator.NonBankAmount, 0, len(breakdown.NonBank))
+	for _, payment := range breakdown.NonBank {
+		nonBank = append(nonBank, validator.NonBankAmount{Method: payment.Method, Amount: payment.Amount})
+	}
+	return validator.ValidatePayments(breakdown.BankCharges, breakdown.Total, nonBank)
+}
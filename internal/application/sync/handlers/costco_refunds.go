@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	costcoprovider "github.com/eshaffer321/itemize/internal/adapters/providers/costco"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// CostcoRefundHandler categorizes the Monarch credit for a Costco return.
+// Returned items take the categories they were charged to when they were
+// bought, so a refund offsets the same budget lines as the purchase: items of
+// an online order are looked up in that order, and items on a warehouse
+// refund receipt, which doesn't say which receipt they were bought on, by
+// item number in the latest earlier Costco purchase. Items with no processed
+// purchase go to the splitter's categorizer.
+type CostcoRefundHandler struct {
+	matcher   *matcher.Matcher
+	splitter  CategorySplitter
+	monarch   MonarchClient
+	purchases PurchaseHistory
+	logger    *slog.Logger
+}
+
+// NewCostcoRefundHandler creates a new Costco refund handler
+func NewCostcoRefundHandler(
+	matcher *matcher.Matcher,
+	splitter CategorySplitter,
+	monarch MonarchClient,
+	logger *slog.Logger,
+) *CostcoRefundHandler {
+	return &CostcoRefundHandler{
+		matcher:  matcher,
+		splitter: splitter,
+		monarch:  monarch,
+		logger:   logger,
+	}
+}
+
+// SetPurchaseHistory lets refunds take the categories their items were charged
+// to when they were bought.
+func (h *CostcoRefundHandler) SetPurchaseHistory(history PurchaseHistory) {
+	h.purchases = history
+}
+
+// ProcessRefund matches a Costco refund to a unique Monarch credit and
+// categorizes it, splitting across categories when the returned items span
+// several. Refunds without a reported amount, or without item prices when a
+// split is needed, are skipped rather than guessed.
+func (h *CostcoRefundHandler) ProcessRefund(
+	ctx context.Context,
+	refund *costcoprovider.RefundOrder,
+	monarchTxns []*monarch.Transaction,
+	usedTxnIDs map[string]bool,
+	catCategories []categorizer.Category,
+	monarchCategories []*monarch.TransactionCategory,
+	dryRun bool,
+) (*ProcessResult, error) {
+	result := &ProcessResult{}
+	switch {
+	case refund == nil:
+		result.Skipped = true
+		result.SkipReason = "missing Costco refund"
+		return result, nil
+	case !refund.HasRefundAmount():
+		result.Skipped = true
+		result.SkipReason = "Costco did not report a refund total"
+		return result, nil
+	case len(refund.GetItems()) == 0:
+		result.Skipped = true
+		result.SkipReason = "Costco did not report the returned items"
+		return result, nil
+	}
+
+	transaction, skipReason, err := h.matchCredit(refund, monarchTxns, usedTxnIDs)
+	if err != nil {
+		return nil, err
+	}
+	if transaction == nil {
+		result.Skipped = true
+		result.SkipReason = skipReason
+		return result, nil
+	}
+
+	order, err := h.withPurchaseCategories(refund)
+	if err != nil {
+		return nil, err
+	}
+	splits, err := h.splitter.CreateSplits(ctx, order, transaction, catCategories, monarchCategories)
+	if err != nil {
+		return nil, fmt.Errorf("costco refund split creation error: %w", err)
+	}
+	if splits != nil && !refund.HasItemPrices() {
+		result.Skipped = true
+		result.SkipReason = "returned items span several categories but Costco did not report their prices"
+		return result, nil
+	}
+	result.Splits = splits
+
+	if splits == nil {
+		categoryID, notes, categoryErr := h.splitter.GetSingleCategoryInfo(ctx, order, catCategories)
+		if categoryErr != nil {
+			return nil, fmt.Errorf("costco refund category error: %w", categoryErr)
+		}
+		if categoryID == "" {
+			result.Skipped = true
+			result.SkipReason = "categorizer did not return a valid Monarch category"
+			return result, nil
+		}
+		result.CategoryID = categoryID
+		result.MonarchNotes = notes
+		if idx := strings.Index(notes, ":"); idx > 0 {
+			result.CategoryName = notes[:idx]
+		}
+		if !dryRun {
+			if updateErr := h.monarch.UpdateTransaction(ctx, transaction.ID, &monarch.UpdateTransactionParams{
+				CategoryID: &categoryID,
+				Notes:      &notes,
+			}); updateErr != nil {
+				return nil, fmt.Errorf("costco refund transaction update error: %w", updateErr)
+			}
+		}
+	} else if !dryRun {
+		if updateErr := h.monarch.UpdateSplits(ctx, transaction.ID, splits); updateErr != nil {
+			return nil, fmt.Errorf("costco refund split update error: %w", updateErr)
+		}
+	}
+
+	usedTxnIDs[transaction.ID] = true
+	result.Transaction = transaction
+	result.Processed = true
+	h.logInfo("Categorized Costco refund",
+		"refund_id", refund.GetID(),
+		"transaction_id", transaction.ID,
+		"refund_amount", refund.RefundAmount(),
+		"split_count", len(splits),
+		"dry_run", dryRun)
+	return result, nil
+}
+
+// withPurchaseCategories pins the items of a refund to the categories they
+// were charged to when bought: in the online order the refund came from, or,
+// for a warehouse refund, the latest earlier purchase of each item number.
+func (h *CostcoRefundHandler) withPurchaseCategories(refund *costcoprovider.RefundOrder) (providers.Order, error) {
+	var pinned []providers.OrderItem
+	var purchases []*PurchasedItem
+	var err error
+	if refund.IsOnline() {
+		pinned, purchases, err = pinPurchaseCategories(h.purchases, refund.SourceID(), refund.GetItems())
+	} else {
+		pinned, purchases, err = pinLatestPurchaseCategories(h.purchases, refund.GetProviderName(), refund.GetDate(), refund.GetItems())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("costco refund purchase lookup error: %w", err)
+	}
+	found := countPurchased(purchases)
+	if found == 0 {
+		return refund, nil
+	}
+	h.logDebug("Found original purchase categories for Costco refund",
+		"refund_id", refund.GetID(),
+		"items_found", found,
+		"item_count", len(pinned))
+	return &purchasedRefundOrder{Order: refund, items: pinned}, nil
+}
+
+// matchCredit finds the one Monarch credit for a refund. Warehouse refunds
+// are dated and go through the matcher; online refunds have no issue date, so
+// any credit for the exact amount on or after the order date is a candidate.
+func (h *CostcoRefundHandler) matchCredit(
+	refund *costcoprovider.RefundOrder,
+	monarchTxns []*monarch.Transaction,
+	usedTxnIDs map[string]bool,
+) (*monarch.Transaction, string, error) {
+	eligible := eligibleRefundCredits(monarchTxns)
+	amount := refund.RefundAmount()
+
+	if refund.IsOnline() {
+		var found *monarch.Transaction
+		orderDate := refund.GetDate().Format("2006-01-02")
+		for _, tx := range eligible {
+			if usedTxnIDs[tx.ID] || math.Abs(tx.Amount-amount) > 0.011 || tx.Date.Format("2006-01-02") < orderDate {
+				continue
+			}
+			if found != nil {
+				return nil, fmt.Sprintf("ambiguous Costco refund credit for $%.2f", amount), nil
+			}
+			found = tx
+		}
+		if found == nil {
+			return nil, fmt.Sprintf("no Costco credit found for $%.2f", amount), nil
+		}
+		return found, "", nil
+	}
+
+	matchResult, err := h.matcher.FindUniqueMatch(refund, eligible, usedTxnIDs)
+	if errors.Is(err, matcher.ErrAmbiguousMatch) {
+		return nil, fmt.Sprintf("ambiguous Costco refund credit for $%.2f on %s", amount, refund.GetDate().Format("2006-01-02")), nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("costco refund match error: %w", err)
+	}
+	if matchResult == nil {
+		return nil, fmt.Sprintf("no Costco credit found for $%.2f", amount), nil
+	}
+	return matchResult.Transaction, "", nil
+}
+
+// eligibleRefundCredits keeps posted, unsplit credits.
+func eligibleRefundCredits(monarchTxns []*monarch.Transaction) []*monarch.Transaction {
+	eligible := make([]*monarch.Transaction, 0, len(monarchTxns))
+	for _, tx := range monarchTxns {
+		if tx == nil || tx.Pending || tx.Amount <= 0 || tx.HasSplits {
+			continue
+		}
+		eligible = append(eligible, tx)
+	}
+	return eligible
+}
+
+func (h *CostcoRefundHandler) logInfo(msg string, args ...any) {
+	if h.logger != nil {
+		h.logger.Info(msg, args...)
+	}
+}
+
+func (h *CostcoRefundHandler) logDebug(msg string, args ...any) {
+	if h.logger != nil {
+		h.logger.Debug(msg, args...)
+	}
+}
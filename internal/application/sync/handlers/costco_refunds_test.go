@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	costcogo "github.com/eshaffer321/costco-go/pkg/costco"
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	costcoprovider "github.com/eshaffer321/itemize/internal/adapters/providers/costco"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTestCostcoRefundHandler(splitter *simpleTestSplitter, monarchClient *simpleTestMonarch) *CostcoRefundHandler {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	return NewCostcoRefundHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		splitter,
+		monarchClient,
+		logger,
+	)
+}
+
+func returnedOnlineTestOrder(status string) *costcoprovider.RefundOrder {
+	return costcoprovider.NewOnlineRefundOrder(&costcogo.OnlineOrder{
+		OrderNumber:     "1100223344",
+		OrderPlacedDate: "2026-02-20T09:15:00",
+		OrderTotal:      89.99,
+		Status:          status,
+		OrderLineItems: []costcogo.OrderLineItem{
+			{ItemNumber: "100799", ItemDescription: "Sunbeam Heated Throw", Status: "Returned"},
+			{ItemNumber: "100800", ItemDescription: "Throw Pillow", Status: "Delivered"},
+		},
+	})
+}
+
+func TestCostcoRefundHandler_OnlineRefundMatchesLaterCredit(t *testing.T) {
+	splitter := &simpleTestSplitter{categoryID: "home", notes: "Home:\n- Sunbeam Heated Throw $89.99"}
+	monarchClient := &simpleTestMonarch{}
+	handler := createTestCostcoRefundHandler(splitter, monarchClient)
+
+	refund := returnedOnlineTestOrder("Returned")
+	txns := []*monarch.Transaction{
+		{ID: "BEFORE", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 2, 10, 0, 0, 0, 0, time.UTC))},
+		{ID: "CREDIT", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC))},
+		{ID: "CHARGE", Amount: -89.99, Date: simpleToMonarchDate(time.Date(2026, 2, 21, 0, 0, 0, 0, time.UTC))},
+	}
+	used := map[string]bool{}
+
+	result, err := handler.ProcessRefund(context.Background(), refund, txns, used, nil, nil, false)
+	require.NoError(t, err)
+	require.True(t, result.Processed, result.SkipReason)
+	assert.Equal(t, "CREDIT", result.Transaction.ID, "online refunds post weeks after the order, never before it")
+	assert.Equal(t, "home", result.CategoryID)
+	assert.Equal(t, "Home", result.CategoryName)
+	assert.True(t, monarchClient.updateCalled)
+	assert.True(t, used["CREDIT"])
+}
+
+func TestCostcoRefundHandler_OnlineRefundPinsPurchaseCategories(t *testing.T) {
+	splitter := &mockSplitter{categoryID: "home", notes: "Home:\n- Sunbeam Heated Throw $89.99"}
+	handler := NewCostcoRefundHandler(matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}), splitter, &mockMonarch{}, nil)
+	history := &stubPurchaseHistory{items: []PurchasedItem{
+		{SKU: "100799", Name: "Sunbeam Heated Throw", Price: 69.99, CategoryID: "gifts"},
+		{SKU: "100800", Name: "Throw Pillow", Price: 20.00, CategoryID: "home"},
+	}}
+	handler.SetPurchaseHistory(history)
+	txns := []*monarch.Transaction{{ID: "CREDIT", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC))}}
+
+	result, err := handler.ProcessRefund(context.Background(), returnedOnlineTestOrder("Returned"), txns, map[string]bool{}, nil, nil, true)
+	require.NoError(t, err)
+	require.True(t, result.Processed, result.SkipReason)
+	assert.Equal(t, []string{"1100223344"}, history.orderIDs, "an online refund looks up the order it came from")
+	require.NotNil(t, splitter.lastOrder)
+	items := splitter.lastOrder.GetItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, "gifts", providers.PinnedCategory(items[0]))
+	assert.Equal(t, "home", providers.PinnedCategory(items[1]))
+}
+
+func TestCostcoRefundHandler_WarehouseRefundPinsLatestPurchaseCategories(t *testing.T) {
+	splitter := &mockSplitter{categoryID: "home", notes: "Home:\n- KS PAPER TOWEL $24.99"}
+	handler := NewCostcoRefundHandler(matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}), splitter, &mockMonarch{}, nil)
+	history := &stubPurchaseHistory{bySKU: map[string]PurchasedItem{
+		"1147521": {SKU: "1147521", Name: "KS PAPER TOWEL", Price: 24.99, CategoryID: "household"},
+	}}
+	handler.SetPurchaseHistory(history)
+	refund := costcoprovider.NewRefundOrder(&costcogo.Receipt{
+		TransactionDateTime: "2026-03-14T11:42:00",
+		TransactionDate:     "2026-03-14",
+		TransactionType:     "Refund",
+		TransactionBarcode:  "21000100500112603141142",
+		Total:               -52.97,
+		SubTotal:            -49.98,
+		Taxes:               -2.99,
+		ItemArray: []costcogo.ReceiptItem{
+			{ItemNumber: "1147521", ItemDescription01: "KS PAPER TOWEL", Unit: -1, Amount: -24.99},
+			{ItemNumber: "1234567", ItemDescription01: "DURACELL AAA", Unit: -1, Amount: -28.99},
+		},
+	})
+	txns := []*monarch.Transaction{{ID: "CREDIT", Amount: 52.97, Date: simpleToMonarchDate(time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC))}}
+
+	result, err := handler.ProcessRefund(context.Background(), refund, txns, map[string]bool{}, nil, nil, true)
+	require.NoError(t, err)
+	require.True(t, result.Processed, result.SkipReason)
+	assert.Empty(t, history.orderIDs, "a refund receipt names no purchase receipt")
+	assert.Equal(t, []string{"1147521", "1234567"}, history.skus)
+	require.NotNil(t, splitter.lastOrder)
+	items := splitter.lastOrder.GetItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, "household", providers.PinnedCategory(items[0]))
+	assert.Empty(t, providers.PinnedCategory(items[1]), "never bought in a processed order")
+}
+
+func TestCostcoRefundHandler_OnlineRefundAmbiguousCredit(t *testing.T) {
+	handler := createTestCostcoRefundHandler(&simpleTestSplitter{categoryID: "home"}, &simpleTestMonarch{})
+	txns := []*monarch.Transaction{
+		{ID: "CREDIT-1", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))},
+		{ID: "CREDIT-2", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC))},
+	}
+
+	result, err := handler.ProcessRefund(context.Background(), returnedOnlineTestOrder("Returned"), txns, map[string]bool{}, nil, nil, false)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Contains(t, result.SkipReason, "ambiguous")
+}
+
+func TestCostcoRefundHandler_SkipsPartialOnlineReturnWithoutAmount(t *testing.T) {
+	monarchClient := &simpleTestMonarch{}
+	handler := createTestCostcoRefundHandler(&simpleTestSplitter{categoryID: "home"}, monarchClient)
+
+	result, err := handler.ProcessRefund(context.Background(), returnedOnlineTestOrder("Delivered"), nil, map[string]bool{}, nil, nil, false)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Equal(t, "Costco did not report a refund total", result.SkipReason)
+	assert.False(t, monarchClient.updateCalled)
+}
+
+func TestCostcoRefundHandler_SkipsSplitWithoutItemPrices(t *testing.T) {
+	splitter := &simpleTestSplitter{splits: []*monarch.TransactionSplit{{Amount: 40}, {Amount: 49.99}}}
+	monarchClient := &simpleTestMonarch{}
+	handler := createTestCostcoRefundHandler(splitter, monarchClient)
+
+	refund := costcoprovider.NewOnlineRefundOrder(&costcogo.OnlineOrder{
+		OrderNumber:     "1100223399",
+		OrderPlacedDate: "2026-02-20T09:15:00",
+		OrderTotal:      89.99,
+		Status:          "Returned",
+		OrderLineItems: []costcogo.OrderLineItem{
+			{ItemDescription: "Heated Throw"},
+			{ItemDescription: "Throw Pillow"},
+		},
+	})
+	txns := []*monarch.Transaction{{ID: "CREDIT", Amount: 89.99, Date: simpleToMonarchDate(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))}}
+
+	result, err := handler.ProcessRefund(context.Background(), refund, txns, map[string]bool{}, nil, nil, false)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Contains(t, result.SkipReason, "did not report their prices")
+	assert.False(t, monarchClient.updateSplitsCaled)
+}
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/allocator"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// MultiChargeMode selects how an order paid with several bank charges (a
+// multi-delivery Walmart or Amazon order) is applied to its transactions.
+type MultiChargeMode string
+
+const (
+	// MultiChargeConsolidate rewrites the first transaction to the order
+	// total and deletes the other charges. This is the default.
+	MultiChargeConsolidate MultiChargeMode = "consolidate"
+	// MultiChargeSplit leaves every bank charge intact and splits each one
+	// by the items in its shipment, so transactions still match statements.
+	MultiChargeSplit MultiChargeMode = "split"
+)
+
+// ParseMultiChargeMode parses a configured multi-charge mode. An empty value
+// selects MultiChargeConsolidate.
+func ParseMultiChargeMode(value string) (MultiChargeMode, error) {
+	switch mode := MultiChargeMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case "", MultiChargeConsolidate:
+		return MultiChargeConsolidate, nil
+	case MultiChargeSplit:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown multi-charge mode %q (expected %q or %q)", value, MultiChargeConsolidate, MultiChargeSplit)
+}
+
+// ChargeSplitter is implemented by splitters that can split a single charge
+// of an order with the categories assigned to the whole order, so the order
+// is categorized once however many charges it has.
+type ChargeSplitter interface {
+	CategorizeOrder(ctx context.Context, order providers.Order, categories []categorizer.Category) ([]categorizer.ItemCategorization, error)
+	SplitWithCategorizations(order providers.Order, transaction *monarch.Transaction, categorizations []categorizer.ItemCategorization) ([]*monarch.TransactionSplit, string, string, error)
+}
+
+// ChargeProcessResult describes one bank charge of a multi-charge order
+// categorized on its own transaction (MultiChargeSplit).
+type ChargeProcessResult struct {
+	Amount       float64
+	Transaction  *monarch.Transaction
+	Splits       []*monarch.TransactionSplit
+	CategoryID   string
+	CategoryName string
+	MonarchNotes string
+}
+
+// orderCharge pairs a bank charge with the Monarch transaction it matched
+type orderCharge struct {
+	amount      float64
+	transaction *monarch.Transaction
+}
+
+// chargeSplitRequest holds what splitChargesByShipment needs from a handler
+type chargeSplitRequest struct {
+	splitter CategorySplitter
+	monarch  MonarchClient
+	logger   *slog.Logger
+
+	order   providers.Order
+	charges []orderCharge
+	// itemsForCharge returns the items shipped with a charge; nil when the
+	// provider has no per-shipment data
+	itemsForCharge func(chargeAmount float64) []providers.OrderItem
+}
+
+// splitChargesByShipment applies each charge of a multi-charge order to its
+// own transaction instead of consolidating them. The order is categorized
+// once; each transaction is then categorized (or split) by the items of its
+// shipment, allocated pro-rata to the charge amount, and its notes name the
+// sibling charges. When the shipments don't account for every item exactly
+// once, each charge lists all of the order's items and says so.
+func splitChargesByShipment(ctx context.Context, req chargeSplitRequest, catCategories []categorizer.Category, dryRun bool) (*ProcessResult, error) {
+	chargeSplitter, ok := req.splitter.(ChargeSplitter)
+	if !ok {
+		return nil, fmt.Errorf("splitter cannot split individual charges")
+	}
+
+	orderItems := req.order.GetItems()
+	if len(orderItems) == 0 {
+		return nil, fmt.Errorf("order %s has no items to split", req.order.GetID())
+	}
+	categorizations, err := chargeSplitter.CategorizeOrder(ctx, req.order, catCategories)
+	if err != nil {
+		return nil, fmt.Errorf("categorization error: %w", err)
+	}
+
+	indexes, partitioned := shipmentItemIndexes(orderItems, req.charges, req.itemsForCharge)
+	if !partitioned {
+		logWith(req.logger).Warn("Shipments don't partition the order's items; listing every item on each charge",
+			"order_id", req.order.GetID(),
+			"charge_count", len(req.charges))
+	}
+
+	result := &ProcessResult{}
+	for i, charge := range req.charges {
+		chargeResult, err := splitCharge(ctx, req, chargeSplitter, i, indexes[i], categorizations, !partitioned, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		result.Charges = append(result.Charges, *chargeResult)
+		logWith(req.logger).Debug("Applied charge",
+			"order_id", req.order.GetID(),
+			"transaction_id", charge.transaction.ID,
+			"amount", charge.amount,
+			"split_count", len(chargeResult.Splits),
+			"dry_run", dryRun)
+	}
+
+	first := result.Charges[0]
+	result.Transaction = first.Transaction
+	result.Splits = first.Splits
+	result.CategoryID = first.CategoryID
+	result.CategoryName = first.CategoryName
+	result.MonarchNotes = first.MonarchNotes
+	result.Processed = true
+	return result, nil
+}
+
+// splitCharge categorizes one charge's transaction by the order items at
+// indexes and applies it to Monarch.
+func splitCharge(
+	ctx context.Context,
+	req chargeSplitRequest,
+	chargeSplitter ChargeSplitter,
+	chargeIdx int,
+	indexes []int,
+	categorizations []categorizer.ItemCategorization,
+	allItems bool,
+	dryRun bool,
+) (*ChargeProcessResult, error) {
+	charge := req.charges[chargeIdx]
+	orderItems := req.order.GetItems()
+
+	allocItems := make([]allocator.Item, len(indexes))
+	chargeCategorizations := make([]categorizer.ItemCategorization, len(indexes))
+	for i, idx := range indexes {
+		allocItems[i] = allocator.Item{Name: orderItems[idx].GetName(), ListPrice: orderItems[idx].GetPrice()}
+		chargeCategorizations[i] = categorizations[idx]
+	}
+	allocResult, err := allocator.Allocate(allocItems, charge.amount)
+	if err != nil {
+		return nil, fmt.Errorf("allocation error for charge %.2f: %w", charge.amount, err)
+	}
+
+	items := make([]providers.OrderItem, len(indexes))
+	for i, idx := range indexes {
+		items[i] = &chargeItem{OrderItem: orderItems[idx], price: allocResult.Allocations[i].AllocatedCost}
+	}
+	chargeView := &chargeOrder{
+		Order:  req.order,
+		id:     fmt.Sprintf("%s#%d", req.order.GetID(), chargeIdx+1),
+		amount: charge.amount,
+		items:  items,
+	}
+
+	splits, categoryID, notes, err := chargeSplitter.SplitWithCategorizations(chargeView, charge.transaction, chargeCategorizations)
+	if err != nil {
+		return nil, fmt.Errorf("split creation error for charge %.2f: %w", charge.amount, err)
+	}
+
+	reference := chargeReference(req.order.GetID(), req.charges, chargeIdx, allItems)
+	result := &ChargeProcessResult{
+		Amount:      charge.amount,
+		Transaction: charge.transaction,
+		Splits:      splits,
+	}
+	if splits == nil {
+		result.CategoryID = categoryID
+		result.CategoryName = chargeCategorizations[0].CategoryName
+		result.MonarchNotes = notes + "\n\n" + reference
+	} else {
+		result.MonarchNotes = reference
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	reviewed := false
+	params := &monarch.UpdateTransactionParams{
+		Notes:       &result.MonarchNotes,
+		NeedsReview: &reviewed,
+	}
+	if splits != nil {
+		if err := req.monarch.UpdateSplits(ctx, charge.transaction.ID, splits); err != nil {
+			return nil, fmt.Errorf("update splits error: %w", err)
+		}
+	} else if categoryID != "" {
+		params.CategoryID = &categoryID
+	}
+	if err := req.monarch.UpdateTransaction(ctx, charge.transaction.ID, params); err != nil {
+		return nil, fmt.Errorf("update transaction error: %w", err)
+	}
+	return result, nil
+}
+
+// shipmentItemIndexes maps each charge's shipment items back to the order's
+// items by name and price. partitioned is false, and every charge gets every
+// item, unless the shipments account for each order item exactly once.
+func shipmentItemIndexes(
+	orderItems []providers.OrderItem,
+	charges []orderCharge,
+	itemsForCharge func(chargeAmount float64) []providers.OrderItem,
+) (indexes [][]int, partitioned bool) {
+	all := make([]int, len(orderItems))
+	for i := range all {
+		all[i] = i
+	}
+	shared := func() ([][]int, bool) {
+		indexes := make([][]int, len(charges))
+		for i := range indexes {
+			indexes[i] = all
+		}
+		return indexes, false
+	}
+	if itemsForCharge == nil {
+		return shared()
+	}
+
+	used := make([]bool, len(orderItems))
+	indexes = make([][]int, len(charges))
+	for i, charge := range charges {
+		shipment := itemsForCharge(charge.amount)
+		if len(shipment) == 0 {
+			return shared()
+		}
+		for _, item := range shipment {
+			idx := findOrderItem(orderItems, used, item)
+			if idx < 0 {
+				return shared()
+			}
+			used[idx] = true
+			indexes[i] = append(indexes[i], idx)
+		}
+	}
+	for _, u := range used {
+		if !u {
+			return shared()
+		}
+	}
+	return indexes, true
+}
+
+// findOrderItem returns the index of the first unused order item with the
+// same name as item, preferring one with the same price, or -1.
+func findOrderItem(orderItems []providers.OrderItem, used []bool, item providers.OrderItem) int {
+	nameMatch := -1
+	for i, candidate := range orderItems {
+		if used[i] || candidate.GetName() != item.GetName() {
+			continue
+		}
+		if math.Abs(candidate.GetPrice()-item.GetPrice()) < 0.01 {
+			return i
+		}
+		if nameMatch < 0 {
+			nameMatch = i
+		}
+	}
+	return nameMatch
+}
+
+// chargeReference describes a charge's place among its order's charges, so
+// each transaction can be traced to its siblings on the statement.
+func chargeReference(orderID string, charges []orderCharge, chargeIdx int, allItems bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Order %s charge %d of %d ($%.2f)", orderID, chargeIdx+1, len(charges), math.Abs(charges[chargeIdx].amount))
+
+	siblings := make([]string, 0, len(charges)-1)
+	for i, charge := range charges {
+		if i == chargeIdx {
+			continue
+		}
+		sibling := fmt.Sprintf("$%.2f", math.Abs(charge.amount))
+		if charge.transaction != nil {
+			if !charge.transaction.Date.IsZero() {
+				sibling += " on " + charge.transaction.Date.Format("2006-01-02")
+			}
+			sibling += " (" + charge.transaction.ID + ")"
+		}
+		siblings = append(siblings, sibling)
+	}
+	if len(siblings) > 0 {
+		fmt.Fprintf(&b, "\nOther charges: %s", strings.Join(siblings, ", "))
+	}
+	if allItems {
+		b.WriteString("\nShipment items unavailable; items listed for the whole order")
+	}
+	return b.String()
+}
+
+// chargeOrder presents one bank charge of an order as an order of its own:
+// the charge's shipment items, allocated to the charge amount (tax included)
+type chargeOrder struct {
+	providers.Order
+	id     string
+	amount float64
+	items  []providers.OrderItem
+}
+
+func (c *chargeOrder) GetID() string                   { return c.id }
+func (c *chargeOrder) GetTotal() float64               { return c.amount }
+func (c *chargeOrder) GetSubtotal() float64            { return c.amount }
+func (c *chargeOrder) GetTax() float64                 { return 0 }
+func (c *chargeOrder) GetTip() float64                 { return 0 }
+func (c *chargeOrder) GetFees() float64                { return 0 }
+func (c *chargeOrder) GetItems() []providers.OrderItem { return c.items }
+
+// chargeItem is an order item with its cost allocated to a charge
+type chargeItem struct {
+	providers.OrderItem
+	price float64
+}
+
+func (i *chargeItem) GetPrice() float64 { return i.price }
+
+func (i *chargeItem) GetUnitPrice() float64 {
+	if quantity := i.GetQuantity(); quantity > 0 {
+		return i.price / quantity
+	}
+	return i.price
+}
+
+// logWith returns logger, or a logger that discards output when it is nil
+func logWith(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return logger
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// chargeTestCategorizer categorizes items by name and counts its calls
+type chargeTestCategorizer struct {
+	byName map[string]categorizer.Category
+	calls  int
+}
+
+func (c *chargeTestCategorizer) CategorizeItems(ctx context.Context, items []categorizer.Item, categories []categorizer.Category) (*categorizer.CategorizationResult, error) {
+	c.calls++
+	result := &categorizer.CategorizationResult{}
+	for _, item := range items {
+		category := c.byName[item.Name]
+		result.Categorizations = append(result.Categorizations, categorizer.ItemCategorization{
+			ItemName:     item.Name,
+			CategoryID:   category.ID,
+			CategoryName: category.Name,
+			Confidence:   0.9,
+		})
+	}
+	return result, nil
+}
+
+// chargeTestMonarch records the updates made to each transaction
+type chargeTestMonarch struct {
+	params map[string]*monarch.UpdateTransactionParams
+	splits map[string][]*monarch.TransactionSplit
+}
+
+func newChargeTestMonarch() *chargeTestMonarch {
+	return &chargeTestMonarch{
+		params: make(map[string]*monarch.UpdateTransactionParams),
+		splits: make(map[string][]*monarch.TransactionSplit),
+	}
+}
+
+func (m *chargeTestMonarch) UpdateTransaction(ctx context.Context, id string, params *monarch.UpdateTransactionParams) error {
+	m.params[id] = params
+	return nil
+}
+
+func (m *chargeTestMonarch) UpdateSplits(ctx context.Context, id string, splits []*monarch.TransactionSplit) error {
+	m.splits[id] = splits
+	return nil
+}
+
+// shipmentAmazonOrder is an Amazon order whose charges each map to a shipment
+type shipmentAmazonOrder struct {
+	mockAmazonOrder
+	shipments map[float64][]providers.OrderItem
+}
+
+func (o *shipmentAmazonOrder) GetItemsForCharge(chargeAmount float64) []providers.OrderItem {
+	if items, ok := o.shipments[chargeAmount]; ok {
+		return items
+	}
+	return o.items
+}
+
+var chargeTestCategories = map[string]categorizer.Category{
+	"Book":  {ID: "cat_books", Name: "Books"},
+	"Milk":  {ID: "cat_groceries", Name: "Groceries"},
+	"Soap":  {ID: "cat_household", Name: "Household"},
+	"Bread": {ID: "cat_groceries", Name: "Groceries"},
+}
+
+func TestParseMultiChargeMode(t *testing.T) {
+	for value, expected := range map[string]MultiChargeMode{
+		"":            MultiChargeConsolidate,
+		"consolidate": MultiChargeConsolidate,
+		" Split ":     MultiChargeSplit,
+		"split":       MultiChargeSplit,
+	} {
+		mode, err := ParseMultiChargeMode(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, mode, value)
+	}
+
+	_, err := ParseMultiChargeMode("merge")
+	assert.ErrorContains(t, err, `unknown multi-charge mode "merge"`)
+}
+
+func TestAmazonHandler_ProcessOrder_SplitModeSplitsEachShipment(t *testing.T) {
+	orderDate := time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC)
+	order := &shipmentAmazonOrder{
+		mockAmazonOrder: mockAmazonOrder{
+			id:       "112-0000000-0000001",
+			date:     orderDate,
+			total:    48.60,
+			subtotal: 45.00,
+			tax:      3.60,
+			items: []providers.OrderItem{
+				&mockItem{name: "Book", price: 20.00},
+				&mockItem{name: "Milk", price: 10.00},
+				&mockItem{name: "Soap", price: 15.00},
+			},
+			bankCharges: []float64{21.60, 27.00},
+		},
+		shipments: map[float64][]providers.OrderItem{
+			21.60: {&mockItem{name: "Book", price: 20.00}},
+			27.00: {&mockItem{name: "Milk", price: 10.00}, &mockItem{name: "Soap", price: 15.00}},
+		},
+	}
+	txns := []*monarch.Transaction{
+		{ID: "txn-1", Amount: -21.60, Date: toMonarchDate(orderDate)},
+		{ID: "txn-2", Amount: -27.00, Date: toMonarchDate(orderDate.AddDate(0, 0, 2))},
+	}
+
+	cat := &chargeTestCategorizer{byName: chargeTestCategories}
+	monarchClient := newChargeTestMonarch()
+	handler := NewAmazonHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		&mockConsolidator{err: assert.AnError},
+		splitter.NewSplitter(cat),
+		monarchClient,
+		nil,
+	)
+	handler.SetMultiChargeMode(MultiChargeSplit)
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, false)
+
+	require.NoError(t, err)
+	require.True(t, result.Processed)
+	assert.Equal(t, 1, cat.calls, "the order is categorized once")
+	require.Len(t, result.Charges, 2)
+	assert.Equal(t, "txn-1", result.Transaction.ID)
+
+	first := monarchClient.params["txn-1"]
+	require.NotNil(t, first, "first charge is kept and categorized")
+	require.NotNil(t, first.CategoryID)
+	assert.Equal(t, "cat_books", *first.CategoryID)
+	assert.Contains(t, *first.Notes, "Books:\n- Book $21.60")
+	assert.Contains(t, *first.Notes, "Order 112-0000000-0000001 charge 1 of 2 ($21.60)")
+	assert.Contains(t, *first.Notes, "Other charges: $27.00 on 2026-05-11 (txn-2)")
+
+	splits := monarchClient.splits["txn-2"]
+	require.Len(t, splits, 2, "second shipment spans two categories")
+	total := 0.0
+	for _, split := range splits {
+		total += split.Amount
+	}
+	assert.InDelta(t, -27.00, total, 0.001)
+	require.NotNil(t, monarchClient.params["txn-2"])
+	assert.Contains(t, *monarchClient.params["txn-2"].Notes, "charge 2 of 2 ($27.00)")
+	assert.Contains(t, *monarchClient.params["txn-2"].Notes, "$21.60 on 2026-05-09 (txn-1)")
+}
+
+func TestAmazonHandler_ProcessOrder_ConsolidatesByDefault(t *testing.T) {
+	order := &mockAmazonOrder{
+		id:          "112-0000000-0000002",
+		date:        time.Now(),
+		total:       30.00,
+		subtotal:    30.00,
+		items:       []providers.OrderItem{&mockItem{name: "Milk", price: 30.00}},
+		bankCharges: []float64{10.00, 20.00},
+	}
+	txns := []*monarch.Transaction{
+		{ID: "txn-1", Amount: -10.00, Date: toMonarchDate(time.Now())},
+		{ID: "txn-2", Amount: -20.00, Date: toMonarchDate(time.Now())},
+	}
+	consolidator := &mockConsolidator{result: &ConsolidationResult{
+		ConsolidatedTransaction: &monarch.Transaction{ID: "txn-1", Amount: -30.00},
+	}}
+	handler := NewAmazonHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		consolidator,
+		&mockSplitter{categoryID: "cat_groceries", notes: "Groceries"},
+		&mockMonarch{},
+		nil,
+	)
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, true)
+
+	require.NoError(t, err)
+	assert.True(t, result.Processed)
+	assert.Empty(t, result.Charges)
+	assert.InDelta(t, -30.00, result.Transaction.Amount, 0.001)
+}
+
+func TestWalmartHandler_ProcessOrder_SplitModeWithoutDeliveryItems(t *testing.T) {
+	orderDate := time.Date(2026, 5, 9, 0, 0, 0, 0, time.UTC)
+	order := &walmartTestOrder{
+		id:       "WM-SPLIT",
+		date:     orderDate,
+		total:    150.00,
+		subtotal: 140.00,
+		tax:      10.00,
+		items: []providers.OrderItem{
+			&walmartTestItem{name: "Milk", price: 40.00, quantity: 2},
+			&walmartTestItem{name: "Bread", price: 100.00, quantity: 1},
+		},
+		charges:        []float64{80.00, 70.00},
+		isMultiDeliver: true,
+	}
+	txns := []*monarch.Transaction{
+		{ID: "txn-1", Amount: -80.00, Date: walmartToMonarchDate(orderDate)},
+		{ID: "txn-2", Amount: -70.00, Date: walmartToMonarchDate(orderDate.AddDate(0, 0, 1))},
+	}
+
+	cat := &chargeTestCategorizer{byName: chargeTestCategories}
+	monarchClient := newChargeTestMonarch()
+	consolidator := &walmartTestConsolidator{err: assert.AnError}
+	handler := NewWalmartHandler(
+		matcher.NewMatcher(matcher.Config{AmountTolerance: 0.01, DateTolerance: 5}),
+		consolidator,
+		splitter.NewSplitter(cat),
+		monarchClient,
+		nil,
+	)
+	handler.SetMultiChargeMode(MultiChargeSplit)
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, false)
+
+	require.NoError(t, err)
+	require.True(t, result.Processed)
+	assert.Nil(t, consolidator.receivedTransactions, "charges are not consolidated")
+	require.Len(t, result.Charges, 2)
+	assert.InDelta(t, 80.00, result.Charges[0].Amount, 0.001)
+	assert.InDelta(t, 70.00, result.Charges[1].Amount, 0.001)
+
+	for _, id := range []string{"txn-1", "txn-2"} {
+		params := monarchClient.params[id]
+		require.NotNil(t, params, id)
+		assert.Equal(t, "cat_groceries", *params.CategoryID)
+		assert.Contains(t, *params.Notes, "Shipment items unavailable; items listed for the whole order")
+	}
+	assert.Contains(t, *monarchClient.params["txn-2"].Notes, "- Bread $50.00", "items are allocated to the charge amount")
+}
+
+func TestShipmentItemIndexes(t *testing.T) {
+	orderItems := []providers.OrderItem{
+		&mockItem{name: "Milk", price: 5.00},
+		&mockItem{name: "Milk", price: 5.00},
+		&mockItem{name: "Soap", price: 3.00},
+	}
+	charges := []orderCharge{{amount: 5.40}, {amount: 8.64}}
+
+	t.Run("shipments that partition the items", func(t *testing.T) {
+		shipments := map[float64][]providers.OrderItem{
+			5.40: {&mockItem{name: "Milk", price: 5.00}},
+			8.64: {&mockItem{name: "Milk", price: 5.00}, &mockItem{name: "Soap", price: 3.00}},
+		}
+
+		indexes, partitioned := shipmentItemIndexes(orderItems, charges, func(amount float64) []providers.OrderItem {
+			return shipments[amount]
+		})
+
+		assert.True(t, partitioned)
+		assert.Equal(t, [][]int{{0}, {1, 2}}, indexes)
+	})
+
+	t.Run("shipments that overlap fall back to every item", func(t *testing.T) {
+		indexes, partitioned := shipmentItemIndexes(orderItems, charges, func(float64) []providers.OrderItem {
+			return orderItems
+		})
+
+		assert.False(t, partitioned)
+		assert.Equal(t, [][]int{{0, 1, 2}, {0, 1, 2}}, indexes)
+	})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+)
+
+// PurchaseHistory looks up how the items of an already processed order were
+// categorized.
+type PurchaseHistory interface {
+	// PurchasedItems returns the order's items with the categories they were
+	// charged to, or nil when the order hasn't been processed.
+	PurchasedItems(orderID string) ([]PurchasedItem, error)
+
+	// LatestPurchases returns, keyed by SKU, each item as last bought from
+	// provider in a processed order dated before the given time. It serves
+	// refunds that don't say which order they came from.
+	LatestPurchases(provider string, skus []string, before time.Time) (map[string]PurchasedItem, error)
+}
+
+// PurchasedItem is an item of a processed order and the Monarch category it
+// was charged to.
+type PurchasedItem struct {
+	SKU        string
+	Name       string
+	Price      float64
+	CategoryID string
+}
+
+// pinPurchaseCategories looks up the order a refund came from and pins each
+// returned item to the category it was charged to there. purchases holds the
+// purchase found for each item, or nil where the order doesn't account for
+// it. Unpinned items, and every item of an order that wasn't processed, are
+// left to the splitter's categorizer.
+func pinPurchaseCategories(history PurchaseHistory, orderID string, items []providers.OrderItem) ([]providers.OrderItem, []*PurchasedItem, error) {
+	if history == nil || orderID == "" {
+		return items, nil, nil
+	}
+	purchased, err := history.PurchasedItems(orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(purchased) == 0 {
+		return items, nil, nil
+	}
+
+	pinned := make([]providers.OrderItem, len(items))
+	purchases := make([]*PurchasedItem, len(items))
+	for i, item := range items {
+		purchase := findPurchasedItem(purchased, item)
+		if purchase == nil {
+			pinned[i] = item
+			continue
+		}
+		purchases[i] = purchase
+		pinned[i] = &purchasedRefundItem{OrderItem: item, price: item.GetPrice(), categoryID: purchase.CategoryID}
+	}
+	return pinned, purchases, nil
+}
+
+// pinLatestPurchaseCategories pins each returned item to the category its SKU
+// was charged to the last time it was bought from provider before the refund.
+// Items without a SKU, or never bought in a processed order, are left to the
+// splitter's categorizer.
+func pinLatestPurchaseCategories(history PurchaseHistory, provider string, before time.Time, items []providers.OrderItem) ([]providers.OrderItem, []*PurchasedItem, error) {
+	if history == nil {
+		return items, nil, nil
+	}
+	var skus []string
+	for _, item := range items {
+		if sku := item.GetSKU(); sku != "" {
+			skus = append(skus, sku)
+		}
+	}
+	if len(skus) == 0 {
+		return items, nil, nil
+	}
+	latest, err := history.LatestPurchases(provider, skus, before)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(latest) == 0 {
+		return items, nil, nil
+	}
+
+	pinned := make([]providers.OrderItem, len(items))
+	purchases := make([]*PurchasedItem, len(items))
+	for i, item := range items {
+		purchase, found := latest[item.GetSKU()]
+		if item.GetSKU() == "" || !found {
+			pinned[i] = item
+			continue
+		}
+		purchases[i] = &purchase
+		pinned[i] = &purchasedRefundItem{OrderItem: item, price: item.GetPrice(), categoryID: purchase.CategoryID}
+	}
+	return pinned, purchases, nil
+}
+
+// countPurchased returns how many items were found in their original order.
+func countPurchased(purchases []*PurchasedItem) int {
+	found := 0
+	for _, purchase := range purchases {
+		if purchase != nil {
+			found++
+		}
+	}
+	return found
+}
+
+// findPurchasedItem finds a returned item among an order's purchased items by
+// SKU, or by name for records stored without one.
+func findPurchasedItem(purchased []PurchasedItem, item providers.OrderItem) *PurchasedItem {
+	if sku := item.GetSKU(); sku != "" {
+		for i := range purchased {
+			if strings.EqualFold(purchased[i].SKU, sku) {
+				return &purchased[i]
+			}
+		}
+	}
+	name := strings.ToLower(strings.TrimSpace(item.GetName()))
+	if name == "" {
+		return nil
+	}
+	for i := range purchased {
+		if strings.ToLower(strings.TrimSpace(purchased[i].Name)) == name {
+			return &purchased[i]
+		}
+	}
+	return nil
+}
+
+// purchasedRefundOrder is a refund whose items carry the categories they were
+// bought under.
+type purchasedRefundOrder struct {
+	providers.Order
+	items []providers.OrderItem
+}
+
+func (o *purchasedRefundOrder) GetItems() []providers.OrderItem { return o.items }
+
+// purchasedRefundItem is a returned item pinned to its purchase category,
+// priced at its share of the refund credit.
+type purchasedRefundItem struct {
+	providers.OrderItem
+	price      float64
+	categoryID string
+}
+
+func (i *purchasedRefundItem) GetPrice() float64      { return i.price }
+func (i *purchasedRefundItem) GetUnitPrice() float64  { return i.price }
+func (i *purchasedRefundItem) PinnedCategory() string { return i.categoryID }
@@ -51,7 +51,26 @@ func (h *SimpleHandler) ProcessOrder(
 ) (*ProcessResult, error) {
 	result := &ProcessResult{}
 
-	// Step 1: Match transaction using order total
+	// Step 1: Check the card charges against the total less non-bank tenders
+	breakdown, err := paymentBreakdown(order)
+	if err != nil {
+		return nil, fmt.Errorf("payment breakdown error: %w", err)
+	}
+	if breakdown != nil {
+		result.ChargeValidation = validateBreakdown(breakdown)
+		if !result.ChargeValidation.Valid {
+			h.logWarn("Skipping order - bank charges don't add up",
+				"order_id", order.GetID(),
+				"reason", result.ChargeValidation.Reason,
+				"bank_sum", result.ChargeValidation.BankChargesSum,
+				"expected", result.ChargeValidation.ExpectedSum)
+			result.Skipped = true
+			result.SkipReason = result.ChargeValidation.Reason
+			return result, nil
+		}
+	}
+
+	// Step 2: Match transaction using order total
 	matchResult, err := h.matcher.FindMatch(order, monarchTxns, usedTxnIDs)
 	if err != nil {
 		return nil, fmt.Errorf("matching error: %w", err)
@@ -91,7 +110,7 @@ func (h *SimpleHandler) ProcessOrder(
 		"date_diff_days", matchResult.DateDiff,
 	)
 
-	// Step 2: Check if transaction already has splits
+	// Step 3: Check if transaction already has splits
 	if transaction.HasSplits {
 		h.logDebug("Transaction already has splits", "transaction_id", transaction.ID)
 		result.Skipped = true
@@ -99,7 +118,7 @@ func (h *SimpleHandler) ProcessOrder(
 		return result, nil
 	}
 
-	// Step 3: Categorize and create splits
+	// Step 4: Categorize and create splits
 	splits, err := h.splitter.CreateSplits(ctx, order, transaction, catCategories, monarchCategories)
 	if err != nil {
 		return nil, fmt.Errorf("split creation error: %w", err)
@@ -107,7 +126,7 @@ func (h *SimpleHandler) ProcessOrder(
 
 	result.Splits = splits
 
-	// Step 4: Apply to Monarch
+	// Step 5: Apply to Monarch
 	if splits == nil {
 		// Single category - update transaction category
 		return h.applySingleCategory(ctx, order, transaction, catCategories, dryRun, result)
@@ -28,6 +28,7 @@ type simpleTestOrder struct {
 	tax          float64
 	items        []providers.OrderItem
 	providerName string
+	breakdown    *providers.PaymentBreakdown
 }
 
 func (m *simpleTestOrder) GetID() string                   { return m.id }
@@ -41,6 +42,10 @@ func (m *simpleTestOrder) GetItems() []providers.OrderItem { return m.items }
 func (m *simpleTestOrder) GetProviderName() string         { return m.providerName }
 func (m *simpleTestOrder) GetRawData() interface{}         { return nil }
 
+func (m *simpleTestOrder) GetPaymentBreakdown() (*providers.PaymentBreakdown, error) {
+	return m.breakdown, nil
+}
+
 // simpleTestItem implements providers.OrderItem
 type simpleTestItem struct {
 	name     string
@@ -155,6 +160,39 @@ func TestSimpleHandler_ProcessOrder_Success(t *testing.T) {
 	assert.True(t, usedTxnIDs["txn-1"])
 }
 
+func TestSimpleHandler_ProcessOrder_ValidatesCharges(t *testing.T) {
+	splitter := &simpleTestSplitter{categoryID: "groceries", notes: "Groceries: Milk"}
+	handler := createTestSimpleHandler(t, splitter, &simpleTestMonarch{})
+
+	orderDate := time.Now()
+	order := &simpleTestOrder{
+		id:           "RECEIPT-001",
+		date:         orderDate,
+		total:        100.00,
+		providerName: "Costco",
+		items:        []providers.OrderItem{&simpleTestItem{name: "Milk", price: 100.00, quantity: 1}},
+		breakdown: &providers.PaymentBreakdown{
+			Total:       150.00,
+			BankCharges: []float64{100.00},
+			NonBank:     []providers.NonBankPayment{{Method: "SHOP CARD", Amount: 50.00}},
+		},
+	}
+	txns := []*monarch.Transaction{{ID: "txn-1", Amount: -100.00, Date: simpleToMonarchDate(orderDate)}}
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	assert.True(t, result.Processed)
+	assert.True(t, result.ChargeValidation.Valid)
+
+	// An unrecognized tender leaves the card charges short
+	order.breakdown.NonBank = nil
+	result, err = handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.False(t, result.ChargeValidation.Valid)
+	assert.Contains(t, result.SkipReason, "missing $50.00")
+}
+
 func TestSimpleHandler_ProcessOrder_NoMatch_Skipped(t *testing.T) {
 	handler := createTestSimpleHandler(t, nil, nil)
 
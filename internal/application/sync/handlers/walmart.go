@@ -4,6 +4,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -14,9 +15,17 @@ import (
 	walmartprovider "github.com/eshaffer321/itemize/internal/adapters/providers/walmart"
 	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/domain/matcher"
+	"github.com/eshaffer321/itemize/internal/domain/validator"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 )
 
+// Errors a WalmartOrder returns from GetFinalCharges (and GetRefundCharges)
+// when the order has no bank charge to match, yet or at all.
+var (
+	ErrWalmartPaymentPending = walmartprovider.ErrPaymentPending
+	ErrWalmartNoBankCharges  = walmartprovider.ErrNoBankCharges
+)
+
 // WalmartOrder extends providers.Order with Walmart-specific methods
 type WalmartOrder interface {
 	providers.Order
@@ -35,6 +44,20 @@ type WalmartOrderWithRefundItems interface {
 	GetRefundItems() ([]providers.OrderItem, error)
 }
 
+// WalmartOrderWithReturns extends WalmartOrder with the items sent back in
+// each return.
+type WalmartOrderWithReturns interface {
+	WalmartOrder
+	GetReturns() ([]walmartprovider.Return, error)
+}
+
+// WalmartOrderWithDeliveries extends WalmartOrder with the items of each
+// delivery, which is charged separately.
+type WalmartOrderWithDeliveries interface {
+	WalmartOrder
+	GetItemsForCharge(chargeAmount float64) []providers.OrderItem
+}
+
 // WalmartOrderWithLedger extends WalmartOrder with ledger access for persistence
 type WalmartOrderWithLedger interface {
 	WalmartOrder
@@ -43,13 +66,15 @@ type WalmartOrderWithLedger interface {
 
 // WalmartHandler processes Walmart orders with multi-delivery and gift card support
 type WalmartHandler struct {
-	matcher       *matcher.Matcher
-	consolidator  TransactionConsolidator
-	splitter      CategorySplitter
-	monarch       MonarchClient
-	ledgerStorage LedgerStorage
-	syncRunID     int64
-	logger        *slog.Logger
+	matcher         *matcher.Matcher
+	consolidator    TransactionConsolidator
+	splitter        CategorySplitter
+	monarch         MonarchClient
+	ledgerStorage   LedgerStorage
+	syncRunID       int64
+	multiChargeMode MultiChargeMode
+	purchases       PurchaseHistory
+	logger          *slog.Logger
 }
 
 // NewWalmartHandler creates a new Walmart order handler
@@ -75,6 +100,19 @@ func (h *WalmartHandler) SetLedgerStorage(storage LedgerStorage, syncRunID int64
 	h.syncRunID = syncRunID
 }
 
+// SetPurchaseHistory lets refunds take the categories their items were
+// charged to when the order was processed. Without it, returned items are
+// categorized by the splitter like any other order.
+func (h *WalmartHandler) SetPurchaseHistory(history PurchaseHistory) {
+	h.purchases = history
+}
+
+// SetMultiChargeMode selects how multi-delivery orders are applied:
+// consolidated into one transaction (the default) or split charge by charge.
+func (h *WalmartHandler) SetMultiChargeMode(mode MultiChargeMode) {
+	h.multiChargeMode = mode
+}
+
 // ProcessOrder processes a Walmart order
 func (h *WalmartHandler) ProcessOrder(
 	ctx context.Context,
@@ -88,25 +126,36 @@ func (h *WalmartHandler) ProcessOrder(
 	// Step 1: Get bank charges from ledger
 	bankCharges, err := order.GetFinalCharges()
 	refundCharges := h.getRefundCharges(order)
-	refundItems := h.getRefundItems(order, len(refundCharges))
+	refunds := h.getRefunds(order, refundCharges)
+
+	// Check the card charges against the total less gift cards and Walmart
+	// Cash before the ledger is saved, so the ledger records the result
+	var validation *validator.ChargeValidation
+	if err == nil {
+		validation = h.validateCharges(order, refundCharges)
+	}
 
-	h.saveLedgerIfAvailable(order)
+	h.saveLedgerIfAvailable(order, validation)
 
 	if err != nil {
 		// Check if this is a pending order (not yet charged)
-		if strings.Contains(err.Error(), "payment pending") && len(refundCharges) == 0 {
+		if errors.Is(err, ErrWalmartPaymentPending) && len(refundCharges) == 0 {
 			h.logInfo("Skipping order - not yet charged", "order_id", order.GetID())
 			result := &ProcessResult{}
 			result.Skipped = true
 			result.SkipReason = "payment pending"
 			return result, nil
 		}
-		if strings.Contains(err.Error(), "no positive charges found") && len(refundCharges) > 0 {
+		if errors.Is(err, ErrWalmartNoBankCharges) && len(refundCharges) > 0 {
 			h.logInfo("Processing refund-only order",
 				"order_id", order.GetID(),
 				"refund_count", len(refundCharges),
 				"refunds", refundCharges)
-			return h.processRefundOnlyOrder(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refundCharges, refundItems, dryRun)
+			result, err := h.processRefundOnlyOrder(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refunds, dryRun)
+			if err == nil && result != nil {
+				h.recordChargeMatches(order, refundMatches(result.Refunds), dryRun)
+			}
+			return result, err
 		}
 		// For other ledger errors, fall through to regular matching using order total
 		h.logWarn("Failed to get ledger charges, falling back to order total",
@@ -120,20 +169,33 @@ func (h *WalmartHandler) ProcessOrder(
 		"charges", bankCharges,
 		"charge_count", len(bankCharges))
 
+	if validation != nil && !validation.Valid {
+		h.logWarn("Skipping order - bank charges don't add up",
+			"order_id", order.GetID(),
+			"reason", validation.Reason,
+			"bank_sum", validation.BankChargesSum,
+			"expected", validation.ExpectedSum,
+			"difference", validation.Difference)
+		return &ProcessResult{Skipped: true, SkipReason: validation.Reason, ChargeValidation: validation}, nil
+	}
+
 	var result *ProcessResult
 	if len(bankCharges) > 1 {
 		result, err = h.processMultiDeliveryOrder(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, bankCharges, dryRun)
 	} else {
 		result, err = h.processSingleChargeOrder(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, bankCharges[0], dryRun)
 	}
-	if err != nil || len(refundCharges) == 0 || len(refundItems) == 0 {
-		if err == nil && len(refundCharges) > 0 && len(refundItems) == 0 {
-			h.logInfo("Skipping refund without item-level Walmart detail", "order_id", order.GetID())
+	if err == nil && result != nil {
+		result.ChargeValidation = validation
+		if result.Processed {
+			h.recordChargeMatches(order, paymentMatches(bankCharges, result), dryRun)
 		}
+	}
+	if err != nil || len(refunds) == 0 {
 		return result, err
 	}
 
-	refundResults, refundErr := h.processRefundCharges(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refundCharges, refundItems, dryRun)
+	refundResults, refundErr := h.processRefundCharges(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refunds, dryRun)
 	if refundErr != nil {
 		h.logWarn("Failed to process refund charges",
 			"order_id", order.GetID(),
@@ -141,6 +203,7 @@ func (h *WalmartHandler) ProcessOrder(
 		return result, nil
 	}
 	result.Refunds = refundResults
+	h.recordChargeMatches(order, refundMatches(refundResults), dryRun)
 	if result.Skipped {
 		result.Skipped = false
 		result.SkipReason = ""
@@ -153,20 +216,116 @@ func (h *WalmartHandler) ProcessOrder(
 	return result, nil
 }
 
-func (h *WalmartHandler) getRefundItems(order WalmartOrder, refundCount int) []providers.OrderItem {
-	if refundCount != 1 {
+// validateCharges checks the order's card charges against its total less gift
+// cards, Walmart Cash and other non-bank payments. Walmart may lower the total
+// after a return, so charges that add up once refunds are netted out pass too.
+func (h *WalmartHandler) validateCharges(order WalmartOrder, refundCharges []float64) *validator.ChargeValidation {
+	breakdown, err := paymentBreakdown(order)
+	if err != nil {
+		h.logWarn("Failed to get payment breakdown", "order_id", order.GetID(), "error", err)
 		return nil
 	}
-	refundOrder, ok := order.(WalmartOrderWithRefundItems)
-	if !ok {
+	if breakdown == nil {
 		return nil
 	}
-	items, err := refundOrder.GetRefundItems()
-	if err != nil {
-		h.logWarn("Failed to get refunded Walmart items", "order_id", order.GetID(), "error", err)
+
+	validation := validateBreakdown(breakdown)
+	if validation.Valid || len(refundCharges) == 0 {
+		return validation
+	}
+	net := *breakdown
+	net.BankCharges = append([]float64(nil), breakdown.BankCharges...)
+	for _, refund := range refundCharges {
+		net.BankCharges = append(net.BankCharges, -refund)
+	}
+	if netted := validateBreakdown(&net); netted.Valid {
+		return netted
+	}
+	return validation
+}
+
+// walmartRefund is a refund charge and the returned items it pays for
+type walmartRefund struct {
+	amount float64
+	items  []providers.OrderItem
+}
+
+// refundReturnTolerance is how far a refund may be from a return's expected
+// total, which estimates the tax at the order's rate.
+const refundReturnTolerance = 0.05
+
+// getRefunds pairs the order's refund charges with the returns they pay for.
+// Refunds that can't be tied to returned items are left out rather than
+// spread over the whole order.
+func (h *WalmartHandler) getRefunds(order WalmartOrder, refundCharges []float64) []walmartRefund {
+	if len(refundCharges) == 0 {
 		return nil
 	}
-	return items
+
+	var refunds []walmartRefund
+	if withReturns, ok := order.(WalmartOrderWithReturns); ok {
+		returns, err := withReturns.GetReturns()
+		if err != nil {
+			h.logWarn("Failed to get Walmart returns", "order_id", order.GetID(), "error", err)
+			return nil
+		}
+		refunds = pairRefundsWithReturns(refundCharges, returns)
+	} else if withItems, ok := order.(WalmartOrderWithRefundItems); ok && len(refundCharges) == 1 {
+		items, err := withItems.GetRefundItems()
+		if err != nil {
+			h.logWarn("Failed to get refunded Walmart items", "order_id", order.GetID(), "error", err)
+			return nil
+		}
+		if len(items) > 0 {
+			refunds = []walmartRefund{{amount: refundCharges[0], items: items}}
+		}
+	}
+
+	if len(refunds) < len(refundCharges) {
+		h.logInfo("Skipping refunds without item-level Walmart detail",
+			"order_id", order.GetID(),
+			"refund_count", len(refundCharges),
+			"identified", len(refunds))
+	}
+	return refunds
+}
+
+// pairRefundsWithReturns matches each refund charge to the unclaimed return
+// whose expected total is closest to it. A lone refund and return always
+// pair, and a lone refund covering every return at once gets all their items.
+func pairRefundsWithReturns(refundCharges []float64, returns []walmartprovider.Return) []walmartRefund {
+	if len(refundCharges) == 1 && len(returns) == 1 {
+		return []walmartRefund{{amount: refundCharges[0], items: returns[0].Items}}
+	}
+
+	claimed := make([]bool, len(returns))
+	var refunds []walmartRefund
+	for _, amount := range refundCharges {
+		best := -1
+		bestDiff := refundReturnTolerance
+		for i, ret := range returns {
+			if diff := math.Abs(ret.Total() - amount); !claimed[i] && diff <= bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+		if best >= 0 {
+			claimed[best] = true
+			refunds = append(refunds, walmartRefund{amount: amount, items: returns[best].Items})
+		}
+	}
+
+	if len(refunds) == 0 && len(refundCharges) == 1 && len(returns) > 1 {
+		total := 0.0
+		var items []providers.OrderItem
+		for _, ret := range returns {
+			total += ret.Total()
+			items = append(items, ret.Items...)
+		}
+		if math.Abs(total-refundCharges[0]) <= refundReturnTolerance {
+			refunds = []walmartRefund{{amount: refundCharges[0], items: items}}
+		}
+	}
+	return refunds
 }
 
 func (h *WalmartHandler) processSingleChargeOrder(
@@ -205,7 +364,7 @@ func (h *WalmartHandler) getRefundCharges(order WalmartOrder) []float64 {
 
 	refunds, err := refundOrder.GetRefundCharges()
 	if err != nil {
-		if strings.Contains(err.Error(), "payment pending") {
+		if errors.Is(err, ErrWalmartPaymentPending) {
 			h.logDebug("No refund charges available for pending order",
 				"order_id", order.GetID())
 			return nil
@@ -383,6 +542,14 @@ func (h *WalmartHandler) processMultiDeliveryOrder(
 		"order_id", order.GetID(),
 		"transaction_count", len(matchedTxns))
 
+	if h.multiChargeMode == MultiChargeSplit {
+		deliveryCharges := make([]orderCharge, len(matchedTxns))
+		for i, txn := range matchedTxns {
+			deliveryCharges[i] = orderCharge{amount: charges[i], transaction: txn}
+		}
+		return h.splitDeliveries(ctx, order, deliveryCharges, catCategories, dryRun)
+	}
+
 	// Consolidate transactions into one
 	consolidationResult, err := h.consolidator.ConsolidateTransactions(ctx, matchedTxns, order, dryRun)
 	if err != nil {
@@ -478,6 +645,14 @@ func (h *WalmartHandler) processMultiDeliveryAggregateFallback(
 		"ledger_total", ledgerTotal,
 		"transaction_count", len(matchedTxns))
 
+	if h.multiChargeMode == MultiChargeSplit {
+		subsetCharges := make([]orderCharge, len(matchedTxns))
+		for i, txn := range matchedTxns {
+			subsetCharges[i] = orderCharge{amount: math.Abs(txn.Amount), transaction: txn}
+		}
+		return h.splitDeliveries(ctx, order, subsetCharges, catCategories, dryRun)
+	}
+
 	consolidationResult, err := h.consolidator.ConsolidateTransactions(ctx, matchedTxns, matchOrder, dryRun)
 	if err != nil {
 		return nil, fmt.Errorf("aggregate fallback consolidation error: %w", err)
@@ -486,6 +661,32 @@ func (h *WalmartHandler) processMultiDeliveryAggregateFallback(
 	return h.categorizeAndApplySplits(ctx, order, consolidationResult.ConsolidatedTransaction, catCategories, monarchCategories, dryRun)
 }
 
+// splitDeliveries leaves each delivery's charge intact and categorizes it by
+// the items of that delivery
+func (h *WalmartHandler) splitDeliveries(
+	ctx context.Context,
+	order WalmartOrder,
+	charges []orderCharge,
+	catCategories []categorizer.Category,
+	dryRun bool,
+) (*ProcessResult, error) {
+	h.logInfo("Splitting each delivery charge by its items",
+		"order_id", order.GetID(),
+		"charge_count", len(charges))
+
+	req := chargeSplitRequest{
+		splitter: h.splitter,
+		monarch:  h.monarch,
+		logger:   h.logger,
+		order:    order,
+		charges:  charges,
+	}
+	if deliveries, ok := order.(WalmartOrderWithDeliveries); ok {
+		req.itemsForCharge = deliveries.GetItemsForCharge
+	}
+	return splitChargesByShipment(ctx, req, catCategories, dryRun)
+}
+
 func interruptedConsolidationTransactions(
 	primary *monarch.Transaction,
 	partialMatches []*matcher.MatchResult,
@@ -546,32 +747,40 @@ func hasPendingTransaction(transactions []*monarch.Transaction) bool {
 	return false
 }
 
-func (h *WalmartHandler) processRefundOnlyOrder(ctx context.Context, order WalmartOrder, monarchTxns []*monarch.Transaction, usedTxnIDs map[string]bool, catCategories []categorizer.Category, monarchCategories []*monarch.TransactionCategory, refundCharges []float64, refundItems []providers.OrderItem, dryRun bool) (*ProcessResult, error) {
+func (h *WalmartHandler) processRefundOnlyOrder(ctx context.Context, order WalmartOrder, monarchTxns []*monarch.Transaction, usedTxnIDs map[string]bool, catCategories []categorizer.Category, monarchCategories []*monarch.TransactionCategory, refunds []walmartRefund, dryRun bool) (*ProcessResult, error) {
 	result := &ProcessResult{}
-	if len(refundItems) == 0 {
+	if len(refunds) == 0 {
 		result.Skipped = true
 		result.SkipReason = "refund item not identified"
 		return result, nil
 	}
-	refunds, err := h.processRefundCharges(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refundCharges, refundItems, dryRun)
+	refundResults, err := h.processRefundCharges(ctx, order, monarchTxns, usedTxnIDs, catCategories, monarchCategories, refunds, dryRun)
 	if err != nil {
 		result.Skipped = true
 		result.SkipReason = err.Error()
 		return result, nil
 	}
 	result.Processed = true
-	result.Refunds = refunds
-	if len(refunds) > 0 {
-		result.Transaction = refunds[0].Transaction
-		result.Splits = refunds[0].Splits
+	result.Refunds = refundResults
+	if len(refundResults) > 0 {
+		result.Transaction = refundResults[0].Transaction
+		result.Splits = refundResults[0].Splits
 	}
 	return result, nil
 }
 
-func (h *WalmartHandler) processRefundCharges(ctx context.Context, order WalmartOrder, monarchTxns []*monarch.Transaction, usedTxnIDs map[string]bool, catCategories []categorizer.Category, monarchCategories []*monarch.TransactionCategory, refundCharges []float64, refundItems []providers.OrderItem, dryRun bool) ([]RefundProcessResult, error) {
-	refunds := make([]RefundProcessResult, 0, len(refundCharges))
-	for _, amount := range refundCharges {
-		refundOrder := newRefundOrderView(order, amount, refundItems)
+// processRefundCharges matches each refund to its credit and splits it by the
+// categories of the items returned, as they were charged when the order was
+// processed
+func (h *WalmartHandler) processRefundCharges(ctx context.Context, order WalmartOrder, monarchTxns []*monarch.Transaction, usedTxnIDs map[string]bool, catCategories []categorizer.Category, monarchCategories []*monarch.TransactionCategory, walmartRefunds []walmartRefund, dryRun bool) ([]RefundProcessResult, error) {
+	refunds := make([]RefundProcessResult, 0, len(walmartRefunds))
+	for _, refund := range walmartRefunds {
+		amount := refund.amount
+		items, purchases, err := pinPurchaseCategories(h.purchases, order.GetID(), refund.items)
+		if err != nil {
+			return refunds, fmt.Errorf("refund purchase lookup error: %w", err)
+		}
+		refundOrder := newRefundOrderView(order, amount, items)
 		matchResult, err := h.matcher.FindMatch(refundOrder, monarchTxns, usedTxnIDs)
 		if err != nil {
 			return refunds, fmt.Errorf("refund match error: %w", err)
@@ -581,6 +790,12 @@ func (h *WalmartHandler) processRefundCharges(ctx context.Context, order Walmart
 		}
 		usedTxnIDs[matchResult.Transaction.ID] = true
 		h.logInfo("Matched refund transaction", "order_id", order.GetID(), "transaction_id", matchResult.Transaction.ID, "refund_amount", amount, "date_diff_days", matchResult.DateDiff)
+		if found := countPurchased(purchases); found > 0 {
+			h.logDebug("Found original purchase categories for Walmart refund",
+				"order_id", order.GetID(),
+				"items_found", found,
+				"item_count", len(items))
+		}
 		refundResult, err := h.categorizeAndApplySplits(ctx, refundOrder, matchResult.Transaction, catCategories, monarchCategories, dryRun)
 		if err != nil {
 			return refunds, fmt.Errorf("refund split creation error: %w", err)
@@ -667,6 +882,11 @@ func (l *ledgerAmountOrder) GetTotal() float64 {
 	return l.ledgerAmount
 }
 
+// GetPaymentCards keeps the wrapped order's cards visible to the matcher
+func (l *ledgerAmountOrder) GetPaymentCards() []providers.PaymentCard {
+	return providers.OrderPaymentCards(l.Order)
+}
+
 type refundOrderView struct {
 	WalmartOrder
 	refundAmount float64
@@ -677,7 +897,7 @@ func newRefundOrderView(order WalmartOrder, refundAmount float64, items []provid
 	return &refundOrderView{
 		WalmartOrder: order,
 		refundAmount: refundAmount,
-		items:        scaleRefundItems(items, refundAmount),
+		items:        allocateRefund(items, refundAmount),
 	}
 }
 
@@ -705,30 +925,39 @@ func (r *refundOrderView) GetItems() []providers.OrderItem {
 	return r.items
 }
 
-func scaleRefundItems(items []providers.OrderItem, refundAmount float64) []providers.OrderItem {
-	if len(items) == 0 {
-		return []providers.OrderItem{refundOrderItem{name: "Walmart refund", price: math.Abs(refundAmount), quantity: 1}}
-	}
+// GetPaymentCards returns the cards of the original order, which Walmart
+// refunds to
+func (r *refundOrderView) GetPaymentCards() []providers.PaymentCard {
+	return providers.OrderPaymentCards(r.WalmartOrder)
+}
 
+// allocateRefund spreads a refund over the returned items by price, so the
+// tax refunded with each item stays with it
+func allocateRefund(items []providers.OrderItem, refundAmount float64) []providers.OrderItem {
+	prices := make([]float64, len(items))
 	itemTotal := 0.0
-	for _, item := range items {
-		itemTotal += math.Abs(item.GetPrice())
-	}
-	if itemTotal == 0 {
-		return []providers.OrderItem{refundOrderItem{name: "Walmart refund", price: math.Abs(refundAmount), quantity: 1}}
+	for i, item := range items {
+		prices[i] = math.Abs(item.GetPrice())
+		if prices[i] == 0 {
+			prices[i] = math.Abs(item.GetUnitPrice() * item.GetQuantity())
+		}
+		itemTotal += prices[i]
 	}
 
-	scaled := make([]providers.OrderItem, 0, len(items))
-	for _, item := range items {
-		scaledPrice := math.Abs(item.GetPrice()) / itemTotal * math.Abs(refundAmount)
-		scaled = append(scaled, refundOrderItem{
+	allocated := make([]providers.OrderItem, 0, len(items))
+	for i, item := range items {
+		share := 1 / float64(len(items))
+		if itemTotal > 0 {
+			share = prices[i] / itemTotal
+		}
+		allocated = append(allocated, refundOrderItem{
 			original: item,
 			name:     item.GetName(),
-			price:    scaledPrice,
+			price:    share * math.Abs(refundAmount),
 			quantity: item.GetQuantity(),
 		})
 	}
-	return scaled
+	return allocated
 }
 
 type refundOrderItem struct {
@@ -775,6 +1004,12 @@ func (i refundOrderItem) GetSKU() string {
 	return i.original.GetSKU()
 }
 
+// PinnedCategory implements providers.PinnedCategoryItem, passing on the
+// purchase category of the returned item
+func (i refundOrderItem) PinnedCategory() string {
+	return providers.PinnedCategory(i.original)
+}
+
 func (i refundOrderItem) GetCategory() string {
 	if i.original == nil {
 		return ""
@@ -816,8 +1051,9 @@ func (h *WalmartHandler) logWarn(msg string, args ...any) {
 	}
 }
 
-// saveLedgerIfAvailable extracts and saves ledger data if storage is configured
-func (h *WalmartHandler) saveLedgerIfAvailable(order WalmartOrder) {
+// saveLedgerIfAvailable extracts and saves ledger data if storage is configured.
+// A failed charge validation marks the saved ledger invalid.
+func (h *WalmartHandler) saveLedgerIfAvailable(order WalmartOrder, validation *validator.ChargeValidation) {
 	// Skip if no storage configured
 	if h.ledgerStorage == nil {
 		return
@@ -838,6 +1074,10 @@ func (h *WalmartHandler) saveLedgerIfAvailable(order WalmartOrder) {
 
 	// Convert the raw ledger to LedgerData
 	ledgerData := h.convertToLedgerData(order.GetID(), rawLedger)
+	if validation != nil && !validation.Valid {
+		ledgerData.IsValid = false
+		ledgerData.ValidationNotes = validation.Reason
+	}
 
 	// Save it
 	if err := h.ledgerStorage.SaveLedger(ledgerData, h.syncRunID); err != nil {
@@ -851,6 +1091,52 @@ func (h *WalmartHandler) saveLedgerIfAvailable(order WalmartOrder) {
 	}
 }
 
+// recordChargeMatches writes the transactions an order's ledger charges
+// matched back to the saved ledger
+func (h *WalmartHandler) recordChargeMatches(order WalmartOrder, matches []ChargeMatch, dryRun bool) {
+	if h.ledgerStorage == nil || dryRun || len(matches) == 0 {
+		return
+	}
+	if err := h.ledgerStorage.RecordChargeMatches(order.GetID(), matches); err != nil {
+		h.logWarn("Failed to record ledger charge matches",
+			"order_id", order.GetID(),
+			"error", err)
+	}
+}
+
+// paymentMatches pairs the bank charges of a processed order with the
+// transactions they ended up in: in split mode each charge's own
+// transaction, otherwise the order's (possibly consolidated) transaction.
+func paymentMatches(bankCharges []float64, result *ProcessResult) []ChargeMatch {
+	var matches []ChargeMatch
+	if len(result.Charges) > 0 {
+		for _, charge := range result.Charges {
+			if charge.Transaction != nil {
+				matches = append(matches, ChargeMatch{Amount: charge.Amount, TransactionID: charge.Transaction.ID, SplitCount: len(charge.Splits)})
+			}
+		}
+		return matches
+	}
+	if result.Transaction == nil {
+		return nil
+	}
+	for _, amount := range bankCharges {
+		matches = append(matches, ChargeMatch{Amount: amount, TransactionID: result.Transaction.ID, SplitCount: len(result.Splits)})
+	}
+	return matches
+}
+
+// refundMatches pairs refund charges with their refund transactions
+func refundMatches(refunds []RefundProcessResult) []ChargeMatch {
+	var matches []ChargeMatch
+	for _, refund := range refunds {
+		if refund.Transaction != nil {
+			matches = append(matches, ChargeMatch{Amount: -math.Abs(refund.Amount), TransactionID: refund.Transaction.ID, SplitCount: len(refund.Splits)})
+		}
+	}
+	return matches
+}
+
 // convertToLedgerData converts raw Walmart ledger to the handler's LedgerData format
 func (h *WalmartHandler) convertToLedgerData(orderID string, rawLedger interface{}) *LedgerData {
 	ledgerData := &LedgerData{
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	walmartprovider "github.com/eshaffer321/itemize/internal/adapters/providers/walmart"
 	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/domain/matcher"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
@@ -69,6 +70,16 @@ func (m *walmartTestOrder) IsMultiDelivery() (bool, error) {
 	return m.isMultiDeliver, nil
 }
 
+// walmartReturnsTestOrder adds Walmart's returns to a test order
+type walmartReturnsTestOrder struct {
+	*walmartTestOrder
+	returns []walmartprovider.Return
+}
+
+func (m *walmartReturnsTestOrder) GetReturns() ([]walmartprovider.Return, error) {
+	return m.returns, nil
+}
+
 // walmartTestItem implements providers.OrderItem
 type walmartTestItem struct {
 	name     string
@@ -99,6 +110,7 @@ type walmartSplitterCall struct {
 	transactionAmount float64
 	itemTotal         float64
 	itemNames         []string
+	pinned            []string
 }
 
 func (m *walmartTestSplitter) CreateSplits(ctx context.Context, order providers.Order, transaction *monarch.Transaction, catCategories []categorizer.Category, monarchCategories []*monarch.TransactionCategory) ([]*monarch.TransactionSplit, error) {
@@ -107,9 +119,11 @@ func (m *walmartTestSplitter) CreateSplits(ctx context.Context, order providers.
 	}
 	itemTotal := 0.0
 	itemNames := make([]string, 0, len(order.GetItems()))
+	pinned := make([]string, 0, len(order.GetItems()))
 	for _, item := range order.GetItems() {
 		itemTotal += item.GetPrice()
 		itemNames = append(itemNames, item.GetName())
+		pinned = append(pinned, providers.PinnedCategory(item))
 	}
 	m.calls = append(m.calls, walmartSplitterCall{
 		orderTotal:        order.GetTotal(),
@@ -117,6 +131,7 @@ func (m *walmartTestSplitter) CreateSplits(ctx context.Context, order providers.
 		transactionAmount: transaction.Amount,
 		itemTotal:         itemTotal,
 		itemNames:         itemNames,
+		pinned:            pinned,
 	})
 	return m.splits, nil
 }
@@ -167,6 +182,26 @@ func (m *walmartTestConsolidator) ConsolidateTransactions(ctx context.Context, t
 // Test Helpers
 // =============================================================================
 
+// walmartTestLedgerStorage records the saved ledgers and the charge matches
+// written back to them
+type walmartTestLedgerStorage struct {
+	ledgers []*LedgerData
+	matches map[string][]ChargeMatch
+}
+
+func (m *walmartTestLedgerStorage) SaveLedger(ledger *LedgerData, syncRunID int64) error {
+	m.ledgers = append(m.ledgers, ledger)
+	return nil
+}
+
+func (m *walmartTestLedgerStorage) RecordChargeMatches(orderID string, matches []ChargeMatch) error {
+	if m.matches == nil {
+		m.matches = make(map[string][]ChargeMatch)
+	}
+	m.matches[orderID] = append(m.matches[orderID], matches...)
+	return nil
+}
+
 func walmartToMonarchDate(t time.Time) monarch.Date {
 	return monarch.Date{Time: t}
 }
@@ -234,7 +269,7 @@ func TestWalmartHandler_ProcessOrder_PendingOrder_Skipped(t *testing.T) {
 		id:         "ORDER-PENDING",
 		date:       time.Now(),
 		total:      50.00,
-		chargesErr: errors.New("order not yet charged (payment pending)"),
+		chargesErr: ErrWalmartPaymentPending,
 	}
 
 	result, err := handler.ProcessOrder(
@@ -378,6 +413,164 @@ func TestWalmartHandler_ProcessOrder_ProcessesRefundCharge(t *testing.T) {
 	assert.InDelta(t, 5.58, splitter.calls[1].itemTotal, 0.01, "refund item prices should be scaled to the refund amount")
 }
 
+func TestWalmartHandler_ProcessOrder_SplitsEachRefundByItsReturn(t *testing.T) {
+	splitter := &walmartTestSplitter{categoryID: "groceries", notes: "Groceries"}
+	handler := createTestWalmartHandler(t, splitter, nil, &walmartTestMonarch{})
+	history := &stubPurchaseHistory{items: []PurchasedItem{
+		{Name: "Towels", Price: 40.00, CategoryID: "home"},
+		{Name: "Lamp", Price: 35.00, CategoryID: "furniture"},
+		{Name: "Milk", Price: 40.00, CategoryID: "groceries"},
+	}}
+	handler.SetPurchaseHistory(history)
+
+	orderDate := time.Now()
+	towels := &walmartTestItem{name: "Towels", price: 20.00, quantity: 2}
+	bathMat := &walmartTestItem{name: "Bath mat", price: 5.00, quantity: 1}
+	lamp := &walmartTestItem{name: "Lamp", price: 35.00, quantity: 1}
+	order := &walmartReturnsTestOrder{
+		walmartTestOrder: &walmartTestOrder{
+			id:            "ORDER-RETURNS",
+			date:          orderDate,
+			total:         108.00,
+			subtotal:      100.00,
+			tax:           8.00,
+			items:         []providers.OrderItem{towels, bathMat, lamp, &walmartTestItem{name: "Milk", price: 40.00, quantity: 1}},
+			charges:       []float64{108.00},
+			refundCharges: []float64{37.80, 27.00, 12.00},
+		},
+		returns: []walmartprovider.Return{
+			{ID: "return-1", Items: []providers.OrderItem{towels, bathMat}, Subtotal: 25.00, Tax: 2.00},
+			{ID: "return-2", Items: []providers.OrderItem{lamp}, Subtotal: 35.00, Tax: 2.80},
+		},
+	}
+	txns := []*monarch.Transaction{
+		{ID: "purchase-txn", Amount: -108.00, Date: walmartToMonarchDate(orderDate)},
+		{ID: "lamp-refund", Amount: 37.80, Date: walmartToMonarchDate(orderDate.AddDate(0, 0, 1))},
+		{ID: "towel-refund", Amount: 27.00, Date: walmartToMonarchDate(orderDate.AddDate(0, 0, 2))},
+		{ID: "other-credit", Amount: 12.00, Date: walmartToMonarchDate(orderDate.AddDate(0, 0, 2))},
+	}
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	require.Len(t, result.Refunds, 2, "the $12.00 refund has no return and is left alone")
+	assert.Equal(t, "lamp-refund", result.Refunds[0].Transaction.ID)
+	assert.Equal(t, "towel-refund", result.Refunds[1].Transaction.ID)
+
+	require.Len(t, splitter.calls, 3)
+	assert.Equal(t, []string{"", "", "", ""}, splitter.calls[0].pinned, "the purchase is categorized as usual")
+	assert.Equal(t, []string{"Lamp"}, splitter.calls[1].itemNames)
+	assert.InDelta(t, 37.80, splitter.calls[1].itemTotal, 0.001)
+	assert.Equal(t, []string{"furniture"}, splitter.calls[1].pinned)
+	assert.Equal(t, []string{"Towels", "Bath mat"}, splitter.calls[2].itemNames)
+	assert.InDelta(t, 27.00, splitter.calls[2].itemTotal, 0.001)
+	assert.Equal(t, []string{"home", ""}, splitter.calls[2].pinned, "items missing from the order's record are left to the categorizer")
+	assert.Equal(t, []string{"ORDER-RETURNS", "ORDER-RETURNS"}, history.orderIDs, "refunds look up their own order")
+}
+
+func TestPairRefundsWithReturns(t *testing.T) {
+	towels := &walmartTestItem{name: "Towels", price: 20.00, quantity: 2}
+	lamp := &walmartTestItem{name: "Lamp", price: 35.00, quantity: 1}
+	returns := []walmartprovider.Return{
+		{ID: "return-1", Items: []providers.OrderItem{towels}, Subtotal: 20.00, Tax: 1.60},
+		{ID: "return-2", Items: []providers.OrderItem{lamp}, Subtotal: 35.00, Tax: 2.80},
+	}
+
+	t.Run("lone refund and return pair whatever the amount", func(t *testing.T) {
+		refunds := pairRefundsWithReturns([]float64{18.00}, returns[:1])
+		require.Len(t, refunds, 1)
+		assert.Equal(t, []providers.OrderItem{towels}, refunds[0].items)
+	})
+
+	t.Run("lone refund covering every return", func(t *testing.T) {
+		refunds := pairRefundsWithReturns([]float64{59.40}, returns)
+		require.Len(t, refunds, 1)
+		assert.Equal(t, []providers.OrderItem{towels, lamp}, refunds[0].items)
+	})
+
+	t.Run("refunds that match no return", func(t *testing.T) {
+		assert.Empty(t, pairRefundsWithReturns([]float64{10.00}, returns))
+	})
+}
+
+func TestWalmartHandler_ProcessOrder_RecordsChargeMatches(t *testing.T) {
+	splitter := &walmartTestSplitter{categoryID: "groceries", notes: "Groceries"}
+	handler := createTestWalmartHandler(t, splitter, nil, &walmartTestMonarch{})
+	ledgerStorage := &walmartTestLedgerStorage{}
+	handler.SetLedgerStorage(ledgerStorage, 1)
+
+	orderDate := time.Now()
+	order := &walmartTestOrder{
+		id:            "ORDER-LEDGER",
+		date:          orderDate,
+		total:         86.06,
+		subtotal:      80.00,
+		tax:           6.06,
+		items:         []providers.OrderItem{&walmartTestItem{name: "Milk", price: 80.00, quantity: 1}},
+		charges:       []float64{86.06},
+		refundCharges: []float64{5.58},
+		refundItems:   []providers.OrderItem{&walmartTestItem{name: "Milk", price: 5.00, quantity: 1}},
+	}
+	txns := []*monarch.Transaction{
+		{ID: "purchase-txn", Amount: -86.06, Date: walmartToMonarchDate(orderDate)},
+		{ID: "refund-txn", Amount: 5.58, Date: walmartToMonarchDate(orderDate.AddDate(0, 0, 1))},
+	}
+
+	// Dry runs leave the ledger alone
+	_, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	assert.Empty(t, ledgerStorage.matches)
+
+	result, err := handler.ProcessOrder(context.Background(), order, txns, make(map[string]bool), nil, nil, false)
+	require.NoError(t, err)
+	require.True(t, result.Processed)
+	assert.Equal(t, []ChargeMatch{
+		{Amount: 86.06, TransactionID: "purchase-txn"},
+		{Amount: -5.58, TransactionID: "refund-txn"},
+	}, ledgerStorage.matches["ORDER-LEDGER"])
+}
+
+func TestWalmartHandler_ProcessOrder_ValidatesChargesAgainstNonBankPayments(t *testing.T) {
+	splitter := &walmartTestSplitter{categoryID: "groceries", notes: "Groceries"}
+	handler := createTestWalmartHandler(t, splitter, nil, &walmartTestMonarch{})
+	ledgerStorage := &walmartTestLedgerStorage{}
+	handler.SetLedgerStorage(ledgerStorage, 1)
+
+	orderDate := time.Now()
+	receipt := func(tcNumber string, giftCard float64) *walmartprovider.Order {
+		return (&walmartprovider.Receipt{
+			TCNumber: tcNumber,
+			Date:     orderDate,
+			Items:    []walmartprovider.ReceiptItem{{Name: "Milk", Quantity: 1, UnitPrice: 50.00, Price: 50.00}},
+			Subtotal: 50.00,
+			Total:    50.00,
+			Tenders: []walmartprovider.ReceiptTender{
+				{PaymentType: "CREDITCARD", Description: "VISA", LastFour: "1234", Amount: 30.00},
+				{PaymentType: "GIFTCARD", Description: "GIFT CARD", Amount: giftCard},
+			},
+		}).ToOrder()
+	}
+	txns := []*monarch.Transaction{{ID: "card-txn", Amount: -30.00, Date: walmartToMonarchDate(orderDate)}}
+
+	result, err := handler.ProcessOrder(context.Background(), receipt("PAID", 20.00), txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	assert.True(t, result.Processed)
+	require.NotNil(t, result.ChargeValidation)
+	assert.True(t, result.ChargeValidation.Valid)
+	assert.Equal(t, 30.00, result.ChargeValidation.ExpectedSum)
+
+	result, err = handler.ProcessOrder(context.Background(), receipt("SHORT", 10.00), txns, make(map[string]bool), nil, nil, true)
+	require.NoError(t, err)
+	assert.True(t, result.Skipped)
+	assert.Contains(t, result.SkipReason, "missing $10.00")
+	assert.False(t, result.ChargeValidation.Valid)
+	assert.Len(t, splitter.calls, 1, "splits aren't applied when the charges don't add up")
+
+	require.Len(t, ledgerStorage.ledgers, 2)
+	assert.True(t, ledgerStorage.ledgers[0].IsValid)
+	assert.False(t, ledgerStorage.ledgers[1].IsValid)
+	assert.Equal(t, result.SkipReason, ledgerStorage.ledgers[1].ValidationNotes)
+}
+
 func TestWalmartHandler_ProcessOrder_CategorizesIdentifiedRefundItemOnly(t *testing.T) {
 	splitter := &walmartTestSplitter{categoryID: "groceries", notes: "Groceries: Chobani creamer"}
 	monarchClient := &walmartTestMonarch{}
@@ -417,7 +610,7 @@ func TestWalmartHandler_ProcessOrder_ProcessesRefundOnlyLedger(t *testing.T) {
 		total:         15.00,
 		subtotal:      15.00,
 		items:         []providers.OrderItem{&walmartTestItem{name: "Returned item", price: 15.00, quantity: 1}},
-		chargesErr:    errors.New("no positive charges found (order may be fully refunded or paid entirely with gift card)"),
+		chargesErr:    ErrWalmartNoBankCharges,
 		refundCharges: []float64{15.00},
 		refundItems:   []providers.OrderItem{&walmartTestItem{name: "Returned item", price: 15.00, quantity: 1}},
 	}
@@ -0,0 +1,287 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// historyPageSize is the page size used when scanning processing records.
+const historyPageSize = 500
+
+// splitNoteItemLine matches an item line written by the splitter into split
+// and transaction notes: "- Name $1.23" or "- Name (x2) $1.23".
+var splitNoteItemLine = regexp.MustCompile(`^- (.+?)(?: \(x\d+\))? \$-?[\d,]*\.?\d+$`)
+
+// LoadCategoryHistory collects past item→category assignments from applied
+// (non-dry-run) processing records to train a categorizer.HistoryCategorizer.
+//
+// Single-category orders label every item with the record's category.
+// Multi-category orders are labeled from the item lines in each split's notes.
+// Items recorded as low confidence without a fallback are skipped so that
+// uncertain guesses don't become training data.
+func LoadCategoryHistory(repo storage.OrderRepository) ([]categorizer.LabeledItem, error) {
+	var examples []categorizer.LabeledItem
+	for _, status := range []string{"success", "provisional"} {
+		for offset := 0; ; offset += historyPageSize {
+			page, err := repo.ListOrders(storage.OrderFilters{
+				Status:    status,
+				Limit:     historyPageSize,
+				Offset:    offset,
+				OrderBy:   "processed_at",
+				OrderDesc: false,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list %s orders: %w", status, err)
+			}
+			for _, record := range page.Orders {
+				examples = append(examples, labeledItemsFromRecord(record)...)
+			}
+			if len(page.Orders) < historyPageSize {
+				break
+			}
+		}
+	}
+	return examples, nil
+}
+
+// labeledItemsFromRecord extracts training examples from one processing record.
+func labeledItemsFromRecord(record *storage.ProcessingRecord) []categorizer.LabeledItem {
+	if record == nil || record.DryRun {
+		return nil
+	}
+
+	uncertain := make(map[string]bool)
+	if record.LowConfidenceJSON != "" {
+		var items []storage.LowConfidenceItem
+		if err := json.Unmarshal([]byte(record.LowConfidenceJSON), &items); err == nil {
+			for _, item := range items {
+				if item.FallbackCategory == "" {
+					uncertain[strings.ToLower(strings.TrimSpace(item.Name))] = true
+				}
+			}
+		}
+	}
+	keep := func(name string) bool {
+		return !uncertain[strings.ToLower(strings.TrimSpace(name))]
+	}
+
+	var examples []categorizer.LabeledItem
+
+	if len(record.Splits) > 0 {
+		for _, split := range record.Splits {
+			if split.CategoryID == "" {
+				continue
+			}
+			categoryName, names := parseSplitNotes(split.Notes)
+			if split.CategoryName != "" {
+				categoryName = split.CategoryName
+			}
+			if len(split.Items) > 0 {
+				// Records list their split items; the notes only name them
+				// for records written before they did
+				names = nil
+				for _, item := range split.Items {
+					names = append(names, item.Name)
+				}
+			}
+			for _, name := range names {
+				if keep(name) {
+					examples = append(examples, categorizer.LabeledItem{
+						Name:         name,
+						CategoryID:   split.CategoryID,
+						CategoryName: categoryName,
+					})
+				}
+			}
+		}
+		return examples
+	}
+
+	if record.CategoryID == "" {
+		return nil
+	}
+	for _, item := range record.Items {
+		if keep(item.Name) {
+			examples = append(examples, categorizer.LabeledItem{
+				Name:         item.Name,
+				CategoryID:   record.CategoryID,
+				CategoryName: record.CategoryName,
+			})
+		}
+	}
+	return examples
+}
+
+// parseSplitNotes returns the category name header and item names from notes
+// produced by the splitter ("Groceries:\n- Milk $3.99\n- Bread (x2) $4.98").
+func parseSplitNotes(notes string) (string, []string) {
+	lines := strings.Split(notes, "\n")
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	categoryName := ""
+	if idx := strings.Index(lines[0], ":"); idx > 0 {
+		categoryName = strings.TrimSpace(lines[0][:idx])
+	}
+
+	var names []string
+	for _, line := range lines[1:] {
+		if match := splitNoteItemLine.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			names = append(names, match[1])
+		}
+	}
+	return categoryName, names
+}
+
+// purchaseHistory reads how an order's items were categorized from its
+// processing record, so refunds can be returned to the same categories. The
+// record is read on every lookup, so purchases recorded earlier in the same
+// run are found.
+type purchaseHistory struct {
+	repo storage.OrderRepository
+}
+
+// PurchasedItems implements handlers.PurchaseHistory. Orders that were only
+// dry-run, failed or never processed have no purchase history.
+func (h *purchaseHistory) PurchasedItems(orderID string) ([]handlers.PurchasedItem, error) {
+	if h.repo == nil || orderID == "" {
+		return nil, nil
+	}
+	record, err := h.repo.GetRecord(orderID)
+	if err != nil {
+		return nil, fmt.Errorf("load order %s: %w", orderID, err)
+	}
+	if record == nil || record.DryRun || (record.Status != "success" && record.Status != "provisional") {
+		return nil, nil
+	}
+	return purchasedItemsFromRecord(record), nil
+}
+
+// LatestPurchases implements handlers.PurchaseHistory by scanning the
+// provider's applied purchase records (credits, such as refund records, are
+// skipped) dated before the given time, keeping for each SKU the most recent
+// order that categorized it.
+func (h *purchaseHistory) LatestPurchases(provider string, skus []string, before time.Time) (map[string]handlers.PurchasedItem, error) {
+	if h.repo == nil || len(skus) == 0 {
+		return nil, nil
+	}
+	key := func(value string) string { return strings.ToLower(strings.TrimSpace(value)) }
+	wanted := make(map[string]bool, len(skus))
+	for _, sku := range skus {
+		wanted[key(sku)] = true
+	}
+
+	latest := make(map[string]handlers.PurchasedItem)
+	latestDate := make(map[string]time.Time)
+	for _, status := range []string{"success", "provisional"} {
+		for offset := 0; ; offset += historyPageSize {
+			page, err := h.repo.ListOrders(storage.OrderFilters{
+				Provider:  provider,
+				Status:    status,
+				Limit:     historyPageSize,
+				Offset:    offset,
+				OrderBy:   "processed_at",
+				OrderDesc: false,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list %s %s orders: %w", status, provider, err)
+			}
+			for _, record := range page.Orders {
+				if record.DryRun || record.OrderTotal < 0 || !record.OrderDate.Before(before) {
+					continue
+				}
+				for _, item := range purchasedItemsFromRecord(record) {
+					sku := key(item.SKU)
+					if !wanted[sku] {
+						continue
+					}
+					if date, seen := latestDate[sku]; seen && !record.OrderDate.After(date) {
+						continue
+					}
+					latest[sku] = item
+					latestDate[sku] = record.OrderDate
+				}
+			}
+			if len(page.Orders) < historyPageSize {
+				break
+			}
+		}
+	}
+
+	found := make(map[string]handlers.PurchasedItem, len(latest))
+	for _, sku := range skus {
+		if item, ok := latest[key(sku)]; ok {
+			found[sku] = item
+		}
+	}
+	return found, nil
+}
+
+// purchasedItemsFromRecord lists the items of a processed order with the
+// category each was charged to: the record's category for single-category
+// orders, otherwise the split holding the item, found by SKU or by the item
+// names in the split's items and notes. Items no split accounts for are left
+// out.
+func purchasedItemsFromRecord(record *storage.ProcessingRecord) []handlers.PurchasedItem {
+	items := make([]handlers.PurchasedItem, 0, len(record.Items))
+	if len(record.Splits) == 0 {
+		if record.CategoryID == "" {
+			return nil
+		}
+		for _, item := range record.Items {
+			items = append(items, handlers.PurchasedItem{
+				SKU:        item.SKU,
+				Name:       item.Name,
+				Price:      item.TotalPrice,
+				CategoryID: record.CategoryID,
+			})
+		}
+		return items
+	}
+
+	key := func(value string) string { return strings.ToLower(strings.TrimSpace(value)) }
+	bySKU := make(map[string]string)
+	byName := make(map[string]string)
+	for _, split := range record.Splits {
+		if split.CategoryID == "" {
+			continue
+		}
+		_, names := parseSplitNotes(split.Notes)
+		for _, item := range split.Items {
+			if item.SKU != "" {
+				bySKU[key(item.SKU)] = split.CategoryID
+			}
+			names = append(names, item.Name)
+		}
+		for _, name := range names {
+			byName[key(name)] = split.CategoryID
+		}
+	}
+	for _, item := range record.Items {
+		categoryID := ""
+		if item.SKU != "" {
+			categoryID = bySKU[key(item.SKU)]
+		}
+		if categoryID == "" {
+			categoryID = byName[key(item.Name)]
+		}
+		if categoryID == "" {
+			continue
+		}
+		items = append(items, handlers.PurchasedItem{
+			SKU:        item.SKU,
+			Name:       item.Name,
+			Price:      item.TotalPrice,
+			CategoryID: categoryID,
+		})
+	}
+	return items
+}
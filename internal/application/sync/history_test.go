@@ -0,0 +1,127 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSplitNotes(t *testing.T) {
+	name, items := parseSplitNotes("Groceries: (4 items)\n- Milk $3.99\n- Bread (x2) $4.98\n- Eggs, Large $-2.50\nnot an item line")
+
+	assert.Equal(t, "Groceries", name)
+	assert.Equal(t, []string{"Milk", "Bread", "Eggs, Large"}, items)
+}
+
+func TestLoadCategoryHistory(t *testing.T) {
+	store := storage.NewMockRepository()
+	now := time.Now()
+
+	// Single-category order
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "single", Status: "success", ProcessedAt: now,
+		CategoryID: "groceries", CategoryName: "Groceries",
+		Items:             []storage.OrderItem{{Name: "Milk"}, {Name: "Mystery Snack"}},
+		LowConfidenceJSON: `[{"name":"Mystery Snack","category_id":"groceries","confidence":0.2,"flagged_for_review":true}]`,
+	}))
+	// Multi-category order labeled from split notes
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "multi", Status: "provisional", ProcessedAt: now,
+		Items: []storage.OrderItem{{Name: "Paper Towels"}, {Name: "Bananas"}},
+		Splits: []storage.SplitDetail{
+			{CategoryID: "household", Notes: "Home & Garden:\n- Paper Towels $12.99"},
+			{CategoryID: "groceries", Notes: "Groceries:\n- Bananas (x3) $1.50"},
+		},
+	}))
+	// Split items listed on the record, with notes naming the same items
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "itemized", Status: "success", ProcessedAt: now,
+		Splits: []storage.SplitDetail{
+			{
+				CategoryID: "electronics", CategoryName: "Electronics",
+				Notes: "Electronics:\n- USB Cable $9.99",
+				Items: []storage.OrderItem{{Name: "USB Cable", TotalPrice: 9.99}},
+			},
+		},
+	}))
+	// Ignored: dry run and failures
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "dry", Status: "success", DryRun: true, CategoryID: "groceries", Items: []storage.OrderItem{{Name: "Dry Item"}},
+	}))
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "failed", Status: "failed", CategoryID: "groceries", Items: []storage.OrderItem{{Name: "Failed Item"}},
+	}))
+
+	examples, err := LoadCategoryHistory(store)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []categorizer.LabeledItem{
+		{Name: "Milk", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "Paper Towels", CategoryID: "household", CategoryName: "Home & Garden"},
+		{Name: "Bananas", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "USB Cable", CategoryID: "electronics", CategoryName: "Electronics"},
+	}, examples, "an item listed in both the split and its notes is one example")
+}
+
+func TestPurchasedItemsFromRecord(t *testing.T) {
+	t.Run("single category", func(t *testing.T) {
+		items := purchasedItemsFromRecord(&storage.ProcessingRecord{
+			CategoryID: "kid-needs",
+			Items:      []storage.OrderItem{{Name: "Kids Cup", SKU: "B0CUP", TotalPrice: 10}},
+		})
+		assert.Equal(t, []handlers.PurchasedItem{{SKU: "B0CUP", Name: "Kids Cup", Price: 10, CategoryID: "kid-needs"}}, items)
+	})
+
+	t.Run("split items matched by SKU or name", func(t *testing.T) {
+		items := purchasedItemsFromRecord(&storage.ProcessingRecord{
+			Items: []storage.OrderItem{
+				{Name: "Kids Cup", SKU: "B0CUP", TotalPrice: 10},
+				{Name: "USB Cable", TotalPrice: 15},
+				{Name: "Gift Wrap", TotalPrice: 3},
+			},
+			Splits: []storage.SplitDetail{
+				{CategoryID: "kid-needs", Items: []storage.OrderItem{{Name: "Kids Cup (renamed)", SKU: "B0CUP"}}},
+				{CategoryID: "electronics", Notes: "Electronics:\n- USB Cable $15.00"},
+			},
+		})
+		assert.Equal(t, []handlers.PurchasedItem{
+			{SKU: "B0CUP", Name: "Kids Cup", Price: 10, CategoryID: "kid-needs"},
+			{Name: "USB Cable", Price: 15, CategoryID: "electronics"},
+		}, items)
+	})
+
+	t.Run("uncategorized", func(t *testing.T) {
+		assert.Nil(t, purchasedItemsFromRecord(&storage.ProcessingRecord{Items: []storage.OrderItem{{Name: "Kids Cup"}}}))
+	})
+}
+
+func TestPurchaseHistory_ReadsRecordOnEveryLookup(t *testing.T) {
+	repo := storage.NewMockRepository()
+	history := &purchaseHistory{repo: repo}
+
+	items, err := history.PurchasedItems("ORDER-1")
+	require.NoError(t, err)
+	assert.Nil(t, items, "an order that wasn't processed has no purchase history")
+
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "ORDER-1", Status: "success", CategoryID: "home",
+		Items: []storage.OrderItem{{Name: "Lamp", TotalPrice: 35}},
+	}))
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "ORDER-2", Status: "success", DryRun: true, CategoryID: "home",
+		Items: []storage.OrderItem{{Name: "Towels", TotalPrice: 20}},
+	}))
+
+	items, err = history.PurchasedItems("ORDER-1")
+	require.NoError(t, err)
+	assert.Equal(t, []handlers.PurchasedItem{{Name: "Lamp", Price: 35, CategoryID: "home"}}, items, "a purchase recorded earlier in the run is found")
+
+	items, err = history.PurchasedItems("ORDER-2")
+	require.NoError(t, err)
+	assert.Nil(t, items, "dry runs were never applied")
+}
@@ -0,0 +1,198 @@
+package sync
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// Reasons a Monarch transaction is left out of the provider's candidates
+const (
+	excludedSplit      = "split transaction"
+	excludedNoMerchant = "no merchant"
+	excludedMerchant   = "merchant matches no provider alias"
+	excludedAccount    = "account is not a configured Monarch account"
+)
+
+// merchantSearchProvider is implemented by providers whose charges post under
+// more than one Monarch merchant, such as Amazon's Whole Foods purchases.
+type merchantSearchProvider interface {
+	MerchantSearchTerms() []string
+}
+
+// merchantFilter selects the Monarch transactions that can belong to the
+// provider's orders: parent transactions whose merchant (or the bank's raw
+// name) matches one of the provider's aliases, optionally limited to the
+// Monarch accounts its cards belong to.
+type merchantFilter struct {
+	terms    []string // lowercase substrings
+	patterns []*regexp.Regexp
+	accounts []string // lowercase account IDs, names or last four digits
+	// searchAll is set when a configured alias may only match the bank's
+	// raw name, which Monarch's search isn't known to cover
+	searchAll bool
+}
+
+// merchantExclusion records why a transaction was left out.
+type merchantExclusion struct {
+	Transaction *monarch.Transaction
+	Reason      string
+}
+
+// newMerchantFilter builds the provider's merchant filter from its built-in
+// search terms (its display name when it has none) and the configured
+// aliases and Monarch accounts. An alias wrapped in slashes, e.g.
+// "/^WM SUPERCENTER #\d+/", is a case-insensitive regular expression; any
+// other alias matches as a case-insensitive substring.
+func (o *Orchestrator) newMerchantFilter(opts Options) (*merchantFilter, error) {
+	var searchTerms []string
+	if provider, ok := o.provider.(merchantSearchProvider); ok {
+		searchTerms = provider.MerchantSearchTerms()
+	}
+	return newProviderMerchantFilter(o.provider.DisplayName(), searchTerms, opts.MerchantAliases, opts.MonarchAccounts)
+}
+
+// newProviderMerchantFilter selects a provider's transactions: those under
+// its search terms (or its name, without any) or one of the aliases.
+func newProviderMerchantFilter(name string, searchTerms, aliases, accounts []string) (*merchantFilter, error) {
+	terms := []string{name}
+	if len(searchTerms) > 0 {
+		terms = searchTerms
+	}
+	filter, err := newMerchantFilter(append(append([]string(nil), terms...), aliases...), accounts)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if strings.TrimSpace(alias) != "" {
+			filter.searchAll = true
+		}
+	}
+	return filter, nil
+}
+
+func newMerchantFilter(aliases, accounts []string) (*merchantFilter, error) {
+	filter := &merchantFilter{}
+	for _, alias := range aliases {
+		alias = strings.TrimSpace(alias)
+		if len(alias) > 2 && strings.HasPrefix(alias, "/") && strings.HasSuffix(alias, "/") {
+			pattern, err := regexp.Compile("(?i)" + alias[1:len(alias)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid merchant alias %s: %w", alias, err)
+			}
+			filter.patterns = append(filter.patterns, pattern)
+		} else if alias != "" {
+			filter.terms = append(filter.terms, strings.ToLower(alias))
+		}
+	}
+	for _, account := range accounts {
+		if account = strings.ToLower(strings.TrimSpace(account)); account != "" {
+			filter.accounts = append(filter.accounts, account)
+		}
+	}
+	return filter, nil
+}
+
+// searchTerms returns the Monarch searches that together cover every
+// transaction the filter can keep, or nil when every transaction in the
+// window is needed: for a regular expression alias, and for any configured
+// alias, which may match only the bank's raw name. A term containing another
+// term is left out, since the shorter search already finds it.
+func (f *merchantFilter) searchTerms() []string {
+	if f.searchAll || len(f.patterns) > 0 {
+		return nil
+	}
+	var searches []string
+	for _, term := range f.terms {
+		covered := false
+		for _, other := range f.terms {
+			if other != term && strings.Contains(term, other) {
+				covered = true
+				break
+			}
+		}
+		if !covered && !slices.Contains(searches, term) {
+			searches = append(searches, term)
+		}
+	}
+	return searches
+}
+
+// apply returns the transactions the filter keeps and why each other one was
+// left out.
+func (f *merchantFilter) apply(transactions []*monarch.Transaction) ([]*monarch.Transaction, []merchantExclusion) {
+	var kept []*monarch.Transaction
+	var excluded []merchantExclusion
+	for _, tx := range transactions {
+		if reason := f.exclusionReason(tx); reason != "" {
+			excluded = append(excluded, merchantExclusion{Transaction: tx, Reason: reason})
+			continue
+		}
+		kept = append(kept, tx)
+	}
+	return kept, excluded
+}
+
+func (f *merchantFilter) exclusionReason(tx *monarch.Transaction) string {
+	switch {
+	case tx.IsSplitTransaction:
+		// Only parent transactions are processed
+		return excludedSplit
+	case tx.Merchant == nil && tx.PlaidName == "":
+		return excludedNoMerchant
+	case !f.matchesMerchant(tx):
+		return excludedMerchant
+	case !f.matchesAccount(tx.Account):
+		return excludedAccount
+	}
+	return ""
+}
+
+// matchesMerchant checks the Monarch merchant name and the name the bank
+// reported, which keeps e.g. "WM SUPERCENTER #1234" after a rename in Monarch.
+func (f *merchantFilter) matchesMerchant(tx *monarch.Transaction) bool {
+	names := []string{tx.PlaidName}
+	if tx.Merchant != nil {
+		names = append(names, tx.Merchant.Name)
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		lower := strings.ToLower(name)
+		for _, term := range f.terms {
+			if strings.Contains(lower, term) {
+				return true
+			}
+		}
+		for _, pattern := range f.patterns {
+			if pattern.MatchString(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesAccount checks a transaction's account against the configured
+// accounts by ID, display name or last four digits. With no accounts
+// configured, every account matches.
+func (f *merchantFilter) matchesAccount(account *monarch.Account) bool {
+	if len(f.accounts) == 0 {
+		return true
+	}
+	if account == nil {
+		return false
+	}
+	for _, configured := range f.accounts {
+		if configured == strings.ToLower(account.ID) ||
+			configured == strings.ToLower(account.DisplayName) ||
+			(account.Mask != "" && configured == strings.ToLower(account.Mask)) {
+			return true
+		}
+	}
+	return false
+}
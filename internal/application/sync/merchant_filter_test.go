@@ -0,0 +1,117 @@
+package sync
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// merchantTermsProvider is a MockProvider with Monarch merchant search terms
+type merchantTermsProvider struct {
+	*MockProvider
+	terms []string
+}
+
+func (p *merchantTermsProvider) MerchantSearchTerms() []string { return p.terms }
+
+func transactionIDs(transactions []*monarch.Transaction) []string {
+	ids := make([]string, len(transactions))
+	for i, tx := range transactions {
+		ids[i] = tx.ID
+	}
+	return ids
+}
+
+func TestOrchestrator_NewMerchantFilterUsesProviderTerms(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("Costco")
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	transactions := []*monarch.Transaction{
+		{ID: "costco", Merchant: &monarch.Merchant{Name: "Costco"}},
+		{ID: "wfm", Merchant: &monarch.Merchant{Name: "Whole Foods Market"}},
+	}
+
+	plain, err := NewOrchestrator(mockProvider, nil, nil, logger).newMerchantFilter(Options{})
+	require.NoError(t, err)
+	kept, _ := plain.apply(transactions)
+	assert.Equal(t, []string{"costco"}, transactionIDs(kept), "display name by default")
+
+	searching := NewOrchestrator(&merchantTermsProvider{MockProvider: mockProvider, terms: []string{"Amazon", "Whole Foods"}}, nil, nil, logger)
+	filter, err := searching.newMerchantFilter(Options{})
+	require.NoError(t, err)
+	kept, _ = filter.apply(transactions)
+	assert.Equal(t, []string{"wfm"}, transactionIDs(kept), "search terms replace the display name")
+}
+
+func TestMerchantFilter_KeepsProviderMerchants(t *testing.T) {
+	filter, err := newMerchantFilter([]string{"Amazon", " whole foods "}, nil)
+	require.NoError(t, err)
+	transactions := []*monarch.Transaction{
+		{ID: "amazon", Merchant: &monarch.Merchant{Name: "Amazon"}},
+		{ID: "wfm", Merchant: &monarch.Merchant{Name: "Whole Foods Market"}},
+		{ID: "split", Merchant: &monarch.Merchant{Name: "Amazon"}, IsSplitTransaction: true},
+		{ID: "target", Merchant: &monarch.Merchant{Name: "Target"}},
+		{ID: "no-merchant"},
+	}
+
+	kept, _ := filter.apply(transactions)
+
+	assert.Equal(t, []string{"amazon", "wfm"}, transactionIDs(kept))
+}
+
+func TestMerchantFilter_AliasesAndExclusionReasons(t *testing.T) {
+	filter, err := newMerchantFilter([]string{"Walmart", " wal-mart ", `/^WM SUPERCENTER #\d+$/`}, nil)
+	require.NoError(t, err)
+	transactions := []*monarch.Transaction{
+		{ID: "walmart", Merchant: &monarch.Merchant{Name: "Walmart"}},
+		{ID: "dash", Merchant: &monarch.Merchant{Name: "WAL-MART #5"}},
+		{ID: "regex", Merchant: &monarch.Merchant{Name: "wm supercenter #1234"}},
+		{ID: "renamed", Merchant: &monarch.Merchant{Name: "Groceries run"}, PlaidName: "WM SUPERCENTER #77"},
+		{ID: "split", Merchant: &monarch.Merchant{Name: "Walmart"}, IsSplitTransaction: true},
+		{ID: "target", Merchant: &monarch.Merchant{Name: "Target"}},
+		{ID: "no-merchant"},
+	}
+
+	kept, excluded := filter.apply(transactions)
+
+	assert.Equal(t, []string{"walmart", "dash", "regex", "renamed"}, transactionIDs(kept))
+	reasons := make(map[string]string)
+	for _, exclusion := range excluded {
+		reasons[exclusion.Transaction.ID] = exclusion.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"split":       excludedSplit,
+		"target":      excludedMerchant,
+		"no-merchant": excludedNoMerchant,
+	}, reasons)
+}
+
+func TestMerchantFilter_MonarchAccounts(t *testing.T) {
+	filter, err := newMerchantFilter([]string{"Amazon"}, []string{"Prime Visa", "acct-2", "4242"})
+	require.NoError(t, err)
+	transactions := []*monarch.Transaction{
+		{ID: "by-name", Merchant: &monarch.Merchant{Name: "Amazon"}, Account: &monarch.Account{ID: "acct-1", DisplayName: "prime visa"}},
+		{ID: "by-id", Merchant: &monarch.Merchant{Name: "Amazon"}, Account: &monarch.Account{ID: "acct-2", DisplayName: "Checking"}},
+		{ID: "by-mask", Merchant: &monarch.Merchant{Name: "Amazon"}, Account: &monarch.Account{ID: "acct-3", Mask: "4242"}},
+		{ID: "other-card", Merchant: &monarch.Merchant{Name: "Amazon"}, Account: &monarch.Account{ID: "acct-4", DisplayName: "Amex"}},
+		{ID: "no-account", Merchant: &monarch.Merchant{Name: "Amazon"}},
+	}
+
+	kept, excluded := filter.apply(transactions)
+
+	assert.Equal(t, []string{"by-name", "by-id", "by-mask"}, transactionIDs(kept))
+	require.Len(t, excluded, 2)
+	for _, exclusion := range excluded {
+		assert.Equal(t, excludedAccount, exclusion.Reason)
+	}
+}
+
+func TestNewMerchantFilter_RejectsInvalidRegex(t *testing.T) {
+	_, err := newMerchantFilter([]string{"/WM (SUPERCENTER/"}, nil)
+
+	assert.ErrorContains(t, err, "invalid merchant alias /WM (SUPERCENTER/")
+}
@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+const (
+	// monarchPageSize is the page size used when listing Monarch transactions.
+	monarchPageSize = 500
+
+	// monarchMaxPages bounds how many pages one Monarch transaction query
+	// reads (monarchMaxPages * monarchPageSize transactions). A query with
+	// more is reported as truncated and the sync records a warning.
+	monarchMaxPages = 100
+)
+
+// TransactionPageQuery identifies one page of a Monarch transaction query.
+// Monarch filters by calendar date, so the range is kept as dates.
+type TransactionPageQuery struct {
+	StartDate string // YYYY-MM-DD
+	EndDate   string // YYYY-MM-DD
+	Search    string // Monarch's free-text search ("" for every transaction)
+	Offset    int
+}
+
+// TransactionPageSource fetches one page of Monarch transactions.
+type TransactionPageSource func(ctx context.Context, query TransactionPageQuery) (*monarch.TransactionList, error)
+
+// MonarchTransactionPages returns a TransactionPageSource that reads pages of
+// monarchPageSize transactions from Monarch.
+func MonarchTransactionPages(client *monarch.Client) TransactionPageSource {
+	return func(ctx context.Context, query TransactionPageQuery) (*monarch.TransactionList, error) {
+		start, err := time.Parse("2006-01-02", query.StartDate)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.Parse("2006-01-02", query.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		builder := client.Transactions.Query().
+			Between(start, end).
+			Limit(monarchPageSize).
+			Offset(query.Offset)
+		if query.Search != "" {
+			builder = builder.Search(query.Search)
+		}
+		return builder.Execute(ctx)
+	}
+}
+
+// transactionPager pages through Monarch transaction queries. It keeps every
+// page it reads, so a query repeated while the pager is alive (one sync run)
+// is served from memory.
+type transactionPager struct {
+	source   TransactionPageSource
+	maxPages int
+	pages    map[TransactionPageQuery]*monarch.TransactionList
+}
+
+// transactionQueryStats describes one paged query, for the fetch log.
+type transactionQueryStats struct {
+	Search      string `json:"search,omitempty"`
+	Pages       int    `json:"pages"`
+	CachedPages int    `json:"cached_pages,omitempty"`
+	Fetched     int    `json:"fetched"`
+	TotalCount  int    `json:"total_count"`
+	Truncated   bool   `json:"truncated,omitempty"`
+}
+
+func newTransactionPager(source TransactionPageSource) *transactionPager {
+	return &transactionPager{
+		source:   source,
+		maxPages: monarchMaxPages,
+		pages:    make(map[TransactionPageQuery]*monarch.TransactionList),
+	}
+}
+
+// fetch reads every page of the transactions dated between start and end
+// (matching search, when set). When Monarch has more than maxPages pages, the
+// rest are left unread and the stats report the query as truncated; a
+// maxPages of 0 reads every page.
+func (p *transactionPager) fetch(ctx context.Context, start, end time.Time, search string) ([]*monarch.Transaction, transactionQueryStats, error) {
+	stats := transactionQueryStats{Search: search}
+	query := TransactionPageQuery{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Search:    search,
+	}
+
+	var transactions []*monarch.Transaction
+	for {
+		if p.maxPages > 0 && stats.Pages == p.maxPages {
+			stats.Truncated = true
+			return transactions, stats, nil
+		}
+		page, cached := p.pages[query]
+		if !cached {
+			var err error
+			page, err = p.source(ctx, query)
+			if err != nil {
+				return nil, stats, fmt.Errorf("failed to fetch transactions: %w", err)
+			}
+			p.pages[query] = page
+		} else {
+			stats.CachedPages++
+		}
+		stats.Pages++
+		stats.TotalCount = page.TotalCount
+		stats.Fetched += len(page.Transactions)
+		transactions = append(transactions, page.Transactions...)
+		if !page.HasMore || len(page.Transactions) == 0 {
+			return transactions, stats, nil
+		}
+		query.Offset = page.NextOffset
+	}
+}
@@ -0,0 +1,188 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransactionPages serves pages of pageSize transactions from a fixed
+// list, filtering by search like Monarch does, and records every query.
+type fakeTransactionPages struct {
+	transactions []*monarch.Transaction
+	pageSize     int
+	queries      []TransactionPageQuery
+}
+
+func (f *fakeTransactionPages) source(_ context.Context, query TransactionPageQuery) (*monarch.TransactionList, error) {
+	f.queries = append(f.queries, query)
+	var matching []*monarch.Transaction
+	for _, tx := range f.transactions {
+		if query.Search == "" || strings.Contains(strings.ToLower(tx.Merchant.Name), query.Search) {
+			matching = append(matching, tx)
+		}
+	}
+	end := min(query.Offset+f.pageSize, len(matching))
+	return &monarch.TransactionList{
+		Transactions: matching[query.Offset:end],
+		TotalCount:   len(matching),
+		HasMore:      end < len(matching),
+		NextOffset:   end,
+	}, nil
+}
+
+func merchantTransactions(merchant string, count int) []*monarch.Transaction {
+	transactions := make([]*monarch.Transaction, count)
+	for i := range transactions {
+		transactions[i] = &monarch.Transaction{
+			ID:       fmt.Sprintf("%s-%d", strings.ToLower(merchant), i),
+			Merchant: &monarch.Merchant{Name: merchant},
+		}
+	}
+	return transactions
+}
+
+func TestTransactionPager_PagesAndTruncates(t *testing.T) {
+	fake := &fakeTransactionPages{transactions: merchantTransactions("Walmart", 5), pageSize: 2}
+	pager := newTransactionPager(fake.source)
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	transactions, stats, err := pager.fetch(context.Background(), start, end, "")
+	require.NoError(t, err)
+	assert.Len(t, transactions, 5)
+	assert.Equal(t, transactionQueryStats{Pages: 3, Fetched: 5, TotalCount: 5}, stats)
+	require.Len(t, fake.queries, 3)
+	assert.Equal(t, TransactionPageQuery{StartDate: "2025-01-01", EndDate: "2025-03-01", Offset: 4}, fake.queries[2])
+
+	capped := newTransactionPager(fake.source)
+	capped.maxPages = 2
+	transactions, stats, err = capped.fetch(context.Background(), start, end, "")
+	require.NoError(t, err)
+	assert.Len(t, transactions, 4)
+	assert.True(t, stats.Truncated)
+	assert.Equal(t, 5, stats.TotalCount)
+}
+
+func TestMerchantFilter_SearchTerms(t *testing.T) {
+	filter, err := newMerchantFilter([]string{"Amazon", "AMAZON MKTP", "Whole Foods", "amazon"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amazon", "whole foods"}, filter.searchTerms())
+
+	filter, err = newMerchantFilter([]string{"Walmart", `/^WM SUPERCENTER/`}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter.searchTerms(), "a regex alias needs every transaction")
+
+	filter, err = newProviderMerchantFilter("Amazon", []string{"Amazon", "Whole Foods"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"amazon", "whole foods"}, filter.searchTerms())
+
+	filter, err = newProviderMerchantFilter("Walmart", nil, []string{"WM SUPERCENTER"}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, filter.searchTerms(), "a configured alias may match only the bank's raw name")
+}
+
+func TestFetchMonarchTransactions_SearchesEachMerchantTerm(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("Amazon")
+	transactions := append(merchantTransactions("Amazon", 3), merchantTransactions("Whole Foods", 2)...)
+	transactions = append(transactions, merchantTransactions("Target", 4)...)
+	fake := &fakeTransactionPages{transactions: transactions, pageSize: 2}
+	store := storage.NewMockRepository()
+	orchestrator := NewOrchestrator(&merchantTermsProvider{MockProvider: mockProvider, terms: []string{"Amazon", "Whole Foods"}}, nil, store, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	orchestrator.transactionPages = fake.source
+
+	merchants, err := orchestrator.newMerchantFilter(Options{})
+	require.NoError(t, err)
+	kept, err := orchestrator.fetchMonarchTransactions(context.Background(), Options{LookbackDays: 14}, merchants)
+	require.NoError(t, err)
+	assert.Len(t, kept, 5)
+	for _, query := range fake.queries {
+		assert.NotEmpty(t, query.Search, "Target transactions are never fetched")
+	}
+
+	fetches, err := store.GetProviderFetchesByRunID(0)
+	require.NoError(t, err)
+	require.Len(t, fetches, 1)
+	var response struct {
+		Queries   []transactionQueryStats `json:"queries"`
+		Truncated bool                    `json:"truncated"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(fetches[0].ResponseJSON), &response))
+	assert.Equal(t, []transactionQueryStats{
+		{Search: "amazon", Pages: 2, Fetched: 3, TotalCount: 3},
+		{Search: "whole foods", Pages: 1, Fetched: 2, TotalCount: 2},
+	}, response.Queries)
+	assert.False(t, response.Truncated)
+}
+
+func TestFetchMonarchTransactions_RecordsTruncationWarning(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("Walmart")
+	fake := &fakeTransactionPages{transactions: merchantTransactions("Walmart", 5), pageSize: 2}
+	store := storage.NewMockRepository()
+	runID, err := store.StartSyncRun("Walmart", 14, false)
+	require.NoError(t, err)
+	orchestrator := NewOrchestrator(mockProvider, nil, store, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	orchestrator.runID = runID
+	orchestrator.transactionPages = fake.source
+	orchestrator.transactionPager = newTransactionPager(fake.source)
+	orchestrator.transactionPager.maxPages = 1
+
+	// A configured alias falls back to one unfiltered query
+	merchants, err := orchestrator.newMerchantFilter(Options{MerchantAliases: []string{"WM SUPERCENTER"}})
+	require.NoError(t, err)
+	kept, err := orchestrator.fetchMonarchTransactions(context.Background(), Options{LookbackDays: 14}, merchants)
+	require.NoError(t, err, "a truncated fetch does not abort the sync")
+	assert.Len(t, kept, 2)
+	require.Len(t, fake.queries, 1)
+	assert.Empty(t, fake.queries[0].Search)
+
+	run, err := store.GetSyncRun(runID)
+	require.NoError(t, err)
+	require.Len(t, run.Warnings, 1)
+	assert.Contains(t, run.Warnings[0], "read 2 of 5 transactions")
+
+	fetches, err := store.GetProviderFetchesByRunID(runID)
+	require.NoError(t, err)
+	require.Len(t, fetches, 1)
+	assert.Contains(t, fetches[0].ResponseJSON, `"truncated":true`)
+	assert.Empty(t, fetches[0].Error)
+}
+
+func TestFetchTransactionPages_CachesPagesForTheRun(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("Walmart")
+	fake := &fakeTransactionPages{transactions: merchantTransactions("Walmart", 5), pageSize: 2}
+	orchestrator := NewOrchestrator(mockProvider, nil, nil, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+	orchestrator.transactionPages = fake.source
+	start := time.Date(2025, 1, 1, 10, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	_, queries, err := orchestrator.fetchTransactionPages(context.Background(), start, end, []string{"walmart"})
+	require.NoError(t, err)
+	assert.Zero(t, queries[0].CachedPages)
+	require.Len(t, fake.queries, 3)
+
+	transactions, queries, err := orchestrator.fetchTransactionPages(context.Background(), start, end, []string{"walmart"})
+	require.NoError(t, err)
+	assert.Len(t, transactions, 5)
+	assert.Equal(t, 3, queries[0].CachedPages)
+	assert.Len(t, fake.queries, 3, "the repeated query is served from the run's pages")
+
+	// A new run starts with an empty cache
+	orchestrator.transactionPager = nil
+	_, _, err = orchestrator.fetchTransactionPages(context.Background(), start, end, []string{"walmart"})
+	require.NoError(t, err)
+	assert.Len(t, fake.queries, 6)
+}
@@ -3,6 +3,7 @@ package sync
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
@@ -13,7 +14,10 @@ import (
 
 // handleResult processes the result from a provider handler and records success/error
 // Returns (processed, skipped, error) matching processOrder signature
-func (o *Orchestrator) handleResult(order providers.Order, result *handlers.ProcessResult, err error, opts Options) (bool, bool, error) {
+func (o *Orchestrator) handleResult(ctx context.Context, order providers.Order, result *handlers.ProcessResult, err error, opts Options) (bool, bool, error) {
+	if result != nil {
+		result.MatchDiagnosticsJSON = o.withAssignmentDiagnostics(order.GetID(), result.MatchDiagnosticsJSON)
+	}
 	if err != nil {
 		o.logger.Error("Handler error", "order_id", order.GetID(), "error", err)
 		o.recordError(order, err.Error(), nil)
@@ -36,8 +40,15 @@ func (o *Orchestrator) handleResult(order providers.Order, result *handlers.Proc
 		return false, false, fmt.Errorf("skipped: %s", result.SkipReason)
 	}
 	if result.Processed {
+		o.reviewLowConfidence(ctx, order, result, opts.DryRun)
+		o.applyTags(ctx, order, result, opts.DryRun)
 		// Pass the full result to capture audit trail data (category, notes, transaction, etc.)
-		o.recordSuccessWithResult(order, result.Transaction, result.Splits, 0, opts.DryRun, result, nil)
+		splits := result.Splits
+		if len(result.Charges) > 0 {
+			splits = chargeSplits(result.Charges)
+		}
+		o.recordSuccessWithResult(order, result.Transaction, splits, 0, opts.DryRun, result, chargeDeliveryInfo(result))
+		o.learnCardAccount(order, result, opts.DryRun)
 	}
 	return result.Processed, result.Skipped, nil
 }
@@ -93,21 +104,21 @@ func (o *Orchestrator) processOrder(
 	if amazonOrder, ok := handlers.AsAmazonOrder(order); ok && o.amazonHandler != nil {
 		o.logger.Debug("Using Amazon handler for order", "order_id", order.GetID())
 		result, err := o.amazonHandler.ProcessOrder(ctx, amazonOrder, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts.DryRun)
-		return o.handleResult(order, result, err, opts)
+		return o.handleResult(ctx, order, result, err, opts)
 	}
 
 	// Use Walmart handler for Walmart orders (handles multi-delivery and gift cards)
 	if walmartOrder, ok := handlers.AsWalmartOrder(order); ok && o.walmartHandler != nil {
 		o.logger.Debug("Using Walmart handler for order", "order_id", order.GetID())
 		result, err := o.walmartHandler.ProcessOrder(ctx, walmartOrder, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts.DryRun)
-		return o.handleResult(order, result, err, opts)
+		return o.handleResult(ctx, order, result, err, opts)
 	}
 
 	// Use Simple handler for all other providers (Costco, etc.)
 	if o.simpleHandler != nil {
 		o.logger.Debug("Using Simple handler for order", "order_id", order.GetID())
 		result, err := o.simpleHandler.ProcessOrder(ctx, order, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts.DryRun)
-		return o.handleResult(order, result, err, opts)
+		return o.handleResult(ctx, order, result, err, opts)
 	}
 
 	// No handler available (testing mode without clients)
@@ -121,6 +132,10 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 		Errors: make([]error, 0),
 	}
 
+	if opts.SinceLast && opts.OrderID == "" {
+		opts.LookbackDays = o.sinceLastLookbackDays(opts.LookbackDays, time.Now())
+	}
+
 	o.logger.Debug("Starting sync",
 		"provider", o.provider.DisplayName(),
 		"lookback_days", opts.LookbackDays,
@@ -129,12 +144,33 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 		"force", opts.Force,
 	)
 
+	multiChargeMode, err := handlers.ParseMultiChargeMode(opts.MultiChargeMode)
+	if err != nil {
+		return nil, err
+	}
+	if o.amazonHandler != nil {
+		o.amazonHandler.SetMultiChargeMode(multiChargeMode)
+	}
+	if o.walmartHandler != nil {
+		o.walmartHandler.SetMultiChargeMode(multiChargeMode)
+	}
+	merchants, err := o.newMerchantFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	// Monarch pages are cached for this run only
+	o.transactionPager = nil
+
 	// 1. Start sync run tracking before external fetches so fetch logs are tied to a run.
 	if o.storage != nil {
 		var err error
 		o.runID, err = o.storage.StartSyncRun(o.provider.DisplayName(), opts.LookbackDays, opts.DryRun)
 		if err != nil {
 			o.logger.Warn("Failed to start sync run tracking", "error", err)
+		} else if accounts := o.providerAccounts(); len(accounts) > 0 {
+			if err := o.storage.SetSyncRunAccount(o.runID, strings.Join(accounts, ",")); err != nil {
+				o.logger.Warn("Failed to record sync run account", "error", err)
+			}
 		}
 		if o.consolidator != nil {
 			o.consolidator.SetRunID(o.runID)
@@ -149,18 +185,21 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 	}
 
 	// 2. Fetch orders from provider
+	o.reportProgress(opts, ProgressUpdate{Phase: "fetching_orders"})
+	fetchedFrom := time.Now().AddDate(0, 0, -opts.LookbackDays)
 	orders, err := o.fetchOrders(ctx, opts)
 	if err != nil {
 		o.completeFailedRun(1)
 		return nil, err
 	}
+	orders, costcoRefunds := splitCostcoRefunds(orders)
 
 	// 3. Fetch Monarch transactions (if clients configured)
 	if o.clients == nil {
 		return result, nil // Testing mode
 	}
 
-	providerTransactions, err := o.fetchMonarchTransactions(ctx, opts)
+	providerTransactions, err := o.fetchMonarchTransactions(ctx, opts, merchants)
 	if err != nil {
 		o.completeFailedRun(1)
 		return nil, err
@@ -172,6 +211,8 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 		o.completeFailedRun(1)
 		return nil, err
 	}
+	catCategories = o.filterCategoryGroups(catCategories, opts.CategoryGroups)
+	o.configureConfidencePolicy(catCategories, opts)
 
 	amazonReturns, returnsErr := o.fetchAmazonReturns(ctx)
 	if returnsErr != nil {
@@ -180,9 +221,20 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 		o.logger.Error("Amazon return ledger unavailable; continuing with order sync", "error", returnsErr)
 	}
 
-	// 5. Process orders
+	// 5. Categorize uncached items across all orders in batched LLM calls
+	o.warmCategoryCache(ctx, orders, catCategories, opts)
+
+	// 6. Process orders, each against the transactions assigned to it
 	usedTransactionIDs := make(map[string]bool)
+	o.setCardAccounts(opts)
+	o.assignment = o.assignTransactions(orders, providerTransactions, usedTransactionIDs, opts)
+	defer func() {
+		o.matcher.SetAssignment(nil)
+		o.assignment = nil
+	}()
+	o.reportProgress(opts, ProgressUpdate{Phase: "processing_orders", TotalOrders: len(orders)})
 
+	failedOrders := make(map[string]bool)
 	for i, order := range orders {
 		if opts.OrderID != "" && order.GetID() != opts.OrderID {
 			o.logger.Debug("Skipping order (not matching -order-id filter)",
@@ -196,28 +248,45 @@ func (o *Orchestrator) Run(ctx context.Context, opts Options) (*Result, error) {
 
 		processed, skipped, err := o.processOrder(ctx, order, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts)
 		if err != nil {
+			failedOrders[order.GetID()] = true
 			result.ErrorCount++
 			result.Errors = append(result.Errors, fmt.Errorf("order %s (%s, $%.2f): %w",
 				order.GetID(),
 				order.GetDate().Format("2006-01-02"),
 				order.GetTotal(),
 				err))
-			continue
+		} else {
+			if processed {
+				result.ProcessedCount++
+			}
+			if skipped {
+				result.SkippedCount++
+			}
 		}
 
-		if processed {
-			result.ProcessedCount++
-		}
-		if skipped {
-			result.SkippedCount++
-		}
+		o.reportProgress(opts, ProgressUpdate{
+			Phase:           "processing_orders",
+			TotalOrders:     len(orders),
+			ProcessedOrders: result.ProcessedCount,
+			SkippedOrders:   result.SkippedCount,
+			ErroredOrders:   result.ErrorCount,
+		})
 	}
 
 	if returnsErr == nil && len(amazonReturns) > 0 {
 		o.processAmazonReturns(ctx, amazonReturns, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts, time.Now(), result)
 	}
+	if len(costcoRefunds) > 0 {
+		o.processCostcoRefunds(ctx, costcoRefunds, providerTransactions, usedTransactionIDs, catCategories, monarchCategories, opts, result)
+	}
 
-	// 6. Complete sync run
+	// 7. Advance the sync watermarks. Runs that only saw part of the
+	// provider's orders leave them where they were.
+	if !opts.DryRun && opts.OrderID == "" && (opts.MaxOrders == 0 || len(orders) < opts.MaxOrders) {
+		o.saveWatermarks(orders, failedOrders, fetchedFrom, opts.SinceLast)
+	}
+
+	// 8. Complete sync run
 	if o.storage != nil && o.runID > 0 {
 		if err := o.storage.CompleteSyncRun(o.runID, len(orders), result.ProcessedCount, result.SkippedCount, result.ErrorCount); err != nil {
 			o.logger.Error("Failed to complete sync run", "run_id", o.runID, "error", err)
@@ -235,3 +304,17 @@ func (o *Orchestrator) completeFailedRun(errorCount int) {
 		o.logger.Warn("Failed to complete failed sync run tracking", "error", err)
 	}
 }
+
+// accountProvider is implemented by providers that read named accounts, such
+// as Amazon's cookie accounts.
+type accountProvider interface {
+	Accounts() []string
+}
+
+// providerAccounts returns the accounts the provider reads, if it names any.
+func (o *Orchestrator) providerAccounts() []string {
+	if provider, ok := o.provider.(accountProvider); ok {
+		return provider.Accounts()
+	}
+	return nil
+}
@@ -539,3 +539,44 @@ func TestOrchestrator_fetchOrders_LogsProviderFetch(t *testing.T) {
 	assert.Equal(t, 1, logs[0].OrderCount)
 	assert.Contains(t, logs[0].ResponseJSON, "receipt-1")
 }
+
+// accountsProvider is a MockProvider that reads named accounts
+type accountsProvider struct {
+	*MockProvider
+	accounts []string
+}
+
+func (p *accountsProvider) Accounts() []string { return p.accounts }
+
+// accountOrder is an order fetched from a named account
+type accountOrder struct {
+	mockSimpleOrder
+	account string
+}
+
+func (o *accountOrder) GetAccount() string { return o.account }
+
+func TestOrchestrator_Run_TagsSyncRunAndRecordsWithAccount(t *testing.T) {
+	order := &accountOrder{
+		mockSimpleOrder: mockSimpleOrder{id: "111-0000000-0000001", date: time.Now(), total: 10, providerName: "Amazon"},
+		account:         "wife",
+	}
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("Amazon")
+	mockProvider.On("FetchOrders", mock.Anything, mock.Anything).Return([]providers.Order{order}, nil)
+	provider := &accountsProvider{MockProvider: mockProvider, accounts: []string{"mine", "wife"}}
+	store := storage.NewMockRepository()
+	orchestrator := NewOrchestrator(provider, nil, store, slog.Default())
+
+	_, err := orchestrator.Run(context.Background(), Options{LookbackDays: 7})
+	require.NoError(t, err)
+
+	run, err := store.GetSyncRun(orchestrator.runID)
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	assert.Equal(t, "mine,wife", run.Account)
+
+	orchestrator.recordPending(order, "payment pending")
+	require.NotNil(t, store.LastSavedRecord)
+	assert.Equal(t, "wife", store.LastSavedRecord.Account)
+}
@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+)
+
+// itemCacheWarmer categorizes a cross-order batch of items up front so the
+// per-order splitter calls are served from the categorization cache.
+type itemCacheWarmer interface {
+	WarmCache(ctx context.Context, items []categorizer.Item, categories []categorizer.Category, opts categorizer.BatchOptions) (*categorizer.WarmResult, error)
+}
+
+// warmCategoryCache collects the items of every order this run will process,
+// deduplicates them and categorizes the uncached ones in a few batched LLM
+// calls. Per-order processing is unchanged: it still calls the categorizer,
+// which now finds the answers in its cache.
+//
+// Failures are logged and otherwise ignored; any item left uncached is
+// categorized on demand exactly as before.
+func (o *Orchestrator) warmCategoryCache(ctx context.Context, orders []providers.Order, catCategories []categorizer.Category, opts Options) {
+	if o.cacheWarmer == nil {
+		return
+	}
+
+	items := o.collectUncategorizedItems(orders, opts)
+	if len(items) == 0 {
+		return
+	}
+
+	o.reportProgress(opts, ProgressUpdate{
+		Phase:       "categorizing_items",
+		TotalOrders: len(orders),
+		TotalItems:  len(items),
+	})
+
+	warmResult, err := o.cacheWarmer.WarmCache(ctx, items, catCategories, categorizer.BatchOptions{
+		OnChunk: func(done, total int) {
+			o.reportProgress(opts, ProgressUpdate{
+				Phase:            "categorizing_items",
+				TotalOrders:      len(orders),
+				TotalItems:       total,
+				CategorizedItems: done,
+			})
+		},
+	})
+	if err != nil {
+		o.logger.Warn("Batch categorization incomplete; remaining items will be categorized per order", "error", err)
+	}
+	if warmResult != nil {
+		o.logger.Debug("Batch categorization finished",
+			"items", warmResult.RequestedItems,
+			"unique_items", warmResult.UniqueItems,
+			"already_cached", warmResult.CachedItems,
+			"llm_calls", warmResult.LLMCalls,
+			"failed_calls", warmResult.FailedChunks,
+		)
+	}
+}
+
+// collectUncategorizedItems returns the items of orders that the processing
+// loop will actually look at, honoring the -order-id filter and skipping
+// orders already recorded as processed (unless forced). Items with a pinned
+// category never reach the categorizer and are left out.
+func (o *Orchestrator) collectUncategorizedItems(orders []providers.Order, opts Options) []categorizer.Item {
+	var items []categorizer.Item
+	for _, order := range orders {
+		if opts.OrderID != "" && order.GetID() != opts.OrderID {
+			continue
+		}
+		if !opts.Force && o.storage != nil && o.storage.IsProcessed(order.GetID()) {
+			continue
+		}
+		for _, orderItem := range order.GetItems() {
+			if providers.PinnedCategory(orderItem) != "" {
+				continue
+			}
+			items = append(items, categorizer.Item{
+				Name:     orderItem.GetName(),
+				Price:    orderItem.GetPrice(),
+				Quantity: int(orderItem.GetQuantity()),
+			})
+		}
+	}
+	return items
+}
+
+// reportProgress forwards a progress update to the caller, if one is listening.
+func (o *Orchestrator) reportProgress(opts Options, update ProgressUpdate) {
+	if opts.ProgressCallback != nil {
+		opts.ProgressCallback(update)
+	}
+}
@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCacheWarmer records the items passed to WarmCache
+type fakeCacheWarmer struct {
+	items      []categorizer.Item
+	categories []categorizer.Category
+	err        error
+}
+
+func (f *fakeCacheWarmer) WarmCache(_ context.Context, items []categorizer.Item, categories []categorizer.Category, opts categorizer.BatchOptions) (*categorizer.WarmResult, error) {
+	f.items = items
+	f.categories = categories
+	if opts.OnChunk != nil {
+		opts.OnChunk(len(items), len(items))
+	}
+	return &categorizer.WarmResult{RequestedItems: len(items), UniqueItems: len(items)}, f.err
+}
+
+func prefetchTestOrders() []providers.Order {
+	now := time.Now()
+	return []providers.Order{
+		&mockSimpleOrder{id: "done", date: now, items: []providers.OrderItem{
+			&mockOrderItem{name: "Old Item", price: 1, quantity: 1},
+		}},
+		&mockSimpleOrder{id: "new-1", date: now, items: []providers.OrderItem{
+			&mockOrderItem{name: "Milk", price: 3.99, quantity: 1},
+			&mockOrderItem{name: "Bread", price: 2.49, quantity: 2},
+		}},
+		&mockSimpleOrder{id: "new-2", date: now, items: []providers.OrderItem{
+			&mockOrderItem{name: "Milk", price: 3.99, quantity: 1},
+		}},
+	}
+}
+
+func TestWarmCategoryCache_CollectsItemsFromUnprocessedOrders(t *testing.T) {
+	store := storage.NewMockRepository()
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{OrderID: "done", Status: "success"}))
+
+	warmer := &fakeCacheWarmer{}
+	o := &Orchestrator{
+		cacheWarmer: warmer,
+		storage:     store,
+		logger:      slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+
+	var updates []ProgressUpdate
+	opts := Options{ProgressCallback: func(u ProgressUpdate) { updates = append(updates, u) }}
+	categories := []categorizer.Category{{ID: "cat_1", Name: "Groceries"}}
+
+	o.warmCategoryCache(context.Background(), prefetchTestOrders(), categories, opts)
+
+	require.Len(t, warmer.items, 3, "duplicates are left for the categorizer to dedupe")
+	assert.Equal(t, "Milk", warmer.items[0].Name)
+	assert.Equal(t, "Bread", warmer.items[1].Name)
+	assert.Equal(t, 2, warmer.items[1].Quantity)
+	assert.Equal(t, categories, warmer.categories)
+
+	require.Len(t, updates, 2)
+	assert.Equal(t, ProgressUpdate{Phase: "categorizing_items", TotalOrders: 3, TotalItems: 3}, updates[0])
+	assert.Equal(t, 3, updates[1].CategorizedItems)
+}
+
+func TestWarmCategoryCache_ForceAndOrderFilter(t *testing.T) {
+	store := storage.NewMockRepository()
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{OrderID: "done", Status: "success"}))
+
+	warmer := &fakeCacheWarmer{}
+	o := &Orchestrator{cacheWarmer: warmer, storage: store, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+
+	o.warmCategoryCache(context.Background(), prefetchTestOrders(), nil, Options{Force: true, OrderID: "done"})
+
+	require.Len(t, warmer.items, 1)
+	assert.Equal(t, "Old Item", warmer.items[0].Name)
+}
+
+// pinnedItem is an order item whose provider already knows its category
+type pinnedItem struct{ mockOrderItem }
+
+func (p *pinnedItem) PinnedCategory() string { return "Gas" }
+
+func TestWarmCategoryCache_SkipsPinnedItems(t *testing.T) {
+	warmer := &fakeCacheWarmer{}
+	o := &Orchestrator{cacheWarmer: warmer, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+
+	orders := []providers.Order{
+		&mockSimpleOrder{id: "gas", date: time.Now(), items: []providers.OrderItem{
+			&pinnedItem{mockOrderItem{name: "Gas (Regular)", price: 52.37, quantity: 13.4}},
+			&mockOrderItem{name: "Car Wash", price: 10, quantity: 1},
+		}},
+	}
+	o.warmCategoryCache(context.Background(), orders, nil, Options{})
+
+	require.Len(t, warmer.items, 1)
+	assert.Equal(t, "Car Wash", warmer.items[0].Name)
+}
+
+func TestWarmCategoryCache_ErrorIsNotFatal(t *testing.T) {
+	warmer := &fakeCacheWarmer{err: errors.New("rate limited")}
+	o := &Orchestrator{cacheWarmer: warmer, logger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+
+	assert.NotPanics(t, func() {
+		o.warmCategoryCache(context.Background(), prefetchTestOrders(), nil, Options{})
+	})
+	assert.Len(t, warmer.items, 4)
+}
+
+func TestOrchestrator_Run_ReportsFetchProgress(t *testing.T) {
+	mockProvider := new(MockProvider)
+	mockProvider.On("DisplayName").Return("TestProvider")
+	mockProvider.On("FetchOrders", mock.Anything, mock.Anything).Return([]providers.Order{}, nil)
+
+	orchestrator := NewOrchestrator(mockProvider, nil, nil, slog.New(slog.NewTextHandler(os.Stderr, nil)))
+
+	var phases []string
+	_, err := orchestrator.Run(context.Background(), Options{
+		DryRun:           true,
+		ProgressCallback: func(u ProgressUpdate) { phases = append(phases, u.Phase) },
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fetching_orders"}, phases)
+}
@@ -7,7 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/domain/validator"
 	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 	"github.com/stretchr/testify/assert"
@@ -88,6 +92,57 @@ func TestRecordSuccessWithResult_PendingTransactionIsProvisional(t *testing.T) {
 	assert.False(t, store.IsProcessed(order.GetID()))
 }
 
+func TestRecordSuccessWithResult_StoresPromptHash(t *testing.T) {
+	categories := []categorizer.Category{{ID: "groceries", Name: "Groceries"}}
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "LLM categorized", source: categorizer.SourceLLM, want: "3f2a9c1b7d4e"},
+		{name: "history categorized", source: categorizer.SourceHistory, want: ""},
+		{name: "pinned", source: categorizer.SourcePinned, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spl := splitter.NewSplitter(&scoredCategorizer{result: &categorizer.CategorizationResult{
+				Categorizations: []categorizer.ItemCategorization{
+					{ItemName: "Milk", CategoryID: "groceries", CategoryName: "Groceries", Confidence: 1, Source: tt.source},
+				},
+			}})
+			store := storage.NewMockRepository()
+			orch := &Orchestrator{storage: store, splitter: spl, logger: reconciliationTestLogger(), promptHash: "3f2a9c1b7d4e"}
+			order := reconciliationTestOrder("ORDER-PROMPT", time.Now(), 12.5)
+			order.items = []providers.OrderItem{&mockOrderItem{name: "Milk", price: 12.5, quantity: 1}}
+			_, err := spl.CategorizeOrder(context.Background(), order, categories)
+			require.NoError(t, err)
+
+			transaction := &monarch.Transaction{ID: "txn-1", Amount: -12.5}
+			result := &handlers.ProcessResult{Processed: true, Transaction: transaction, CategoryID: "groceries"}
+			orch.recordSuccessWithResult(order, transaction, nil, 1, false, result, nil)
+
+			record, err := store.GetRecord(order.GetID())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, record.PromptHash)
+		})
+	}
+}
+
+func TestRecordError_StoresChargeValidation(t *testing.T) {
+	store := storage.NewMockRepository()
+	orch := &Orchestrator{storage: store, logger: reconciliationTestLogger()}
+	order := reconciliationTestOrder("ORDER-SHORT", time.Now(), 50)
+	validation := validator.ValidatePayments([]float64{30}, 50, []validator.NonBankAmount{{Method: "GIFTCARD", Amount: 10}})
+	result := &handlers.ProcessResult{Skipped: true, SkipReason: validation.Reason, ChargeValidation: validation}
+
+	orch.recordError(order, result.SkipReason, result)
+
+	record, err := store.GetRecord(order.GetID())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"valid":false,"bank_charges_sum":30,"expected_sum":40,"difference":-10,
+		"non_bank":[{"method":"GIFTCARD","amount":10}],"reason":"`+validation.Reason+`"}`, record.ChargeValidationJSON)
+}
+
 func TestProcessOrder_ReconcilesRedirectedSingleCategoryFromCachedRecord(t *testing.T) {
 	orderDate := time.Date(2026, 7, 26, 0, 0, 0, 0, time.UTC)
 	order := reconciliationTestOrder("ORDER-AIR-FILTER", orderDate, 25.75)
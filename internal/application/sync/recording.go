@@ -2,10 +2,13 @@ package sync
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
 	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 )
@@ -20,6 +23,7 @@ func convertOrderItems(items []providers.OrderItem) []storage.OrderItem {
 	for i, item := range items {
 		result[i] = storage.OrderItem{
 			Name:       item.GetName(),
+			SKU:        item.GetSKU(),
 			Quantity:   item.GetQuantity(),
 			UnitPrice:  item.GetUnitPrice(),
 			TotalPrice: item.GetPrice(),
@@ -46,6 +50,52 @@ func convertSplits(splits []*monarch.TransactionSplit) []storage.SplitDetail {
 	return result
 }
 
+// attachTags copies the tags assigned by applyTags onto the stored items and
+// fills each split with its items and their combined tags.
+func attachTags(record *storage.ProcessingRecord, tagged []handlers.TaggedItem) {
+	if len(tagged) == 0 {
+		return
+	}
+
+	byName := make(map[string]handlers.TaggedItem, len(tagged))
+	for _, item := range tagged {
+		byName[strings.ToLower(strings.TrimSpace(item.Name))] = item
+	}
+	categoryOf := make(map[int]string, len(record.Items))
+	for i := range record.Items {
+		item, ok := byName[strings.ToLower(strings.TrimSpace(record.Items[i].Name))]
+		if !ok && i < len(tagged) {
+			item = tagged[i]
+		}
+		record.Items[i].Tags = item.Tags
+		categoryOf[i] = item.CategoryID
+	}
+
+	for s := range record.Splits {
+		split := &record.Splits[s]
+		for i, item := range record.Items {
+			if categoryOf[i] != split.CategoryID {
+				continue
+			}
+			split.Items = append(split.Items, item)
+			split.Tags = tagger.Merge(split.Tags, item.Tags)
+		}
+	}
+}
+
+// chargeValidationJSON serializes the handler's charge validation, or returns
+// "" when the order wasn't checked
+func chargeValidationJSON(result *handlers.ProcessResult) string {
+	if result.ChargeValidation == nil {
+		return ""
+	}
+	data, err := json.Marshal(result.ChargeValidation)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // Recording and audit trail functions for the sync orchestrator.
 // These handle persisting processing results and API call logs to storage.
 
@@ -72,6 +122,7 @@ func (o *Orchestrator) recordError(order providers.Order, errorMsg string, resul
 			record.CategoryID = result.CategoryID
 			record.CategoryName = result.CategoryName
 			record.MonarchNotes = result.MonarchNotes
+			record.ChargeValidationJSON = chargeValidationJSON(result)
 		}
 		o.populateRecordAudit(order, record)
 		if err := o.storage.SaveRecord(record); err != nil {
@@ -164,6 +215,10 @@ func (o *Orchestrator) recordSuccessWithResult(
 			record.CategoryName = result.CategoryName
 			record.MonarchNotes = result.MonarchNotes
 			record.MatchDiagnosticsJSON = result.MatchDiagnosticsJSON
+			record.LowConfidenceJSON = result.LowConfidenceJSON
+			record.ChargeValidationJSON = chargeValidationJSON(result)
+			record.PromptHash = o.orderPromptHash(order)
+			attachTags(record, result.TaggedItems)
 			if len(result.ReconciledTransactions) > 0 {
 				record.SplitCount = len(result.ReconciledTransactions)
 			}
@@ -178,7 +233,24 @@ func (o *Orchestrator) recordSuccessWithResult(
 	}
 }
 
+// orderPromptHash returns the prompt hash to store with an order's record:
+// set only when the LLM categorized at least one of its items, not when
+// history, pinned or refund purchase categories placed every item.
+func (o *Orchestrator) orderPromptHash(order providers.Order) string {
+	if o.promptHash == "" || o.splitter == nil {
+		return ""
+	}
+	for _, cat := range o.splitter.Categorizations(order.GetID(), len(order.GetItems())) {
+		if cat.Source == categorizer.SourceLLM {
+			return o.promptHash
+		}
+	}
+	return ""
+}
+
 func (o *Orchestrator) populateRecordAudit(order providers.Order, record *storage.ProcessingRecord) {
+	record.Account = providers.OrderAccount(order)
+
 	if rawData := order.GetRawData(); rawData != nil {
 		if rawJSON, err := json.Marshal(rawData); err == nil {
 			record.RawOrderJSON = string(rawJSON)
@@ -226,6 +298,23 @@ func (o *Orchestrator) recordOrderTransactions(order providers.Order, record *st
 			o.logger.Error("Failed to save order transaction", "order_id", order.GetID(), "transaction_id", result.Transaction.ID, "error", err)
 		}
 	}
+	for _, charge := range result.Charges {
+		if charge.Transaction == nil || (result.Transaction != nil && charge.Transaction.ID == result.Transaction.ID) {
+			continue
+		}
+		if err := o.storage.SaveOrderTransaction(&storage.OrderTransaction{
+			RunID:         o.runID,
+			OrderID:       order.GetID(),
+			TransactionID: charge.Transaction.ID,
+			Role:          "charge",
+			Amount:        charge.Transaction.Amount,
+			CategoryID:    charge.CategoryID,
+			CategoryName:  charge.CategoryName,
+			Notes:         charge.MonarchNotes,
+		}); err != nil {
+			o.logger.Error("Failed to save charge order transaction", "order_id", order.GetID(), "transaction_id", charge.Transaction.ID, "error", err)
+		}
+	}
 	for _, refund := range result.Refunds {
 		if refund.Transaction == nil {
 			continue
@@ -246,6 +335,48 @@ func (o *Orchestrator) recordOrderTransactions(order providers.Order, record *st
 	}
 }
 
+// chargeSplits returns the splits recorded for an order whose charges were
+// categorized separately: each charge's splits, or the whole charge as one
+// split when it took a single category.
+func chargeSplits(charges []handlers.ChargeProcessResult) []*monarch.TransactionSplit {
+	var splits []*monarch.TransactionSplit
+	for _, charge := range charges {
+		if len(charge.Splits) > 0 {
+			splits = append(splits, charge.Splits...)
+			continue
+		}
+		amount := -charge.Amount
+		if charge.Transaction != nil {
+			amount = charge.Transaction.Amount
+		}
+		splits = append(splits, &monarch.TransactionSplit{
+			Amount:     amount,
+			CategoryID: charge.CategoryID,
+			Notes:      charge.MonarchNotes,
+		})
+	}
+	return splits
+}
+
+// chargeDeliveryInfo describes an order whose charges were categorized
+// separately, or returns nil for other orders.
+func chargeDeliveryInfo(result *handlers.ProcessResult) *storage.MultiDeliveryInfo {
+	if len(result.Charges) == 0 {
+		return nil
+	}
+	info := &storage.MultiDeliveryInfo{
+		IsMultiDelivery: true,
+		ChargeCount:     len(result.Charges),
+	}
+	for _, charge := range result.Charges {
+		info.ChargeAmounts = append(info.ChargeAmounts, charge.Amount)
+		if charge.Transaction != nil {
+			info.OriginalTransactionIDs = append(info.OriginalTransactionIDs, charge.Transaction.ID)
+		}
+	}
+	return info
+}
+
 // extractFeesBreakdown extracts fee breakdown from provider-specific order data
 func extractFeesBreakdown(order providers.Order) string {
 	rawData := order.GetRawData()
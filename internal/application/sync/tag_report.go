@@ -0,0 +1,95 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// TaggedPurchase is one item carrying a tag, with the order and Monarch
+// transaction it came from as proof of purchase.
+type TaggedPurchase struct {
+	OrderDate     time.Time
+	Provider      string
+	OrderID       string
+	TransactionID string
+	Item          string
+	Quantity      float64
+	Amount        float64 // Item price before tax
+	Tax           float64 // Share of the order's tax, pro-rata by price
+	Tags          []string
+}
+
+// Total is the item price including its share of tax.
+func (p TaggedPurchase) Total() float64 {
+	return p.Amount + p.Tax
+}
+
+// LoadTaggedPurchases lists items tagged with tag (case-insensitive) in
+// applied processing records whose order date falls in [from, to). A zero
+// from or to leaves that side open. Results are sorted by order date.
+func LoadTaggedPurchases(repo storage.OrderRepository, tag string, from, to time.Time) ([]TaggedPurchase, error) {
+	var purchases []TaggedPurchase
+	for _, status := range []string{"success", "provisional"} {
+		for offset := 0; ; offset += historyPageSize {
+			page, err := repo.ListOrders(storage.OrderFilters{
+				Status:    status,
+				Limit:     historyPageSize,
+				Offset:    offset,
+				OrderBy:   "date",
+				OrderDesc: false,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("list %s orders: %w", status, err)
+			}
+			for _, record := range page.Orders {
+				if record.DryRun || (!from.IsZero() && record.OrderDate.Before(from)) ||
+					(!to.IsZero() && !record.OrderDate.Before(to)) {
+					continue
+				}
+				purchases = append(purchases, taggedPurchasesFromRecord(record, tag)...)
+			}
+			if len(page.Orders) < historyPageSize {
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(purchases, func(i, j int) bool {
+		if !purchases[i].OrderDate.Equal(purchases[j].OrderDate) {
+			return purchases[i].OrderDate.Before(purchases[j].OrderDate)
+		}
+		return purchases[i].OrderID < purchases[j].OrderID
+	})
+	return purchases, nil
+}
+
+func taggedPurchasesFromRecord(record *storage.ProcessingRecord, tag string) []TaggedPurchase {
+	taxRate := 0.0
+	if record.OrderSubtotal > 0 {
+		taxRate = record.OrderTax / record.OrderSubtotal
+	}
+
+	var purchases []TaggedPurchase
+	for _, item := range record.Items {
+		if !tagger.Has(item.Tags, tag) {
+			continue
+		}
+		purchases = append(purchases, TaggedPurchase{
+			OrderDate:     record.OrderDate,
+			Provider:      record.Provider,
+			OrderID:       record.OrderID,
+			TransactionID: record.TransactionID,
+			Item:          item.Name,
+			Quantity:      item.Quantity,
+			Amount:        item.TotalPrice,
+			Tax:           math.Round(item.TotalPrice*taxRate*100) / 100,
+			Tags:          item.Tags,
+		})
+	}
+	return purchases
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTaggedPurchases(t *testing.T) {
+	store := storage.NewMockRepository()
+	day := func(d int) time.Time { return time.Date(2026, time.March, d, 0, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "late", Provider: "Walmart", Status: "success", OrderDate: day(20), TransactionID: "TXN-2",
+		OrderSubtotal: 20, OrderTax: 2,
+		Items: []storage.OrderItem{
+			{Name: "Bandages", Quantity: 2, TotalPrice: 10, Tags: []string{"HSA/FSA"}},
+			{Name: "Chips", Quantity: 1, TotalPrice: 10},
+		},
+	}))
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "early", Provider: "Amazon", Status: "provisional", OrderDate: day(5), TransactionID: "TXN-1",
+		Items: []storage.OrderItem{{Name: "Thermometer", Quantity: 1, TotalPrice: 15, Tags: []string{"hsa/fsa", "Gift"}}},
+	}))
+	// Ignored: dry run, failed and outside the date range
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "dry", Status: "success", DryRun: true, OrderDate: day(6),
+		Items: []storage.OrderItem{{Name: "Dry", Tags: []string{"HSA/FSA"}}},
+	}))
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "failed", Status: "failed", OrderDate: day(7),
+		Items: []storage.OrderItem{{Name: "Failed", Tags: []string{"HSA/FSA"}}},
+	}))
+	require.NoError(t, store.SaveRecord(&storage.ProcessingRecord{
+		OrderID: "april", Status: "success", OrderDate: time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		Items: []storage.OrderItem{{Name: "April", Tags: []string{"HSA/FSA"}}},
+	}))
+
+	purchases, err := LoadTaggedPurchases(store, "HSA/FSA", day(1), time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, purchases, 2)
+
+	assert.Equal(t, "early", purchases[0].OrderID)
+	assert.Equal(t, "Thermometer", purchases[0].Item)
+	assert.Zero(t, purchases[0].Tax)
+
+	assert.Equal(t, TaggedPurchase{
+		OrderDate: day(20), Provider: "Walmart", OrderID: "late", TransactionID: "TXN-2",
+		Item: "Bandages", Quantity: 2, Amount: 10, Tax: 1, Tags: []string{"HSA/FSA"},
+	}, purchases[1])
+	assert.Equal(t, 11.0, purchases[1].Total())
+}
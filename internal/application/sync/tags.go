@@ -0,0 +1,203 @@
+package sync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// newTagColor is the color given to tags itemize creates in Monarch.
+const newTagColor = "#19D2A5"
+
+// tagClient is the subset of Monarch operations needed to tag transactions
+// and their splits.
+type tagClient interface {
+	ListTags(ctx context.Context) ([]*monarch.Tag, error)
+	CreateTag(ctx context.Context, name, color string) (*monarch.Tag, error)
+	SetTransactionTags(ctx context.Context, id string, tagIDs []string) error
+	GetSplits(ctx context.Context, id string) ([]*monarch.TransactionSplit, error)
+}
+
+// applyTags assigns configured tags to the items of a processed order and
+// applies them in Monarch: to the transaction for single-category orders, or
+// to each split for the items in that split's category. The tagged items are
+// attached to the result so they are stored with the processing record.
+// Tagging failures are logged and never fail the order.
+func (o *Orchestrator) applyTags(ctx context.Context, order providers.Order, result *handlers.ProcessResult, dryRun bool) {
+	if o.tagger == nil || o.splitter == nil || result == nil || !result.Processed {
+		return
+	}
+	orderItems := order.GetItems()
+	categorizations := o.splitter.Categorizations(order.GetID(), len(orderItems))
+	if len(categorizations) == 0 {
+		return
+	}
+
+	tagsByCategory := make(map[string][]string)
+	var orderTags []string
+	tagged := make([]handlers.TaggedItem, 0, len(categorizations))
+	for i, cat := range categorizations {
+		name := cat.ItemName
+		if name == "" && i < len(orderItems) {
+			name = orderItems[i].GetName()
+		}
+		tags := o.tagger.Tags(tagger.Item{
+			Name:         name,
+			CategoryID:   cat.CategoryID,
+			CategoryName: cat.CategoryName,
+			LLMTags:      cat.Tags,
+		})
+		tagged = append(tagged, handlers.TaggedItem{Name: name, CategoryID: cat.CategoryID, Tags: tags})
+		tagsByCategory[cat.CategoryID] = tagger.Merge(tagsByCategory[cat.CategoryID], tags)
+		orderTags = tagger.Merge(orderTags, tags)
+	}
+	result.TaggedItems = tagged
+
+	if len(orderTags) == 0 {
+		return
+	}
+	o.logger.Info("Tagged items",
+		"order_id", order.GetID(),
+		"tags", strings.Join(orderTags, ", "),
+		"dry_run", dryRun)
+
+	if dryRun || len(appliedTransactions(result)) == 0 || o.tagClient == nil {
+		return
+	}
+
+	// Charges split separately are each tagged with their own categories
+	if len(result.Charges) > 0 {
+		for _, charge := range result.Charges {
+			if charge.Transaction == nil {
+				continue
+			}
+			o.tagTransaction(ctx, order, charge.Transaction, len(charge.Splits) > 0, tagsByCategory[charge.CategoryID], tagsByCategory)
+		}
+		return
+	}
+	o.tagTransaction(ctx, order, result.Transaction, len(result.Splits) > 0, orderTags, tagsByCategory)
+}
+
+// tagTransaction applies tags to a transaction: all of transactionTags when
+// it isn't split, otherwise each split's category tags to that split.
+func (o *Orchestrator) tagTransaction(
+	ctx context.Context,
+	order providers.Order,
+	transaction *monarch.Transaction,
+	hasSplits bool,
+	transactionTags []string,
+	tagsByCategory map[string][]string,
+) {
+	if !hasSplits {
+		if len(transactionTags) == 0 {
+			return
+		}
+		if err := o.setTransactionTags(ctx, transaction.ID, transaction.Tags, transactionTags); err != nil {
+			o.logger.Warn("Failed to tag transaction",
+				"order_id", order.GetID(),
+				"transaction_id", transaction.ID,
+				"error", err)
+		}
+		return
+	}
+
+	splits, err := o.tagClient.GetSplits(ctx, transaction.ID)
+	if err != nil {
+		o.logger.Warn("Failed to load splits for tagging",
+			"order_id", order.GetID(),
+			"transaction_id", transaction.ID,
+			"error", err)
+		return
+	}
+	for _, split := range splits {
+		if split == nil || split.ID == "" {
+			continue
+		}
+		tags := tagsByCategory[splitCategoryID(split)]
+		if len(tags) == 0 {
+			continue
+		}
+		if err := o.setTransactionTags(ctx, split.ID, nil, tags); err != nil {
+			o.logger.Warn("Failed to tag split",
+				"order_id", order.GetID(),
+				"split_id", split.ID,
+				"error", err)
+		}
+	}
+}
+
+// setTransactionTags adds tags (by name) to a transaction, keeping the tags
+// it already has. Monarch replaces the whole tag set on every call.
+func (o *Orchestrator) setTransactionTags(ctx context.Context, transactionID string, existing []*monarch.Tag, names []string) error {
+	ids := make([]string, 0, len(existing)+len(names))
+	seen := make(map[string]bool)
+	for _, tag := range existing {
+		if tag != nil && !seen[tag.ID] {
+			seen[tag.ID] = true
+			ids = append(ids, tag.ID)
+		}
+	}
+	kept := len(ids)
+
+	for _, name := range names {
+		tag, err := o.monarchTag(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			ids = append(ids, tag.ID)
+		}
+	}
+	if len(ids) == kept {
+		return nil // Already tagged
+	}
+	return o.tagClient.SetTransactionTags(ctx, transactionID, ids)
+}
+
+// monarchTag looks up a Monarch tag by name (case-insensitive), creating it
+// the first time it is needed.
+func (o *Orchestrator) monarchTag(ctx context.Context, name string) (*monarch.Tag, error) {
+	if o.monarchTags == nil {
+		tags, err := o.tagClient.ListTags(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("list Monarch tags: %w", err)
+		}
+		o.monarchTags = make(map[string]*monarch.Tag, len(tags))
+		for _, tag := range tags {
+			if tag != nil {
+				o.monarchTags[strings.ToLower(tag.Name)] = tag
+			}
+		}
+	}
+
+	key := strings.ToLower(name)
+	if tag, ok := o.monarchTags[key]; ok {
+		return tag, nil
+	}
+	tag, err := o.tagClient.CreateTag(ctx, name, newTagColor)
+	if err != nil {
+		return nil, fmt.Errorf("create Monarch tag %q: %w", name, err)
+	}
+	if tag == nil {
+		return nil, fmt.Errorf("create Monarch tag %q: no tag returned", name)
+	}
+	o.logger.Info("Created Monarch tag", "tag", name)
+	o.monarchTags[key] = tag
+	return tag, nil
+}
+
+func splitCategoryID(split *monarch.TransactionSplit) string {
+	if split.CategoryID != "" {
+		return split.CategoryID
+	}
+	if split.Category != nil {
+		return split.Category.ID
+	}
+	return ""
+}
@@ -0,0 +1,219 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/application/sync/handlers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTagClient records tag writes against an in-memory tag list
+type fakeTagClient struct {
+	tags    []*monarch.Tag
+	splits  []*monarch.TransactionSplit
+	created []string
+	set     map[string][]string
+}
+
+func (f *fakeTagClient) ListTags(context.Context) ([]*monarch.Tag, error) {
+	return f.tags, nil
+}
+
+func (f *fakeTagClient) CreateTag(_ context.Context, name, color string) (*monarch.Tag, error) {
+	f.created = append(f.created, name)
+	tag := &monarch.Tag{ID: "tag_" + name, Name: name, Color: color}
+	f.tags = append(f.tags, tag)
+	return tag, nil
+}
+
+func (f *fakeTagClient) SetTransactionTags(_ context.Context, id string, tagIDs []string) error {
+	if f.set == nil {
+		f.set = make(map[string][]string)
+	}
+	f.set[id] = tagIDs
+	return nil
+}
+
+func (f *fakeTagClient) GetSplits(context.Context, string) ([]*monarch.TransactionSplit, error) {
+	return f.splits, nil
+}
+
+func newTaggingOrchestrator(t *testing.T, categorizations []categorizer.ItemCategorization, client *fakeTagClient) *Orchestrator {
+	t.Helper()
+	itemTagger, err := tagger.New([]tagger.Rule{
+		{Tag: "HSA/FSA", Match: []string{"bandage"}, Categories: []string{"Medical"}},
+		{Tag: "Gift", Description: "Bought as a gift"},
+	})
+	require.NoError(t, err)
+	return &Orchestrator{
+		splitter:  splitter.NewSplitter(&scoredCategorizer{result: &categorizer.CategorizationResult{Categorizations: categorizations}}),
+		tagger:    itemTagger,
+		tagClient: client,
+		logger:    slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+}
+
+func taggingTestOrder() *mockSimpleOrder {
+	return &mockSimpleOrder{id: "ORDER-T", date: time.Now(), total: 30, subtotal: 30, items: []providers.OrderItem{
+		&mockOrderItem{name: "Bandages", price: 5, quantity: 1},
+		&mockOrderItem{name: "Thermometer", price: 15, quantity: 1},
+		&mockOrderItem{name: "Teddy Bear", price: 10, quantity: 1},
+	}}
+}
+
+var taggingTestCategories = []categorizer.Category{{ID: "med", Name: "Medical"}, {ID: "toys", Name: "Toys"}}
+
+func TestApplyTags_TagsEachSplitByItsItems(t *testing.T) {
+	client := &fakeTagClient{
+		tags: []*monarch.Tag{{ID: "tag_hsa", Name: "hsa/fsa"}},
+		splits: []*monarch.TransactionSplit{
+			{ID: "SPLIT-MED", CategoryID: "med"},
+			{ID: "SPLIT-TOYS", Category: &monarch.TransactionCategory{ID: "toys"}},
+		},
+	}
+	o := newTaggingOrchestrator(t, []categorizer.ItemCategorization{
+		{ItemName: "Bandages", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Thermometer", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Teddy Bear", CategoryID: "toys", CategoryName: "Toys", Confidence: 0.9, Tags: []string{"gift"}},
+	}, client)
+
+	order := taggingTestOrder()
+	txn := &monarch.Transaction{ID: "TXN-T", Amount: -30}
+	splits, err := o.splitter.CreateSplits(context.Background(), order, txn, taggingTestCategories, nil)
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+
+	result := &handlers.ProcessResult{Processed: true, Transaction: txn, Splits: splits}
+	o.applyTags(context.Background(), order, result, false)
+
+	assert.Equal(t, []handlers.TaggedItem{
+		{Name: "Bandages", CategoryID: "med", Tags: []string{"HSA/FSA"}},
+		{Name: "Thermometer", CategoryID: "med", Tags: []string{"HSA/FSA"}},
+		{Name: "Teddy Bear", CategoryID: "toys", Tags: []string{"Gift"}},
+	}, result.TaggedItems)
+	assert.Equal(t, []string{"Gift"}, client.created, "existing tags are matched by name, missing ones created")
+	assert.Equal(t, map[string][]string{
+		"SPLIT-MED":  {"tag_hsa"},
+		"SPLIT-TOYS": {"tag_Gift"},
+	}, client.set)
+}
+
+func TestApplyTags_SingleCategoryKeepsExistingTags(t *testing.T) {
+	client := &fakeTagClient{tags: []*monarch.Tag{{ID: "tag_hsa", Name: "HSA/FSA"}}}
+	o := newTaggingOrchestrator(t, []categorizer.ItemCategorization{
+		{ItemName: "Bandages", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+	}, client)
+
+	order := &mockSimpleOrder{id: "ORDER-S", date: time.Now(), total: 5, subtotal: 5, items: []providers.OrderItem{
+		&mockOrderItem{name: "Bandages", price: 5, quantity: 1},
+	}}
+	txn := &monarch.Transaction{ID: "TXN-S", Amount: -5, Tags: []*monarch.Tag{{ID: "tag_travel", Name: "Travel"}}}
+	splits, err := o.splitter.CreateSplits(context.Background(), order, txn, taggingTestCategories, nil)
+	require.NoError(t, err)
+	require.Nil(t, splits)
+
+	o.applyTags(context.Background(), order, &handlers.ProcessResult{Processed: true, Transaction: txn}, false)
+	assert.Equal(t, []string{"tag_travel", "tag_hsa"}, client.set["TXN-S"])
+
+	// Already tagged: no write
+	client.set = nil
+	txn.Tags = append(txn.Tags, &monarch.Tag{ID: "tag_hsa", Name: "HSA/FSA"})
+	o.applyTags(context.Background(), order, &handlers.ProcessResult{Processed: true, Transaction: txn}, false)
+	assert.Nil(t, client.set)
+}
+
+func TestApplyTags_TagsEachChargeByItsCategory(t *testing.T) {
+	client := &fakeTagClient{}
+	o := newTaggingOrchestrator(t, []categorizer.ItemCategorization{
+		{ItemName: "Bandages", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Thermometer", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Teddy Bear", CategoryID: "toys", CategoryName: "Toys", Confidence: 0.9, Tags: []string{"gift"}},
+	}, client)
+
+	order := taggingTestOrder()
+	_, err := o.splitter.CategorizeOrder(context.Background(), order, taggingTestCategories)
+	require.NoError(t, err)
+
+	medical := &monarch.Transaction{ID: "TXN-MED", Amount: -20}
+	toys := &monarch.Transaction{ID: "TXN-TOYS", Amount: -10}
+	result := &handlers.ProcessResult{
+		Processed:   true,
+		Transaction: medical,
+		Charges: []handlers.ChargeProcessResult{
+			{Amount: 20, Transaction: medical, CategoryID: "med"},
+			{Amount: 10, Transaction: toys, CategoryID: "toys"},
+		},
+	}
+	o.applyTags(context.Background(), order, result, false)
+
+	assert.Equal(t, map[string][]string{
+		"TXN-MED":  {"tag_HSA/FSA"},
+		"TXN-TOYS": {"tag_Gift"},
+	}, client.set)
+
+	record := &storage.ProcessingRecord{Items: convertOrderItems(order.GetItems()), Splits: convertSplits(chargeSplits(result.Charges))}
+	attachTags(record, result.TaggedItems)
+	require.Len(t, record.Splits, 2)
+	assert.InDelta(t, -20, record.Splits[0].Amount, 0.001)
+	assert.Equal(t, []string{"HSA/FSA"}, record.Splits[0].Tags)
+	assert.Equal(t, []string{"Gift"}, record.Splits[1].Tags)
+
+	info := chargeDeliveryInfo(result)
+	require.NotNil(t, info)
+	assert.Equal(t, []string{"TXN-MED", "TXN-TOYS"}, info.OriginalTransactionIDs)
+	assert.Equal(t, []float64{20, 10}, info.ChargeAmounts)
+}
+
+func TestApplyTags_DryRunRecordsWithoutMonarchWrites(t *testing.T) {
+	client := &fakeTagClient{}
+	o := newTaggingOrchestrator(t, []categorizer.ItemCategorization{
+		{ItemName: "Bandages", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Thermometer", CategoryID: "med", CategoryName: "Medical", Confidence: 0.9},
+		{ItemName: "Teddy Bear", CategoryID: "toys", CategoryName: "Toys", Confidence: 0.9},
+	}, client)
+
+	order := taggingTestOrder()
+	txn := &monarch.Transaction{ID: "TXN-D", Amount: -30}
+	splits, err := o.splitter.CreateSplits(context.Background(), order, txn, taggingTestCategories, nil)
+	require.NoError(t, err)
+
+	result := &handlers.ProcessResult{Processed: true, Transaction: txn, Splits: splits}
+	o.applyTags(context.Background(), order, result, true)
+
+	require.Len(t, result.TaggedItems, 3)
+	assert.Nil(t, client.set)
+	assert.Empty(t, client.created)
+}
+
+func TestAttachTags_FillsItemsAndSplits(t *testing.T) {
+	record := &storage.ProcessingRecord{
+		Items: []storage.OrderItem{{Name: "Bandages"}, {Name: "Thermometer"}, {Name: "Teddy Bear"}},
+		Splits: []storage.SplitDetail{
+			{CategoryID: "med", Amount: -20},
+			{CategoryID: "toys", Amount: -10},
+		},
+	}
+	attachTags(record, []handlers.TaggedItem{
+		{Name: "bandages", CategoryID: "med", Tags: []string{"HSA/FSA"}},
+		{Name: "Thermometer", CategoryID: "med"},
+		{Name: "Teddy Bear", CategoryID: "toys", Tags: []string{"Gift"}},
+	})
+
+	assert.Equal(t, []string{"HSA/FSA"}, record.Items[0].Tags)
+	assert.Empty(t, record.Items[1].Tags)
+	require.Len(t, record.Splits[0].Items, 2)
+	assert.Equal(t, []string{"HSA/FSA"}, record.Splits[0].Tags)
+	assert.Equal(t, []string{"Gift"}, record.Splits[1].Tags)
+	assert.Equal(t, "Teddy Bear", record.Splits[1].Items[0].Name)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"log/slog"
+	"math"
 	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/clients"
@@ -12,17 +13,22 @@ import (
 	"github.com/eshaffer321/itemize/internal/domain/categorizer"
 	"github.com/eshaffer321/itemize/internal/domain/matcher"
 	"github.com/eshaffer321/itemize/internal/domain/splitter"
+	"github.com/eshaffer321/itemize/internal/domain/tagger"
 	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 )
 
 // ProgressUpdate represents a progress update during sync
 type ProgressUpdate struct {
-	Phase           string // "fetching_orders", "processing_orders"
+	Phase           string // "fetching_orders", "categorizing_items", "processing_orders"
 	TotalOrders     int
 	ProcessedOrders int
 	SkippedOrders   int
 	ErroredOrders   int
+
+	// Item counts for the "categorizing_items" phase (unique, deduplicated items)
+	TotalItems       int
+	CategorizedItems int
 }
 
 // ProgressCallback is called to report progress during sync
@@ -33,10 +39,34 @@ type Options struct {
 	DryRun           bool
 	LookbackDays     int
 	MaxOrders        int
+	SinceLast        bool // Look back to the provider's sync watermark instead of LookbackDays
 	Force            bool
 	Verbose          bool
 	OrderID          string           // If set, only process this specific order (for testing)
 	ProgressCallback ProgressCallback // Optional callback for progress updates
+
+	// Low-confidence categorization handling (see splitter.ConfidencePolicy)
+	MinConfidence    float64 // Items below this confidence are low confidence (0 = disabled)
+	FallbackCategory string  // Monarch category ID or name for low-confidence items (empty = flag for review)
+
+	// CategoryGroups limits which Monarch category groups items may be assigned to
+	CategoryGroups categorizer.GroupFilter
+
+	// MultiChargeMode selects how orders with several bank charges are applied
+	// to Monarch: "consolidate" into one transaction (default) or "split" each
+	// charge by its shipment's items
+	MultiChargeMode string
+
+	// MerchantAliases are extra Monarch merchant names for the provider's
+	// charges, as substrings or /regular expressions/ (see newMerchantFilter)
+	MerchantAliases []string
+	// MonarchAccounts limits candidates to these Monarch accounts, by ID,
+	// display name or last four digits (empty = every account)
+	MonarchAccounts []string
+	// CardAccounts maps payment cards (last four digits) to the Monarch
+	// accounts their charges post to; orders paid with a mapped card are not
+	// matched to charges on other accounts
+	CardAccounts map[string][]string
 }
 
 // Result holds sync results
@@ -59,13 +89,28 @@ type Orchestrator struct {
 	amazonHandler  *handlers.AmazonHandler
 	walmartHandler *handlers.WalmartHandler
 	simpleHandler  *handlers.SimpleHandler
-	monarchAdapter *monarchAdapter
+	// costcoRefundHandler categorizes Costco returns with the categories of
+	// the original purchase
+	costcoRefundHandler *handlers.CostcoRefundHandler
+	monarchAdapter      *monarchAdapter
+	cacheWarmer         itemCacheWarmer // Cross-order batch categorization (nil = disabled)
+	promptHash          string          // Hash of the LLM prompt, stored on each record
+	tagger              *tagger.Tagger  // Assigns Monarch tags to items (nil = disabled)
+	tagClient           tagClient
+	monarchTags         map[string]*monarch.Tag // Monarch tags by lowercase name, loaded on first use
+	reviewClient        reviewClient            // Flags low-confidence transactions for review
 	// reconciliationClient resolves pending transaction IDs to their posted
 	// replacements and reapplies cached categorization without another LLM call.
 	reconciliationClient transactionReconciliationClient
 	storage              storage.Repository // Interface instead of concrete type
 	logger               *slog.Logger
 	runID                int64 // Current sync run ID for API logging
+	// assignment is the run's assignment of transactions to order charges
+	assignment *matcher.Assignment
+	// transactionPages reads pages of Monarch transactions; transactionPager
+	// pages through a query with it
+	transactionPages TransactionPageSource
+	transactionPager *transactionPager
 }
 
 // NewOrchestrator creates a new sync orchestrator
@@ -75,10 +120,19 @@ func NewOrchestrator(
 	store storage.Repository,
 	logger *slog.Logger,
 ) *Orchestrator {
-	// Create splitter with categorizer from clients (if available)
+	// Create splitter with categorizer from clients (if available).
+	// An explicit ItemCategorizer (e.g. history-first) takes precedence.
+	var itemCategorizer categorizer.ItemCategorizer
+	if clients != nil {
+		if clients.ItemCategorizer != nil {
+			itemCategorizer = clients.ItemCategorizer
+		} else if clients.Categorizer != nil {
+			itemCategorizer = clients.Categorizer
+		}
+	}
 	var spl *splitter.Splitter
-	if clients != nil && clients.Categorizer != nil {
-		spl = splitter.NewSplitter(clients.Categorizer)
+	if itemCategorizer != nil {
+		spl = splitter.NewSplitter(itemCategorizer)
 	}
 
 	// Create matcher with standard config (reused across all orders)
@@ -113,6 +167,8 @@ func NewOrchestrator(
 			mAdapter,
 			logger,
 		)
+		// Returned items take the categories of the order they were bought in
+		amazonHandler.SetPurchaseHistory(&purchaseHistory{repo: store})
 	}
 
 	// Create Walmart handler (if all dependencies available)
@@ -125,6 +181,8 @@ func NewOrchestrator(
 			mAdapter,
 			logger,
 		)
+		// Returned items take the categories of the order they were bought in
+		walmartHandler.SetPurchaseHistory(&purchaseHistory{repo: store})
 	}
 
 	// Create Simple handler for providers without special handling (Costco, etc.)
@@ -138,6 +196,41 @@ func NewOrchestrator(
 		)
 	}
 
+	// Create Costco refund handler; returned items take their purchase category
+	var costcoRefundHandler *handlers.CostcoRefundHandler
+	if clients != nil && clients.Monarch != nil && spl != nil {
+		costcoRefundHandler = handlers.NewCostcoRefundHandler(
+			transactionMatcher,
+			&splitterAdapter{spl},
+			mAdapter,
+			logger,
+		)
+		costcoRefundHandler.SetPurchaseHistory(&purchaseHistory{repo: store})
+	}
+
+	warmer, _ := itemCategorizer.(itemCacheWarmer)
+
+	promptHash := ""
+	if clients != nil && clients.Categorizer != nil {
+		promptHash = clients.Categorizer.PromptHash()
+	}
+
+	var itemTagger *tagger.Tagger
+	if clients != nil {
+		itemTagger = clients.Tagger
+	}
+	var tags tagClient
+	var review reviewClient
+	if mAdapter != nil {
+		tags = mAdapter
+		review = mAdapter
+	}
+
+	var transactionPages TransactionPageSource
+	if clients != nil && clients.Monarch != nil {
+		transactionPages = MonarchTransactionPages(clients.Monarch)
+	}
+
 	return &Orchestrator{
 		provider:             provider,
 		clients:              clients,
@@ -147,8 +240,15 @@ func NewOrchestrator(
 		amazonHandler:        amazonHandler,
 		walmartHandler:       walmartHandler,
 		simpleHandler:        simpleHandler,
+		costcoRefundHandler:  costcoRefundHandler,
 		monarchAdapter:       mAdapter,
+		cacheWarmer:          warmer,
+		promptHash:           promptHash,
+		tagger:               itemTagger,
+		tagClient:            tags,
+		reviewClient:         review,
 		reconciliationClient: mAdapter,
+		transactionPages:     transactionPages,
 		storage:              store,
 		logger:               logger,
 	}
@@ -183,6 +283,14 @@ func (a *splitterAdapter) GetSingleCategoryInfo(ctx context.Context, order provi
 	return a.splitter.GetSingleCategoryInfo(ctx, order, categories)
 }
 
+func (a *splitterAdapter) CategorizeOrder(ctx context.Context, order providers.Order, categories []categorizer.Category) ([]categorizer.ItemCategorization, error) {
+	return a.splitter.CategorizeOrder(ctx, order, categories)
+}
+
+func (a *splitterAdapter) SplitWithCategorizations(order providers.Order, transaction *monarch.Transaction, categorizations []categorizer.ItemCategorization) ([]*monarch.TransactionSplit, string, string, error) {
+	return a.splitter.SplitWithCategorizations(order, transaction, categorizations)
+}
+
 // monarchAdapter wraps monarch.Client to implement handlers.MonarchClient
 type monarchAdapter struct {
 	client  *monarch.Client
@@ -222,6 +330,31 @@ func (a *monarchAdapter) UpdateSplits(ctx context.Context, id string, splits []*
 	return err
 }
 
+func (a *monarchAdapter) ListTags(ctx context.Context) ([]*monarch.Tag, error) {
+	start := time.Now()
+	tags, err := a.client.Tags.List(ctx)
+	a.logAPICallCompletion(ctx, "", "Tags.List", tags, err, time.Since(start))
+	return tags, err
+}
+
+func (a *monarchAdapter) CreateTag(ctx context.Context, name, color string) (*monarch.Tag, error) {
+	request := map[string]string{"name": name, "color": color}
+	a.logAPICallIntent(ctx, "", "Tags.Create", request)
+	start := time.Now()
+	tag, err := a.client.Tags.Create(ctx, name, color)
+	a.logAPICallCompletion(ctx, "", "Tags.Create", tag, err, time.Since(start))
+	return tag, err
+}
+
+func (a *monarchAdapter) SetTransactionTags(ctx context.Context, id string, tagIDs []string) error {
+	a.logAPICallIntent(ctx, id, "Tags.SetTransactionTags", tagIDs)
+	start := time.Now()
+	err := a.client.Tags.SetTransactionTags(ctx, id, tagIDs...)
+	response := map[string]any{"ok": err == nil, "tag_count": len(tagIDs)}
+	a.logAPICallCompletion(ctx, id, "Tags.SetTransactionTags", response, err, time.Since(start))
+	return err
+}
+
 func (a *monarchAdapter) logAPICallIntent(ctx context.Context, transactionID, method string, request any) {
 	a.logAPICallPhase(ctx, transactionID, method, "intent", request, nil, nil, 0)
 }
@@ -351,3 +484,32 @@ func (a *ledgerStorageAdapter) SaveLedger(ledger *handlers.LedgerData, syncRunID
 
 	return a.repo.SaveLedger(orderLedger)
 }
+
+// RecordChargeMatches marks the charges of the order's latest ledger as
+// matched. Each match takes the first charge of the same amount not already
+// taken by another match; gift card payments never match.
+func (a *ledgerStorageAdapter) RecordChargeMatches(orderID string, matches []handlers.ChargeMatch) error {
+	if a.repo == nil {
+		return nil
+	}
+	ledger, err := a.repo.GetLatestLedger(orderID)
+	if err != nil || ledger == nil {
+		return err
+	}
+
+	taken := make(map[int64]bool)
+	for _, match := range matches {
+		for _, charge := range ledger.Charges {
+			if taken[charge.ID] || charge.PaymentMethod == "GIFTCARD" ||
+				math.Abs(charge.ChargeAmount-match.Amount) > 0.01 {
+				continue
+			}
+			taken[charge.ID] = true
+			if err := a.repo.UpdateChargeMatch(charge.ID, match.TransactionID, 1.0, match.SplitCount); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package sync
+
+import (
+	"math"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// sinceLastOverlapDays is how far before its watermark an incremental sync
+// starts, so orders that show up in the provider's history a little late are
+// still fetched.
+const sinceLastOverlapDays = 2
+
+// openRecordStatuses are the processing record statuses that leave an order
+// open for the next incremental sync.
+var openRecordStatuses = map[string]bool{
+	"pending":     true,
+	"provisional": true,
+	"failed":      true,
+}
+
+// watermarkAccounts returns the provider accounts watermarks are kept for:
+// the accounts the provider reads, or "" for single-account providers.
+func (o *Orchestrator) watermarkAccounts() []string {
+	if accounts := o.providerAccounts(); len(accounts) > 0 {
+		return accounts
+	}
+	return []string{""}
+}
+
+// sinceLastLookbackDays returns how many days an incremental (-since-last)
+// sync must look back: to the oldest watermark of the provider's accounts or
+// the oldest order any of them left open, whichever is earlier. When an
+// account has no watermark yet the regular lookback is used.
+func (o *Orchestrator) sinceLastLookbackDays(lookbackDays int, now time.Time) int {
+	if o.storage == nil {
+		return lookbackDays
+	}
+
+	var from time.Time
+	for _, account := range o.watermarkAccounts() {
+		watermark, err := o.storage.GetSyncWatermark(o.provider.DisplayName(), account)
+		if err != nil {
+			o.logger.Warn("Failed to load sync watermark; using lookback days", "account", account, "days", lookbackDays, "error", err)
+			return lookbackDays
+		}
+		if watermark == nil {
+			o.logger.Info("No sync watermark yet; using lookback days", "account", account, "days", lookbackDays)
+			return lookbackDays
+		}
+		if fetchFrom := watermark.FetchFrom(); from.IsZero() || fetchFrom.Before(from) {
+			from = fetchFrom
+		}
+	}
+
+	days := int(math.Ceil(now.Sub(from).Hours()/24)) + sinceLastOverlapDays
+	if days < 1 {
+		days = 1
+	}
+	o.logger.Info("Syncing since last watermark",
+		"from", from.Format("2006-01-02"),
+		"lookback_days", days)
+	return days
+}
+
+// saveWatermarks records how far each provider account was synced by a run
+// that fetched the orders dated from fetchedFrom on. A run only advances an
+// account's watermark when it was incremental (sinceLast) or its window
+// reached back to where the watermark says to fetch from; a shorter run would
+// otherwise settle the orders it never looked at.
+//
+// The watermark is settled through the latest order date the run settled (or
+// the previous watermark's, when later or when the run didn't reach it). Orders whose record is still
+// pending, provisional or failed, and those that errored (failed), stay open
+// so the next incremental sync fetches them again, as do the previous
+// watermark's open orders dated before the window.
+func (o *Orchestrator) saveWatermarks(orders []providers.Order, failed map[string]bool, fetchedFrom time.Time, sinceLast bool) {
+	if o.storage == nil {
+		return
+	}
+
+	accounts := o.watermarkAccounts()
+	fetched := make(map[string]bool, len(orders))
+	open := make(map[string][]storage.OpenOrder)
+	settledThrough := make(map[string]time.Time)
+	for _, order := range orders {
+		fetched[order.GetID()] = true
+		account := providers.OrderAccount(order)
+		if len(accounts) == 1 {
+			account = accounts[0]
+		}
+
+		status := ""
+		if failed[order.GetID()] {
+			status = "failed"
+		}
+		if record, err := o.storage.GetRecord(order.GetID()); err == nil && record != nil && openRecordStatuses[record.Status] {
+			status = record.Status
+		}
+		if status == "" {
+			if order.GetDate().After(settledThrough[account]) {
+				settledThrough[account] = order.GetDate()
+			}
+			continue
+		}
+		open[account] = append(open[account], storage.OpenOrder{
+			OrderID:   order.GetID(),
+			OrderDate: order.GetDate(),
+			Status:    status,
+		})
+	}
+
+	for _, account := range accounts {
+		previous, err := o.storage.GetSyncWatermark(o.provider.DisplayName(), account)
+		if err != nil {
+			o.logger.Warn("Failed to load sync watermark; leaving it as it was", "account", account, "error", err)
+			continue
+		}
+
+		watermark := &storage.SyncWatermark{
+			Provider:       o.provider.DisplayName(),
+			Account:        account,
+			SettledThrough: settledThrough[account],
+			OpenOrders:     open[account],
+			RunID:          o.runID,
+		}
+		if previous != nil {
+			covered := !fetchedFrom.After(previous.FetchFrom())
+			if !covered && !sinceLast {
+				o.logger.Debug("Sync window starts after the watermark; leaving it as it was",
+					"account", account,
+					"fetched_from", fetchedFrom.Format("2006-01-02"),
+					"watermark_from", previous.FetchFrom().Format("2006-01-02"))
+				continue
+			}
+			// An incremental run that didn't reach back to this account's
+			// watermark (another account had none) settles nothing new
+			if !covered || previous.SettledThrough.After(watermark.SettledThrough) {
+				watermark.SettledThrough = previous.SettledThrough
+			}
+			for _, order := range previous.OpenOrders {
+				if !fetched[order.OrderID] && order.OrderDate.Before(fetchedFrom) {
+					watermark.OpenOrders = append(watermark.OpenOrders, order)
+				}
+			}
+		}
+		if watermark.SettledThrough.IsZero() {
+			// Nothing settled yet: the next sync starts where this one did
+			watermark.SettledThrough = fetchedFrom
+		}
+
+		if err := o.storage.SaveSyncWatermark(watermark); err != nil {
+			o.logger.Warn("Failed to save sync watermark", "account", account, "error", err)
+			continue
+		}
+		o.logger.Debug("Saved sync watermark",
+			"account", account,
+			"settled_through", watermark.SettledThrough.Format("2006-01-02"),
+			"open_orders", len(watermark.OpenOrders))
+	}
+}
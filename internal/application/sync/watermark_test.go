@@ -0,0 +1,142 @@
+package sync
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_SinceLastFetchesFromWatermark(t *testing.T) {
+	now := time.Now()
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveSyncWatermark(&storage.SyncWatermark{
+		Provider:       "Walmart",
+		SettledThrough: now.AddDate(0, 0, -5),
+		OpenOrders:     []storage.OpenOrder{{OrderID: "open-1", OrderDate: now.AddDate(0, 0, -20), Status: "provisional"}},
+	}))
+	provider := &MockProvider{}
+	provider.On("DisplayName").Return("Walmart")
+	var fetched providers.FetchOptions
+	provider.On("FetchOrders", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { fetched = args.Get(1).(providers.FetchOptions) }).
+		Return([]providers.Order{}, nil)
+	orchestrator := &Orchestrator{provider: provider, storage: repo, logger: slog.Default()}
+
+	_, err := orchestrator.Run(context.Background(), Options{LookbackDays: 14, SinceLast: true})
+
+	require.NoError(t, err)
+	days := int(fetched.EndDate.Sub(fetched.StartDate).Hours()/24 + 0.5)
+	assert.InDelta(t, 20+sinceLastOverlapDays, days, 1, "widened to the open order plus the overlap")
+}
+
+func TestSinceLastLookbackDays(t *testing.T) {
+	now := time.Date(2026, time.July, 17, 12, 0, 0, 0, time.UTC)
+	provider := &MockProvider{}
+	provider.On("DisplayName").Return("Costco")
+	repo := storage.NewMockRepository()
+	orchestrator := &Orchestrator{provider: provider, storage: repo, logger: slog.Default()}
+
+	assert.Equal(t, 14, orchestrator.sinceLastLookbackDays(14, now), "no watermark yet")
+
+	require.NoError(t, repo.SaveSyncWatermark(&storage.SyncWatermark{Provider: "Costco", SettledThrough: now.AddDate(0, 0, -3)}))
+	assert.Equal(t, 3+sinceLastOverlapDays, orchestrator.sinceLastLookbackDays(14, now))
+
+	require.NoError(t, repo.SaveSyncWatermark(&storage.SyncWatermark{Provider: "Costco", SettledThrough: now.AddDate(0, 0, -60)}))
+	assert.Equal(t, 60+sinceLastOverlapDays, orchestrator.sinceLastLookbackDays(14, now), "a missed stretch widens past -days")
+
+	orchestrator.storage = nil
+	assert.Equal(t, 14, orchestrator.sinceLastLookbackDays(14, now))
+}
+
+func TestSaveWatermarks_KeepsUnsettledOrdersOpen(t *testing.T) {
+	now := time.Date(2026, time.July, 17, 12, 0, 0, 0, time.UTC)
+	repo := storage.NewMockRepository()
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{OrderID: "settled", Status: "success"}))
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{OrderID: "pending", Status: "pending"}))
+	require.NoError(t, repo.SaveRecord(&storage.ProcessingRecord{OrderID: "provisional", Status: "provisional"}))
+	provider := &MockProvider{}
+	provider.On("DisplayName").Return("Walmart")
+	orchestrator := &Orchestrator{provider: provider, storage: repo, runID: 9, logger: slog.Default()}
+	orders := []providers.Order{
+		&mockOrder{id: "settled", date: now.AddDate(0, 0, -2)},
+		&mockOrder{id: "pending", date: now.AddDate(0, 0, -1)},
+		&mockOrder{id: "provisional", date: now.AddDate(0, 0, -4)},
+		&mockOrder{id: "errored", date: now.AddDate(0, 0, -6)},
+		&mockOrder{id: "already-split", date: now.AddDate(0, 0, -3)},
+	}
+
+	orchestrator.saveWatermarks(orders, map[string]bool{"errored": true}, now.AddDate(0, 0, -14), false)
+
+	watermark, err := repo.GetSyncWatermark("Walmart", "")
+	require.NoError(t, err)
+	require.NotNil(t, watermark)
+	assert.Equal(t, now.AddDate(0, 0, -2), watermark.SettledThrough, "the latest settled order, not the time of the run")
+	assert.Equal(t, int64(9), watermark.RunID)
+	statuses := make(map[string]string)
+	for _, order := range watermark.OpenOrders {
+		statuses[order.OrderID] = order.Status
+	}
+	assert.Equal(t, map[string]string{"pending": "pending", "provisional": "provisional", "errored": "failed"}, statuses)
+	assert.Equal(t, now.AddDate(0, 0, -6), watermark.FetchFrom())
+}
+
+func TestSaveWatermarks_ShortRunAfterStaleWatermark(t *testing.T) {
+	now := time.Date(2026, time.July, 17, 12, 0, 0, 0, time.UTC)
+	stale := &storage.SyncWatermark{
+		Provider:       "Walmart",
+		SettledThrough: now.AddDate(0, 0, -30),
+		OpenOrders:     []storage.OpenOrder{{OrderID: "old-open", OrderDate: now.AddDate(0, 0, -40), Status: "failed"}},
+		RunID:          3,
+	}
+	provider := &MockProvider{}
+	provider.On("DisplayName").Return("Walmart")
+	orders := []providers.Order{&mockOrder{id: "recent", date: now.AddDate(0, 0, -2)}}
+
+	t.Run("a regular run leaves the watermark alone", func(t *testing.T) {
+		repo := storage.NewMockRepository()
+		require.NoError(t, repo.SaveSyncWatermark(stale))
+		orchestrator := &Orchestrator{provider: provider, storage: repo, runID: 9, logger: slog.Default()}
+
+		orchestrator.saveWatermarks(orders, nil, now.AddDate(0, 0, -5), false)
+
+		watermark, err := repo.GetSyncWatermark("Walmart", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), watermark.RunID)
+		assert.Equal(t, now.AddDate(0, 0, -40), watermark.FetchFrom(), "the 35 days the run never fetched are still unsynced")
+	})
+
+	t.Run("an incremental run keeps what it didn't reach", func(t *testing.T) {
+		repo := storage.NewMockRepository()
+		require.NoError(t, repo.SaveSyncWatermark(stale))
+		orchestrator := &Orchestrator{provider: provider, storage: repo, runID: 9, logger: slog.Default()}
+
+		orchestrator.saveWatermarks(orders, nil, now.AddDate(0, 0, -5), true)
+
+		watermark, err := repo.GetSyncWatermark("Walmart", "")
+		require.NoError(t, err)
+		assert.Equal(t, int64(9), watermark.RunID)
+		assert.Equal(t, now.AddDate(0, 0, -30), watermark.SettledThrough, "nothing before the window is settled")
+		require.Len(t, watermark.OpenOrders, 1)
+		assert.Equal(t, "old-open", watermark.OpenOrders[0].OrderID, "open orders outside the window carry over")
+	})
+
+	t.Run("a run covering the watermark advances it", func(t *testing.T) {
+		repo := storage.NewMockRepository()
+		require.NoError(t, repo.SaveSyncWatermark(stale))
+		orchestrator := &Orchestrator{provider: provider, storage: repo, runID: 9, logger: slog.Default()}
+
+		orchestrator.saveWatermarks(orders, nil, now.AddDate(0, 0, -42), false)
+
+		watermark, err := repo.GetSyncWatermark("Walmart", "")
+		require.NoError(t, err)
+		assert.Equal(t, now.AddDate(0, 0, -2), watermark.SettledThrough)
+		assert.Empty(t, watermark.OpenOrders, "old-open was in the window and no longer open")
+	})
+}
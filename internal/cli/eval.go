@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/eshaffer321/itemize/internal/adapters/clients"
+	"github.com/eshaffer321/itemize/internal/application/eval"
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/infrastructure/config"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// EvalFlags holds the CLI flags for the eval command.
+type EvalFlags struct {
+	Dataset     string
+	Export      string
+	Backend     string
+	Model       string
+	Prompt      string
+	Label       string
+	BatchSize   int
+	InputPrice  float64
+	OutputPrice float64
+	History     bool
+	NoSave      bool
+	Mistakes    int
+}
+
+// ParseEvalFlags parses command line flags for the eval command.
+func ParseEvalFlags() *EvalFlags {
+	flags := &EvalFlags{}
+	flag.StringVar(&flags.Dataset, "dataset", "", "Labeled YAML dataset to evaluate")
+	flag.StringVar(&flags.Export, "export", "", "Write a dataset built from categorized orders in the database to this file ('-' for stdout) and exit")
+	flag.StringVar(&flags.Backend, "backend", "", "LLM backend: 'openai' or 'anthropic' (default: CATEGORIZER_PROVIDER / auto-detect)")
+	flag.StringVar(&flags.Model, "model", "", "Model to evaluate (default: configured model for the backend)")
+	flag.StringVar(&flags.Prompt, "prompt", "", "Prompt template file to evaluate (default: categorizer.prompt from config)")
+	flag.StringVar(&flags.Label, "label", "", "Note stored with the run, e.g. what changed")
+	flag.IntVar(&flags.BatchSize, "batch", 0, "Items per LLM call (default 40)")
+	flag.Float64Var(&flags.InputPrice, "input-price", 0, "USD per million prompt tokens, for cost estimates")
+	flag.Float64Var(&flags.OutputPrice, "output-price", 0, "USD per million completion tokens, for cost estimates")
+	flag.BoolVar(&flags.History, "history", false, "List stored eval runs (filtered by -dataset name if given) and exit")
+	flag.BoolVar(&flags.NoSave, "no-save", false, "Don't store the run in the database")
+	flag.IntVar(&flags.Mistakes, "mistakes", 20, "Number of misclassified items to print")
+	flag.Parse()
+	return flags
+}
+
+// RunEval runs the eval command: export a dataset, list past runs, or score a
+// dataset against an LLM backend and store the result.
+func RunEval(cfg *config.Config, flags *EvalFlags) error {
+	store, err := storage.NewStorage(cfg.Storage.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	switch {
+	case flags.Export != "":
+		return exportEvalDataset(store, flags.Export)
+	case flags.History:
+		runs, err := store.ListEvalRuns(evalDatasetName(flags.Dataset), 50)
+		if err != nil {
+			return err
+		}
+		PrintEvalRuns(os.Stdout, runs)
+		return nil
+	case flags.Dataset == "":
+		return fmt.Errorf("-dataset is required (or use -export / -history)")
+	}
+
+	ds, err := eval.LoadDataset(flags.Dataset)
+	if err != nil {
+		return err
+	}
+
+	if flags.Backend != "" {
+		cfg.Categorizer.Provider = flags.Backend
+	}
+	if flags.Model != "" {
+		cfg.OpenAI.Model = flags.Model
+		cfg.Anthropic.Model = flags.Model
+	}
+	if flags.Prompt != "" {
+		cfg.Categorizer.Prompt.TemplateFile = flags.Prompt
+	}
+	client, model, err := clients.NewChatClient(cfg)
+	if err != nil {
+		return err
+	}
+	prompt, err := clients.NewPrompt(cfg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Evaluating %s (%d items) with %s/%s\n", ds.Name, len(ds.Items), clients.BackendName(client), model)
+	report, err := eval.Run(context.Background(), client, ds, eval.Options{
+		Backend:               clients.BackendName(client),
+		Model:                 model,
+		Label:                 flags.Label,
+		BatchSize:             flags.BatchSize,
+		Prompt:                prompt,
+		InputPricePerMillion:  flags.InputPrice,
+		OutputPricePerMillion: flags.OutputPrice,
+	})
+	if err != nil {
+		return err
+	}
+	PrintEvalReport(os.Stdout, report, flags.Mistakes)
+
+	if flags.NoSave {
+		return nil
+	}
+	run, err := report.EvalRun()
+	if err != nil {
+		return err
+	}
+	if err := store.SaveEvalRun(run); err != nil {
+		return fmt.Errorf("save eval run: %w", err)
+	}
+	fmt.Printf("\nSaved as eval run #%d (compare with: itemize eval -history -dataset %s)\n", run.ID, flags.Dataset)
+	return nil
+}
+
+// evalDatasetName maps a -dataset path to the name stored with runs.
+func evalDatasetName(path string) string {
+	if path == "" {
+		return ""
+	}
+	if ds, err := eval.LoadDataset(path); err == nil {
+		return ds.Name
+	}
+	return path
+}
+
+func exportEvalDataset(store storage.OrderRepository, path string) error {
+	examples, err := sync.LoadCategoryHistory(store)
+	if err != nil {
+		return fmt.Errorf("load categorization history: %w", err)
+	}
+	if len(examples) == 0 {
+		return fmt.Errorf("no categorized orders in the database to export")
+	}
+	ds := eval.DatasetFromHistory("history", examples)
+
+	if path == "-" {
+		return eval.WriteDataset(os.Stdout, ds)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := eval.WriteDataset(f, ds); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("Exported %d items in %d categories to %s\n", len(ds.Items), len(ds.Categories), path)
+	return nil
+}
+
+// PrintEvalReport prints accuracy, cost, latency, per-category stats, the
+// confusion matrix and up to maxMistakes misclassified items.
+func PrintEvalReport(w io.Writer, report *eval.Report, maxMistakes int) {
+	_, _ = fmt.Fprintln(w, strings.Repeat("-", 60))
+	_, _ = fmt.Fprintf(w, "Accuracy: %.1f%% (%d/%d)", report.Accuracy*100, report.Correct, report.Items)
+	if report.Unanswered > 0 {
+		_, _ = fmt.Fprintf(w, " | Unanswered: %d", report.Unanswered)
+	}
+	if report.FailedBatches > 0 {
+		_, _ = fmt.Fprintf(w, " | Failed batches: %d", report.FailedBatches)
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintf(w, "Prompt: %s | Calls: %d | Tokens: %d in / %d out | Cost: $%.4f\n",
+		report.PromptHash, report.Calls, report.PromptTokens, report.CompletionTokens, report.CostUSD)
+	_, _ = fmt.Fprintf(w, "Latency: total %dms | mean %dms | p50 %dms | p95 %dms | max %dms\n",
+		report.Latency.TotalMs, report.Latency.MeanMs, report.Latency.P50Ms, report.Latency.P95Ms, report.Latency.MaxMs)
+
+	_, _ = fmt.Fprintln(w, "\nPer category:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  #\tCategory\tExpected\tPredicted\tCorrect\tPrecision\tRecall")
+	for i, row := range report.Categories {
+		_, _ = fmt.Fprintf(tw, "  %d\t%s\t%d\t%d\t%d\t%.2f\t%.2f\n",
+			i+1, row.Name, row.Expected, row.Predicted, row.Correct, row.Precision, row.Recall)
+	}
+	_ = tw.Flush()
+
+	// Columns are numbered like the rows above to keep the matrix narrow
+	_, _ = fmt.Fprintln(w, "\nConfusion matrix (rows: expected, columns: predicted):")
+	tw = tabwriter.NewWriter(w, 0, 0, 1, ' ', tabwriter.AlignRight)
+	header := "\t"
+	for i := range report.Categories {
+		header += fmt.Sprintf("%d\t", i+1)
+	}
+	if report.Unanswered > 0 {
+		header += "none\t"
+	}
+	_, _ = fmt.Fprintln(tw, header)
+	for i, expected := range report.Categories {
+		predictions := report.Confusion[expected.ID]
+		if len(predictions) == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%d\t", i+1)
+		for _, predicted := range report.Categories {
+			line += cellCount(predictions[predicted.ID])
+		}
+		if report.Unanswered > 0 {
+			line += cellCount(predictions[eval.NoAnswer])
+		}
+		_, _ = fmt.Fprintln(tw, line)
+	}
+	_ = tw.Flush()
+
+	if len(report.Mistakes) == 0 || maxMistakes <= 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nMisclassified (%d):\n", len(report.Mistakes))
+	for i, mistake := range report.Mistakes {
+		if i == maxMistakes {
+			_, _ = fmt.Fprintf(w, "  ... and %d more\n", len(report.Mistakes)-maxMistakes)
+			break
+		}
+		_, _ = fmt.Fprintf(w, "  - %s: expected %s, got %s (%.2f)\n",
+			mistake.Item, mistake.Expected, mistake.Predicted, mistake.Confidence)
+	}
+}
+
+func cellCount(count int) string {
+	if count == 0 {
+		return ".\t"
+	}
+	return fmt.Sprintf("%d\t", count)
+}
+
+// PrintEvalRuns prints stored eval runs, newest first.
+func PrintEvalRuns(w io.Writer, runs []storage.EvalRun) {
+	if len(runs) == 0 {
+		_, _ = fmt.Fprintln(w, "No eval runs stored yet.")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tWhen\tDataset\tBackend\tModel\tPrompt\tAccuracy\tCost\tTime\tLabel")
+	for _, run := range runs {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%.1f%% (%d/%d)\t$%.4f\t%dms\t%s\n",
+			run.ID, run.CreatedAt, run.Dataset, run.Backend, run.Model, run.PromptHash,
+			run.Accuracy*100, run.CorrectCount, run.ItemCount, run.CostUSD, run.DurationMs, run.Label)
+	}
+	_ = tw.Flush()
+}
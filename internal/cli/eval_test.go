@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eshaffer321/itemize/internal/application/eval"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintEvalReport(t *testing.T) {
+	report := &eval.Report{
+		Items:      4,
+		Correct:    2,
+		Unanswered: 1,
+		Accuracy:   0.5,
+		PromptHash: "abc123def456",
+		Categories: []eval.CategoryStats{
+			{ID: "cat_1", Name: "Groceries", Expected: 3, Predicted: 3, Correct: 2},
+			{ID: "cat_2", Name: "Home & Garden", Expected: 1, Predicted: 0, Correct: 0},
+		},
+		Confusion: map[string]map[string]int{
+			"cat_1": {"cat_1": 2, eval.NoAnswer: 1},
+			"cat_2": {"cat_1": 1},
+		},
+		Mistakes: []eval.Mistake{
+			{Item: "Trash Bags", Expected: "Home & Garden", Predicted: "Groceries", Confidence: 0.7},
+			{Item: "Milk", Expected: "Groceries", Predicted: eval.NoAnswer},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintEvalReport(&buf, report, 1)
+	out := buf.String()
+
+	assert.Contains(t, out, "Accuracy: 50.0% (2/4) | Unanswered: 1")
+	assert.Contains(t, out, "Prompt: abc123def456")
+	assert.Contains(t, out, "Home & Garden")
+	assert.Contains(t, out, "none")
+	assert.Contains(t, out, "Trash Bags: expected Home & Garden, got Groceries (0.70)")
+	assert.Contains(t, out, "... and 1 more")
+}
+
+func TestPrintEvalRuns(t *testing.T) {
+	var buf bytes.Buffer
+	PrintEvalRuns(&buf, nil)
+	assert.Contains(t, buf.String(), "No eval runs stored yet.")
+
+	buf.Reset()
+	PrintEvalRuns(&buf, []storage.EvalRun{{ID: 3, Dataset: "groceries.yaml", Backend: "openai", Model: "gpt-5.4-nano", Accuracy: 0.875, CorrectCount: 7, ItemCount: 8, Label: "baseline"}})
+	assert.Contains(t, buf.String(), "87.5% (7/8)")
+	assert.Contains(t, buf.String(), "baseline")
+}
@@ -13,6 +13,7 @@ import (
 type SyncFlags struct {
 	DryRun               bool
 	LookbackDays         int
+	SinceLast            bool
 	MaxOrders            int
 	Force                bool
 	Verbose              bool
@@ -20,10 +21,15 @@ type SyncFlags struct {
 	Account              string
 	CookieFile           string
 	ListAccounts         bool
+	AllAccounts          bool
 	ImportBrowserProfile string
 	PlaywrightRoot       string
 	Headless             bool
 	SkipAuthCheck        bool
+	Receipt              string
+	TCNumber             string
+	StoreID              string
+	ReceiptDate          string
 	ExtraArgs            []string
 }
 
@@ -32,6 +38,7 @@ func ParseSyncFlags(providerName string) SyncFlags {
 	var flags SyncFlags
 	flag.BoolVar(&flags.DryRun, "dry-run", false, "Run without making changes")
 	flag.IntVar(&flags.LookbackDays, "days", 14, "Number of days to look back")
+	flag.BoolVar(&flags.SinceLast, "since-last", false, "Look back to where the last sync left off (-days is used until there is one)")
 	flag.IntVar(&flags.MaxOrders, "max", 0, "Maximum orders to process (0 = all)")
 	flag.BoolVar(&flags.Force, "force", false, "Force reprocess already processed orders")
 	flag.BoolVar(&flags.Verbose, "verbose", false, "Verbose output")
@@ -39,10 +46,15 @@ func ParseSyncFlags(providerName string) SyncFlags {
 	flag.StringVar(&flags.Account, "account", "", "Amazon cookie account name (overrides AMAZON_ACCOUNT_NAME; run -list-accounts to see saved accounts)")
 	flag.StringVar(&flags.CookieFile, "cookie-file", "", "Explicit Amazon cookie file (overrides AMAZON_COOKIE_FILE)")
 	flag.BoolVar(&flags.ListAccounts, "list-accounts", false, "List saved Amazon cookie accounts and exit")
+	flag.BoolVar(&flags.AllAccounts, "all-accounts", false, "Sync every saved Amazon cookie account in one run")
 	flag.StringVar(&flags.ImportBrowserProfile, "import-browser-profile", "", "Import Amazon cookies from this Chromium/Playwright browser profile and exit")
 	flag.StringVar(&flags.PlaywrightRoot, "playwright-root", "", "Directory containing node_modules/playwright for Amazon cookie import")
 	flag.BoolVar(&flags.Headless, "headless", false, "Run Amazon browser profile import headlessly")
 	flag.BoolVar(&flags.SkipAuthCheck, "skip-auth-check", false, "Skip Amazon auth validation after importing cookies")
+	flag.StringVar(&flags.Receipt, "receipt", "", "Walmart receipt file, or directory of receipts, to sync instead of the linked account")
+	flag.StringVar(&flags.TCNumber, "tc", "", "TC number printed on the Walmart receipt to look up")
+	flag.StringVar(&flags.StoreID, "store", "", "Walmart store number (ST#) of the receipt to look up")
+	flag.StringVar(&flags.ReceiptDate, "receipt-date", "", "Date of the Walmart receipt to look up (YYYY-MM-DD)")
 
 	flag.Usage = func() {
 		if providerName == "amazon" {
@@ -79,6 +91,7 @@ func PrintAmazonUsage(w io.Writer) {
   itemize amazon setup -account <name>
   itemize amazon returns -account <name>
   itemize amazon -account <name> [sync options]
+  itemize amazon -all-accounts [sync options]
 
 First-time setup:
   setup                    Creates a browser profile and opens Chromium for Amazon sign-in
@@ -86,6 +99,8 @@ First-time setup:
 
 Account management:
   -list-accounts           List saved Amazon accounts
+  -all-accounts            Sync every saved account in one run, matching their
+                           orders against one set of Monarch transactions
 
 Direct Amazon data:
   returns                  Print Amazon return/refund records as JSON; does not write to Monarch
@@ -93,6 +108,7 @@ Direct Amazon data:
 Sync options:
   -dry-run                 Preview without making Monarch changes
   -days int                Number of days to look back (default 14)
+  -since-last              Look back to where the last sync left off
   -max int                 Maximum orders to process (0 = all)
   -order-id string         Process only one order
   -force                   Reprocess previously processed orders
@@ -118,6 +134,7 @@ func (f SyncFlags) ToSyncOptions() sync.Options {
 	return sync.Options{
 		DryRun:       f.DryRun,
 		LookbackDays: f.LookbackDays,
+		SinceLast:    f.SinceLast,
 		MaxOrders:    f.MaxOrders,
 		Force:        f.Force,
 		Verbose:      f.Verbose,
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/eshaffer321/itemize/internal/adapters/clients"
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/itemize/internal/infrastructure/config"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// defaultHistoryMinConfidence is used in "first" mode when no cutoff is configured.
+const defaultHistoryMinConfidence = 0.9
+
+// EnableHistoryCategorizer trains the offline categorizer from past processing
+// records and installs it on c according to cfg.Categorizer.History. It is a
+// no-op when history mode is off.
+func EnableHistoryCategorizer(cfg *config.Config, c *clients.Clients, store storage.OrderRepository, logger *slog.Logger) error {
+	historyCfg := cfg.Categorizer.History
+	if !historyCfg.Enabled() || c == nil {
+		return nil
+	}
+
+	examples, err := sync.LoadCategoryHistory(store)
+	if err != nil {
+		return fmt.Errorf("load categorization history: %w", err)
+	}
+	history := categorizer.NewHistoryCategorizer(examples)
+
+	if historyCfg.Standalone() {
+		if history.Size() == 0 {
+			return fmt.Errorf("categorizer history mode is \"only\" but no categorized orders are stored yet")
+		}
+		c.ItemCategorizer = history
+		logger.Info("Using history categorizer only (no LLM calls)", "examples", history.Size())
+		return nil
+	}
+
+	if c.Categorizer == nil {
+		return fmt.Errorf("categorizer history mode \"first\" requires an LLM categorizer")
+	}
+	if history.Size() == 0 {
+		logger.Info("No categorization history yet; using LLM categorizer")
+		return nil
+	}
+
+	cutoff := historyCfg.MinConfidence
+	if cutoff <= 0 {
+		cutoff = defaultHistoryMinConfidence
+	}
+	c.ItemCategorizer = categorizer.NewStagedCategorizer(history, c.Categorizer, cutoff)
+	logger.Info("Using history categorizer before LLM", "examples", history.Size(), "min_confidence", cutoff)
+	return nil
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"sort"
@@ -40,7 +41,9 @@ func NewCostcoProvider(cfg *config.Config, verbose bool) (providers.OrderProvide
 	}
 
 	costcoClient := costcogo.NewClient(costcoConfig)
-	return costco.NewProvider(costcoClient, costcoLogger), nil
+	provider := costco.NewProvider(costcoClient, costcoLogger)
+	provider.SetGasCategory(cfg.Providers.Costco.GasCategory)
+	return provider, nil
 }
 
 // NewWalmartProvider creates a new Walmart provider with a system-scoped logger
@@ -75,16 +78,37 @@ func NewWalmartProvider(cfg *config.Config, verbose bool) (providers.OrderProvid
 	return walmart.NewProvider(walmartClient, walmartLogger), nil
 }
 
+// NewWalmartReceiptProvider creates a Walmart provider that reads in-store
+// receipts from -receipt instead of the linked account, optionally narrowed to
+// the receipt with the given TC number, store and date.
+func NewWalmartReceiptProvider(cfg *config.Config, flags SyncFlags) (providers.OrderProvider, error) {
+	if flags.Receipt == "" {
+		return nil, fmt.Errorf("-tc, -store and -receipt-date look up a receipt in the files given with -receipt")
+	}
+
+	query := walmart.ReceiptQuery{TCNumber: flags.TCNumber, StoreID: flags.StoreID}
+	if flags.ReceiptDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", flags.ReceiptDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -receipt-date: %w", err)
+		}
+		query.Date = date
+	}
+
+	loggingCfg := cfg.Observability.Logging
+	if flags.Verbose {
+		loggingCfg.Level = "debug"
+	}
+	walmartLogger := logging.NewLoggerWithSystem(loggingCfg, "walmart")
+
+	return walmart.NewReceiptProvider([]string{flags.Receipt}, query, walmartLogger), nil
+}
+
 // NewAmazonProvider creates a new Amazon provider with a system-scoped logger.
 // account, if non-empty, overrides cfg.Providers.Amazon.AccountName (and thus
 // AMAZON_ACCOUNT_NAME) — it's the value of the -account flag.
 func NewAmazonProvider(cfg *config.Config, verbose bool, account string) (*amazonprovider.Provider, error) {
-	// Create an amazon-scoped logger with verbose flag
-	loggingCfg := cfg.Observability.Logging
-	if verbose {
-		loggingCfg.Level = "debug"
-	}
-	amazonLogger := logging.NewLoggerWithSystem(loggingCfg, "amazon")
+	amazonLogger := newAmazonLogger(cfg, verbose)
 
 	profile := cfg.Providers.Amazon.AccountName
 	if account != "" {
@@ -97,7 +121,59 @@ func NewAmazonProvider(cfg *config.Config, verbose bool, account string) (*amazo
 		CookieFile: cfg.Providers.Amazon.CookieFile,
 	}
 
-	return amazonprovider.NewProvider(amazonLogger, providerCfg), nil
+	provider := amazonprovider.NewProvider(amazonLogger, providerCfg)
+	if err := configureAmazonProvider(provider, cfg); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// NewAmazonAccountsProvider creates a provider that reads every saved Amazon
+// cookie account (see ListAmazonAccounts), for `itemize amazon -all-accounts`.
+// Each account uses its own cookie file, so AMAZON_COOKIE_FILE is ignored.
+func NewAmazonAccountsProvider(cfg *config.Config, verbose bool) (*amazonprovider.MultiAccountProvider, error) {
+	names, err := ListAmazonAccounts(cfg)
+	if err != nil {
+		return nil, err
+	}
+	amazonLogger := newAmazonLogger(cfg, verbose)
+
+	accounts := make([]*amazonprovider.Provider, 0, len(names))
+	for _, name := range names {
+		account := amazonprovider.NewProvider(amazonLogger, &amazonprovider.ProviderConfig{Profile: name})
+		if len(account.Accounts()) == 0 {
+			// NewProvider already warned that the name is unusable
+			continue
+		}
+		if err := configureAmazonProvider(account, cfg); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no saved Amazon accounts found; run 'itemize amazon setup -account <name>' to create one")
+	}
+	return amazonprovider.NewMultiAccountProvider(amazonLogger, accounts), nil
+}
+
+// configureAmazonProvider applies the digital and grocery order settings to
+// provider
+func configureAmazonProvider(provider *amazonprovider.Provider, cfg *config.Config) error {
+	amazonCfg := cfg.Providers.Amazon
+	if err := provider.SetDigitalOrders(amazonCfg.DigitalOrders, amazonCfg.DigitalCategories); err != nil {
+		return fmt.Errorf("invalid providers.amazon.digital_categories: %w", err)
+	}
+	provider.SetGroceryOrders(amazonCfg.GroceryOrders)
+	return nil
+}
+
+// newAmazonLogger creates an amazon-scoped logger, at debug level when verbose
+func newAmazonLogger(cfg *config.Config, verbose bool) *slog.Logger {
+	loggingCfg := cfg.Observability.Logging
+	if verbose {
+		loggingCfg.Level = "debug"
+	}
+	return logging.NewLoggerWithSystem(loggingCfg, "amazon")
 }
 
 // ResolveAmazonAccount returns the explicit Amazon cookie account for this run.
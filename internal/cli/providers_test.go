@@ -38,6 +38,31 @@ func TestListAmazonAccounts_MissingDirReturnsEmptyNotError(t *testing.T) {
 	assert.Empty(t, accounts)
 }
 
+func TestNewAmazonAccountsProvider_ReadsEverySavedAccount(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".amazon-go")
+	require.NoError(t, os.Mkdir(dir, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cookies-wife.json"), []byte("x"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cookies-me.json"), []byte("x"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cookies-bad.name.json"), []byte("x"), 0o600))
+	cfg := &config.Config{}
+	cfg.Providers.Amazon.CookieFile = filepath.Join(home, "explicit.json")
+
+	provider, err := NewAmazonAccountsProvider(cfg, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"me", "wife"}, provider.Accounts(), "unusable account names are skipped")
+}
+
+func TestNewAmazonAccountsProvider_RequiresSavedAccounts(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	_, err := NewAmazonAccountsProvider(&config.Config{}, false)
+
+	assert.ErrorContains(t, err, "no saved Amazon accounts found")
+}
+
 func TestResolveAmazonAccount_UsesSinglePositionalAccount(t *testing.T) {
 	cfg := &config.Config{}
 	account, err := ResolveAmazonAccount(cfg, "", []string{"amazon-wife"})
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	amazonprovider "github.com/eshaffer321/itemize/internal/adapters/providers/amazon"
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/infrastructure/config"
+	"github.com/eshaffer321/itemize/internal/infrastructure/logging"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// ReconcileChargesFlags holds the CLI flags for the reconcile-charges command.
+type ReconcileChargesFlags struct {
+	Provider        string
+	StaleDays       int
+	NeverPostedDays int
+	Limit           int
+	DryRun          bool
+	Verbose         bool
+}
+
+// ParseReconcileChargesFlags parses command line flags for the
+// reconcile-charges command.
+func ParseReconcileChargesFlags() *ReconcileChargesFlags {
+	flags := &ReconcileChargesFlags{}
+	flag.StringVar(&flags.Provider, "provider", "", "Only reconcile this provider's charges (default: all)")
+	flag.IntVar(&flags.StaleDays, "stale-days", 3, "Only retry charges at least this many days old")
+	flag.IntVar(&flags.NeverPostedDays, "never-posted-days", 14, "Report charges still unmatched after this many days as never posted")
+	flag.IntVar(&flags.Limit, "limit", 500, "Maximum number of unmatched charges to check")
+	flag.BoolVar(&flags.DryRun, "dry-run", false, "Report matches without recording them")
+	flag.BoolVar(&flags.Verbose, "verbose", false, "Verbose output")
+	flag.Parse()
+	return flags
+}
+
+// RunReconcileCharges retries matching stale unmatched ledger charges against
+// fresh Monarch transactions and reports the charges that never posted.
+func RunReconcileCharges(cfg *config.Config, flags *ReconcileChargesFlags) error {
+	store, err := storage.NewStorage(cfg.Storage.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	monarchClient, err := monarch.NewClientWithToken(cfg.GetAPIKey(cfg.Monarch.APIKey, "MONARCH_TOKEN"))
+	if err != nil {
+		return err
+	}
+
+	loggingCfg := cfg.Observability.Logging
+	if flags.Verbose {
+		loggingCfg.Level = "debug"
+	}
+	logger := logging.NewLoggerWithSystem(loggingCfg, "reconcile")
+
+	aliases := make(map[string][]string)
+	for _, provider := range []string{"walmart", "costco", "amazon"} {
+		aliases[provider] = cfg.Providers.MerchantFilter(provider).MerchantAliases
+	}
+	reconciler := sync.NewChargeReconciler(store, sync.MonarchTransactions(monarchClient), logger)
+	report, err := reconciler.Run(context.Background(), sync.ChargeReconcileOptions{
+		Provider:            flags.Provider,
+		StaleAfter:          time.Duration(flags.StaleDays) * 24 * time.Hour,
+		NeverPostedAfter:    time.Duration(flags.NeverPostedDays) * 24 * time.Hour,
+		Limit:               flags.Limit,
+		DryRun:              flags.DryRun,
+		MerchantSearchTerms: map[string][]string{"amazon": amazonprovider.MerchantSearchTerms()},
+		MerchantAliases:     aliases,
+		CardAccounts:        cfg.Monarch.CardAccounts,
+	})
+	if err != nil {
+		return err
+	}
+
+	PrintChargeReconcileReport(os.Stdout, report, flags.DryRun)
+	return nil
+}
+
+// PrintChargeReconcileReport prints each checked charge with its outcome and
+// a summary line.
+func PrintChargeReconcileReport(w io.Writer, report *sync.ChargeReconcileReport, dryRun bool) {
+	if len(report.Outcomes) == 0 {
+		_, _ = fmt.Fprintln(w, "No stale unmatched charges.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Charged\tProvider\tOrder\tCard\tAmount\tStatus\tTransaction")
+	for _, outcome := range report.Outcomes {
+		charge := outcome.Charge
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t$%.2f\t%s\t%s\n",
+			outcome.ChargedAt.Format("2006-01-02"), charge.Provider, charge.OrderID, charge.CardLastFour,
+			charge.ChargeAmount, outcome.Status, outcome.TransactionID)
+	}
+	_ = tw.Flush()
+
+	suffix := ""
+	if dryRun {
+		suffix = " (dry run, nothing recorded)"
+	}
+	_, _ = fmt.Fprintf(w, "\nChecked %d charges: %d matched, %d pending, %d never posted%s\n",
+		len(report.Outcomes), report.Count(sync.ChargeMatched), report.Count(sync.ChargePending),
+		report.Count(sync.ChargeNeverPosted), suffix)
+}
@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintChargeReconcileReport(t *testing.T) {
+	chargedAt := time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)
+	report := &sync.ChargeReconcileReport{Outcomes: []sync.ChargeOutcome{
+		{Charge: storage.LedgerCharge{OrderID: "W-1", Provider: "walmart", CardLastFour: "1234", ChargeAmount: 42.17},
+			ChargedAt: chargedAt, Status: sync.ChargeMatched, TransactionID: "TXN-1"},
+		{Charge: storage.LedgerCharge{OrderID: "W-2", Provider: "walmart", ChargeAmount: 10},
+			ChargedAt: chargedAt, Status: sync.ChargeNeverPosted},
+	}}
+
+	var buf bytes.Buffer
+	PrintChargeReconcileReport(&buf, report, true)
+	out := buf.String()
+
+	assert.Contains(t, out, "2026-03-05  walmart   W-1    1234  $42.17  matched       TXN-1")
+	assert.Contains(t, out, "never_posted")
+	assert.Contains(t, out, "Checked 2 charges: 1 matched, 0 pending, 1 never posted (dry run, nothing recorded)")
+
+	buf.Reset()
+	PrintChargeReconcileReport(&buf, &sync.ChargeReconcileReport{}, false)
+	assert.Equal(t, "No stale unmatched charges.\n", buf.String())
+}
@@ -57,6 +57,10 @@ func RunServe(cfg *config.Config, flags *ServeFlags) error {
 	if err != nil {
 		logger.Warn("failed to initialize clients, sync endpoints will be disabled", slog.Any("error", err))
 	} else {
+		if err := EnableHistoryCategorizer(cfg, serviceClients, store, logger); err != nil {
+			logger.Warn("history categorizer disabled", slog.Any("error", err))
+		}
+
 		// Create provider factory map
 		providerFactory := map[string]service.ProviderFactory{
 			"walmart": func(c *config.Config, verbose bool) (providers.OrderProvider, error) {
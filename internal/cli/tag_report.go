@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/eshaffer321/itemize/internal/infrastructure/config"
+	"github.com/eshaffer321/itemize/internal/infrastructure/storage"
+)
+
+// DefaultReportTag is the tag reported on when -tag is not given.
+const DefaultReportTag = "HSA/FSA"
+
+// TagReportFlags holds the CLI flags for the tag-report command.
+type TagReportFlags struct {
+	Tag  string
+	Year int
+	From string
+	To   string
+	CSV  bool
+	Out  string
+}
+
+// ParseTagReportFlags parses command line flags for the tag-report command.
+func ParseTagReportFlags() *TagReportFlags {
+	flags := &TagReportFlags{}
+	flag.StringVar(&flags.Tag, "tag", DefaultReportTag, "Tag to report on")
+	flag.IntVar(&flags.Year, "year", 0, "Calendar year to report (overrides -from/-to)")
+	flag.StringVar(&flags.From, "from", "", "First order date to include (YYYY-MM-DD)")
+	flag.StringVar(&flags.To, "to", "", "Last order date to include (YYYY-MM-DD)")
+	flag.BoolVar(&flags.CSV, "csv", false, "Write CSV instead of a table")
+	flag.StringVar(&flags.Out, "out", "", "Write the report to this file instead of stdout")
+	flag.Parse()
+	return flags
+}
+
+// RunTagReport lists items carrying a tag with the order and transaction they
+// were bought in, e.g. HSA/FSA-eligible purchases for a reimbursement claim.
+func RunTagReport(cfg *config.Config, flags *TagReportFlags) error {
+	from, to, err := reportRange(flags)
+	if err != nil {
+		return err
+	}
+
+	store, err := storage.NewStorage(cfg.Storage.DatabasePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	purchases, err := sync.LoadTaggedPurchases(store, flags.Tag, from, to)
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if flags.Out != "" {
+		f, err := os.Create(flags.Out)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+
+	if flags.CSV {
+		return WriteTagReportCSV(w, purchases)
+	}
+	PrintTagReport(w, flags.Tag, purchases)
+	return nil
+}
+
+// reportRange converts -year or -from/-to into a half-open date range.
+func reportRange(flags *TagReportFlags) (time.Time, time.Time, error) {
+	if flags.Year != 0 {
+		from := time.Date(flags.Year, time.January, 1, 0, 0, 0, 0, time.Local)
+		return from, from.AddDate(1, 0, 0), nil
+	}
+
+	var from, to time.Time
+	var err error
+	if flags.From != "" {
+		if from, err = time.ParseInLocation("2006-01-02", flags.From, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid -from date: %w", err)
+		}
+	}
+	if flags.To != "" {
+		if to, err = time.ParseInLocation("2006-01-02", flags.To, time.Local); err != nil {
+			return from, to, fmt.Errorf("invalid -to date: %w", err)
+		}
+		to = to.AddDate(0, 0, 1) // inclusive
+	}
+	return from, to, nil
+}
+
+// PrintTagReport prints tagged purchases as a table with a total.
+func PrintTagReport(w io.Writer, tag string, purchases []sync.TaggedPurchase) {
+	if len(purchases) == 0 {
+		_, _ = fmt.Fprintf(w, "No items tagged %q.\n", tag)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Date\tProvider\tOrder\tItem\tQty\tAmount\tTax\tTotal\tTransaction")
+	total := 0.0
+	for _, p := range purchases {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%g\t$%.2f\t$%.2f\t$%.2f\t%s\n",
+			p.OrderDate.Format("2006-01-02"), p.Provider, p.OrderID, p.Item, p.Quantity,
+			p.Amount, p.Tax, p.Total(), p.TransactionID)
+		total += p.Total()
+	}
+	_ = tw.Flush()
+	_, _ = fmt.Fprintf(w, "\n%d items tagged %q, total $%.2f\n", len(purchases), tag, total)
+}
+
+// WriteTagReportCSV writes tagged purchases as CSV, one row per item.
+func WriteTagReportCSV(w io.Writer, purchases []sync.TaggedPurchase) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"date", "provider", "order_id", "item", "quantity", "amount", "tax", "total", "transaction_id", "tags"}); err != nil {
+		return err
+	}
+	for _, p := range purchases {
+		if err := out.Write([]string{
+			p.OrderDate.Format("2006-01-02"),
+			p.Provider,
+			p.OrderID,
+			p.Item,
+			fmt.Sprintf("%g", p.Quantity),
+			fmt.Sprintf("%.2f", p.Amount),
+			fmt.Sprintf("%.2f", p.Tax),
+			fmt.Sprintf("%.2f", p.Total()),
+			p.TransactionID,
+			strings.Join(p.Tags, ";"),
+		}); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
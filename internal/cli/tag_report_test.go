@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/application/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTaggedPurchases() []sync.TaggedPurchase {
+	return []sync.TaggedPurchase{
+		{OrderDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC), Provider: "Amazon", OrderID: "111-222", TransactionID: "TXN-1",
+			Item: "Thermometer, digital", Quantity: 1, Amount: 15, Tags: []string{"HSA/FSA", "Gift"}},
+		{OrderDate: time.Date(2026, 3, 20, 0, 0, 0, 0, time.UTC), Provider: "Walmart", OrderID: "W-9", TransactionID: "TXN-2",
+			Item: "Bandages", Quantity: 2, Amount: 10, Tax: 1, Tags: []string{"HSA/FSA"}},
+	}
+}
+
+func TestPrintTagReport(t *testing.T) {
+	var buf bytes.Buffer
+	PrintTagReport(&buf, "HSA/FSA", testTaggedPurchases())
+	out := buf.String()
+
+	assert.Contains(t, out, "2026-03-20  Walmart")
+	assert.Contains(t, out, "$11.00")
+	assert.Contains(t, out, `2 items tagged "HSA/FSA", total $26.00`)
+
+	buf.Reset()
+	PrintTagReport(&buf, "Gift", nil)
+	assert.Equal(t, "No items tagged \"Gift\".\n", buf.String())
+}
+
+func TestWriteTagReportCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTagReportCSV(&buf, testTaggedPurchases()))
+
+	assert.Equal(t, "date,provider,order_id,item,quantity,amount,tax,total,transaction_id,tags\n"+
+		"2026-03-05,Amazon,111-222,\"Thermometer, digital\",1,15.00,0.00,15.00,TXN-1,HSA/FSA;Gift\n"+
+		"2026-03-20,Walmart,W-9,Bandages,2,10.00,1.00,11.00,TXN-2,HSA/FSA\n", buf.String())
+}
+
+func TestReportRange(t *testing.T) {
+	from, to, err := reportRange(&TagReportFlags{Year: 2025, From: "2020-01-01"})
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local), from)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local), to)
+
+	from, to, err = reportRange(&TagReportFlags{To: "2026-03-31"})
+	require.NoError(t, err)
+	assert.True(t, from.IsZero())
+	assert.Equal(t, time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local), to, "-to is inclusive")
+
+	_, _, err = reportRange(&TagReportFlags{From: "03/01/2026"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package categorizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Batch defaults for cross-order cache warming. Chunks stay small enough that
+// a single LLM response remains well inside output-token limits.
+const (
+	DefaultBatchChunkSize   = 40
+	DefaultBatchConcurrency = 3
+)
+
+// BatchOptions controls how WarmCache splits work across LLM calls.
+type BatchOptions struct {
+	ChunkSize   int // Max items per LLM call (0 = DefaultBatchChunkSize)
+	Concurrency int // Max in-flight LLM calls (0 = DefaultBatchConcurrency)
+
+	// OnChunk, if set, is called after each chunk finishes with the number of
+	// unique items handled so far and the total number of unique items.
+	OnChunk func(done, total int)
+}
+
+// WarmResult summarizes a cache-warming pass.
+type WarmResult struct {
+	RequestedItems int // Items passed in (including duplicates)
+	UniqueItems    int // Items remaining after normalized-name dedupe
+	CachedItems    int // Unique items already in the cache
+	LLMCalls       int // Chunks sent to the LLM
+	FailedChunks   int // Chunks whose LLM call failed
+}
+
+// WarmCache categorizes every uncached item in one pass so later per-order
+// CategorizeItems calls are served from the cache. Items are deduplicated by
+// normalized name, split into chunks and sent to the LLM with bounded
+// concurrency. Results are validated exactly as in CategorizeItems.
+//
+// A failed chunk is not fatal: its items simply stay uncached and are
+// categorized on demand later. The returned error joins all chunk failures.
+func (c *Categorizer) WarmCache(ctx context.Context, items []Item, categories []Category, opts BatchOptions) (*WarmResult, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultBatchChunkSize
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	result := &WarmResult{RequestedItems: len(items)}
+
+	offered := make(map[string]Category, len(categories))
+	for _, category := range categories {
+		offered[category.ID] = category
+	}
+
+	seen := make(map[string]bool, len(items))
+	var uncached []Item
+	for _, item := range items {
+		normalizedName := c.normalizeItemName(item.Name)
+		if normalizedName == "" || seen[normalizedName] {
+			continue
+		}
+		seen[normalizedName] = true
+		result.UniqueItems++
+
+		if _, found := c.cachedCategory(normalizedName, offered); found {
+			result.CachedItems++
+			continue
+		}
+		uncached = append(uncached, item)
+	}
+
+	if len(uncached) == 0 {
+		return result, nil
+	}
+
+	var chunks [][]Item
+	for start := 0; start < len(uncached); start += chunkSize {
+		end := min(start+chunkSize, len(uncached))
+		chunks = append(chunks, uncached[start:end])
+	}
+	result.LLMCalls = len(chunks)
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		done = result.CachedItems
+	)
+	sem := make(chan struct{}, concurrency)
+
+	for i, chunk := range chunks {
+		if ctx.Err() != nil {
+			mu.Lock()
+			errs = append(errs, ctx.Err())
+			result.FailedChunks += len(chunks) - i
+			mu.Unlock()
+			break
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(index int, chunk []Item) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			llmResult, err := c.callLLM(ctx, chunk, categories)
+			if err == nil {
+				c.resolveLLMCategorizations(llmResult, len(chunk), categories)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.FailedChunks++
+				errs = append(errs, fmt.Errorf("chunk %d/%d: %w", index+1, len(chunks), err))
+			}
+			done += len(chunk)
+			if opts.OnChunk != nil {
+				opts.OnChunk(done, result.UniqueItems)
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	return result, errors.Join(errs...)
+}
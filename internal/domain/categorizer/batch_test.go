@@ -0,0 +1,198 @@
+package categorizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promptItemLine = regexp.MustCompile(`(?m)^\d+\. (.+) - \$`)
+
+// echoChatClient answers every prompt by assigning each listed item to a fixed
+// category, recording how many calls were made and how many overlapped.
+type echoChatClient struct {
+	categoryID   string
+	categoryName string
+	delay        time.Duration
+	failOn       string   // Fail any call whose prompt lists this item
+	tags         []string // Tags assigned to every item
+
+	mu           sync.Mutex
+	calls        int
+	inFlight     int
+	maxInFlight  int
+	promptsItems [][]string
+}
+
+func (c *echoChatClient) CreateChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	var names []string
+	for _, match := range promptItemLine.FindAllStringSubmatch(request.Messages[1].Content, -1) {
+		names = append(names, match[1])
+	}
+
+	c.mu.Lock()
+	c.calls++
+	c.inFlight++
+	c.maxInFlight = max(c.maxInFlight, c.inFlight)
+	c.promptsItems = append(c.promptsItems, names)
+	c.mu.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+
+	result := CategorizationResult{}
+	for _, name := range names {
+		if name == c.failOn {
+			return nil, errors.New("bad request")
+		}
+		result.Categorizations = append(result.Categorizations, ItemCategorization{
+			ItemName:     name,
+			CategoryID:   c.categoryID,
+			CategoryName: c.categoryName,
+			Confidence:   0.8,
+			Tags:         c.tags,
+		})
+	}
+	content, _ := json.Marshal(result)
+	return &ChatCompletionResponse{Choices: []Choice{{Message: Message{Content: string(content)}}}}, nil
+}
+
+func TestCategorizer_WarmCache_DedupesAndChunks(t *testing.T) {
+	client := &echoChatClient{categoryID: "cat_1", categoryName: "Groceries"}
+	cache := NewMemoryCache()
+	cache.Set("eggs", CacheEntry{CategoryID: "cat_1", Confidence: 1.0})
+	c := NewCategorizer(client, cache, "gpt-4o-mini")
+
+	items := []Item{
+		{Name: "Milk", Price: 3.99},
+		{Name: "milk ", Price: 3.99}, // same normalized name, different order
+		{Name: "Bread", Price: 2.49},
+		{Name: "Eggs", Price: 4.29}, // already cached
+		{Name: "Apples", Price: 5.00},
+		{Name: "Butter", Price: 4.00},
+		{Name: "Cheese", Price: 6.00},
+	}
+	categories := []Category{{ID: "cat_1", Name: "Groceries"}}
+
+	var progress [][2]int
+	result, err := c.WarmCache(context.Background(), items, categories, BatchOptions{
+		ChunkSize:   2,
+		Concurrency: 1,
+		OnChunk:     func(done, total int) { progress = append(progress, [2]int{done, total}) },
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 7, result.RequestedItems)
+	assert.Equal(t, 6, result.UniqueItems)
+	assert.Equal(t, 1, result.CachedItems)
+	assert.Equal(t, 3, result.LLMCalls)
+	assert.Equal(t, 0, result.FailedChunks)
+	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, [][2]int{{3, 6}, {5, 6}, {6, 6}}, progress)
+	assert.Equal(t, 6, cache.Size())
+
+	// Per-order categorization is now served entirely from the cache and keeps
+	// the confidence the LLM reported during the batch pass.
+	orderResult, err := c.CategorizeItems(context.Background(), []Item{{Name: "Milk"}, {Name: "Eggs"}}, categories)
+	require.NoError(t, err)
+	assert.Equal(t, 3, client.calls, "no further LLM calls")
+	require.Len(t, orderResult.Categorizations, 2)
+	assert.Equal(t, "cat_1", orderResult.Categorizations[0].CategoryID)
+	assert.Equal(t, 0.8, orderResult.Categorizations[0].Confidence)
+	assert.Equal(t, 1.0, orderResult.Categorizations[1].Confidence)
+}
+
+func TestCategorizer_WarmCache_LimitsConcurrency(t *testing.T) {
+	client := &echoChatClient{categoryID: "cat_1", categoryName: "Groceries", delay: 20 * time.Millisecond}
+	c := NewCategorizer(client, NewMemoryCache(), "gpt-4o-mini")
+
+	var items []Item
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		items = append(items, Item{Name: name, Price: 1})
+	}
+
+	result, err := c.WarmCache(context.Background(), items, []Category{{ID: "cat_1", Name: "Groceries"}}, BatchOptions{
+		ChunkSize:   1,
+		Concurrency: 2,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 8, result.LLMCalls)
+	assert.Equal(t, 8, client.calls)
+	assert.LessOrEqual(t, client.maxInFlight, 2)
+	assert.Equal(t, 2, client.maxInFlight)
+}
+
+func TestCategorizer_WarmCache_FailedChunkLeavesItemsUncached(t *testing.T) {
+	client := &echoChatClient{categoryID: "cat_1", categoryName: "Groceries", failOn: "Bread"}
+	cache := NewMemoryCache()
+	c := NewCategorizer(client, cache, "gpt-4o-mini")
+
+	items := []Item{{Name: "Milk"}, {Name: "Bread"}}
+	result, err := c.WarmCache(context.Background(), items, []Category{{ID: "cat_1", Name: "Groceries"}}, BatchOptions{
+		ChunkSize: 1,
+	})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad request")
+	assert.Equal(t, 1, result.FailedChunks)
+
+	_, milkCached := cache.Get("milk")
+	_, breadCached := cache.Get("bread")
+	assert.True(t, milkCached)
+	assert.False(t, breadCached)
+}
+
+func TestCategorizer_WarmCache_AllCached(t *testing.T) {
+	client := &echoChatClient{}
+	cache := NewMemoryCache()
+	cache.Set("milk", CacheEntry{CategoryID: "cat_1", Confidence: 1.0})
+	c := NewCategorizer(client, cache, "gpt-4o-mini")
+
+	result, err := c.WarmCache(context.Background(), []Item{{Name: "Milk"}}, nil, BatchOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, result.LLMCalls)
+	assert.Equal(t, 0, client.calls)
+}
+
+func TestCategorizer_CacheHitKeepsLLMTags(t *testing.T) {
+	client := &echoChatClient{categoryID: "groceries", categoryName: "Groceries", tags: []string{"Gift"}}
+	c := NewCategorizer(client, NewMemoryCache(), "gpt-4o-mini")
+	categories := []Category{{ID: "groceries", Name: "Groceries"}}
+
+	_, err := c.WarmCache(context.Background(), []Item{{Name: "Chocolate Box", Price: 20}}, categories, BatchOptions{})
+	require.NoError(t, err)
+
+	result, err := c.CategorizeItems(context.Background(), []Item{{Name: "Chocolate Box", Price: 20}}, categories)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.calls, "second lookup is a cache hit")
+	require.Len(t, result.Categorizations, 1)
+	assert.Equal(t, []string{"Gift"}, result.Categorizations[0].Tags)
+}
+
+func TestCategorizer_CacheKeepsConfidenceAcrossCategorizers(t *testing.T) {
+	client := &echoChatClient{categoryID: "groceries", categoryName: "Groceries"}
+	cache := NewMemoryCache()
+	categories := []Category{{ID: "groceries", Name: "Groceries"}}
+
+	_, err := NewCategorizer(client, cache, "gpt-4o-mini").CategorizeItems(context.Background(), []Item{{Name: "Mystery Box"}}, categories)
+	require.NoError(t, err)
+
+	// A later run shares the cache but not the categorizer that filled it
+	result, err := NewCategorizer(client, cache, "gpt-4o-mini").CategorizeItems(context.Background(), []Item{{Name: "Mystery Box"}}, categories)
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.calls, "second run is a cache hit")
+	require.Len(t, result.Categorizations, 1)
+	assert.Equal(t, 0.8, result.Categorizations[0].Confidence, "the LLM's confidence is kept, not reported as 1.0")
+}
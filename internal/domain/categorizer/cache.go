@@ -7,31 +7,31 @@ import (
 // MemoryCache is a simple in-memory cache implementation
 type MemoryCache struct {
 	mu    sync.RWMutex
-	store map[string]string
+	store map[string]CacheEntry
 }
 
 // NewMemoryCache creates a new memory cache
 func NewMemoryCache() *MemoryCache {
 	return &MemoryCache{
-		store: make(map[string]string),
+		store: make(map[string]CacheEntry),
 	}
 }
 
 // Get retrieves a value from cache
-func (c *MemoryCache) Get(key string) (string, bool) {
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	value, found := c.store[key]
-	return value, found
+	entry, found := c.store[key]
+	return entry, found
 }
 
 // Set stores a value in cache
-func (c *MemoryCache) Set(key string, value string) {
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.store[key] = value
+	c.store[key] = entry
 }
 
 // Clear removes all entries from cache
@@ -39,7 +39,7 @@ func (c *MemoryCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.store = make(map[string]string)
+	c.store = make(map[string]CacheEntry)
 }
 
 // Size returns the number of cached entries
@@ -13,24 +13,25 @@ func TestMemoryCache_GetSet(t *testing.T) {
 	cache := NewMemoryCache()
 
 	// Test Set and Get
-	cache.Set("milk", "cat_1")
+	cache.Set("milk", CacheEntry{CategoryID: "cat_1", Confidence: 1.0})
 
-	value, found := cache.Get("milk")
+	entry, found := cache.Get("milk")
 	assert.True(t, found)
-	assert.Equal(t, "cat_1", value)
+	assert.Equal(t, "cat_1", entry.CategoryID)
+	assert.Equal(t, 1.0, entry.Confidence)
 
 	// Test Get non-existent
-	value, found = cache.Get("bread")
+	entry, found = cache.Get("bread")
 	assert.False(t, found)
-	assert.Empty(t, value)
+	assert.Empty(t, entry.CategoryID)
 }
 
 func TestMemoryCache_Clear(t *testing.T) {
 	cache := NewMemoryCache()
 
 	// Add items
-	cache.Set("milk", "cat_1")
-	cache.Set("bread", "cat_1")
+	cache.Set("milk", CacheEntry{CategoryID: "cat_1", Confidence: 1.0})
+	cache.Set("bread", CacheEntry{CategoryID: "cat_1", Confidence: 1.0})
 
 	assert.Equal(t, 2, cache.Size())
 
@@ -59,7 +60,7 @@ func TestMemoryCache_Concurrent(t *testing.T) {
 			defer wg.Done()
 			key := fmt.Sprintf("key_%d", id)
 			value := fmt.Sprintf("value_%d", id)
-			cache.Set(key, value)
+			cache.Set(key, CacheEntry{CategoryID: value, Confidence: 1.0})
 		}(i)
 	}
 
@@ -84,14 +85,14 @@ func TestMemoryCache_Size(t *testing.T) {
 
 	assert.Equal(t, 0, cache.Size())
 
-	cache.Set("item1", "cat1")
+	cache.Set("item1", CacheEntry{CategoryID: "cat1", Confidence: 1.0})
 	assert.Equal(t, 1, cache.Size())
 
-	cache.Set("item2", "cat2")
+	cache.Set("item2", CacheEntry{CategoryID: "cat2", Confidence: 1.0})
 	assert.Equal(t, 2, cache.Size())
 
 	// Overwrite existing
-	cache.Set("item1", "cat3")
+	cache.Set("item1", CacheEntry{CategoryID: "cat3", Confidence: 1.0})
 	assert.Equal(t, 2, cache.Size())
 }
 
@@ -112,14 +113,14 @@ func TestMemoryCache_Integration(t *testing.T) {
 	}
 
 	// Cache mappings
-	cache.Set(items[0], categories[0])
-	cache.Set(items[1], categories[1])
-	cache.Set(items[2], categories[2])
+	cache.Set(items[0], CacheEntry{CategoryID: categories[0], Confidence: 1.0})
+	cache.Set(items[1], CacheEntry{CategoryID: categories[1], Confidence: 1.0})
+	cache.Set(items[2], CacheEntry{CategoryID: categories[2], Confidence: 1.0})
 
 	// Verify all cached correctly
 	for i, item := range items {
-		value, found := cache.Get(item)
+		entry, found := cache.Get(item)
 		require.True(t, found, "Item %s should be cached", item)
-		assert.Equal(t, categories[i], value)
+		assert.Equal(t, categories[i], entry.CategoryID)
 	}
 }
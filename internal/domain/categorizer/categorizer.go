@@ -16,10 +16,14 @@ type Item struct {
 	Quantity int     `json:"quantity,omitempty"`
 }
 
-// Category represents a Monarch category
+// Category represents a Monarch category. Group fields are empty for
+// categories that don't come from Monarch (e.g. tests, eval datasets).
 type Category struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	GroupID   string `json:"group_id,omitempty"`
+	GroupName string `json:"group_name,omitempty"`
+	GroupType string `json:"group_type,omitempty"` // "expense", "income" or "transfer"
 }
 
 // ItemCategorization represents the categorization result for a single item
@@ -28,8 +32,22 @@ type ItemCategorization struct {
 	CategoryID   string  `json:"category_id"`
 	CategoryName string  `json:"category_name"`
 	Confidence   float64 `json:"confidence"`
+
+	// Tags are the configured tags (see PromptConfig.Tags) the LLM says apply
+	Tags []string `json:"tags,omitempty"`
+
+	// Source records what assigned the category (one of the Source
+	// constants); empty for items nothing could place.
+	Source string `json:"-"`
 }
 
+// Where a categorization came from.
+const (
+	SourceLLM     = "llm"     // the LLM, directly or from its cache
+	SourceHistory = "history" // the offline history categorizer
+	SourcePinned  = "pinned"  // a category pinned on the order item
+)
+
 // CategorizationResult contains all categorization results
 type CategorizationResult struct {
 	Categorizations []ItemCategorization `json:"categorizations"`
@@ -58,6 +76,13 @@ type Message struct {
 
 type ChatCompletionResponse struct {
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"`
+}
+
+// Usage reports token counts for a completion when the backend provides them.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 type Choice struct {
@@ -70,10 +95,20 @@ type ChatClient interface {
 	CreateChatCompletion(ctx context.Context, request ChatCompletionRequest) (*ChatCompletionResponse, error)
 }
 
+// CacheEntry is a cached categorization: the category the LLM chose for an
+// item, with the confidence and tags it gave. Keeping the confidence with the
+// category means a cache hit is held to the same threshold as the original
+// answer.
+type CacheEntry struct {
+	CategoryID string
+	Confidence float64
+	Tags       []string
+}
+
 // Cache interface for category mappings
 type Cache interface {
-	Get(key string) (string, bool)
-	Set(key string, value string)
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
 }
 
 // Categorizer handles item categorization using a pluggable LLM backend.
@@ -81,6 +116,7 @@ type Categorizer struct {
 	client ChatClient
 	cache  Cache
 	Model  string
+	prompt *Prompt // nil uses the built-in prompt
 }
 
 // NewCategorizer creates a new categorizer
@@ -98,69 +134,68 @@ func NewCategorizer(client ChatClient, cache Cache, model string) *Categorizer {
 
 const DefaultModel = "gpt-5.4-nano"
 
-// CategorizeItems categorizes a list of items using available categories
+// CategorizeItems categorizes a list of items using available categories.
+// Cached items are answered from the cache and the rest in one LLM call;
+// results are returned in item order.
 func (c *Categorizer) CategorizeItems(ctx context.Context, items []Item, categories []Category) (*CategorizationResult, error) {
 	if len(items) == 0 {
 		return &CategorizationResult{Categorizations: []ItemCategorization{}}, nil
 	}
 
-	result := &CategorizationResult{
-		Categorizations: make([]ItemCategorization, 0, len(items)),
-	}
-
 	// Build category map for quick lookup
 	categoryMap := make(map[string]Category)
 	for _, cat := range categories {
 		categoryMap[cat.ID] = cat
 	}
 
-	// Separate cached and uncached items
-	var uncachedItems []Item
-	for _, item := range items {
-		normalizedName := c.normalizeItemName(item.Name)
-
-		// Check cache
-		if categoryID, found := c.cache.Get(normalizedName); found {
-			// Use cached categorization
-			cat := categoryMap[categoryID]
-			result.Categorizations = append(result.Categorizations, ItemCategorization{
+	return CategorizeInStages(ctx, items,
+		func(_ int, item Item) (ItemCategorization, bool) {
+			entry, found := c.cachedCategory(c.normalizeItemName(item.Name), categoryMap)
+			if !found {
+				return ItemCategorization{}, false
+			}
+			// Use cached categorization, with the confidence and tags the
+			// LLM gave when it was cached
+			return ItemCategorization{
 				ItemName:     item.Name,
-				CategoryID:   categoryID,
-				CategoryName: cat.Name,
-				Confidence:   1.0, // 100% confidence for cached items
-			})
-		} else {
-			uncachedItems = append(uncachedItems, item)
-		}
-	}
-
-	// If all items were cached, return early
-	if len(uncachedItems) == 0 {
-		return result, nil
-	}
-
-	// Call the LLM for uncached items
-	llmResult, err := c.callLLM(ctx, uncachedItems, categories)
-	if err != nil {
-		return nil, fmt.Errorf("LLM categorization failed: %w", err)
-	}
+				CategoryID:   entry.CategoryID,
+				CategoryName: categoryMap[entry.CategoryID].Name,
+				Confidence:   entry.Confidence,
+				Tags:         entry.Tags,
+				Source:       SourceLLM,
+			}, true
+		},
+		func(ctx context.Context, uncachedItems []Item) (*CategorizationResult, error) {
+			llmResult, err := c.callLLM(ctx, uncachedItems, categories)
+			if err != nil {
+				return nil, fmt.Errorf("LLM categorization failed: %w", err)
+			}
+			return &CategorizationResult{Categorizations: c.resolveLLMCategorizations(llmResult, len(uncachedItems), categories)}, nil
+		})
+}
 
+// resolveLLMCategorizations validates the LLM's answer for a batch of
+// uncached items against the Monarch category list and caches every valid
+// assignment along with its confidence and tags. itemCount caps the number of
+// entries accepted.
+func (c *Categorizer) resolveLLMCategorizations(llmResult *CategorizationResult, itemCount int, categories []Category) []ItemCategorization {
 	// Build a lookup so we can validate what the LLM returned
 	categoryByID := make(map[string]Category, len(categories))
 	categoryByName := make(map[string]Category, len(categories))
-	for _, c := range categories {
-		categoryByID[c.ID] = c
-		categoryByName[strings.ToLower(c.Name)] = c
+	for _, category := range categories {
+		categoryByID[category.ID] = category
+		categoryByName[strings.ToLower(category.Name)] = category
 	}
 
 	// Truncate extra entries — LLMs occasionally hallucinate more categorizations
 	// than items sent. Extra entries corrupt category-group detection downstream.
 	llmCategorizations := llmResult.Categorizations
-	if len(llmCategorizations) > len(uncachedItems) {
-		llmCategorizations = llmCategorizations[:len(uncachedItems)]
+	if len(llmCategorizations) > itemCount {
+		llmCategorizations = llmCategorizations[:itemCount]
 	}
 
 	// Process LLM results
+	resolved := make([]ItemCategorization, 0, len(llmCategorizations))
 	for _, cat := range llmCategorizations {
 		// If the LLM returned an ID that isn't in the Monarch category list,
 		// try to recover via name match before falling back to empty.
@@ -176,14 +211,18 @@ func (c *Categorizer) CategorizeItems(ctx context.Context, items []Item, categor
 
 		// Only cache valid IDs so future lookups don't reuse a bad value
 		if cat.CategoryID != "" {
-			normalizedName := c.normalizeItemName(cat.ItemName)
-			c.cache.Set(normalizedName, cat.CategoryID)
+			c.cache.Set(c.normalizeItemName(cat.ItemName), CacheEntry{
+				CategoryID: cat.CategoryID,
+				Confidence: cat.Confidence,
+				Tags:       cat.Tags,
+			})
 		}
 
-		result.Categorizations = append(result.Categorizations, cat)
+		cat.Source = SourceLLM
+		resolved = append(resolved, cat)
 	}
 
-	return result, nil
+	return resolved
 }
 
 // Retry configuration
@@ -215,7 +254,10 @@ func isRetryableError(err error) bool {
 
 // callLLM makes the actual API call to the LLM with retry logic
 func (c *Categorizer) callLLM(ctx context.Context, items []Item, categories []Category) (*CategorizationResult, error) {
-	prompt := c.buildPrompt(items, categories)
+	prompt, err := c.buildPrompt(items, categories)
+	if err != nil {
+		return nil, err
+	}
 
 	request := ChatCompletionRequest{
 		Model: c.Model,
@@ -225,7 +267,7 @@ func (c *Categorizer) callLLM(ctx context.Context, items []Item, categories []Ca
 		Messages: []Message{
 			{
 				Role:    "system",
-				Content: "You are a helpful assistant that categorizes shopping items into appropriate categories. Always respond with valid JSON.",
+				Content: systemPrompt,
 			},
 			{
 				Role:    "user",
@@ -278,54 +320,51 @@ func isGPT5Model(model string) bool {
 	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(model)), "gpt-5")
 }
 
-// buildPrompt creates the prompt for OpenAI
-func (c *Categorizer) buildPrompt(items []Item, categories []Category) string {
-	var itemsList strings.Builder
-	for i, item := range items {
-		itemsList.WriteString(fmt.Sprintf("%d. %s - $%.2f\n", i+1, item.Name, item.Price))
-	}
+const systemPrompt = "You are a helpful assistant that categorizes shopping items into appropriate categories. Always respond with valid JSON."
 
-	var categoriesList strings.Builder
-	for _, cat := range categories {
-		categoriesList.WriteString(fmt.Sprintf("- %s (ID: %s)\n", cat.Name, cat.ID))
+// PromptHash identifies the prompt template and guidance this categorizer
+// sends, so categorizations can be traced to the prompt that produced them.
+func (c *Categorizer) PromptHash() string {
+	return c.currentPrompt().Hash()
+}
+
+// SetPrompt replaces the categorization prompt. Call before categorizing;
+// nil restores the built-in prompt.
+func (c *Categorizer) SetPrompt(prompt *Prompt) {
+	c.prompt = prompt
+}
+
+func (c *Categorizer) currentPrompt() *Prompt {
+	if c.prompt == nil {
+		return defaultPrompt
 	}
+	return c.prompt
+}
+
+var defaultPrompt = DefaultPrompt()
 
-	prompt := fmt.Sprintf(`Please categorize the following items into the most appropriate categories.
-
-Items to categorize:
-%s
-
-Available categories (use ONLY these exact IDs):
-%s
-
-IMPORTANT Instructions:
-1. Match each item to the MOST appropriate category from the list above
-2. You MUST use the exact category_id values shown in the list — do NOT invent IDs or use words like "Uncategorized"
-3. If no category is a good fit, pick the closest one available
-4. Distinguish between different types of items:
-   - "Groceries" should be used ONLY for food items (milk, bread, meat, produce, snacks, beverages)
-   - "Home & Garden" for cleaning supplies, paper products, laundry, trash bags, home maintenance
-   - "Personal Care" for toiletries: shampoo, deodorant, toothpaste, soap, cosmetics
-   - "Health & Wellness" for vitamins, medicine, first aid
-5. Do NOT put non-food items in Groceries
-6. Provide a confidence score (0.0 to 1.0) for each categorization
-
-Return the result as a JSON object with this structure:
-{
-  "categorizations": [
-    {
-      "item_name": "exact item name",
-      "category_id": "exact ID from the list above",
-      "category_name": "category name",
-      "confidence": 0.95
-    }
-  ]
-}`, itemsList.String(), categoriesList.String())
-
-	return prompt
+// buildPrompt renders the categorization prompt for a batch of items
+func (c *Categorizer) buildPrompt(items []Item, categories []Category) (string, error) {
+	return c.currentPrompt().Render(items, categories)
 }
 
 // normalizeItemName normalizes an item name for cache key
 func (c *Categorizer) normalizeItemName(name string) string {
 	return strings.ToLower(strings.TrimSpace(name))
 }
+
+// cachedCategory looks up a cached categorization. A cached category that
+// isn't offered this time (e.g. its group is now denied) is treated as a miss
+// so the item is asked again rather than reused.
+func (c *Categorizer) cachedCategory(normalizedName string, offered map[string]Category) (CacheEntry, bool) {
+	entry, found := c.cache.Get(normalizedName)
+	if !found {
+		return CacheEntry{}, false
+	}
+	if len(offered) > 0 {
+		if _, ok := offered[entry.CategoryID]; !ok {
+			return CacheEntry{}, false
+		}
+	}
+	return entry, true
+}
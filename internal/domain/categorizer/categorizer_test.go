@@ -23,18 +23,19 @@ func (m *MockChatClient) CreateChatCompletion(ctx context.Context, request ChatC
 	return nil, args.Error(1)
 }
 
-// MockCache for testing
+// MockCache for testing. Expectations are set on category IDs; cached entries
+// report full confidence.
 type MockCache struct {
 	mock.Mock
 }
 
-func (m *MockCache) Get(key string) (string, bool) {
+func (m *MockCache) Get(key string) (CacheEntry, bool) {
 	args := m.Called(key)
-	return args.String(0), args.Bool(1)
+	return CacheEntry{CategoryID: args.String(0), Confidence: 1.0}, args.Bool(1)
 }
 
-func (m *MockCache) Set(key string, value string) {
-	m.Called(key, value)
+func (m *MockCache) Set(key string, entry CacheEntry) {
+	m.Called(key, entry.CategoryID)
 }
 
 func TestCategorizer_CategorizeItems_Success(t *testing.T) {
@@ -220,10 +221,12 @@ func TestCategorizer_CategorizeItems_PartialCache(t *testing.T) {
 	// Check cached item
 	assert.Equal(t, "Great Value Milk", result.Categorizations[0].ItemName)
 	assert.Equal(t, float64(1.0), result.Categorizations[0].Confidence)
+	assert.Equal(t, SourceLLM, result.Categorizations[0].Source, "cache hits are LLM answers")
 
 	// Check newly categorized item
 	assert.Equal(t, "iPhone Charger", result.Categorizations[1].ItemName)
 	assert.Equal(t, float64(0.98), result.Categorizations[1].Confidence)
+	assert.Equal(t, SourceLLM, result.Categorizations[1].Source)
 
 	mockClient.AssertExpectations(t)
 	mockCache.AssertExpectations(t)
@@ -295,7 +298,8 @@ func TestCategorizer_BuildPrompt(t *testing.T) {
 		{ID: "cat_2", Name: "Electronics"},
 	}
 
-	prompt := categorizer.buildPrompt(items, categories)
+	prompt, err := categorizer.buildPrompt(items, categories)
+	require.NoError(t, err)
 
 	// Verify prompt contains key information
 	assert.Contains(t, prompt, "Milk")
@@ -425,3 +429,41 @@ func TestNewCategorizer_DefaultsModelWhenEmpty(t *testing.T) {
 
 	assert.Equal(t, "gpt-5.4-nano", categorizer.Model)
 }
+
+func TestCategorizer_PartiallyCachedBatchKeepsItemOrder(t *testing.T) {
+	client := &echoChatClient{categoryID: "cat_1", categoryName: "Groceries"}
+	cache := NewMemoryCache()
+	cache.Set("paper towels", CacheEntry{CategoryID: "cat_2", Confidence: 1.0})
+	c := NewCategorizer(client, cache, "gpt-4o-mini")
+
+	result, err := c.CategorizeItems(context.Background(), []Item{
+		{Name: "Milk"},
+		{Name: "Paper Towels"},
+		{Name: "Bread"},
+	}, []Category{{ID: "cat_1", Name: "Groceries"}, {ID: "cat_2", Name: "Household"}})
+	require.NoError(t, err)
+
+	require.Len(t, result.Categorizations, 3)
+	assert.Equal(t, []string{"Milk", "Bread"}, client.promptsItems[0], "only uncached items reach the LLM")
+	assert.Equal(t, "cat_1", result.Categorizations[0].CategoryID)
+	assert.Equal(t, "cat_2", result.Categorizations[1].CategoryID)
+	assert.Equal(t, "Paper Towels", result.Categorizations[1].ItemName)
+	assert.Equal(t, "cat_1", result.Categorizations[2].CategoryID)
+}
+
+func TestAlignCategorizations(t *testing.T) {
+	items := []Item{{Name: "Milk"}, {Name: "Bread"}, {Name: "milk "}, {Name: "Eggs"}, {Name: "Butter"}}
+	aligned := AlignCategorizations(items, []ItemCategorization{
+		{ItemName: "bread", CategoryID: "bakery"},
+		{ItemName: "Milk", CategoryID: "dairy-1"},
+		{ItemName: "Large Eggs", CategoryID: "eggs"}, // reworded by the LLM
+		{ItemName: "Milk", CategoryID: "dairy-2"},
+	})
+
+	require.Len(t, aligned, 5)
+	assert.Equal(t, "dairy-1", aligned[0].CategoryID)
+	assert.Equal(t, "bakery", aligned[1].CategoryID)
+	assert.Equal(t, "dairy-2", aligned[2].CategoryID, "repeated names are matched in order")
+	assert.Equal(t, "eggs", aligned[3].CategoryID, "unmatched answers fill the remaining items in order")
+	assert.Equal(t, ItemCategorization{ItemName: "Butter"}, aligned[4], "an unanswered item gets an empty categorization")
+}
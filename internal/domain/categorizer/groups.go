@@ -0,0 +1,96 @@
+package categorizer
+
+import "strings"
+
+// GroupFilter restricts the categories offered for categorization by Monarch
+// category group. Entries match a group's name, ID or type, case-insensitively
+// (e.g. "Income", "transfer", "Business").
+//
+// When Allow is non-empty only categories in those groups are kept. Deny
+// removes groups afterwards. Categories without group info are kept unless
+// Allow is set.
+type GroupFilter struct {
+	Allow []string
+	Deny  []string
+}
+
+// IsZero reports whether the filter keeps every category.
+func (f GroupFilter) IsZero() bool {
+	return len(f.Allow) == 0 && len(f.Deny) == 0
+}
+
+// Apply returns the categories that pass the filter, in their original order.
+func (f GroupFilter) Apply(categories []Category) []Category {
+	if f.IsZero() {
+		return categories
+	}
+	allow := groupSet(f.Allow)
+	deny := groupSet(f.Deny)
+
+	kept := make([]Category, 0, len(categories))
+	for _, category := range categories {
+		if len(allow) > 0 && !inGroup(category, allow) {
+			continue
+		}
+		if inGroup(category, deny) {
+			continue
+		}
+		kept = append(kept, category)
+	}
+	return kept
+}
+
+func groupSet(entries []string) map[string]bool {
+	set := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+			set[entry] = true
+		}
+	}
+	return set
+}
+
+func inGroup(category Category, set map[string]bool) bool {
+	for _, key := range []string{category.GroupName, category.GroupID, category.GroupType} {
+		if key != "" && set[strings.ToLower(key)] {
+			return true
+		}
+	}
+	return false
+}
+
+// categoryGroup is a run of categories sharing a group, used to present
+// categories to the LLM under group headings.
+type categoryGroup struct {
+	Name       string
+	Categories []Category
+}
+
+// groupCategories groups categories by group, ordered by each group's first
+// appearance. Categories without a group come last under an empty name.
+func groupCategories(categories []Category) []categoryGroup {
+	var groups []categoryGroup
+	index := make(map[string]int)
+	var ungrouped []Category
+	for _, category := range categories {
+		key := category.GroupID
+		if key == "" {
+			key = category.GroupName
+		}
+		if key == "" {
+			ungrouped = append(ungrouped, category)
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, categoryGroup{Name: category.GroupName})
+		}
+		groups[i].Categories = append(groups[i].Categories, category)
+	}
+	if len(ungrouped) > 0 {
+		groups = append(groups, categoryGroup{Categories: ungrouped})
+	}
+	return groups
+}
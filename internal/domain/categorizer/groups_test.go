@@ -0,0 +1,72 @@
+package categorizer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func groupedTestCategories() []Category {
+	return []Category{
+		{ID: "groceries", Name: "Groceries", GroupID: "g_food", GroupName: "Food & Dining", GroupType: "expense"},
+		{ID: "paycheck", Name: "Paychecks", GroupID: "g_income", GroupName: "Income", GroupType: "income"},
+		{ID: "restaurants", Name: "Restaurants", GroupID: "g_food", GroupName: "Food & Dining", GroupType: "expense"},
+		{ID: "transfer", Name: "Transfer", GroupID: "g_transfers", GroupName: "Transfers", GroupType: "transfer"},
+		{ID: "office", Name: "Office Supplies", GroupID: "g_business", GroupName: "Business", GroupType: "expense"},
+		{ID: "misc", Name: "Miscellaneous"},
+	}
+}
+
+func categoryIDs(categories []Category) []string {
+	ids := make([]string, 0, len(categories))
+	for _, category := range categories {
+		ids = append(ids, category.ID)
+	}
+	return ids
+}
+
+func TestGroupFilter_Apply(t *testing.T) {
+	categories := groupedTestCategories()
+
+	tests := []struct {
+		name   string
+		filter GroupFilter
+		want   []string
+	}{
+		{"zero keeps all", GroupFilter{}, []string{"groceries", "paycheck", "restaurants", "transfer", "office", "misc"}},
+		{"deny by name, type and ID", GroupFilter{Deny: []string{"business", "INCOME", "g_transfers"}}, []string{"groceries", "restaurants", "misc"}},
+		{"deny by type", GroupFilter{Deny: []string{"income", "transfer"}}, []string{"groceries", "restaurants", "office", "misc"}},
+		{"allow drops ungrouped", GroupFilter{Allow: []string{"Food & Dining"}}, []string{"groceries", "restaurants"}},
+		{"allow then deny", GroupFilter{Allow: []string{"expense"}, Deny: []string{"Business"}}, []string{"groceries", "restaurants"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, categoryIDs(tt.filter.Apply(categories)))
+		})
+	}
+}
+
+func TestDefaultPrompt_ListsCategoriesByGroup(t *testing.T) {
+	prompt, err := DefaultPrompt().Render([]Item{{Name: "Milk", Price: 3.99}}, groupedTestCategories())
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "[Food & Dining]\n- Groceries (ID: groceries)\n- Restaurants (ID: restaurants)\n[Income]\n- Paychecks (ID: paycheck)\n")
+	assert.Contains(t, prompt, "[Business]\n- Office Supplies (ID: office)\n- Miscellaneous (ID: misc)\n", "ungrouped categories come last without a heading")
+}
+
+func TestCategorizer_CachedCategoryOutsideOfferedListIsAskedAgain(t *testing.T) {
+	client := &echoChatClient{categoryID: "groceries", categoryName: "Groceries"}
+	cache := NewMemoryCache()
+	cache.Set("gift card", CacheEntry{CategoryID: "paycheck", Confidence: 1.0})
+	c := NewCategorizer(client, cache, "gpt-4o-mini")
+
+	offered := GroupFilter{Deny: []string{"Income"}}.Apply(groupedTestCategories())
+	result, err := c.CategorizeItems(context.Background(), []Item{{Name: "Gift Card", Price: 25}}, offered)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, client.calls)
+	require.Len(t, result.Categorizations, 1)
+	assert.Equal(t, "groceries", result.Categorizations[0].CategoryID)
+}
@@ -0,0 +1,234 @@
+package categorizer
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ItemCategorizer is anything that can assign categories to a batch of items.
+// It matches splitter.Categorizer so implementations can be stacked.
+type ItemCategorizer interface {
+	CategorizeItems(ctx context.Context, items []Item, categories []Category) (*CategorizationResult, error)
+}
+
+// LabeledItem is a past categorization used to train a HistoryCategorizer.
+type LabeledItem struct {
+	Name         string
+	CategoryID   string
+	CategoryName string
+}
+
+// HistoryCategorizer predicts categories offline from previously categorized
+// items. Exact item-name matches are answered from a lookup table; everything
+// else goes through a multinomial naive Bayes model over name tokens.
+//
+// A HistoryCategorizer is read-only after construction and safe for
+// concurrent use.
+type HistoryCategorizer struct {
+	exact   map[string]map[string]int // normalized name -> category ID -> count
+	classes map[string]*historyClass  // category ID -> token statistics
+	names   map[string]string         // category ID -> most recent name
+	vocab   map[string]bool
+	docs    int
+}
+
+type historyClass struct {
+	docs   int
+	tokens map[string]int
+	total  int
+}
+
+// NewHistoryCategorizer trains a model from labeled items. Items without a
+// name or category ID are ignored.
+func NewHistoryCategorizer(examples []LabeledItem) *HistoryCategorizer {
+	h := &HistoryCategorizer{
+		exact:   make(map[string]map[string]int),
+		classes: make(map[string]*historyClass),
+		names:   make(map[string]string),
+		vocab:   make(map[string]bool),
+	}
+
+	for _, example := range examples {
+		name := strings.ToLower(strings.TrimSpace(example.Name))
+		if name == "" || example.CategoryID == "" {
+			continue
+		}
+
+		if h.exact[name] == nil {
+			h.exact[name] = make(map[string]int)
+		}
+		h.exact[name][example.CategoryID]++
+		if example.CategoryName != "" {
+			h.names[example.CategoryID] = example.CategoryName
+		}
+
+		class := h.classes[example.CategoryID]
+		if class == nil {
+			class = &historyClass{tokens: make(map[string]int)}
+			h.classes[example.CategoryID] = class
+		}
+		class.docs++
+		h.docs++
+		for _, token := range tokenizeItemName(name) {
+			class.tokens[token]++
+			class.total++
+			h.vocab[token] = true
+		}
+	}
+
+	return h
+}
+
+// Size returns the number of training examples the model was built from.
+func (h *HistoryCategorizer) Size() int {
+	return h.docs
+}
+
+// Predict returns the most likely category for an item name. Only categories
+// present in categories are considered (all known categories when empty).
+// ok is false when the history has nothing to say about the item.
+//
+// Confidence is the share of past assignments for exact name matches. For
+// token matches it is the naive Bayes posterior scaled by the fraction of the
+// item's tokens seen in training, so names that are mostly unfamiliar don't
+// get overconfident predictions from a single shared word.
+func (h *HistoryCategorizer) Predict(name string, categories []Category) (ItemCategorization, bool) {
+	allowed := make(map[string]string, len(categories))
+	for _, category := range categories {
+		allowed[category.ID] = category.Name
+	}
+	isAllowed := func(id string) bool {
+		if len(allowed) == 0 {
+			return true
+		}
+		_, ok := allowed[id]
+		return ok
+	}
+	categoryName := func(id string) string {
+		if n, ok := allowed[id]; ok && n != "" {
+			return n
+		}
+		return h.names[id]
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(name))
+
+	// 1. Exact name seen before
+	if counts, found := h.exact[normalized]; found {
+		bestID, bestCount, total := "", 0, 0
+		for _, id := range sortedKeys(counts) {
+			if !isAllowed(id) {
+				continue
+			}
+			total += counts[id]
+			if counts[id] > bestCount {
+				bestID, bestCount = id, counts[id]
+			}
+		}
+		if bestID != "" {
+			return ItemCategorization{
+				ItemName:     name,
+				CategoryID:   bestID,
+				CategoryName: categoryName(bestID),
+				Confidence:   float64(bestCount) / float64(total),
+				Source:       SourceHistory,
+			}, true
+		}
+	}
+
+	// 2. Naive Bayes over tokens
+	tokens := tokenizeItemName(normalized)
+	var known []string
+	for _, token := range tokens {
+		if h.vocab[token] {
+			known = append(known, token)
+		}
+	}
+	if len(known) == 0 {
+		return ItemCategorization{ItemName: name}, false
+	}
+
+	vocabSize := float64(len(h.vocab))
+	ids := make([]string, 0, len(h.classes))
+	scores := make([]float64, 0, len(h.classes))
+	for _, id := range sortedKeys(h.classes) {
+		if !isAllowed(id) {
+			continue
+		}
+		class := h.classes[id]
+		score := math.Log(float64(class.docs) / float64(h.docs))
+		for _, token := range known {
+			score += math.Log((float64(class.tokens[token]) + 1) / (float64(class.total) + vocabSize))
+		}
+		ids = append(ids, id)
+		scores = append(scores, score)
+	}
+	if len(ids) == 0 {
+		return ItemCategorization{ItemName: name}, false
+	}
+
+	// Softmax for the posterior of the best class
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	sum := 0.0
+	for _, score := range scores {
+		sum += math.Exp(score - scores[best])
+	}
+	posterior := 1 / sum
+	coverage := float64(len(known)) / float64(len(tokens))
+
+	return ItemCategorization{
+		ItemName:     name,
+		CategoryID:   ids[best],
+		CategoryName: categoryName(ids[best]),
+		Confidence:   posterior * coverage,
+		Source:       SourceHistory,
+	}, true
+}
+
+// CategorizeItems implements splitter.Categorizer using history alone. Items
+// the history knows nothing about are returned with an empty CategoryID and
+// zero confidence, the same shape the LLM categorizer uses for unmappable
+// answers; the splitter moves them to the fallback category, or leaves the
+// order alone without one.
+func (h *HistoryCategorizer) CategorizeItems(_ context.Context, items []Item, categories []Category) (*CategorizationResult, error) {
+	result := &CategorizationResult{Categorizations: make([]ItemCategorization, 0, len(items))}
+	for _, item := range items {
+		prediction, _ := h.Predict(item.Name, categories)
+		result.Categorizations = append(result.Categorizations, prediction)
+	}
+	return result, nil
+}
+
+// tokenizeItemName splits a lowercased item name into word tokens, dropping
+// single characters and pure numbers (sizes and counts vary too much to help).
+func tokenizeItemName(name string) []string {
+	fields := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := fields[:0]
+	for _, field := range fields {
+		if len(field) < 2 || strings.IndexFunc(field, unicode.IsLetter) < 0 {
+			continue
+		}
+		tokens = append(tokens, field)
+	}
+	return tokens
+}
+
+// sortedKeys returns map keys in a stable order so ties resolve deterministically.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
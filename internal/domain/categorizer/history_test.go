@@ -0,0 +1,183 @@
+package categorizer
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func historyTrainingSet() []LabeledItem {
+	return []LabeledItem{
+		{Name: "Great Value Whole Milk 1 Gallon", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "Great Value 2% Milk Half Gallon", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "Organic Bananas", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "Fresh Bananas 3lb", CategoryID: "groceries", CategoryName: "Groceries"},
+		{Name: "Bounty Paper Towels 6 Rolls", CategoryID: "household", CategoryName: "Home & Garden"},
+		{Name: "Charmin Toilet Paper 12 Rolls", CategoryID: "household", CategoryName: "Home & Garden"},
+		{Name: "Tide Laundry Detergent", CategoryID: "household", CategoryName: "Home & Garden"},
+		{Name: "Tide Laundry Detergent", CategoryID: "household", CategoryName: "Home & Garden"},
+		{Name: "Tide Laundry Detergent", CategoryID: "groceries", CategoryName: "Groceries"}, // mislabeled once
+		{Name: "", CategoryID: "groceries"},
+		{Name: "Unlabeled", CategoryID: ""},
+	}
+}
+
+var historyCategories = []Category{
+	{ID: "groceries", Name: "Groceries"},
+	{ID: "household", Name: "Home & Garden"},
+}
+
+func TestHistoryCategorizer_ExactMatch(t *testing.T) {
+	h := NewHistoryCategorizer(historyTrainingSet())
+	assert.Equal(t, 9, h.Size())
+
+	prediction, ok := h.Predict("  TIDE laundry detergent ", historyCategories)
+	require.True(t, ok)
+	assert.Equal(t, "household", prediction.CategoryID)
+	assert.Equal(t, "Home & Garden", prediction.CategoryName)
+	assert.InDelta(t, 2.0/3.0, prediction.Confidence, 0.0001)
+	assert.Equal(t, "  TIDE laundry detergent ", prediction.ItemName)
+	assert.Equal(t, SourceHistory, prediction.Source)
+}
+
+func TestHistoryCategorizer_TokenMatch(t *testing.T) {
+	h := NewHistoryCategorizer(historyTrainingSet())
+
+	prediction, ok := h.Predict("Great Value Skim Milk", historyCategories)
+	require.True(t, ok)
+	assert.Equal(t, "groceries", prediction.CategoryID)
+	assert.Greater(t, prediction.Confidence, 0.5)
+
+	prediction, ok = h.Predict("Paper Towels Select-A-Size", historyCategories)
+	require.True(t, ok)
+	assert.Equal(t, "household", prediction.CategoryID)
+}
+
+func TestHistoryCategorizer_UnfamiliarTokensLowerConfidence(t *testing.T) {
+	h := NewHistoryCategorizer(historyTrainingSet())
+
+	familiar, ok := h.Predict("Organic Bananas Bunch", historyCategories)
+	require.True(t, ok)
+	mostlyUnknown, ok := h.Predict("Organic Quinoa Kale Chia Crackers", historyCategories)
+	require.True(t, ok)
+
+	assert.Less(t, mostlyUnknown.Confidence, familiar.Confidence)
+}
+
+func TestHistoryCategorizer_NoPrediction(t *testing.T) {
+	h := NewHistoryCategorizer(historyTrainingSet())
+
+	_, ok := h.Predict("HDMI Cable 6ft", historyCategories)
+	assert.False(t, ok)
+
+	// Categories that no longer exist in Monarch are never predicted
+	_, ok = h.Predict("Organic Bananas", []Category{{ID: "household", Name: "Home & Garden"}})
+	require.True(t, ok, "token model still answers from allowed classes")
+	prediction, _ := h.Predict("Organic Bananas", []Category{{ID: "household", Name: "Home & Garden"}})
+	assert.Equal(t, "household", prediction.CategoryID)
+
+	_, ok = h.Predict("Organic Bananas", []Category{{ID: "travel", Name: "Travel"}})
+	assert.False(t, ok)
+}
+
+func TestHistoryCategorizer_CategorizeItems(t *testing.T) {
+	h := NewHistoryCategorizer(historyTrainingSet())
+
+	result, err := h.CategorizeItems(context.Background(), []Item{
+		{Name: "Organic Bananas"},
+		{Name: "HDMI Cable"},
+	}, historyCategories)
+	require.NoError(t, err)
+	require.Len(t, result.Categorizations, 2)
+	assert.Equal(t, "groceries", result.Categorizations[0].CategoryID)
+	assert.Equal(t, 1.0, result.Categorizations[0].Confidence)
+	assert.Equal(t, "", result.Categorizations[1].CategoryID)
+	assert.Equal(t, "HDMI Cable", result.Categorizations[1].ItemName)
+}
+
+func TestTokenizeItemName(t *testing.T) {
+	assert.Equal(t, []string{"great", "value", "milk", "gallon", "12oz"}, tokenizeItemName("great value 2% milk, 1 gallon (12oz)"))
+}
+
+func TestStagedCategorizer_OnlyUnknownItemsReachFallback(t *testing.T) {
+	ctx := context.Background()
+	mockClient := new(MockChatClient)
+	mockCache := new(MockCache)
+	llm := NewCategorizer(mockClient, mockCache, "gpt-4o-mini")
+
+	mockCache.On("Get", "hdmi cable").Return("", false)
+	mockCache.On("Set", "hdmi cable", "electronics").Return()
+
+	llmResponse, _ := json.Marshal(CategorizationResult{Categorizations: []ItemCategorization{
+		{ItemName: "HDMI Cable", CategoryID: "electronics", CategoryName: "Electronics", Confidence: 0.9},
+	}})
+	mockClient.On("CreateChatCompletion", ctx, mock.MatchedBy(func(req ChatCompletionRequest) bool {
+		// Only the item history couldn't place is sent to the LLM
+		return len(promptItemLine.FindAllString(req.Messages[1].Content, -1)) == 1
+	})).Return(&ChatCompletionResponse{Choices: []Choice{{Message: Message{Content: string(llmResponse)}}}}, nil).Once()
+
+	staged := NewStagedCategorizer(NewHistoryCategorizer(historyTrainingSet()), llm, 0.9)
+	categories := append([]Category{{ID: "electronics", Name: "Electronics"}}, historyCategories...)
+
+	result, err := staged.CategorizeItems(ctx, []Item{
+		{Name: "Organic Bananas"},
+		{Name: "HDMI Cable"},
+		{Name: "Bounty Paper Towels 6 Rolls"},
+	}, categories)
+	require.NoError(t, err)
+
+	require.Len(t, result.Categorizations, 3)
+	assert.Equal(t, "groceries", result.Categorizations[0].CategoryID)
+	assert.Equal(t, "electronics", result.Categorizations[1].CategoryID)
+	assert.Equal(t, "household", result.Categorizations[2].CategoryID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestStagedCategorizer_WarmCacheSkipsKnownItems(t *testing.T) {
+	client := &echoChatClient{categoryID: "electronics", categoryName: "Electronics"}
+	llm := NewCategorizer(client, NewMemoryCache(), "gpt-4o-mini")
+	staged := NewStagedCategorizer(NewHistoryCategorizer(historyTrainingSet()), llm, 0.9)
+
+	result, err := staged.WarmCache(context.Background(), []Item{
+		{Name: "Organic Bananas"},
+		{Name: "HDMI Cable"},
+	}, []Category{{ID: "electronics", Name: "Electronics"}, {ID: "groceries", Name: "Groceries"}}, BatchOptions{})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, result.RequestedItems)
+	assert.Equal(t, 1, result.UniqueItems)
+	require.Len(t, client.promptsItems, 1)
+	assert.Equal(t, []string{"HDMI Cable"}, client.promptsItems[0])
+}
+
+func TestStagedCategorizer_PartiallyCachedFallbackKeepsItemOrder(t *testing.T) {
+	client := &echoChatClient{categoryID: "electronics", categoryName: "Electronics"}
+	cache := NewMemoryCache()
+	cache.Set("gift card", CacheEntry{CategoryID: "gifts", Confidence: 1.0})
+	staged := NewStagedCategorizer(NewHistoryCategorizer(historyTrainingSet()), NewCategorizer(client, cache, "gpt-4o-mini"), 0.9)
+	categories := append([]Category{{ID: "electronics", Name: "Electronics"}, {ID: "gifts", Name: "Gifts"}}, historyCategories...)
+
+	// The fallback answers its cache hit (Gift Card) before the LLM's items
+	result, err := staged.CategorizeItems(context.Background(), []Item{
+		{Name: "Organic Bananas"},
+		{Name: "HDMI Cable"},
+		{Name: "Gift Card"},
+		{Name: "USB Hub"},
+	}, categories)
+	require.NoError(t, err)
+
+	require.Len(t, result.Categorizations, 4)
+	for i, want := range []struct{ name, categoryID string }{
+		{"Organic Bananas", "groceries"},
+		{"HDMI Cable", "electronics"},
+		{"Gift Card", "gifts"},
+		{"USB Hub", "electronics"},
+	} {
+		assert.Equal(t, want.name, result.Categorizations[i].ItemName)
+		assert.Equal(t, want.categoryID, result.Categorizations[i].CategoryID, want.name)
+	}
+}
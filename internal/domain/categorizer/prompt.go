@@ -0,0 +1,237 @@
+package categorizer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultGuidance is the household guidance used when none is configured.
+const DefaultGuidance = `Distinguish between different types of items:
+- "Groceries" should be used ONLY for food items (milk, bread, meat, produce, snacks, beverages)
+- "Home & Garden" for cleaning supplies, paper products, laundry, trash bags, home maintenance
+- "Personal Care" for toiletries: shampoo, deodorant, toothpaste, soap, cosmetics
+- "Health & Wellness" for vitamins, medicine, first aid
+Do NOT put non-food items in Groceries.`
+
+// DefaultPromptTemplate is the built-in categorization prompt. Templates are
+// executed with PromptData. Categories are listed under their Monarch group.
+const DefaultPromptTemplate = `Please categorize the following items into the most appropriate categories.
+
+Items to categorize:
+{{range .Items}}{{.Index}}. {{.Name}} - ${{price .Price}}
+{{end}}
+
+Available categories (use ONLY these exact IDs):
+{{range .Groups}}{{if .Name}}[{{.Name}}]
+{{end}}{{range .Categories}}- {{.Name}} (ID: {{.ID}}){{if .Description}}: {{.Description}}{{end}}{{if .Examples}} (e.g. {{join .Examples ", "}}){{end}}
+{{end}}{{end}}
+{{if .Tags}}
+Available tags (optional, an item may have several or none):
+{{range .Tags}}- {{.Name}}: {{.Description}}
+{{end}}{{end}}
+IMPORTANT Instructions:
+1. Match each item to the MOST appropriate category from the list above
+2. You MUST use the exact category_id values shown in the list — do NOT invent IDs or use words like "Uncategorized"
+3. If no category is a good fit, pick the closest one available
+4. Provide a confidence score (0.0 to 1.0) for each categorization
+{{if .Tags}}5. List in "tags" only the tag names above that clearly apply to the item; use [] when none do
+{{end}}{{if .Guidance}}
+Household guidance:
+{{.Guidance}}
+{{end}}
+Return the result as a JSON object with this structure:
+{
+  "categorizations": [
+    {
+      "item_name": "exact item name",
+      "category_id": "exact ID from the list above",
+      "category_name": "category name",
+      "confidence": 0.95{{if .Tags}},
+      "tags": []{{end}}
+    }
+  ]
+}`
+
+// PromptConfig customizes the categorization prompt.
+type PromptConfig struct {
+	// Template is a text/template executed with PromptData. Empty uses
+	// DefaultPromptTemplate.
+	Template string
+
+	// Guidance is household-specific instruction text available to the
+	// template as {{.Guidance}}. Empty renders no guidance; DefaultPrompt uses
+	// DefaultGuidance.
+	Guidance string
+
+	// Categories adds descriptions and examples keyed by Monarch category ID.
+	Categories map[string]CategoryGuidance
+
+	// Tags are offered to the LLM for tagging items ({{.Tags}}). Answers come
+	// back in ItemCategorization.Tags.
+	Tags []TagGuidance
+}
+
+// TagGuidance describes a tag the LLM may assign.
+type TagGuidance struct {
+	Name        string
+	Description string
+}
+
+// CategoryGuidance describes what belongs in one Monarch category.
+type CategoryGuidance struct {
+	Description string
+	Examples    []string
+}
+
+// PromptData is the value prompt templates are executed with. Categories is
+// the flat list; Groups holds the same categories under their Monarch group
+// (ungrouped categories last, in a group with an empty Name).
+type PromptData struct {
+	Items      []PromptItem
+	Categories []PromptCategory
+	Groups     []PromptGroup
+	Tags       []TagGuidance
+	Guidance   string
+}
+
+// PromptGroup is a Monarch category group and its available categories.
+type PromptGroup struct {
+	Name       string
+	Categories []PromptCategory
+}
+
+// PromptItem is an item to categorize; Index is 1-based.
+type PromptItem struct {
+	Index    int
+	Name     string
+	Price    float64
+	Quantity int
+}
+
+// PromptCategory is an available category with its configured guidance.
+type PromptCategory struct {
+	ID          string
+	Name        string
+	Group       string
+	Description string
+	Examples    []string
+}
+
+// Prompt is a parsed categorization prompt template.
+type Prompt struct {
+	tmpl       *template.Template
+	guidance   string
+	categories map[string]CategoryGuidance
+	tags       []TagGuidance
+	hash       string
+}
+
+var promptFuncs = template.FuncMap{
+	"price": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"join":  strings.Join,
+}
+
+// NewPrompt parses and test-renders a prompt template.
+func NewPrompt(cfg PromptConfig) (*Prompt, error) {
+	text := cfg.Template
+	if strings.TrimSpace(text) == "" {
+		text = DefaultPromptTemplate
+	}
+	guidance := cfg.Guidance
+
+	tmpl, err := template.New("prompt").Funcs(promptFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse prompt template: %w", err)
+	}
+
+	p := &Prompt{
+		tmpl:       tmpl,
+		guidance:   guidance,
+		categories: cfg.Categories,
+		tags:       cfg.Tags,
+	}
+	if _, err := p.Render([]Item{{Name: "Example", Price: 1}}, []Category{{ID: "example", Name: "Example"}}); err != nil {
+		return nil, err
+	}
+	p.hash = hashPrompt(systemPrompt, text, guidance, cfg.Categories, cfg.Tags)
+	return p, nil
+}
+
+// DefaultPrompt returns the built-in prompt.
+func DefaultPrompt() *Prompt {
+	p, err := NewPrompt(PromptConfig{Guidance: DefaultGuidance})
+	if err != nil {
+		panic(err) // the built-in template is covered by tests
+	}
+	return p
+}
+
+// Hash fingerprints the template and the guidance it is rendered with, so
+// categorizations can be traced to the prompt that produced them.
+func (p *Prompt) Hash() string {
+	return p.hash
+}
+
+// Render executes the template for a batch of items.
+func (p *Prompt) Render(items []Item, categories []Category) (string, error) {
+	data := PromptData{
+		Items:      make([]PromptItem, 0, len(items)),
+		Categories: make([]PromptCategory, 0, len(categories)),
+		Tags:       p.tags,
+		Guidance:   p.guidance,
+	}
+	for i, item := range items {
+		data.Items = append(data.Items, PromptItem{Index: i + 1, Name: item.Name, Price: item.Price, Quantity: item.Quantity})
+	}
+	for _, category := range categories {
+		data.Categories = append(data.Categories, p.promptCategory(category))
+	}
+	for _, group := range groupCategories(categories) {
+		promptGroup := PromptGroup{Name: group.Name, Categories: make([]PromptCategory, 0, len(group.Categories))}
+		for _, category := range group.Categories {
+			promptGroup.Categories = append(promptGroup.Categories, p.promptCategory(category))
+		}
+		data.Groups = append(data.Groups, promptGroup)
+	}
+
+	var out strings.Builder
+	if err := p.tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("render prompt template: %w", err)
+	}
+	return out.String(), nil
+}
+
+func (p *Prompt) promptCategory(category Category) PromptCategory {
+	guidance := p.categories[category.ID]
+	return PromptCategory{
+		ID:          category.ID,
+		Name:        category.Name,
+		Group:       category.GroupName,
+		Description: guidance.Description,
+		Examples:    guidance.Examples,
+	}
+}
+
+func hashPrompt(system, text, guidance string, categories map[string]CategoryGuidance, tags []TagGuidance) string {
+	h := sha256.New()
+	for _, part := range []string{system, text, guidance} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	ids := make([]string, 0, len(categories))
+	for id := range categories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", id, categories[id].Description, strings.Join(categories[id].Examples, "\x1f"))
+	}
+	for _, tag := range tags {
+		fmt.Fprintf(h, "tag\x00%s\x00%s\x00", tag.Name, tag.Description)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
@@ -0,0 +1,104 @@
+package categorizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPrompt_Render(t *testing.T) {
+	prompt, err := DefaultPrompt().Render(
+		[]Item{{Name: "Milk", Price: 3.99}, {Name: "Bread", Price: 2.5}},
+		[]Category{{ID: "cat_1", Name: "Groceries"}},
+	)
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "1. Milk - $3.99\n2. Bread - $2.50\n")
+	assert.Contains(t, prompt, "- Groceries (ID: cat_1)\n")
+	assert.Contains(t, prompt, "Household guidance:\n"+DefaultGuidance)
+}
+
+func TestNewPrompt_GuidanceAndCategoryDescriptions(t *testing.T) {
+	p, err := NewPrompt(PromptConfig{
+		Guidance: "We buy food for the dog in bulk; pet food is never Groceries.",
+		Categories: map[string]CategoryGuidance{
+			"cat_pets": {Description: "Anything for the dog", Examples: []string{"kibble", "chew toys"}},
+		},
+	})
+	require.NoError(t, err)
+
+	prompt, err := p.Render([]Item{{Name: "Kibble 40lb", Price: 54}}, []Category{
+		{ID: "cat_1", Name: "Groceries"},
+		{ID: "cat_pets", Name: "Pets"},
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, prompt, "pet food is never Groceries")
+	assert.NotContains(t, prompt, "Personal Care", "default guidance replaced")
+	assert.Contains(t, prompt, "- Groceries (ID: cat_1)\n")
+	assert.Contains(t, prompt, "- Pets (ID: cat_pets): Anything for the dog (e.g. kibble, chew toys)\n")
+}
+
+func TestNewPrompt_CustomTemplate(t *testing.T) {
+	p, err := NewPrompt(PromptConfig{
+		Template: `{{.Guidance}}|{{range .Items}}{{.Name}}@{{price .Price}};{{end}}|{{range .Categories}}{{.ID}}{{end}}`,
+		Guidance: "be brief",
+	})
+	require.NoError(t, err)
+
+	prompt, err := p.Render([]Item{{Name: "Milk", Price: 3.5}}, []Category{{ID: "cat_1", Name: "Groceries"}})
+	require.NoError(t, err)
+	assert.Equal(t, "be brief|Milk@3.50;|cat_1", prompt)
+}
+
+func TestNewPrompt_InvalidTemplate(t *testing.T) {
+	_, err := NewPrompt(PromptConfig{Template: "{{range .Items}}"})
+	assert.Error(t, err)
+
+	_, err = NewPrompt(PromptConfig{Template: "{{.NoSuchField}}"})
+	assert.Error(t, err, "execution errors are caught up front")
+}
+
+func TestPrompt_Hash(t *testing.T) {
+	base := DefaultPrompt().Hash()
+	assert.Len(t, base, 12)
+
+	same, err := NewPrompt(PromptConfig{Template: DefaultPromptTemplate, Guidance: DefaultGuidance})
+	require.NoError(t, err)
+	assert.Equal(t, base, same.Hash())
+
+	guided, err := NewPrompt(PromptConfig{Guidance: "different"})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, guided.Hash())
+
+	described, err := NewPrompt(PromptConfig{Categories: map[string]CategoryGuidance{"cat_1": {Description: "food"}}})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, described.Hash())
+
+	tagged, err := NewPrompt(PromptConfig{Tags: []TagGuidance{{Name: "HSA/FSA", Description: "medical"}}})
+	require.NoError(t, err)
+	assert.NotEqual(t, base, tagged.Hash())
+
+	c := NewCategorizer(nil, NewMemoryCache(), "")
+	assert.Equal(t, base, c.PromptHash())
+	c.SetPrompt(guided)
+	assert.Equal(t, guided.Hash(), c.PromptHash())
+}
+
+func TestNewPrompt_Tags(t *testing.T) {
+	untagged, err := DefaultPrompt().Render([]Item{{Name: "Bandages", Price: 4}}, []Category{{ID: "cat_1", Name: "Medical"}})
+	require.NoError(t, err)
+	assert.NotContains(t, untagged, "tags")
+
+	prompt, err := NewPrompt(PromptConfig{Tags: []TagGuidance{
+		{Name: "HSA/FSA", Description: "Eligible for HSA/FSA reimbursement"},
+		{Name: "Gift", Description: "Bought for someone else"},
+	}})
+	require.NoError(t, err)
+
+	rendered, err := prompt.Render([]Item{{Name: "Bandages", Price: 4}}, []Category{{ID: "cat_1", Name: "Medical"}})
+	require.NoError(t, err)
+	assert.Contains(t, rendered, "- HSA/FSA: Eligible for HSA/FSA reimbursement\n- Gift: Bought for someone else\n")
+	assert.Contains(t, rendered, `"tags": []`)
+}
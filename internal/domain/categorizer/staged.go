@@ -0,0 +1,155 @@
+package categorizer
+
+import (
+	"context"
+	"strings"
+)
+
+// cacheWarmer is implemented by categorizers that support batched warm-up.
+type cacheWarmer interface {
+	WarmCache(ctx context.Context, items []Item, categories []Category, opts BatchOptions) (*WarmResult, error)
+}
+
+// StagedCategorizer answers from history first and only sends the items it
+// can't place confidently to the fallback categorizer (normally the LLM).
+type StagedCategorizer struct {
+	history  *HistoryCategorizer
+	fallback ItemCategorizer
+	cutoff   float64
+}
+
+// NewStagedCategorizer creates a history-first categorizer. Predictions with
+// confidence at or above cutoff are used as-is; everything else goes to
+// fallback.
+func NewStagedCategorizer(history *HistoryCategorizer, fallback ItemCategorizer, cutoff float64) *StagedCategorizer {
+	return &StagedCategorizer{
+		history:  history,
+		fallback: fallback,
+		cutoff:   cutoff,
+	}
+}
+
+// CategorizeItems implements splitter.Categorizer. Results are returned in
+// item order regardless of which stage answered.
+func (s *StagedCategorizer) CategorizeItems(ctx context.Context, items []Item, categories []Category) (*CategorizationResult, error) {
+	return CategorizeInStages(ctx, items,
+		func(_ int, item Item) (ItemCategorization, bool) {
+			return s.confident(item, categories)
+		},
+		func(ctx context.Context, remaining []Item) (*CategorizationResult, error) {
+			return s.fallback.CategorizeItems(ctx, remaining, categories)
+		})
+}
+
+// WarmCache warms the fallback's cache with only the items history can't
+// answer, so the batch pre-pass doesn't spend LLM calls on known items.
+func (s *StagedCategorizer) WarmCache(ctx context.Context, items []Item, categories []Category, opts BatchOptions) (*WarmResult, error) {
+	var remaining []Item
+	for _, item := range items {
+		if _, ok := s.confident(item, categories); !ok {
+			remaining = append(remaining, item)
+		}
+	}
+
+	warmer, ok := s.fallback.(cacheWarmer)
+	if !ok || len(remaining) == 0 {
+		return &WarmResult{RequestedItems: len(items)}, nil
+	}
+
+	result, err := warmer.WarmCache(ctx, remaining, categories, opts)
+	if result != nil {
+		result.RequestedItems = len(items)
+	}
+	return result, err
+}
+
+func (s *StagedCategorizer) confident(item Item, categories []Category) (ItemCategorization, bool) {
+	prediction, ok := s.history.Predict(item.Name, categories)
+	if !ok || prediction.Confidence < s.cutoff {
+		return ItemCategorization{}, false
+	}
+	return prediction, true
+}
+
+// CategorizeInStages categorizes items in two stages: answer assigns what it
+// can, item by item, and the rest go to next in a single call (skipped when
+// answer covers every item). The result holds one categorization per item, in
+// item order, whichever stage answered it.
+func CategorizeInStages(
+	ctx context.Context,
+	items []Item,
+	answer func(i int, item Item) (ItemCategorization, bool),
+	next func(ctx context.Context, items []Item) (*CategorizationResult, error),
+) (*CategorizationResult, error) {
+	result := &CategorizationResult{Categorizations: make([]ItemCategorization, len(items))}
+	var remaining []Item
+	var remainingIdx []int
+	for i, item := range items {
+		if categorization, ok := answer(i, item); ok {
+			result.Categorizations[i] = categorization
+			continue
+		}
+		remaining = append(remaining, item)
+		remainingIdx = append(remainingIdx, i)
+	}
+	if len(remaining) == 0 {
+		return result, nil
+	}
+
+	nextResult, err := next(ctx, remaining)
+	if err != nil {
+		return nil, err
+	}
+	for j, categorization := range AlignCategorizations(remaining, nextResult.Categorizations) {
+		result.Categorizations[remainingIdx[j]] = categorization
+	}
+	return result, nil
+}
+
+// AlignCategorizations returns one categorization per item, in item order.
+// Categorizers don't always answer in order (the LLM categorizer lists cache
+// hits first) or for every item, so answers are matched to items by name.
+// Answers whose name matches no item, such as a name the LLM reworded, fill
+// the remaining items in order; an item left without an answer gets an empty
+// categorization.
+func AlignCategorizations(items []Item, categorizations []ItemCategorization) []ItemCategorization {
+	byName := make(map[string][]int, len(categorizations))
+	for j, categorization := range categorizations {
+		name := alignmentKey(categorization.ItemName)
+		byName[name] = append(byName[name], j)
+	}
+
+	aligned := make([]ItemCategorization, len(items))
+	matched := make([]bool, len(items))
+	used := make([]bool, len(categorizations))
+	for i, item := range items {
+		name := alignmentKey(item.Name)
+		if candidates := byName[name]; len(candidates) > 0 {
+			aligned[i] = categorizations[candidates[0]]
+			byName[name] = candidates[1:]
+			used[candidates[0]] = true
+			matched[i] = true
+		}
+	}
+
+	j := 0
+	for i, item := range items {
+		if matched[i] {
+			continue
+		}
+		for j < len(categorizations) && used[j] {
+			j++
+		}
+		if j < len(categorizations) {
+			aligned[i] = categorizations[j]
+			used[j] = true
+		} else {
+			aligned[i] = ItemCategorization{ItemName: item.Name}
+		}
+	}
+	return aligned
+}
+
+func alignmentKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,473 @@
+package matcher
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// Matching orders one at a time in fetch order lets an early order take a
+// charge that fits a later order better, e.g. two $25.00 orders placed three
+// days apart swapping their charges. Assign scores every order charge against
+// every eligible transaction up front and solves the whole run as a
+// minimum-cost bipartite matching, so each charge gets the transaction that is
+// best for the run as a whole rather than the first one it sees.
+
+const (
+	// merchantMismatchCost is added when neither the Monarch merchant nor the
+	// bank's name mentions the order's provider. It only breaks ties between
+	// otherwise equal candidates.
+	merchantMismatchCost = 0.5
+
+	// unassignedCost is the cost of leaving a charge without a transaction.
+	// It is far above any real pairing, so the solver first assigns as many
+	// charges as possible and only then minimizes their cost.
+	unassignedCost = 1e6
+
+	// forbiddenCost marks pairs that are not eligible matches.
+	forbiddenCost = 1e12
+)
+
+// Reasons a candidate lost the assignment
+const (
+	LostToOtherOrder   = "assigned_to_other_order"
+	LostToCloserMatch  = "higher_cost"
+	LostToCardMismatch = "card_mismatch" // posted to an account of another card
+)
+
+// OrderCharges is an order and the bank charges it expects: one per shipment
+// for multi-charge orders, or a single negative amount for a return.
+type OrderCharges struct {
+	Order   providers.Order
+	Charges []float64
+}
+
+// LosingCandidate is an eligible transaction that was not assigned to a
+// charge, with the cost it would have had.
+type LosingCandidate struct {
+	TransactionID string  `json:"transaction_id"`
+	Date          string  `json:"date"`
+	Amount        float64 `json:"amount"`
+	AmountDiff    float64 `json:"amount_diff"`
+	DateDiff      float64 `json:"date_diff_days"`
+	Cost          float64 `json:"cost"`
+	Reason        string  `json:"reason"`
+	WonBy         string  `json:"won_by,omitempty"` // order ID the transaction went to
+	Account       string  `json:"account,omitempty"`
+}
+
+// AssignedCharge is the outcome for one expected charge
+type AssignedCharge struct {
+	Amount float64
+	Match  *MatchResult // nil when no transaction was left for the charge
+	Cost   float64
+	Losing []LosingCandidate
+}
+
+// Assignment is the run-wide assignment of transactions to order charges
+type Assignment struct {
+	charges  map[string][]*AssignedCharge // by order ID
+	reserved map[string]string            // transaction ID -> order ID
+}
+
+// ForOrder returns the assigned charges of an order, or nil when the order
+// was not part of the assignment.
+func (a *Assignment) ForOrder(orderID string) []*AssignedCharge {
+	if a == nil {
+		return nil
+	}
+	return a.charges[orderID]
+}
+
+// ReservedFor returns the order a transaction was assigned to
+func (a *Assignment) ReservedFor(transactionID string) (string, bool) {
+	if a == nil {
+		return "", false
+	}
+	orderID, ok := a.reserved[transactionID]
+	return orderID, ok
+}
+
+// AssignmentDiagnostics explains an order's assignment
+type AssignmentDiagnostics struct {
+	Charges []ChargeDiagnostics `json:"charges"`
+}
+
+// ChargeDiagnostics explains the assignment of one expected charge
+type ChargeDiagnostics struct {
+	Amount        float64           `json:"amount"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	Cost          float64           `json:"cost,omitempty"`
+	Losing        []LosingCandidate `json:"losing_candidates,omitempty"`
+}
+
+// Diagnostics returns why an order got the transactions it did, or nil when
+// the order was not assigned or no other candidate competed for its charges.
+func (a *Assignment) Diagnostics(orderID string) *AssignmentDiagnostics {
+	charges := a.ForOrder(orderID)
+	diagnostics := &AssignmentDiagnostics{}
+	competed := false
+	for _, charge := range charges {
+		entry := ChargeDiagnostics{Amount: charge.Amount, Losing: charge.Losing}
+		if charge.Match != nil {
+			entry.TransactionID = charge.Match.Transaction.ID
+			entry.Cost = charge.Cost
+		}
+		competed = competed || len(charge.Losing) > 0
+		diagnostics.Charges = append(diagnostics.Charges, entry)
+	}
+	if !competed {
+		return nil
+	}
+	return diagnostics
+}
+
+// assignmentSlot is one expected charge of one order
+type assignmentSlot struct {
+	order  providers.Order
+	amount float64
+	charge *AssignedCharge
+}
+
+// assignmentEdge is an eligible slot-transaction pair
+type assignmentEdge struct {
+	slot       int
+	tx         int
+	cost       float64
+	dateDiff   float64
+	amountDiff float64
+	mismatch   bool // the account belongs to another card; never assigned
+}
+
+// Assign finds the assignment of transactions to order charges with the
+// lowest total cost. A pair is eligible under the same rules as FindMatch;
+// its cost is the date difference in days, plus the amount difference in
+// cents, plus small penalties when the merchant doesn't name the provider or
+// the account can't be tied to the order's card. Transactions on another
+// card's account are never assigned but show up as losing candidates.
+func (m *Matcher) Assign(
+	orders []OrderCharges,
+	transactions []*monarch.Transaction,
+	usedTransactionIDs map[string]bool,
+) *Assignment {
+	assignment := &Assignment{
+		charges:  make(map[string][]*AssignedCharge),
+		reserved: make(map[string]string),
+	}
+
+	var slots []assignmentSlot
+	for _, order := range orders {
+		for _, amount := range order.Charges {
+			charge := &AssignedCharge{Amount: amount}
+			id := order.Order.GetID()
+			assignment.charges[id] = append(assignment.charges[id], charge)
+			slots = append(slots, assignmentSlot{order: order.Order, amount: amount, charge: charge})
+		}
+	}
+
+	var edges, mismatched []assignmentEdge
+	for s, slot := range slots {
+		for t, tx := range transactions {
+			if tx == nil || usedTransactionIDs[tx.ID] {
+				continue
+			}
+			edge, ok := m.scorePair(slot, tx)
+			if !ok {
+				continue
+			}
+			edge.slot, edge.tx = s, t
+			if edge.mismatch {
+				mismatched = append(mismatched, edge)
+			} else {
+				edges = append(edges, edge)
+			}
+		}
+	}
+
+	// Charges only compete with charges that share a candidate, so each
+	// connected group is solved on its own.
+	for _, component := range connectedEdges(len(slots), len(transactions), edges) {
+		m.solveComponent(slots, transactions, component, assignment)
+	}
+	for _, edge := range mismatched {
+		losing := newLosingCandidate(transactions[edge.tx], edge)
+		losing.Reason = LostToCardMismatch
+		charge := slots[edge.slot].charge
+		charge.Losing = append(charge.Losing, losing)
+	}
+	return assignment
+}
+
+// scorePair checks that a transaction is an eligible match for a charge and
+// returns its cost.
+func (m *Matcher) scorePair(slot assignmentSlot, tx *monarch.Transaction) (assignmentEdge, bool) {
+	isReturn := slot.amount < 0
+	if isReturn && tx.Amount < 0 || !isReturn && tx.Amount > 0 {
+		return assignmentEdge{}, false
+	}
+
+	dateDiff := math.Abs(tx.Date.Time.Sub(slot.order.GetDate()).Hours() / 24)
+	if dateDiff > float64(m.config.DateTolerance) {
+		return assignmentEdge{}, false
+	}
+
+	const epsilon = 0.0000001
+	amountDiff := math.Abs(math.Abs(slot.amount) - math.Abs(tx.Amount))
+	if amountDiff > m.config.AmountTolerance+epsilon {
+		return assignmentEdge{}, false
+	}
+
+	fit := m.cardFit(slot.order, tx)
+	cost := dateDiff + math.Round(amountDiff*100) + fit.cost()
+	if !merchantNamesProvider(tx, slot.order.GetProviderName()) {
+		cost += merchantMismatchCost
+	}
+	return assignmentEdge{
+		cost:       cost,
+		dateDiff:   dateDiff,
+		amountDiff: amountDiff,
+		mismatch:   fit == cardMismatch,
+	}, true
+}
+
+func merchantNamesProvider(tx *monarch.Transaction, provider string) bool {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return true
+	}
+	if tx.Merchant != nil && strings.Contains(strings.ToLower(tx.Merchant.Name), provider) {
+		return true
+	}
+	return strings.Contains(strings.ToLower(tx.PlaidName), provider)
+}
+
+// connectedEdges groups edges into the connected components of the
+// slot-transaction graph.
+func connectedEdges(slotCount, txCount int, edges []assignmentEdge) [][]assignmentEdge {
+	parent := make([]int, slotCount+txCount)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, edge := range edges {
+		parent[find(edge.slot)] = find(slotCount + edge.tx)
+	}
+
+	groups := make(map[int][]assignmentEdge)
+	var roots []int
+	for _, edge := range edges {
+		root := find(edge.slot)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], edge)
+	}
+	components := make([][]assignmentEdge, 0, len(roots))
+	for _, root := range roots {
+		components = append(components, groups[root])
+	}
+	return components
+}
+
+// solveComponent assigns one connected group of charges and records the
+// candidates each charge lost.
+func (m *Matcher) solveComponent(
+	slots []assignmentSlot,
+	transactions []*monarch.Transaction,
+	edges []assignmentEdge,
+	assignment *Assignment,
+) {
+	rowOf := make(map[int]int)
+	colOf := make(map[int]int)
+	var rows, cols []int
+	for _, edge := range edges {
+		if _, ok := rowOf[edge.slot]; !ok {
+			rowOf[edge.slot] = len(rows)
+			rows = append(rows, edge.slot)
+		}
+		if _, ok := colOf[edge.tx]; !ok {
+			colOf[edge.tx] = len(cols)
+			cols = append(cols, edge.tx)
+		}
+	}
+
+	// One column per transaction, then one "no transaction" column per charge
+	cost := make([][]float64, len(rows))
+	for r := range cost {
+		cost[r] = make([]float64, len(cols)+len(rows))
+		for c := range cost[r] {
+			if c < len(cols) {
+				cost[r][c] = forbiddenCost
+			} else {
+				cost[r][c] = unassignedCost
+			}
+		}
+	}
+	for _, edge := range edges {
+		cost[rowOf[edge.slot]][colOf[edge.tx]] = edge.cost
+	}
+
+	assignedTx := make(map[int]int) // slot -> transaction index
+	winner := make(map[int]int)     // transaction index -> slot
+	for r, c := range minCostAssignment(cost) {
+		if c < len(cols) && cost[r][c] < forbiddenCost {
+			assignedTx[rows[r]] = cols[c]
+			winner[cols[c]] = rows[r]
+		}
+	}
+
+	for _, edge := range edges {
+		charge := slots[edge.slot].charge
+		tx := transactions[edge.tx]
+		if t, ok := assignedTx[edge.slot]; ok && t == edge.tx {
+			charge.Match = &MatchResult{
+				Transaction: tx,
+				DateDiff:    edge.dateDiff,
+				AmountDiff:  edge.amountDiff,
+				Confidence:  1.0,
+			}
+			charge.Cost = edge.cost
+			assignment.reserved[tx.ID] = slots[edge.slot].order.GetID()
+			continue
+		}
+
+		losing := newLosingCandidate(tx, edge)
+		if s, ok := winner[edge.tx]; ok {
+			if wonBy := slots[s].order.GetID(); wonBy != slots[edge.slot].order.GetID() {
+				losing.Reason = LostToOtherOrder
+				losing.WonBy = wonBy
+			}
+		}
+		charge.Losing = append(charge.Losing, losing)
+	}
+
+	for _, slot := range rows {
+		losing := slots[slot].charge.Losing
+		sort.SliceStable(losing, func(i, j int) bool { return losing[i].Cost < losing[j].Cost })
+	}
+}
+
+// newLosingCandidate describes a candidate that lost a charge for a closer
+// match; callers override the reason.
+func newLosingCandidate(tx *monarch.Transaction, edge assignmentEdge) LosingCandidate {
+	return LosingCandidate{
+		TransactionID: tx.ID,
+		Date:          tx.Date.Format("2006-01-02"),
+		Amount:        tx.Amount,
+		AmountDiff:    edge.amountDiff,
+		DateDiff:      edge.dateDiff,
+		Cost:          edge.cost,
+		Reason:        LostToCloserMatch,
+		Account:       accountLabel(tx),
+	}
+}
+
+// minCostAssignment solves the rectangular assignment problem (rows <=
+// columns) with the Hungarian method and returns the column of each row.
+func minCostAssignment(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	width := len(cost[0])
+
+	// Potentials and matching are 1-indexed; column 0 is a sentinel
+	u := make([]float64, n+1)
+	v := make([]float64, width+1)
+	match := make([]int, width+1) // column -> row
+	way := make([]int, width+1)
+
+	for row := 1; row <= n; row++ {
+		match[0] = row
+		column := 0
+		minv := make([]float64, width+1)
+		visited := make([]bool, width+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for match[column] != 0 {
+			visited[column] = true
+			current := match[column]
+			delta := math.Inf(1)
+			next := 0
+			for j := 1; j <= width; j++ {
+				if visited[j] {
+					continue
+				}
+				reduced := cost[current-1][j-1] - u[current] - v[j]
+				if reduced < minv[j] {
+					minv[j] = reduced
+					way[j] = column
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					next = j
+				}
+			}
+			for j := 0; j <= width; j++ {
+				if visited[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			column = next
+		}
+		for column != 0 {
+			previous := way[column]
+			match[column] = match[previous]
+			column = previous
+		}
+	}
+
+	assigned := make([]int, n)
+	for j := 1; j <= width; j++ {
+		if match[j] != 0 {
+			assigned[match[j]-1] = j - 1
+		}
+	}
+	return assigned
+}
+
+// SetAssignment makes the matcher hand each order its assigned transactions
+// and keep other orders away from them. Pass nil to match greedily again.
+func (m *Matcher) SetAssignment(assignment *Assignment) {
+	m.assignment = assignment
+}
+
+// assignedMatch returns the transaction assigned to one of the order's
+// charges that fits amount (negative for a return) and is still unused.
+func (m *Matcher) assignedMatch(orderID string, amount float64, usedTransactionIDs map[string]bool) *MatchResult {
+	const epsilon = 0.0000001
+	for _, charge := range m.assignment.ForOrder(orderID) {
+		if charge.Match == nil || usedTransactionIDs[charge.Match.Transaction.ID] {
+			continue
+		}
+		if (charge.Amount < 0) != (amount < 0) {
+			continue
+		}
+		if math.Abs(math.Abs(charge.Amount)-math.Abs(amount)) > m.config.AmountTolerance+epsilon {
+			continue
+		}
+		match := *charge.Match
+		return &match
+	}
+	return nil
+}
+
+// reservedForOther reports whether a transaction was assigned to a
+// different order.
+func (m *Matcher) reservedForOther(transactionID, orderID string) bool {
+	owner, ok := m.assignment.ReservedFor(transactionID)
+	return ok && owner != orderID
+}
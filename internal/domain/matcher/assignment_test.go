@@ -0,0 +1,171 @@
+package matcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(d int) time.Time {
+	return time.Date(2025, 10, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMatcher_AssignBeatsFetchOrder(t *testing.T) {
+	// Matching first in fetch order gives first the Oct 11 charge (1 day
+	// away), which leaves second nothing within five days of Oct 15.
+	m := NewMatcher(DefaultConfig())
+	first := &mockOrder{id: "first", date: day(10), total: 25.00}
+	second := &mockOrder{id: "second", date: day(15), total: 25.00}
+	transactions := []*monarch.Transaction{
+		makeTransaction("tx-oct11", -25.00, day(11)),
+		makeTransaction("tx-oct7", -25.00, day(7)),
+	}
+
+	assignment := m.Assign([]OrderCharges{
+		{Order: first, Charges: []float64{25.00}},
+		{Order: second, Charges: []float64{25.00}},
+	}, transactions, map[string]bool{})
+
+	require.Len(t, assignment.ForOrder("first"), 1)
+	require.NotNil(t, assignment.ForOrder("first")[0].Match)
+	assert.Equal(t, "tx-oct7", assignment.ForOrder("first")[0].Match.Transaction.ID)
+	assert.Equal(t, 3.0, assignment.ForOrder("first")[0].Match.DateDiff)
+	require.NotNil(t, assignment.ForOrder("second")[0].Match)
+	assert.Equal(t, "tx-oct11", assignment.ForOrder("second")[0].Match.Transaction.ID)
+
+	diagnostics := assignment.Diagnostics("first")
+	require.NotNil(t, diagnostics)
+	require.Len(t, diagnostics.Charges, 1)
+	assert.Equal(t, "tx-oct7", diagnostics.Charges[0].TransactionID)
+	require.Len(t, diagnostics.Charges[0].Losing, 1)
+	lost := diagnostics.Charges[0].Losing[0]
+	assert.Equal(t, "tx-oct11", lost.TransactionID)
+	assert.Equal(t, LostToOtherOrder, lost.Reason)
+	assert.Equal(t, "second", lost.WonBy)
+	assert.Equal(t, 1.0, lost.DateDiff)
+	assert.Nil(t, assignment.Diagnostics("second"), "second had a single candidate")
+}
+
+func TestMatcher_FindMatchUsesAssignment(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	first := &mockOrder{id: "first", date: day(10), total: 40.00}
+	second := &mockOrder{id: "second", date: day(14), total: 40.00}
+	transactions := []*monarch.Transaction{
+		makeTransaction("tx-oct14", -40.00, day(14)),
+		makeTransaction("tx-oct11", -40.00, day(11)),
+		makeTransaction("tx-other", -12.00, day(10)),
+	}
+
+	assignment := m.Assign([]OrderCharges{
+		{Order: second, Charges: []float64{40.00}},
+		{Order: first, Charges: []float64{40.00}},
+	}, transactions, map[string]bool{})
+	m.SetAssignment(assignment)
+
+	used := map[string]bool{}
+	firstMatch, err := m.FindMatch(first, transactions, used)
+	require.NoError(t, err)
+	require.NotNil(t, firstMatch)
+	assert.Equal(t, "tx-oct11", firstMatch.Transaction.ID)
+	used[firstMatch.Transaction.ID] = true
+
+	secondMatch, err := m.FindMatch(second, transactions, used)
+	require.NoError(t, err)
+	require.NotNil(t, secondMatch)
+	assert.Equal(t, "tx-oct14", secondMatch.Transaction.ID)
+}
+
+func TestMatcher_AssignedTransactionsAreReserved(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	assigned := &mockOrder{id: "assigned", date: day(10), total: 25.00}
+	latecomer := &mockOrder{id: "latecomer", date: day(10), total: 25.00}
+	transactions := []*monarch.Transaction{makeTransaction("tx1", -25.00, day(10))}
+
+	m.SetAssignment(m.Assign([]OrderCharges{{Order: assigned, Charges: []float64{25.00}}}, transactions, map[string]bool{}))
+
+	match, err := m.FindMatch(latecomer, transactions, map[string]bool{})
+	require.NoError(t, err)
+	assert.Nil(t, match, "another order's transaction is not a candidate")
+
+	m.SetAssignment(nil)
+	match, err = m.FindMatch(latecomer, transactions, map[string]bool{})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx1", match.Transaction.ID)
+}
+
+func TestMatcher_AssignMultipleCharges(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	multi := &mockOrder{id: "multi", date: day(10), total: 50.00}
+	single := &mockOrder{id: "single", date: day(13), total: 30.00}
+	transactions := []*monarch.Transaction{
+		makeTransaction("tx-30-a", -30.00, day(12)),
+		makeTransaction("tx-20", -20.00, day(11)),
+		makeTransaction("tx-30-b", -30.00, day(9)),
+	}
+
+	m.SetAssignment(m.Assign([]OrderCharges{
+		{Order: multi, Charges: []float64{30.00, 20.00}},
+		{Order: single, Charges: []float64{30.00}},
+	}, transactions, map[string]bool{}))
+
+	result, err := m.FindMultipleMatches(multi, transactions, map[string]bool{}, []float64{30.00, 20.00})
+	require.NoError(t, err)
+	require.True(t, result.AllFound)
+	assert.Equal(t, "tx-30-b", result.Matches[0].Transaction.ID)
+	assert.Equal(t, "tx-20", result.Matches[1].Transaction.ID)
+
+	match, err := m.FindMatch(single, transactions, map[string]bool{"tx-30-b": true, "tx-20": true})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx-30-a", match.Transaction.ID)
+}
+
+func TestMatcher_AssignPrefersProviderMerchant(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	order := &mockOrder{id: "order", date: day(10), total: 25.00}
+	other := makeTransaction("tx-other", -25.00, day(11))
+	other.Merchant = &monarch.Merchant{Name: "Corner Store"}
+	provider := makeTransaction("tx-provider", -25.00, day(11))
+	provider.Merchant = &monarch.Merchant{Name: "Test Market"}
+
+	assignment := m.Assign([]OrderCharges{{Order: order, Charges: []float64{25.00}}},
+		[]*monarch.Transaction{other, provider}, map[string]bool{})
+
+	charge := assignment.ForOrder("order")[0]
+	require.NotNil(t, charge.Match)
+	assert.Equal(t, "tx-provider", charge.Match.Transaction.ID)
+	require.Len(t, charge.Losing, 1)
+	assert.Equal(t, LostToCloserMatch, charge.Losing[0].Reason)
+	assert.Empty(t, charge.Losing[0].WonBy)
+}
+
+func TestMatcher_AssignSkipsUsedAndWrongSign(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	order := &mockOrder{id: "order", date: day(10), total: 25.00}
+	transactions := []*monarch.Transaction{
+		makeTransaction("tx-used", -25.00, day(10)),
+		makeTransaction("tx-refund", 25.00, day(10)),
+	}
+
+	assignment := m.Assign([]OrderCharges{{Order: order, Charges: []float64{25.00}}},
+		transactions, map[string]bool{"tx-used": true})
+
+	require.Len(t, assignment.ForOrder("order"), 1)
+	assert.Nil(t, assignment.ForOrder("order")[0].Match)
+	assert.Nil(t, assignment.Diagnostics("order"))
+	assert.Nil(t, assignment.ForOrder("unknown"))
+}
+
+func TestMinCostAssignment(t *testing.T) {
+	cost := [][]float64{
+		{4, 1, 3},
+		{2, 0, 5},
+	}
+
+	assert.Equal(t, []int{1, 0}, minCostAssignment(cost))
+	assert.Nil(t, minCostAssignment(nil))
+}
@@ -0,0 +1,108 @@
+package matcher
+
+import (
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+)
+
+// cardUnknownCost is added when a transaction's account can't be tied to
+// the card the order was paid with, so a candidate on the known card wins
+// over an otherwise equal one on an unknown account.
+const cardUnknownCost = 1.0
+
+// CardAccounts maps a payment card's last four digits to the Monarch
+// accounts its charges post to, each named by account ID, display name or
+// last four digits.
+type CardAccounts map[string][]string
+
+// SetCardAccounts sets the configured card-to-account mapping used to filter
+// and score candidates. Pass nil to match on any account.
+func (m *Matcher) SetCardAccounts(accounts CardAccounts) {
+	m.cardAccounts = accounts
+}
+
+// SetLearnedCardAccounts sets card-to-account pairings learned from past
+// matches. They only score candidates: a transaction on a learned account is
+// preferred, but transactions on other accounts are never left out.
+func (m *Matcher) SetLearnedCardAccounts(accounts CardAccounts) {
+	m.learnedCardAccounts = accounts
+}
+
+// cardFit is how a transaction's account fits the cards an order was paid with
+type cardFit int
+
+const (
+	cardUnknown  cardFit = iota // no card reported or not every card configured
+	cardMatch                   // the account belongs to one of the cards
+	cardMismatch                // every card is configured and none to this account
+)
+
+// cardFit checks a transaction's account against the order's payment cards.
+// An account whose last four digits are a card's, or that a card was learned
+// to post to, fits without a configured mapping; an account only misfits
+// when every card of the order has a configured mapping.
+func (m *Matcher) cardFit(order providers.Order, tx *monarch.Transaction) cardFit {
+	cards := providers.OrderPaymentCards(order)
+	if len(cards) == 0 || tx.Account == nil {
+		return cardUnknown
+	}
+
+	allMapped := true
+	for _, card := range cards {
+		if card.LastFour != "" && card.LastFour == tx.Account.Mask {
+			return cardMatch
+		}
+		for _, account := range m.learnedCardAccounts[card.LastFour] {
+			if accountNamed(tx.Account, account) {
+				return cardMatch
+			}
+		}
+		accounts, ok := m.cardAccounts[card.LastFour]
+		if !ok || len(accounts) == 0 {
+			allMapped = false
+			continue
+		}
+		for _, account := range accounts {
+			if accountNamed(tx.Account, account) {
+				return cardMatch
+			}
+		}
+	}
+	if allMapped {
+		return cardMismatch
+	}
+	return cardUnknown
+}
+
+// cost is the score penalty for a transaction's card fit
+func (f cardFit) cost() float64 {
+	if f == cardMatch {
+		return 0
+	}
+	return cardUnknownCost
+}
+
+// accountNamed reports whether a Monarch account is the one named by an ID,
+// display name or last four digits
+func accountNamed(account *monarch.Account, name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return false
+	}
+	return name == strings.ToLower(account.ID) ||
+		name == strings.ToLower(account.DisplayName) ||
+		(account.Mask != "" && name == strings.ToLower(account.Mask))
+}
+
+// accountLabel names a transaction's account in diagnostics
+func accountLabel(tx *monarch.Transaction) string {
+	if tx.Account == nil {
+		return ""
+	}
+	if tx.Account.DisplayName != "" {
+		return tx.Account.DisplayName
+	}
+	return tx.Account.ID
+}
@@ -0,0 +1,147 @@
+package matcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCardOrder is an order that reports its payment cards
+type mockCardOrder struct {
+	mockOrder
+	cards []providers.PaymentCard
+}
+
+func (m *mockCardOrder) GetPaymentCards() []providers.PaymentCard { return m.cards }
+
+func cardOrder(id string, date time.Time, total float64, lastFours ...string) *mockCardOrder {
+	order := &mockCardOrder{mockOrder: mockOrder{id: id, date: date, total: total}}
+	for _, lastFour := range lastFours {
+		order.cards = append(order.cards, providers.PaymentCard{LastFour: lastFour})
+	}
+	return order
+}
+
+func onAccount(tx *monarch.Transaction, id, name, mask string) *monarch.Transaction {
+	tx.Account = &monarch.Account{ID: id, DisplayName: name, Mask: mask}
+	return tx
+}
+
+func TestMatcher_CardFit(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	m.SetCardAccounts(CardAccounts{"1211": {"Chase Prime Visa"}})
+
+	prime := onAccount(makeTransaction("tx", -10, day(10)), "acc-1", "Chase Prime Visa", "")
+	masked := onAccount(makeTransaction("tx", -10, day(10)), "acc-2", "Checking", "4455")
+	other := onAccount(makeTransaction("tx", -10, day(10)), "acc-3", "Amex Gold", "")
+	noAccount := makeTransaction("tx", -10, day(10))
+
+	assert.Equal(t, cardMatch, m.cardFit(cardOrder("o", day(10), 10, "1211"), prime))
+	assert.Equal(t, cardMatch, m.cardFit(cardOrder("o", day(10), 10, "4455"), masked), "mask equals the card")
+	assert.Equal(t, cardMismatch, m.cardFit(cardOrder("o", day(10), 10, "1211"), other))
+	assert.Equal(t, cardUnknown, m.cardFit(cardOrder("o", day(10), 10, "1211", "9999"), other), "one card is unmapped")
+	assert.Equal(t, cardUnknown, m.cardFit(cardOrder("o", day(10), 10, "1211"), noAccount))
+	assert.Equal(t, cardUnknown, m.cardFit(&mockOrder{id: "o", date: day(10), total: 10}, other))
+}
+
+func TestMatcher_FindMatchSkipsOtherCards(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	m.SetCardAccounts(CardAccounts{"1211": {"acc-prime"}})
+	order := cardOrder("order", day(10), 42.17, "1211")
+	transactions := []*monarch.Transaction{
+		onAccount(makeTransaction("tx-amex", -42.17, day(10)), "acc-amex", "Amex Gold", "3005"),
+		onAccount(makeTransaction("tx-prime", -42.17, day(12)), "acc-prime", "Chase Prime Visa", ""),
+	}
+
+	match, err := m.FindMatch(order, transactions, map[string]bool{})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx-prime", match.Transaction.ID, "the closer charge is on another card")
+	assert.Equal(t, 2.0, match.DateDiff)
+
+	match, err = m.FindMatch(order, transactions[:1], map[string]bool{})
+	require.NoError(t, err)
+	assert.Nil(t, match)
+}
+
+func TestMatcher_FindMatchPrefersKnownCard(t *testing.T) {
+	// Without a mapping nothing is filtered, but a charge on an account whose
+	// mask is the card's wins over a closer one on an unknown account
+	m := NewMatcher(DefaultConfig())
+	order := cardOrder("order", day(10), 42.17, "1211")
+	transactions := []*monarch.Transaction{
+		onAccount(makeTransaction("tx-unknown", -42.17, day(10)), "acc-1", "Household", ""),
+		onAccount(makeTransaction("tx-card", -42.17, day(10).Add(12*time.Hour)), "acc-2", "Visa", "1211"),
+	}
+
+	match, err := m.FindMatch(order, transactions, map[string]bool{})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx-card", match.Transaction.ID)
+}
+
+func TestMatcher_LearnedCardAccountsOnlyScore(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	m.SetLearnedCardAccounts(CardAccounts{"1211": {"acc-prime"}})
+	order := cardOrder("order", day(10), 42.17, "1211")
+	amex := onAccount(makeTransaction("tx-amex", -42.17, day(10)), "acc-amex", "Amex Gold", "3005")
+	prime := onAccount(makeTransaction("tx-prime", -42.17, day(10).Add(12*time.Hour)), "acc-prime", "Chase Prime Visa", "")
+
+	assert.Equal(t, cardMatch, m.cardFit(order, prime))
+	assert.Equal(t, cardUnknown, m.cardFit(order, amex), "a learned pairing never rules an account out")
+
+	match, err := m.FindMatch(order, []*monarch.Transaction{amex, prime}, map[string]bool{})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx-prime", match.Transaction.ID, "the learned account is preferred")
+
+	match, err = m.FindMatch(order, []*monarch.Transaction{amex}, map[string]bool{})
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "tx-amex", match.Transaction.ID)
+}
+
+func TestMatcher_FindMultipleMatchesSkipsOtherCards(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	m.SetCardAccounts(CardAccounts{"1211": {"Chase Prime Visa"}})
+	order := cardOrder("order", day(10), 50.00, "1211")
+	transactions := []*monarch.Transaction{
+		onAccount(makeTransaction("tx-amex-30", -30.00, day(10)), "acc-amex", "Amex Gold", ""),
+		onAccount(makeTransaction("tx-prime-30", -30.00, day(11)), "acc-prime", "Chase Prime Visa", ""),
+		onAccount(makeTransaction("tx-prime-20", -20.00, day(11)), "acc-prime", "Chase Prime Visa", ""),
+	}
+
+	result, err := m.FindMultipleMatches(order, transactions, map[string]bool{}, []float64{30.00, 20.00})
+	require.NoError(t, err)
+	require.True(t, result.AllFound)
+	assert.Equal(t, "tx-prime-30", result.Matches[0].Transaction.ID)
+	assert.Equal(t, "tx-prime-20", result.Matches[1].Transaction.ID)
+}
+
+func TestMatcher_AssignReportsCardMismatch(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	m.SetCardAccounts(CardAccounts{"1211": {"acc-prime"}})
+	order := cardOrder("order", day(10), 25.00, "1211")
+	transactions := []*monarch.Transaction{
+		onAccount(makeTransaction("tx-amex", -25.00, day(10)), "acc-amex", "Amex Gold", ""),
+		onAccount(makeTransaction("tx-prime", -25.00, day(13)), "acc-prime", "Chase Prime Visa", ""),
+	}
+
+	assignment := m.Assign([]OrderCharges{{Order: order, Charges: []float64{25.00}}}, transactions, map[string]bool{})
+
+	charge := assignment.ForOrder("order")[0]
+	require.NotNil(t, charge.Match)
+	assert.Equal(t, "tx-prime", charge.Match.Transaction.ID)
+
+	diagnostics := assignment.Diagnostics("order")
+	require.NotNil(t, diagnostics)
+	require.Len(t, diagnostics.Charges[0].Losing, 1)
+	lost := diagnostics.Charges[0].Losing[0]
+	assert.Equal(t, "tx-amex", lost.TransactionID)
+	assert.Equal(t, LostToCardMismatch, lost.Reason)
+	assert.Equal(t, "Amex Gold", lost.Account)
+}
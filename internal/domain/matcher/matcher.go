@@ -31,7 +31,10 @@ var ErrAmbiguousMatch = errors.New("ambiguous transaction match")
 
 // Matcher matches orders with Monarch transactions
 type Matcher struct {
-	config Config
+	config              Config
+	assignment          *Assignment  // run-wide assignment set by SetAssignment (nil = greedy)
+	cardAccounts        CardAccounts // set by SetCardAccounts (nil = any account)
+	learnedCardAccounts CardAccounts // set by SetLearnedCardAccounts (score only)
 }
 
 // NewMatcher creates a new matcher with the given config
@@ -51,10 +54,16 @@ func (m *Matcher) FindMatch(
 
 	var bestMatch *monarch.Transaction
 	var bestScore float64 = 999999 // Lower is better
+	var bestDateDiff float64
 
 	orderAmount := order.GetTotal()
 	orderDate := order.GetDate()
 
+	// Prefer the transaction the run-wide assignment gave this order
+	if assigned := m.assignedMatch(order.GetID(), orderAmount, usedTransactionIDs); assigned != nil {
+		return assigned, nil
+	}
+
 	// Handle returns (negative amounts)
 	isReturn := orderAmount < 0
 	if isReturn {
@@ -62,8 +71,8 @@ func (m *Matcher) FindMatch(
 	}
 
 	for _, tx := range transactions {
-		// Skip if already used
-		if usedTransactionIDs[tx.ID] {
+		// Skip if already used or assigned to another order
+		if usedTransactionIDs[tx.ID] || m.reservedForOther(tx.ID, order.GetID()) {
 			continue
 		}
 
@@ -97,12 +106,19 @@ func (m *Matcher) FindMatch(
 			continue
 		}
 
+		// Skip charges on another card, prefer charges on the order's card
+		fit := m.cardFit(order, tx)
+		if fit == cardMismatch {
+			continue
+		}
+
 		// Score based on date closeness (amount is already exact)
-		score := dateDiff
+		score := dateDiff + fit.cost()
 
 		if score < bestScore {
 			bestMatch = tx
 			bestScore = score
+			bestDateDiff = dateDiff
 		}
 	}
 
@@ -113,7 +129,7 @@ func (m *Matcher) FindMatch(
 	// Calculate final result
 	result := &MatchResult{
 		Transaction: bestMatch,
-		DateDiff:    bestScore,
+		DateDiff:    bestDateDiff,
 		AmountDiff:  math.Abs(orderAmount - math.Abs(bestMatch.Amount)),
 		Confidence:  1.0, // For now, all matches are high confidence
 	}
@@ -140,7 +156,10 @@ func (m *Matcher) FindUniqueMatch(
 	const epsilon = 0.0000001
 
 	for _, tx := range transactions {
-		if tx == nil || usedTransactionIDs[tx.ID] {
+		if tx == nil || usedTransactionIDs[tx.ID] || m.reservedForOther(tx.ID, order.GetID()) {
+			continue
+		}
+		if m.cardFit(order, tx) == cardMismatch {
 			continue
 		}
 		if isReturn && tx.Amount < 0 {
@@ -3,7 +3,6 @@ package matcher
 import (
 	"fmt"
 	"math"
-	"time"
 
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
@@ -38,22 +37,24 @@ func (m *Matcher) FindMultipleMatches(
 	// Track matches in this operation to prevent duplicates
 	matchedThisRound := make(map[string]bool)
 
-	orderDate := order.GetDate()
-
 	// Find best match for each charge amount
 	for i, amount := range amounts {
 		if amount <= 0 {
 			return nil, fmt.Errorf("invalid amount at index %d: %.2f (must be positive)", i, amount)
 		}
 
-		// Find best matching transaction for this amount
-		match := m.findBestMatchForAmount(
-			amount,
-			orderDate,
-			transactions,
-			usedTransactionIDs,
-			matchedThisRound,
-		)
+		// Prefer the transaction the run-wide assignment gave this charge,
+		// otherwise find the best matching transaction for this amount
+		match := m.assignedMatch(order.GetID(), amount, mergeUsed(usedTransactionIDs, matchedThisRound))
+		if match == nil {
+			match = m.findBestMatchForAmount(
+				amount,
+				order,
+				transactions,
+				usedTransactionIDs,
+				matchedThisRound,
+			)
+		}
 
 		if match != nil {
 			// Mark as matched in this round
@@ -83,17 +84,20 @@ func (m *Matcher) FindMultipleMatches(
 // Reuses core matching logic from FindMatch but for a single amount
 func (m *Matcher) findBestMatchForAmount(
 	amount float64,
-	orderDate time.Time,
+	order providers.Order,
 	transactions []*monarch.Transaction,
 	usedTransactionIDs map[string]bool,
 	matchedThisRound map[string]bool,
 ) *MatchResult {
 	var bestMatch *monarch.Transaction
 	var bestScore float64 = 999999 // Lower is better
+	var bestDateDiff float64
+
+	orderDate := order.GetDate()
 
 	for _, tx := range transactions {
-		// Skip if already used globally
-		if usedTransactionIDs[tx.ID] {
+		// Skip if already used globally or assigned to another order
+		if usedTransactionIDs[tx.ID] || m.reservedForOther(tx.ID, order.GetID()) {
 			continue
 		}
 
@@ -125,12 +129,19 @@ func (m *Matcher) findBestMatchForAmount(
 			continue
 		}
 
+		// Skip charges on another card, prefer charges on the order's card
+		fit := m.cardFit(order, tx)
+		if fit == cardMismatch {
+			continue
+		}
+
 		// Score based on date closeness (amount is already exact)
-		score := dateDiff
+		score := dateDiff + fit.cost()
 
 		if score < bestScore {
 			bestMatch = tx
 			bestScore = score
+			bestDateDiff = dateDiff
 		}
 	}
 
@@ -141,7 +152,7 @@ func (m *Matcher) findBestMatchForAmount(
 	// Calculate final result
 	result := &MatchResult{
 		Transaction: bestMatch,
-		DateDiff:    bestScore,
+		DateDiff:    bestDateDiff,
 		AmountDiff:  math.Abs(amount - math.Abs(bestMatch.Amount)),
 		Confidence:  1.0,
 	}
@@ -149,6 +160,18 @@ func (m *Matcher) findBestMatchForAmount(
 	return result
 }
 
+// mergeUsed returns the transactions used globally or in this operation
+func mergeUsed(used, matchedThisRound map[string]bool) map[string]bool {
+	merged := make(map[string]bool, len(used)+len(matchedThisRound))
+	for id := range used {
+		merged[id] = true
+	}
+	for id := range matchedThisRound {
+		merged[id] = true
+	}
+	return merged
+}
+
 // validateMultiMatchSum ensures matched transactions sum to requested amounts
 // Uses tolerance-based comparison to handle floating-point arithmetic
 // Note: We validate against sum of requested amounts (result.Amounts), NOT order total,
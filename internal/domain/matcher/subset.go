@@ -1,8 +1,11 @@
 package matcher
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"sort"
+	"time"
 
 	"github.com/eshaffer321/itemize/internal/adapters/providers"
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
@@ -12,14 +15,60 @@ import (
 // charges can post several days after the order date.
 const subsetDateTolerance = 10
 
+// Default limits of the subset search, used when the Config leaves them unset.
+// The state cap bounds the work on typical hardware; the time limit bounds it
+// on slow or busy machines, where visiting the states takes much longer.
+const (
+	defaultSubsetMaxCandidates = 40
+	defaultSubsetMaxStates     = 100_000_000
+	defaultSubsetTimeLimit     = time.Second
+)
+
+var (
+	// ErrAmbiguousSubset indicates that two or more subsets of transactions
+	// sum to the order total equally well, so choosing one would be a guess.
+	ErrAmbiguousSubset = errors.New("ambiguous transaction subset")
+
+	// ErrSubsetSearchLimit indicates that the subset search visited more sum
+	// states, or ran longer, than its limits allow.
+	ErrSubsetSearchLimit = errors.New("subset search limit exceeded")
+)
+
+// subsetLimits bounds the subset search
+type subsetLimits struct {
+	maxCandidates int
+	maxStates     int
+	timeLimit     time.Duration
+}
+
+func (m *Matcher) subsetLimits() subsetLimits {
+	limits := subsetLimits{
+		maxCandidates: m.config.SubsetMaxCandidates,
+		maxStates:     m.config.SubsetMaxStates,
+		timeLimit:     m.config.SubsetTimeLimit,
+	}
+	if limits.maxCandidates <= 0 {
+		limits.maxCandidates = defaultSubsetMaxCandidates
+	}
+	if limits.maxStates <= 0 {
+		limits.maxStates = defaultSubsetMaxStates
+	}
+	if limits.timeLimit <= 0 {
+		limits.timeLimit = defaultSubsetTimeLimit
+	}
+	return limits
+}
+
 // FindSubsetByTotal finds a subset of Monarch transactions whose absolute amounts
 // sum to the order total. Used as a fallback when the Amazon provider cannot
 // discover all bank charges from the order's transaction page (e.g. when
 // subsequent shipment charges post after the provider visited the order page).
 //
 // Only negative (purchase) transactions are considered; refunds/credits are
-// excluded. Returns the matched transactions or an error if no valid subset
-// is found.
+// excluded. The subset closest to the total wins, then the one whose charges
+// post closest together, then the one with fewer transactions. Returns
+// ErrAmbiguousSubset when two subsets tie, ErrSubsetSearchLimit when the
+// search exceeds its state or time limit, or an error if no valid subset is found.
 func (m *Matcher) FindSubsetByTotal(
 	order providers.Order,
 	monarchTxns []*monarch.Transaction,
@@ -31,16 +80,20 @@ func (m *Matcher) FindSubsetByTotal(
 	}
 
 	orderDate := order.GetDate()
+	limits := m.subsetLimits()
 
 	// Collect purchase candidates within the date window
 	var candidates []*monarch.Transaction
 	for _, txn := range monarchTxns {
-		if usedTxnIDs[txn.ID] {
+		if usedTxnIDs[txn.ID] || m.reservedForOther(txn.ID, order.GetID()) {
 			continue
 		}
 		if txn.Amount >= 0 { // skip refunds/credits
 			continue
 		}
+		if m.cardFit(order, txn) == cardMismatch {
+			continue
+		}
 		days := math.Abs(txn.Date.Time.Sub(orderDate).Hours() / 24)
 		if days > subsetDateTolerance {
 			continue
@@ -48,69 +101,305 @@ func (m *Matcher) FindSubsetByTotal(
 		candidates = append(candidates, txn)
 	}
 
-	// Brute-force subset search — n is always small (typically 1–5)
-	matches := subsetSummingTo(candidates, target, m.config.AmountTolerance)
+	// On a busy month keep the candidates closest to the order date
+	if len(candidates) > limits.maxCandidates {
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return math.Abs(candidates[i].Date.Time.Sub(orderDate).Hours()) <
+				math.Abs(candidates[j].Date.Time.Sub(orderDate).Hours())
+		})
+		candidates = candidates[:limits.maxCandidates]
+	}
+
+	matches, err := subsetSummingTo(candidates, target, m.config.AmountTolerance, limits)
+	if err != nil {
+		return nil, err
+	}
 	if matches == nil {
 		return nil, fmt.Errorf("no combination of Monarch transactions sums to order total $%.2f", target)
 	}
 	return matches, nil
 }
 
-// subsetSummingTo returns the closest subset of txns whose absolute amounts
-// sum to target within tolerance, or nil if none exists. When multiple subsets
-// are equally close, it prefers the one with fewer transactions.
-func subsetSummingTo(txns []*monarch.Transaction, target, tolerance float64) []*monarch.Transaction {
-	n := len(txns)
-	if n > 20 {
-		n = 20 // guard; 2^20 is ~1M — still fast, but cap for safety
+// subsetCandidate is a transaction in whole cents and whole days
+type subsetCandidate struct {
+	tx    *monarch.Transaction
+	cents int
+	day   int
+}
+
+// subsetSearch is a dynamic program over sums in whole cents. For each
+// transaction taken as the earliest of the subset, later transactions are
+// added in date order until a subset reaches the target; the date span at
+// that point is the tightest clustering with that earliest transaction.
+type subsetSearch struct {
+	candidates []subsetCandidate
+	sums       []int        // acceptable sums, closest to the target first
+	statesLeft int          // sum states the search may still visit
+	deadline   time.Time    // when the search gives up (zero: never)
+	table      *subsetTable // reused for every earliest transaction
+}
+
+// subsetBest is the best subset found with one earliest transaction
+type subsetBest struct {
+	first, last int // candidate indexes bounding the date span
+	span        int // days between the first and last charge
+	count       int
+	ways        int // subsets tied on span and count (capped at 2)
+	sum         int
+}
+
+// subsetSummingTo returns the subset of txns whose absolute amounts sum
+// closest to target within tolerance, preferring charges that post close
+// together and then fewer transactions, or nil if none exists.
+func subsetSummingTo(txns []*monarch.Transaction, target, tolerance float64, limits subsetLimits) ([]*monarch.Transaction, error) {
+	targetCents := toCents(target)
+	toleranceCents := int(math.Floor(tolerance*100 + 0.0000001))
+
+	var candidates []subsetCandidate
+	for _, tx := range txns {
+		cents := toCents(math.Abs(tx.Amount))
+		if cents == 0 || cents > targetCents+toleranceCents {
+			continue
+		}
+		candidates = append(candidates, subsetCandidate{tx: tx, cents: cents, day: dayNumber(tx.Date.Time)})
 	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].day < candidates[j].day })
 
-	var best []*monarch.Transaction
-	bestDifference := math.Inf(1)
+	// The closest reachable sum wins before anything else
+	difference := closestReachableDifference(candidates, targetCents, toleranceCents)
+	if difference < 0 {
+		return nil, nil
+	}
+	search := &subsetSearch{
+		candidates: candidates,
+		statesLeft: limits.maxStates,
+	}
+	if limits.timeLimit > 0 {
+		search.deadline = time.Now().Add(limits.timeLimit)
+	}
+	if targetCents-difference > 0 {
+		search.sums = append(search.sums, targetCents-difference)
+	}
+	if difference > 0 {
+		search.sums = append(search.sums, targetCents+difference)
+	}
+	search.table = newSubsetTable(search.sums[len(search.sums)-1])
 
-	// Search every valid subset. Iterating by size preserves the fewer-transactions
-	// tie-breaker while allowing an exact total to beat an earlier tolerated match.
-	for size := 1; size <= n; size++ {
-		result, difference := closestSubsetOfSize(txns[:n], target, tolerance, 0, size, nil)
-		if result != nil && difference < bestDifference {
-			best = result
-			bestDifference = difference
+	var best *subsetBest
+	ways := 0
+	for first := range candidates {
+		found, err := search.fromFirst(first, best)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case found == nil:
+		case best == nil || found.span < best.span || (found.span == best.span && found.count < best.count):
+			best, ways = found, found.ways
+		case found.span == best.span && found.count == best.count:
+			ways += found.ways
 		}
 	}
-	return best
+	if best == nil {
+		return nil, nil
+	}
+	if ways > 1 {
+		return nil, fmt.Errorf("%w: several subsets of %d transactions span %d days",
+			ErrAmbiguousSubset, best.count, best.span)
+	}
+	return search.reconstruct(best), nil
 }
 
-// closestSubsetOfSize is a recursive backtracking search for the closest valid
-// subset of exactly `size` transactions.
-func closestSubsetOfSize(
-	txns []*monarch.Transaction,
-	target, tolerance float64,
-	start, remaining int,
-	current []*monarch.Transaction,
-) ([]*monarch.Transaction, float64) {
-	if remaining == 0 {
-		sum := 0.0
-		for _, t := range current {
-			sum += math.Abs(t.Amount)
+// closestReachableDifference returns the smallest distance from target, up
+// to tolerance, of any subset sum, or -1 when no subset is close enough.
+func closestReachableDifference(candidates []subsetCandidate, target, tolerance int) int {
+	limit := target + tolerance
+	reachable := make([]bool, limit+1)
+	reachable[0] = true
+	for _, candidate := range candidates {
+		for sum := limit; sum >= candidate.cents; sum-- {
+			if reachable[sum-candidate.cents] {
+				reachable[sum] = true
+			}
+		}
+	}
+	for difference := 0; difference <= tolerance; difference++ {
+		if target-difference > 0 && reachable[target-difference] {
+			return difference
 		}
-		difference := math.Abs(sum - target)
-		if difference <= tolerance {
-			result := make([]*monarch.Transaction, len(current))
-			copy(result, current)
-			return result, difference
+		if reachable[target+difference] {
+			return difference
 		}
-		return nil, 0
 	}
+	return -1
+}
+
+// fromFirst finds the tightest subsets that start with candidate first.
+// Later transactions are added in date order until one of the target sums is
+// reached; every subset reaching it then ends on that day, so after adding the
+// rest of that day's transactions the table holds exactly the tightest
+// subsets. The search stops early once the span can no longer beat best.
+func (s *subsetSearch) fromFirst(first int, best *subsetBest) (*subsetBest, error) {
+	table := s.table
+	table.start(s.candidates[first].cents)
 
-	var best []*monarch.Transaction
-	bestDifference := math.Inf(1)
-	for i := start; i <= len(txns)-remaining; i++ {
-		found, difference := closestSubsetOfSize(txns, target, tolerance, i+1, remaining-1,
-			append(current, txns[i]))
-		if found != nil && difference < bestDifference {
-			best = found
-			bestDifference = difference
+	reachedAt := -1
+	last := first
+	for next := first; next < len(s.candidates); next++ {
+		span := s.candidates[next].day - s.candidates[first].day
+		if best != nil && span > best.span {
+			break
+		}
+		if reachedAt >= 0 && s.candidates[next].day != s.candidates[reachedAt].day {
+			break
+		}
+		if next > first {
+			cents := s.candidates[next].cents
+			s.statesLeft -= table.statesVisited(cents)
+			if s.statesLeft < 0 {
+				return nil, fmt.Errorf("%w: state limit reached", ErrSubsetSearchLimit)
+			}
+			if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+				return nil, fmt.Errorf("%w: time limit reached", ErrSubsetSearchLimit)
+			}
+			table.addCandidate(cents, nil)
+		}
+		last = next
+		if reachedAt < 0 && table.reachesAny(s.sums) {
+			reachedAt = next
+		}
+	}
+	if reachedAt < 0 {
+		return nil, nil
+	}
+
+	found := &subsetBest{
+		first: first,
+		last:  last,
+		span:  s.candidates[reachedAt].day - s.candidates[first].day,
+	}
+	for _, sum := range s.sums {
+		state := table.states[sum]
+		switch {
+		case state.count == 0:
+		case found.count == 0 || int(state.count) < found.count:
+			found.count, found.ways, found.sum = int(state.count), int(state.ways), sum
+		case int(state.count) == found.count:
+			found.ways = min(found.ways+int(state.ways), 2)
+		}
+	}
+	return found, nil
+}
+
+// reconstruct rebuilds the winning subset by rerunning its dynamic program
+// with a record of which transaction improved each sum.
+func (s *subsetSearch) reconstruct(best *subsetBest) []*monarch.Transaction {
+	table := newSubsetTable(best.sum)
+	table.start(s.candidates[best.first].cents)
+	taken := make([][]bool, best.last-best.first+1)
+	for i := range taken {
+		taken[i] = make([]bool, best.sum+1)
+	}
+	taken[0][s.candidates[best.first].cents] = true
+	for i := 1; i < len(taken); i++ {
+		table.addCandidate(s.candidates[best.first+i].cents, taken[i])
+	}
+
+	var subset []*monarch.Transaction
+	sum := best.sum
+	for i := len(taken) - 1; i >= 0 && sum > 0; i-- {
+		if taken[i][sum] {
+			subset = append(subset, s.candidates[best.first+i].tx)
+			sum -= s.candidates[best.first+i].cents
+		}
+	}
+	// Earliest charge first
+	for i, j := 0, len(subset)-1; i < j; i, j = i+1, j-1 {
+		subset[i], subset[j] = subset[j], subset[i]
+	}
+	return subset
+}
+
+// subsetState is the fewest transactions reaching a sum and how many subsets
+// of that size do (capped at 2)
+type subsetState struct {
+	count uint8
+	ways  uint8
+}
+
+// subsetTable is a 0/1 knapsack over sums in cents. The empty subset is not
+// a state: every subset includes the earliest transaction, added first.
+type subsetTable struct {
+	states []subsetState
+	reach  int // largest sum reachable so far
+}
+
+func newSubsetTable(limit int) *subsetTable {
+	return &subsetTable{states: make([]subsetState, limit+1)}
+}
+
+// start empties the table and adds the earliest transaction
+func (t *subsetTable) start(cents int) {
+	clear(t.states[:min(t.reach, len(t.states)-1)+1])
+	t.reach = 0
+	t.add(cents, subsetState{count: 1, ways: 1})
+}
+
+// add merges a way of reaching sum into its state and reports whether it is
+// the new fewest-transaction way
+func (t *subsetTable) add(sum int, state subsetState) bool {
+	if sum >= len(t.states) {
+		return false
+	}
+	t.reach = max(t.reach, sum)
+	current := &t.states[sum]
+	switch {
+	case current.count == 0 || state.count < current.count:
+		*current = state
+		return true
+	case state.count == current.count:
+		current.ways = min(current.ways+state.ways, 2)
+	}
+	return false
+}
+
+// reachesAny reports whether any of the sums is reachable
+func (t *subsetTable) reachesAny(sums []int) bool {
+	for _, sum := range sums {
+		if t.states[sum].count > 0 {
+			return true
 		}
 	}
-	return best, bestDifference
+	return false
+}
+
+// statesVisited is the number of sums addCandidate visits for a transaction
+// of the given cents, which bounds the search's work independently of the
+// machine it runs on.
+func (t *subsetTable) statesVisited(cents int) int {
+	return max(min(t.reach+cents, len(t.states)-1)-cents+1, 0)
+}
+
+// addCandidate extends every subset with a transaction of the given cents
+// and reports which sums it now reaches with the fewest transactions when
+// taken is not nil.
+func (t *subsetTable) addCandidate(cents int, taken []bool) {
+	for sum := min(t.reach+cents, len(t.states)-1); sum >= cents; sum-- {
+		if from := t.states[sum-cents]; from.count > 0 {
+			improved := t.add(sum, subsetState{count: from.count + 1, ways: from.ways})
+			if taken != nil {
+				taken[sum] = improved
+			}
+		}
+	}
+}
+
+func toCents(amount float64) int {
+	return int(math.Round(amount * 100))
+}
+
+// dayNumber counts calendar days, so charges on the same date span zero days
+func dayNumber(t time.Time) int {
+	year, month, day := t.Date()
+	return int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400)
 }
@@ -1,21 +1,193 @@
 package matcher
 
 import (
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+var testSubsetLimits = subsetLimits{maxCandidates: defaultSubsetMaxCandidates, maxStates: defaultSubsetMaxStates, timeLimit: defaultSubsetTimeLimit}
+
 func TestSubsetSummingTo_PrefersExactTotalOverSmallerToleratedSubset(t *testing.T) {
 	transactions := []*monarch.Transaction{
 		{ID: "near", Amount: -9.99},
 		{ID: "penny", Amount: -0.01},
 	}
 
-	matches := subsetSummingTo(transactions, 10.00, 0.01)
+	matches, err := subsetSummingTo(transactions, 10.00, 0.01, testSubsetLimits)
 
+	require.NoError(t, err)
 	require.Len(t, matches, 2)
 	assert.Equal(t, []string{"near", "penny"}, []string{matches[0].ID, matches[1].ID})
 }
+
+func TestSubsetSummingTo_PrefersTighterDateClustering(t *testing.T) {
+	transactions := []*monarch.Transaction{
+		makeTransaction("early-30", -30.00, day(2)),
+		makeTransaction("early-20", -20.00, day(5)),
+		makeTransaction("close-30", -30.00, day(10)),
+		makeTransaction("close-20", -20.00, day(11)),
+	}
+
+	matches, err := subsetSummingTo(transactions, 50.00, 0.01, testSubsetLimits)
+
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, []string{"close-30", "close-20"}, []string{matches[0].ID, matches[1].ID})
+}
+
+func TestSubsetSummingTo_PrefersFewerTransactionsOnTheSameDay(t *testing.T) {
+	transactions := []*monarch.Transaction{
+		makeTransaction("a", -10.00, day(10)),
+		makeTransaction("b", -15.00, day(10)),
+		makeTransaction("c", -25.00, day(10)),
+	}
+
+	matches, err := subsetSummingTo(transactions, 25.00, 0.01, testSubsetLimits)
+
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "c", matches[0].ID)
+}
+
+func TestSubsetSummingTo_ReportsAmbiguousSubsets(t *testing.T) {
+	transactions := []*monarch.Transaction{
+		makeTransaction("a", -10.00, day(10)),
+		makeTransaction("b", -15.00, day(10)),
+		makeTransaction("c", -12.00, day(10)),
+		makeTransaction("d", -13.00, day(10)),
+	}
+
+	matches, err := subsetSummingTo(transactions, 25.00, 0.01, testSubsetLimits)
+
+	assert.Nil(t, matches)
+	assert.ErrorIs(t, err, ErrAmbiguousSubset)
+}
+
+func TestSubsetSummingTo_FindsSubsetAmongManyCandidates(t *testing.T) {
+	// The old search only looked at the first 20 candidates
+	var transactions []*monarch.Transaction
+	for i := 0; i < 30; i++ {
+		transactions = append(transactions, makeTransaction(fmt.Sprintf("noise-%d", i), -float64(100+7*i)-0.13, day(1+i%10)))
+	}
+	transactions = append(transactions,
+		makeTransaction("shipment-1", -41.17, day(12)),
+		makeTransaction("shipment-2", -8.83, day(12)),
+	)
+
+	matches, err := subsetSummingTo(transactions, 50.00, 0.01, testSubsetLimits)
+
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.ElementsMatch(t, []string{"shipment-1", "shipment-2"}, []string{matches[0].ID, matches[1].ID})
+}
+
+func TestSubsetSummingTo_StateLimit(t *testing.T) {
+	transactions := []*monarch.Transaction{
+		makeTransaction("a", -10.00, day(10)),
+		makeTransaction("b", -15.00, day(11)),
+	}
+
+	_, err := subsetSummingTo(transactions, 25.00, 0.01, subsetLimits{maxCandidates: 40, maxStates: 1})
+
+	assert.ErrorIs(t, err, ErrSubsetSearchLimit)
+}
+
+func TestSubsetSummingTo_TimeLimit(t *testing.T) {
+	transactions := benchmarkSubsetCandidates(500)
+
+	start := time.Now()
+	_, err := subsetSummingTo(transactions, 487.31, 0.01, subsetLimits{maxCandidates: 500, maxStates: math.MaxInt, timeLimit: time.Millisecond})
+
+	assert.ErrorIs(t, err, ErrSubsetSearchLimit)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "the search stops soon after its time limit")
+}
+
+func TestFindSubsetByTotal_TimeLimitFromConfig(t *testing.T) {
+	m := NewMatcher(Config{AmountTolerance: 0.01, DateTolerance: 5, SubsetMaxCandidates: 500, SubsetMaxStates: math.MaxInt, SubsetTimeLimit: time.Nanosecond})
+	order := &mockOrder{id: "order", date: day(10), total: 487.31}
+
+	_, err := m.FindSubsetByTotal(order, benchmarkSubsetCandidates(500), map[string]bool{})
+
+	assert.ErrorIs(t, err, ErrSubsetSearchLimit)
+}
+
+func TestFindSubsetByTotal_KeepsCandidatesClosestToOrderDate(t *testing.T) {
+	m := NewMatcher(Config{AmountTolerance: 0.01, DateTolerance: 5, SubsetMaxCandidates: 2})
+	order := &mockOrder{id: "order", date: day(10), total: 25.00}
+	transactions := []*monarch.Transaction{
+		makeTransaction("far-10", -10.00, day(1)),
+		makeTransaction("far-15", -15.00, day(1)),
+		makeTransaction("near-12", -12.00, day(10)),
+		makeTransaction("near-13", -13.00, day(11)),
+	}
+
+	matches, err := m.FindSubsetByTotal(order, transactions, map[string]bool{})
+
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	assert.Equal(t, []string{"near-12", "near-13"}, []string{matches[0].ID, matches[1].ID})
+}
+
+func TestFindSubsetByTotal_NoCombination(t *testing.T) {
+	m := NewMatcher(DefaultConfig())
+	order := &mockOrder{id: "order", date: day(10), total: 25.00}
+
+	_, err := m.FindSubsetByTotal(order, []*monarch.Transaction{makeTransaction("a", -10.00, day(10))}, map[string]bool{})
+
+	assert.ErrorContains(t, err, "no combination of Monarch transactions sums to order total $25.00")
+}
+
+func benchmarkSubsetCandidates(count int) []*monarch.Transaction {
+	transactions := make([]*monarch.Transaction, 0, count)
+	for i := 0; i < count; i++ {
+		// Deterministic spread of amounts between $5 and $200
+		amount := 5 + float64((i*7919)%19500)/100
+		transactions = append(transactions, makeTransaction(fmt.Sprintf("tx-%d", i), -amount, day(1+i%20)))
+	}
+	return transactions
+}
+
+func BenchmarkFindSubsetByTotal(b *testing.B) {
+	for _, tc := range []struct {
+		count   int
+		wantErr error
+	}{
+		{count: 10},
+		{count: 40},
+		{count: 100},
+		{count: 500, wantErr: ErrAmbiguousSubset},
+	} {
+		b.Run(fmt.Sprintf("candidates=%d", tc.count), func(b *testing.B) {
+			m := NewMatcher(Config{AmountTolerance: 0.01, DateTolerance: 5, SubsetMaxCandidates: tc.count, SubsetMaxStates: math.MaxInt, SubsetTimeLimit: time.Hour})
+			order := &mockOrder{id: "order", date: day(10), total: 487.31}
+			transactions := benchmarkSubsetCandidates(tc.count)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := m.FindSubsetByTotal(order, transactions, map[string]bool{})
+				if tc.wantErr == nil {
+					require.NoError(b, err)
+				} else {
+					require.ErrorIs(b, err, tc.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFindSubsetByTotal_DefaultLimits(b *testing.B) {
+	m := NewMatcher(DefaultConfig())
+	order := &mockOrder{id: "order", date: day(10), total: 1234.56}
+	transactions := benchmarkSubsetCandidates(1000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := m.FindSubsetByTotal(order, transactions, map[string]bool{})
+		// The search completes within the default state limit
+		require.ErrorIs(b, err, ErrAmbiguousSubset)
+	}
+}
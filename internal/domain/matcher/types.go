@@ -1,6 +1,8 @@
 package matcher
 
 import (
+	"time"
+
 	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
 )
 
@@ -8,13 +10,21 @@ import (
 type Config struct {
 	AmountTolerance float64 // Default: 0.01 (1 cent)
 	DateTolerance   int     // Days tolerance (default: 5)
+
+	// Limits of FindSubsetByTotal; zero means the default
+	SubsetMaxCandidates int           // Transactions searched, closest to the order date first (default: 40)
+	SubsetMaxStates     int           // Sum states (in cents) visited before the search gives up (default: 100,000,000)
+	SubsetTimeLimit     time.Duration // Time the search may run before it gives up (default: 1s)
 }
 
 // DefaultConfig returns sensible defaults
 func DefaultConfig() Config {
 	return Config{
-		AmountTolerance: 0.01,
-		DateTolerance:   5,
+		AmountTolerance:     0.01,
+		DateTolerance:       5,
+		SubsetMaxCandidates: defaultSubsetMaxCandidates,
+		SubsetMaxStates:     defaultSubsetMaxStates,
+		SubsetTimeLimit:     defaultSubsetTimeLimit,
 	}
 }
 
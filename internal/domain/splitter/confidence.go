@@ -0,0 +1,157 @@
+package splitter
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+)
+
+// ErrUncategorizedItems is returned when the categorizer has no category for
+// some of an order's items and no fallback category is available. The order
+// is left alone rather than applied with an empty category.
+var ErrUncategorizedItems = errors.New("no category for items and no fallback category")
+
+// ConfidencePolicy controls what happens to items the categorizer is unsure
+// about. The zero value disables the check.
+type ConfidencePolicy struct {
+	// MinConfidence is the lowest categorization confidence accepted as-is
+	// (0 = disabled).
+	MinConfidence float64
+
+	// FallbackCategory is a Monarch category ID or name that low-confidence
+	// items, and items the categorizer has no category for, are moved to.
+	// When empty, or when it doesn't match any category, low-confidence items
+	// keep the LLM's suggestion and the order is flagged for review; an item
+	// without a category fails with ErrUncategorizedItems.
+	FallbackCategory string
+}
+
+// LowConfidenceItem is one item that fell below the policy threshold.
+type LowConfidenceItem struct {
+	Original categorizer.ItemCategorization // What the categorizer suggested
+	Fallback *categorizer.Category          // Category applied instead (nil = none)
+}
+
+// ConfidenceReport describes the low-confidence items of a single order.
+type ConfidenceReport struct {
+	Items []LowConfidenceItem
+
+	// NeedsReview is true when at least one item kept a low-confidence
+	// category because no fallback category was available.
+	NeedsReview bool
+}
+
+// SetConfidencePolicy configures how low-confidence categorizations are
+// handled by subsequent CreateSplits calls.
+func (s *Splitter) SetConfidencePolicy(policy ConfidencePolicy) {
+	s.policy = policy
+	// Categorizations cached under the previous policy are no longer valid
+	s.lastResult = nil
+	s.lastOrderID = ""
+	s.lastReport = nil
+}
+
+// ConfidenceReport returns the low-confidence report for the order most
+// recently categorized by CreateSplits, or nil if every item cleared the
+// threshold (or orderID isn't that order).
+func (s *Splitter) ConfidenceReport(orderID string) *ConfidenceReport {
+	if s.lastOrderID != orderID {
+		return nil
+	}
+	return s.lastReport
+}
+
+// Categorizations returns the (policy-adjusted) categorizations of the order
+// most recently categorized by CreateSplits, one per item, or nil if orderID
+// isn't that order.
+func (s *Splitter) Categorizations(orderID string, itemCount int) []categorizer.ItemCategorization {
+	if s.lastOrderID != orderID || s.lastResult == nil {
+		return nil
+	}
+	categorizations := s.lastResult.Categorizations
+	if len(categorizations) > itemCount {
+		categorizations = categorizations[:itemCount]
+	}
+	return categorizations
+}
+
+// applyConfidencePolicy returns a copy of result with low-confidence and
+// uncategorized items moved to the fallback category, plus a report of what
+// was changed. Items without a category are checked even when the confidence
+// check is disabled, and fail with ErrUncategorizedItems when there is no
+// fallback. The input result is not modified. Only the first itemCount
+// entries are considered, matching how the rest of the splitter maps results
+// to items.
+func (s *Splitter) applyConfidencePolicy(
+	result *categorizer.CategorizationResult,
+	itemCount int,
+	categories []categorizer.Category,
+) (*categorizer.CategorizationResult, *ConfidenceReport, error) {
+	if result == nil {
+		return result, nil, nil
+	}
+
+	fallback := ResolveCategory(s.policy.FallbackCategory, categories)
+
+	adjusted := &categorizer.CategorizationResult{
+		Categorizations: make([]categorizer.ItemCategorization, len(result.Categorizations)),
+	}
+	copy(adjusted.Categorizations, result.Categorizations)
+
+	report := &ConfidenceReport{}
+	var uncategorized []string
+	for i := range adjusted.Categorizations {
+		if i >= itemCount {
+			break
+		}
+		cat := &adjusted.Categorizations[i]
+		if cat.CategoryID != "" && (s.policy.MinConfidence <= 0 || cat.Confidence >= s.policy.MinConfidence) {
+			continue
+		}
+
+		item := LowConfidenceItem{Original: *cat}
+		switch {
+		case fallback != nil:
+			item.Fallback = fallback
+			cat.CategoryID = fallback.ID
+			cat.CategoryName = fallback.Name
+		case cat.CategoryID == "":
+			uncategorized = append(uncategorized, cat.ItemName)
+		default:
+			report.NeedsReview = true
+		}
+		report.Items = append(report.Items, item)
+	}
+
+	if len(uncategorized) > 0 {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUncategorizedItems, strings.Join(uncategorized, ", "))
+	}
+	if len(report.Items) == 0 {
+		return result, nil, nil
+	}
+	return adjusted, report, nil
+}
+
+// ResolveCategory finds a category by ID, falling back to a case-insensitive
+// name match. Returns nil if ref is empty or matches nothing.
+func ResolveCategory(ref string, categories []categorizer.Category) *categorizer.Category {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil
+	}
+	for i := range categories {
+		if categories[i].ID == ref {
+			match := categories[i]
+			return &match
+		}
+	}
+	for i := range categories {
+		if strings.EqualFold(categories[i].Name, ref) {
+			match := categories[i]
+			return &match
+		}
+	}
+	return nil
+}
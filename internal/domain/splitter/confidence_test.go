@@ -0,0 +1,178 @@
+package splitter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eshaffer321/itemize/internal/adapters/providers"
+	"github.com/eshaffer321/itemize/internal/domain/categorizer"
+	"github.com/eshaffer321/monarch-go/v2/pkg/monarch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func confidenceTestOrder() *mockOrder {
+	return &mockOrder{
+		id:       "ORDER-LC",
+		date:     time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		total:    20.00,
+		subtotal: 20.00,
+		items: []providers.OrderItem{
+			&mockOrderItem{name: "Milk", price: 5.00, quantity: 1},
+			&mockOrderItem{name: "Mystery Gadget", price: 15.00, quantity: 1},
+		},
+	}
+}
+
+func confidenceTestCategorizer() *mockCategorizer {
+	return &mockCategorizer{
+		result: &categorizer.CategorizationResult{
+			Categorizations: []categorizer.ItemCategorization{
+				{ItemName: "Milk", CategoryID: "cat_groceries", CategoryName: "Groceries", Confidence: 0.95},
+				{ItemName: "Mystery Gadget", CategoryID: "cat_electronics", CategoryName: "Electronics", Confidence: 0.40},
+			},
+		},
+	}
+}
+
+var confidenceTestCategories = []categorizer.Category{
+	{ID: "cat_groceries", Name: "Groceries"},
+	{ID: "cat_electronics", Name: "Electronics"},
+	{ID: "cat_uncategorized", Name: "Uncategorized"},
+}
+
+func TestSplitter_ConfidencePolicy_Disabled(t *testing.T) {
+	s := NewSplitter(confidenceTestCategorizer())
+	order := confidenceTestOrder()
+
+	splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+	require.NoError(t, err)
+
+	assert.Len(t, splits, 2)
+	assert.Nil(t, s.ConfidenceReport(order.GetID()))
+}
+
+func TestSplitter_ConfidencePolicy_FallbackCategory(t *testing.T) {
+	cat := confidenceTestCategorizer()
+	s := NewSplitter(cat)
+	s.SetConfidencePolicy(ConfidencePolicy{MinConfidence: 0.7, FallbackCategory: "uncategorized"})
+	order := confidenceTestOrder()
+
+	splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+	require.NoError(t, err)
+	require.Len(t, splits, 2)
+
+	splitCategories := map[string]float64{}
+	for _, split := range splits {
+		splitCategories[split.CategoryID] = split.Amount
+	}
+	assert.Equal(t, -15.00, splitCategories["cat_uncategorized"])
+	assert.Equal(t, -5.00, splitCategories["cat_groceries"])
+
+	report := s.ConfidenceReport(order.GetID())
+	require.NotNil(t, report)
+	assert.False(t, report.NeedsReview)
+	require.Len(t, report.Items, 1)
+	assert.Equal(t, "Mystery Gadget", report.Items[0].Original.ItemName)
+	assert.Equal(t, "cat_electronics", report.Items[0].Original.CategoryID)
+	require.NotNil(t, report.Items[0].Fallback)
+	assert.Equal(t, "cat_uncategorized", report.Items[0].Fallback.ID)
+
+	// The categorizer's result must not be mutated
+	assert.Equal(t, "cat_electronics", cat.result.Categorizations[1].CategoryID)
+}
+
+func TestSplitter_ConfidencePolicy_FlagsForReviewWithoutFallback(t *testing.T) {
+	s := NewSplitter(confidenceTestCategorizer())
+	s.SetConfidencePolicy(ConfidencePolicy{MinConfidence: 0.7, FallbackCategory: "Does Not Exist"})
+	order := confidenceTestOrder()
+
+	splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+	require.NoError(t, err)
+	assert.Len(t, splits, 2, "LLM categories are kept")
+
+	report := s.ConfidenceReport(order.GetID())
+	require.NotNil(t, report)
+	assert.True(t, report.NeedsReview)
+	require.Len(t, report.Items, 1)
+	assert.Nil(t, report.Items[0].Fallback)
+
+	assert.Nil(t, s.ConfidenceReport("other-order"))
+}
+
+func TestSplitter_ConfidencePolicy_FallbackCollapsesToSingleCategory(t *testing.T) {
+	s := NewSplitter(&mockCategorizer{
+		result: &categorizer.CategorizationResult{
+			Categorizations: []categorizer.ItemCategorization{
+				{ItemName: "Milk", CategoryID: "cat_groceries", CategoryName: "Groceries", Confidence: 0.5},
+				{ItemName: "Mystery Gadget", CategoryID: "cat_electronics", CategoryName: "Electronics", Confidence: 0.4},
+			},
+		},
+	})
+	s.SetConfidencePolicy(ConfidencePolicy{MinConfidence: 0.7, FallbackCategory: "cat_uncategorized"})
+	order := confidenceTestOrder()
+
+	splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+	require.NoError(t, err)
+	assert.Nil(t, splits, "all items moved to the fallback category")
+
+	categoryID, notes, err := s.GetSingleCategoryInfo(context.Background(), order, confidenceTestCategories)
+	require.NoError(t, err)
+	assert.Equal(t, "cat_uncategorized", categoryID)
+	assert.Contains(t, notes, "Uncategorized:")
+}
+
+func TestSplitter_HistoryOnlyMixedKnownAndUnknownItems(t *testing.T) {
+	history := categorizer.NewHistoryCategorizer([]categorizer.LabeledItem{
+		{Name: "Milk", CategoryID: "cat_groceries", CategoryName: "Groceries"},
+	})
+	order := &mockOrder{
+		id:       "ORDER-HISTORY",
+		total:    20.00,
+		subtotal: 20.00,
+		items: []providers.OrderItem{
+			&mockOrderItem{name: "Milk", price: 5.00, quantity: 1},
+			&mockOrderItem{name: "Zorbo Widget", price: 15.00, quantity: 1},
+		},
+	}
+
+	t.Run("unknown items take the fallback category", func(t *testing.T) {
+		s := NewSplitter(history)
+		s.SetConfidencePolicy(ConfidencePolicy{FallbackCategory: "Uncategorized"})
+
+		splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+		require.NoError(t, err)
+		require.Len(t, splits, 2)
+		splitCategories := map[string]float64{}
+		for _, split := range splits {
+			splitCategories[split.CategoryID] = split.Amount
+		}
+		assert.Equal(t, map[string]float64{"cat_groceries": -5.00, "cat_uncategorized": -15.00}, splitCategories)
+
+		report := s.ConfidenceReport(order.GetID())
+		require.NotNil(t, report)
+		require.Len(t, report.Items, 1)
+		assert.Equal(t, "Zorbo Widget", report.Items[0].Original.ItemName)
+		assert.False(t, report.NeedsReview)
+	})
+
+	t.Run("without a fallback the order is not categorized", func(t *testing.T) {
+		s := NewSplitter(history)
+
+		splits, err := s.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -20}, confidenceTestCategories, nil)
+		require.ErrorIs(t, err, ErrUncategorizedItems)
+		assert.Contains(t, err.Error(), "Zorbo Widget")
+		assert.Nil(t, splits)
+
+		_, _, err = s.GetSingleCategoryInfo(context.Background(), order, confidenceTestCategories)
+		require.ErrorIs(t, err, ErrUncategorizedItems)
+	})
+}
+
+func TestResolveCategory(t *testing.T) {
+	assert.Equal(t, "cat_groceries", ResolveCategory("cat_groceries", confidenceTestCategories).ID)
+	assert.Equal(t, "cat_groceries", ResolveCategory(" groceries ", confidenceTestCategories).ID)
+	assert.Nil(t, ResolveCategory("", confidenceTestCategories))
+	assert.Nil(t, ResolveCategory("Travel", confidenceTestCategories))
+}
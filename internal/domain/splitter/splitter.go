@@ -19,8 +19,10 @@ type Categorizer interface {
 // Splitter creates transaction splits from categorized orders
 type Splitter struct {
 	categorizer Categorizer
+	policy      ConfidencePolicy
 	lastResult  *categorizer.CategorizationResult // Cache last categorization
 	lastOrderID string                            // Track which order was cached
+	lastReport  *ConfidenceReport                 // Low-confidence items of the cached order
 }
 
 // NewSplitter creates a new splitter
@@ -46,32 +48,11 @@ func (s *Splitter) CreateSplits(
 	categories []categorizer.Category,
 	monarchCategories []*monarch.TransactionCategory,
 ) ([]*monarch.TransactionSplit, error) {
-	// Convert items for categorization
-	items := make([]categorizer.Item, len(order.GetItems()))
-	for i, orderItem := range order.GetItems() {
-		items[i] = categorizer.Item{
-			Name:     orderItem.GetName(),
-			Price:    orderItem.GetPrice(),
-			Quantity: int(orderItem.GetQuantity()),
-		}
-	}
-
-	// Get categories from AI (or use cache if same order)
-	var result *categorizer.CategorizationResult
-	if s.lastOrderID == order.GetID() && s.lastResult != nil {
-		// Use cached result
-		result = s.lastResult
-	} else {
-		// Fetch new categorization
-		var err error
-		result, err = s.categorizer.CategorizeItems(ctx, items, categories)
-		if err != nil {
-			return nil, err
-		}
-		// Cache the result
-		s.lastResult = result
-		s.lastOrderID = order.GetID()
+	result, err := s.categorizeOrder(ctx, order, categories)
+	if err != nil {
+		return nil, err
 	}
+	items := order.GetItems()
 
 	// Group items by category to detect single vs multi-category.
 	// Cap at len(items): if the LLM returned extra entries (hallucination), only
@@ -93,6 +74,128 @@ func (s *Splitter) CreateSplits(
 	return s.createMultiCategorySplits(order, transaction, result)
 }
 
+// CategorizeOrder categorizes an order's items, or reuses the cached
+// categorization of the same order, and returns one (policy-adjusted)
+// categorization per item. The result is cached as by CreateSplits, so
+// Categorizations and ConfidenceReport describe this order afterwards.
+func (s *Splitter) CategorizeOrder(
+	ctx context.Context,
+	order providers.Order,
+	categories []categorizer.Category,
+) ([]categorizer.ItemCategorization, error) {
+	result, err := s.categorizeOrder(ctx, order, categories)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pad a short LLM response so every item has an entry
+	items := order.GetItems()
+	categorizations := make([]categorizer.ItemCategorization, len(items))
+	for i, item := range items {
+		if i < len(result.Categorizations) {
+			categorizations[i] = result.Categorizations[i]
+		} else {
+			categorizations[i] = categorizer.ItemCategorization{ItemName: item.GetName()}
+		}
+	}
+	return categorizations, nil
+}
+
+// SplitWithCategorizations creates splits for an order whose items were
+// already categorized (one categorization per item), without calling the
+// categorizer or replacing the cached categorization. This is how a single
+// bank charge of a larger order is split with the categories assigned to the
+// whole order. Like CreateSplits it returns nil splits when every item shares
+// a category; categoryID and notes then describe that category, as
+// GetSingleCategoryInfo would.
+func (s *Splitter) SplitWithCategorizations(
+	order providers.Order,
+	transaction *monarch.Transaction,
+	categorizations []categorizer.ItemCategorization,
+) (splits []*monarch.TransactionSplit, categoryID string, notes string, err error) {
+	items := order.GetItems()
+	if len(categorizations) < len(items) {
+		return nil, "", "", fmt.Errorf("expected %d categorizations, got %d", len(items), len(categorizations))
+	}
+	result := &categorizer.CategorizationResult{Categorizations: categorizations[:len(items)]}
+
+	categoryGroups := make(map[string]bool)
+	for _, cat := range result.Categorizations {
+		categoryGroups[cat.CategoryID] = true
+	}
+	if len(categoryGroups) > 1 {
+		splits, err = s.createMultiCategorySplits(order, transaction, result)
+		return splits, "", "", err
+	}
+
+	categoryID, notes, err = singleCategoryInfo(order, result)
+	return nil, categoryID, notes, err
+}
+
+// categorizeOrder returns the categorization of order, from the cache when it
+// was the order most recently categorized.
+func (s *Splitter) categorizeOrder(
+	ctx context.Context,
+	order providers.Order,
+	categories []categorizer.Category,
+) (*categorizer.CategorizationResult, error) {
+	if s.lastOrderID == order.GetID() && s.lastResult != nil {
+		return s.lastResult, nil
+	}
+
+	// Convert items for categorization
+	items := make([]categorizer.Item, len(order.GetItems()))
+	for i, orderItem := range order.GetItems() {
+		items[i] = categorizer.Item{
+			Name:     orderItem.GetName(),
+			Price:    orderItem.GetPrice(),
+			Quantity: int(orderItem.GetQuantity()),
+		}
+	}
+
+	result, err := s.categorize(ctx, order.GetItems(), items, categories)
+	if err != nil {
+		return nil, err
+	}
+	result, report, err := s.applyConfidencePolicy(result, len(items), categories)
+	if err != nil {
+		return nil, err
+	}
+	// Cache the result
+	s.lastResult = result
+	s.lastOrderID = order.GetID()
+	s.lastReport = report
+	return result, nil
+}
+
+// categorize categorizes an order's items. Items with a pinned category that
+// resolves against categories are assigned it with full confidence; only the
+// rest are sent to the categorizer, which is skipped when every item is pinned.
+func (s *Splitter) categorize(
+	ctx context.Context,
+	orderItems []providers.OrderItem,
+	items []categorizer.Item,
+	categories []categorizer.Category,
+) (*categorizer.CategorizationResult, error) {
+	return categorizer.CategorizeInStages(ctx, items,
+		func(i int, item categorizer.Item) (categorizer.ItemCategorization, bool) {
+			category := ResolveCategory(providers.PinnedCategory(orderItems[i]), categories)
+			if category == nil {
+				return categorizer.ItemCategorization{}, false
+			}
+			return categorizer.ItemCategorization{
+				ItemName:     item.Name,
+				CategoryID:   category.ID,
+				CategoryName: category.Name,
+				Confidence:   1,
+				Source:       categorizer.SourcePinned,
+			}, true
+		},
+		func(ctx context.Context, remaining []categorizer.Item) (*categorizer.CategorizationResult, error) {
+			return s.categorizer.CategorizeItems(ctx, remaining, categories)
+		})
+}
+
 // createMultiCategorySplits creates splits for orders with multiple categories
 func (s *Splitter) createMultiCategorySplits(
 	order providers.Order,
@@ -255,12 +358,22 @@ func (s *Splitter) GetSingleCategoryInfo(
 				Quantity: int(orderItem.GetQuantity()),
 			}
 		}
-		result, err = s.categorizer.CategorizeItems(ctx, items, categories)
+		result, err = s.categorize(ctx, order.GetItems(), items, categories)
+		if err != nil {
+			return "", "", err
+		}
+		result, _, err = s.applyConfidencePolicy(result, len(items), categories)
 		if err != nil {
 			return "", "", err
 		}
 	}
 
+	return singleCategoryInfo(order, result)
+}
+
+// singleCategoryInfo returns the category of a single-category order and notes
+// listing its items with their prices.
+func singleCategoryInfo(order providers.Order, result *categorizer.CategorizationResult) (categoryID string, notes string, err error) {
 	if len(result.Categorizations) == 0 {
 		return "", "", fmt.Errorf("no categorizations returned")
 	}
@@ -803,3 +803,177 @@ func TestSplitter_RoundingToTwoDecimals(t *testing.T) {
 	assert.Equal(t, transaction.Amount, totalSplits,
 		"Rounded splits must sum exactly to transaction amount (Monarch will reject otherwise)")
 }
+
+// pinnedOrderItem is an order item with a category pinned by its provider
+type pinnedOrderItem struct {
+	mockOrderItem
+	pinned string
+}
+
+func (m *pinnedOrderItem) PinnedCategory() string { return m.pinned }
+
+// recordingCategorizer records the items it was asked to categorize
+type recordingCategorizer struct {
+	result *categorizer.CategorizationResult
+	calls  [][]categorizer.Item
+}
+
+func (r *recordingCategorizer) CategorizeItems(ctx context.Context, items []categorizer.Item, categories []categorizer.Category) (*categorizer.CategorizationResult, error) {
+	r.calls = append(r.calls, items)
+	return r.result, nil
+}
+
+// TestSplitter_PinnedCategory tests that items with a pinned category skip the categorizer
+func TestSplitter_PinnedCategory(t *testing.T) {
+	categories := []categorizer.Category{
+		{ID: "cat_gas", Name: "Gas"},
+		{ID: "cat_groceries", Name: "Groceries"},
+	}
+	monarchCategories := []*monarch.TransactionCategory{
+		{ID: "cat_gas", Name: "Gas"},
+		{ID: "cat_groceries", Name: "Groceries"},
+	}
+
+	t.Run("all items pinned skips the categorizer", func(t *testing.T) {
+		order := &mockOrder{
+			id:       "GAS1",
+			total:    52.37,
+			subtotal: 52.37,
+			items: []providers.OrderItem{
+				&pinnedOrderItem{mockOrderItem: mockOrderItem{name: "Gas (Regular)", price: 52.37, quantity: 13.432}, pinned: "Gas"},
+			},
+		}
+		cat := &recordingCategorizer{}
+		splitter := NewSplitter(cat)
+
+		splits, err := splitter.CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -52.37}, categories, monarchCategories)
+		require.NoError(t, err)
+		assert.Nil(t, splits)
+
+		categoryID, notes, err := splitter.GetSingleCategoryInfo(context.Background(), order, categories)
+		require.NoError(t, err)
+		assert.Equal(t, "cat_gas", categoryID)
+		assert.Contains(t, notes, "Gas:")
+		assert.Empty(t, cat.calls, "categorizer should not be called")
+		assert.Equal(t, categorizer.SourcePinned, splitter.Categorizations("GAS1", 1)[0].Source)
+	})
+
+	t.Run("only unpinned items reach the categorizer", func(t *testing.T) {
+		order := &mockOrder{
+			id:       "MIXED1",
+			total:    60.00,
+			subtotal: 60.00,
+			items: []providers.OrderItem{
+				&pinnedOrderItem{mockOrderItem: mockOrderItem{name: "Gas (Premium)", price: 50.00, quantity: 11.2}, pinned: "cat_gas"},
+				&mockOrderItem{name: "Milk", price: 10.00, quantity: 1},
+			},
+		}
+		cat := &recordingCategorizer{result: &categorizer.CategorizationResult{
+			Categorizations: []categorizer.ItemCategorization{
+				{ItemName: "Milk", CategoryID: "cat_groceries", CategoryName: "Groceries", Confidence: 0.9},
+			},
+		}}
+
+		splits, err := NewSplitter(cat).CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -60.00}, categories, monarchCategories)
+		require.NoError(t, err)
+		require.Len(t, splits, 2)
+		require.Len(t, cat.calls, 1)
+		require.Len(t, cat.calls[0], 1)
+		assert.Equal(t, "Milk", cat.calls[0][0].Name)
+	})
+
+	t.Run("unknown pinned category falls back to the categorizer", func(t *testing.T) {
+		order := &mockOrder{
+			id:       "GAS2",
+			total:    40.00,
+			subtotal: 40.00,
+			items: []providers.OrderItem{
+				&pinnedOrderItem{mockOrderItem: mockOrderItem{name: "Gas (Regular)", price: 40.00, quantity: 10}, pinned: "Fuel"},
+			},
+		}
+		cat := &recordingCategorizer{result: &categorizer.CategorizationResult{
+			Categorizations: []categorizer.ItemCategorization{
+				{ItemName: "Gas (Regular)", CategoryID: "cat_gas", CategoryName: "Gas", Confidence: 1},
+			},
+		}}
+
+		_, err := NewSplitter(cat).CreateSplits(context.Background(), order, &monarch.Transaction{ID: "TXN", Amount: -40.00}, categories, monarchCategories)
+		require.NoError(t, err)
+		assert.Len(t, cat.calls, 1)
+	})
+}
+
+// TestSplitter_SplitWithCategorizations tests splitting one charge of an order
+// with the categories assigned to the whole order
+func TestSplitter_SplitWithCategorizations(t *testing.T) {
+	categories := []categorizer.Category{
+		{ID: "cat_groceries", Name: "Groceries"},
+		{ID: "cat_household", Name: "Household"},
+	}
+	order := &mockOrder{
+		id:       "ORDER1",
+		total:    30.00,
+		subtotal: 30.00,
+		items: []providers.OrderItem{
+			&mockOrderItem{name: "Milk", price: 5.00, quantity: 1},
+			&mockOrderItem{name: "Paper Towels", price: 15.00, quantity: 1},
+			&mockOrderItem{name: "Bread", price: 10.00, quantity: 1},
+		},
+	}
+	cat := &recordingCategorizer{result: &categorizer.CategorizationResult{
+		Categorizations: []categorizer.ItemCategorization{
+			{ItemName: "Milk", CategoryID: "cat_groceries", CategoryName: "Groceries", Confidence: 0.9},
+			{ItemName: "Paper Towels", CategoryID: "cat_household", CategoryName: "Household", Confidence: 0.9},
+		},
+	}}
+	splitter := NewSplitter(cat)
+	splitter.SetConfidencePolicy(ConfidencePolicy{FallbackCategory: "Groceries"})
+
+	categorizations, err := splitter.CategorizeOrder(context.Background(), order, categories)
+	require.NoError(t, err)
+	require.Len(t, categorizations, 3, "short responses are padded to one entry per item")
+	assert.Equal(t, "Bread", categorizations[2].ItemName)
+	assert.Equal(t, "cat_groceries", categorizations[2].CategoryID, "an item left without an answer takes the fallback category")
+
+	t.Run("charge with one category returns its notes", func(t *testing.T) {
+		charge := &mockOrder{
+			id:       "ORDER1#1",
+			subtotal: 5.40,
+			items:    []providers.OrderItem{&mockOrderItem{name: "Milk", price: 5.40, quantity: 1}},
+		}
+
+		splits, categoryID, notes, err := splitter.SplitWithCategorizations(charge, &monarch.Transaction{ID: "TXN1", Amount: -5.40}, categorizations[:1])
+		require.NoError(t, err)
+		assert.Nil(t, splits)
+		assert.Equal(t, "cat_groceries", categoryID)
+		assert.Equal(t, "Groceries:\n- Milk $5.40", notes)
+	})
+
+	t.Run("charge with several categories is split", func(t *testing.T) {
+		charge := &mockOrder{
+			id:       "ORDER1#2",
+			subtotal: 16.20,
+			items: []providers.OrderItem{
+				&mockOrderItem{name: "Milk", price: 5.40, quantity: 1},
+				&mockOrderItem{name: "Paper Towels", price: 10.80, quantity: 1},
+			},
+		}
+
+		splits, _, _, err := splitter.SplitWithCategorizations(charge, &monarch.Transaction{ID: "TXN2", Amount: -16.20}, categorizations[:2])
+		require.NoError(t, err)
+		require.Len(t, splits, 2)
+		total := 0.0
+		for _, split := range splits {
+			total += split.Amount
+		}
+		assert.InDelta(t, -16.20, total, 0.001)
+	})
+
+	t.Run("missing categorizations are an error", func(t *testing.T) {
+		_, _, _, err := splitter.SplitWithCategorizations(order, &monarch.Transaction{ID: "TXN3", Amount: -30.00}, categorizations[:1])
+		assert.Error(t, err)
+	})
+
+	assert.Len(t, cat.calls, 1, "the order is categorized once")
+	assert.Len(t, splitter.Categorizations("ORDER1", 3), 3, "the order's categorization stays cached, one per item")
+}
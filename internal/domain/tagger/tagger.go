@@ -0,0 +1,163 @@
+// Package tagger assigns Monarch tags (HSA/FSA, reimbursable, gift, ...) to
+// order items. Tags cut across categories: an item keeps its category and
+// may carry any number of tags.
+package tagger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rule defines one tag and how items earn it. An item gets the tag when its
+// name contains any Match substring, matches Pattern, or was categorized into
+// one of Categories. Description, when set, offers the tag to the LLM so it
+// can also assign it to items no rule matches.
+type Rule struct {
+	Tag         string
+	Description string
+	Match       []string // Case-insensitive substrings of the item name
+	Pattern     string   // Regular expression over the item name (case-insensitive)
+	Categories  []string // Monarch category IDs or names
+}
+
+// Item is a categorized item to tag.
+type Item struct {
+	Name         string
+	CategoryID   string
+	CategoryName string
+
+	// LLMTags are the tags the LLM suggested; only configured tags are kept.
+	LLMTags []string
+}
+
+// Tagger applies a fixed set of rules.
+type Tagger struct {
+	rules []compiledRule
+	names map[string]string // lowercase tag → configured spelling
+}
+
+type compiledRule struct {
+	Rule
+	match      []string
+	pattern    *regexp.Regexp
+	categories map[string]bool
+}
+
+// New validates and compiles rules. Returns nil, nil when there are none so
+// callers can treat a nil Tagger as "tagging disabled".
+func New(rules []Rule) (*Tagger, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	t := &Tagger{names: make(map[string]string, len(rules))}
+	for i, rule := range rules {
+		rule.Tag = strings.TrimSpace(rule.Tag)
+		if rule.Tag == "" {
+			return nil, fmt.Errorf("tag rule %d has no tag name", i+1)
+		}
+		key := strings.ToLower(rule.Tag)
+		if _, dup := t.names[key]; dup {
+			return nil, fmt.Errorf("tag %q is defined more than once", rule.Tag)
+		}
+		t.names[key] = rule.Tag
+
+		compiled := compiledRule{Rule: rule, categories: make(map[string]bool, len(rule.Categories))}
+		for _, substring := range rule.Match {
+			if substring = strings.ToLower(strings.TrimSpace(substring)); substring != "" {
+				compiled.match = append(compiled.match, substring)
+			}
+		}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile("(?i)" + rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tag %q: invalid pattern: %w", rule.Tag, err)
+			}
+			compiled.pattern = pattern
+		}
+		for _, category := range rule.Categories {
+			if category = strings.ToLower(strings.TrimSpace(category)); category != "" {
+				compiled.categories[category] = true
+			}
+		}
+		t.rules = append(t.rules, compiled)
+	}
+	return t, nil
+}
+
+// Rules returns the configured rules in order.
+func (t *Tagger) Rules() []Rule {
+	if t == nil {
+		return nil
+	}
+	rules := make([]Rule, len(t.rules))
+	for i, rule := range t.rules {
+		rules[i] = rule.Rule
+	}
+	return rules
+}
+
+// Tags returns the tags for an item in rule order. Rule matches and LLM
+// suggestions are combined; LLM suggestions that aren't configured tags are
+// dropped.
+func (t *Tagger) Tags(item Item) []string {
+	if t == nil {
+		return nil
+	}
+
+	suggested := make(map[string]bool, len(item.LLMTags))
+	for _, tag := range item.LLMTags {
+		suggested[strings.ToLower(strings.TrimSpace(tag))] = true
+	}
+
+	var tags []string
+	for _, rule := range t.rules {
+		if suggested[strings.ToLower(rule.Tag)] || rule.matches(item) {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	return tags
+}
+
+func (r *compiledRule) matches(item Item) bool {
+	name := strings.ToLower(item.Name)
+	for _, substring := range r.match {
+		if strings.Contains(name, substring) {
+			return true
+		}
+	}
+	if r.pattern != nil && r.pattern.MatchString(item.Name) {
+		return true
+	}
+	return r.categories[strings.ToLower(item.CategoryID)] ||
+		(item.CategoryName != "" && r.categories[strings.ToLower(item.CategoryName)])
+}
+
+// Merge returns the union of tag lists, keeping first-seen order and
+// comparing case-insensitively.
+func Merge(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, tag := range list {
+			key := strings.ToLower(tag)
+			if tag == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, tag)
+		}
+	}
+	return merged
+}
+
+// Has reports whether tags contains tag, case-insensitively.
+func Has(tags []string, tag string) bool {
+	for _, candidate := range tags {
+		if strings.EqualFold(candidate, tag) {
+			return true
+		}
+	}
+	return false
+}
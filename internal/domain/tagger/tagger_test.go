@@ -0,0 +1,65 @@
+package tagger
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRules() []Rule {
+	return []Rule{
+		{Tag: "HSA/FSA", Description: "Eligible medical expenses", Match: []string{"bandage", "ibuprofen"}, Categories: []string{"Medical"}},
+		{Tag: "Reimbursable", Pattern: `\b(toner|printer paper)\b`},
+		{Tag: "Gift", Description: "Bought as a gift"},
+	}
+}
+
+func TestNew_NoRulesDisablesTagging(t *testing.T) {
+	tagger, err := New(nil)
+	require.NoError(t, err)
+	assert.Nil(t, tagger)
+	assert.Nil(t, tagger.Tags(Item{Name: "Bandages"}), "nil tagger is safe to use")
+}
+
+func TestNew_RejectsInvalidRules(t *testing.T) {
+	_, err := New([]Rule{{Tag: " "}})
+	assert.Error(t, err)
+
+	_, err = New([]Rule{{Tag: "Gift"}, {Tag: "gift"}})
+	assert.ErrorContains(t, err, "more than once")
+
+	_, err = New([]Rule{{Tag: "Bad", Pattern: "("}})
+	assert.ErrorContains(t, err, "invalid pattern")
+}
+
+func TestTagger_Tags(t *testing.T) {
+	tagger, err := New(testRules())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		item Item
+		want []string
+	}{
+		{"substring", Item{Name: "Band-Aid flexible bandages 30ct"}, []string{"HSA/FSA"}},
+		{"substring case-insensitive", Item{Name: "Equate IBUPROFEN 200mg"}, []string{"HSA/FSA"}},
+		{"category by name", Item{Name: "Reading glasses", CategoryID: "123", CategoryName: "medical"}, []string{"HSA/FSA"}},
+		{"category by ID", Item{Name: "Thermometer", CategoryID: "Medical"}, []string{"HSA/FSA"}},
+		{"pattern", Item{Name: "HP 64 Toner Cartridge"}, []string{"Reimbursable"}},
+		{"llm suggestion", Item{Name: "Lego set", LLMTags: []string{"gift"}}, []string{"Gift"}},
+		{"unknown llm suggestion dropped", Item{Name: "Lego set", LLMTags: []string{"Birthday"}}, nil},
+		{"rule and llm combined in rule order", Item{Name: "Printer paper", LLMTags: []string{"Gift", "HSA/FSA"}}, []string{"HSA/FSA", "Reimbursable", "Gift"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tagger.Tags(tt.item))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	assert.Equal(t, []string{"HSA/FSA", "Gift"}, Merge([]string{"HSA/FSA"}, nil, []string{"Gift", "hsa/fsa"}, []string{"gift", ""}))
+	assert.True(t, Has([]string{"HSA/FSA"}, "hsa/fsa"))
+	assert.False(t, Has(nil, "Gift"))
+}
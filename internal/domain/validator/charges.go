@@ -13,19 +13,33 @@ import (
 // ChargeValidation contains the result of validating order charges.
 type ChargeValidation struct {
 	// Valid is true if the charges sum correctly
-	Valid bool
+	Valid bool `json:"valid"`
 
 	// BankChargesSum is the sum of all bank charges
-	BankChargesSum float64
+	BankChargesSum float64 `json:"bank_charges_sum"`
 
 	// ExpectedSum is what the bank charges should sum to
-	ExpectedSum float64
+	ExpectedSum float64 `json:"expected_sum"`
 
 	// Difference is the gap between actual and expected
-	Difference float64
+	Difference float64 `json:"difference"`
+
+	// NonBank lists the non-bank payments deducted from the order total
+	// (only set by ValidatePayments)
+	NonBank []NonBankAmount `json:"non_bank,omitempty"`
 
 	// Reason explains why validation failed (empty if valid)
-	Reason string
+	Reason string `json:"reason,omitempty"`
+}
+
+// NonBankAmount is a payment that never reaches the bank, such as a gift
+// card, Walmart Cash, a Costco shop card or rewards.
+type NonBankAmount struct {
+	// Method names the payment as the provider reports it
+	Method string `json:"method"`
+
+	// Amount is the amount paid with this method
+	Amount float64 `json:"amount"`
 }
 
 // ValidateCharges checks that bank charges sum to the expected amount.
@@ -100,6 +114,20 @@ func ValidateCharges(bankCharges []float64, orderTotal, nonBankAmount float64) *
 	}
 }
 
+// ValidatePayments checks that bank charges sum to the order total less the
+// provider-specific non-bank payments. It applies the same rules as
+// ValidateCharges and records the non-bank payments on the result.
+func ValidatePayments(bankCharges []float64, orderTotal float64, nonBank []NonBankAmount) *ChargeValidation {
+	var nonBankAmount float64
+	for _, payment := range nonBank {
+		nonBankAmount += payment.Amount
+	}
+
+	validation := ValidateCharges(bankCharges, orderTotal, roundToCents(nonBankAmount))
+	validation.NonBank = nonBank
+	return validation
+}
+
 // ValidateChargesSimple is a convenience function for orders without non-bank payments.
 // It validates that bank charges sum to the order total.
 func ValidateChargesSimple(bankCharges []float64, orderTotal float64) *ChargeValidation {
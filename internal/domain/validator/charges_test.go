@@ -235,3 +235,30 @@ func TestValidateCharges_ReasonMessages(t *testing.T) {
 		assert.Contains(t, result.Reason, "$50.00")
 	})
 }
+
+func TestValidatePayments(t *testing.T) {
+	t.Run("gift card and Walmart Cash cover the rest", func(t *testing.T) {
+		nonBank := []NonBankAmount{{Method: "GIFTCARD", Amount: 25.00}, {Method: "WALMART_CASH", Amount: 4.50}}
+		result := ValidatePayments([]float64{70.50}, 100.00, nonBank)
+
+		assert.True(t, result.Valid)
+		assert.Equal(t, 70.50, result.ExpectedSum)
+		assert.Equal(t, nonBank, result.NonBank)
+	})
+
+	t.Run("missing bank charge", func(t *testing.T) {
+		result := ValidatePayments([]float64{40.00}, 100.00, []NonBankAmount{{Method: "Shop Card", Amount: 20.00}})
+
+		assert.False(t, result.Valid)
+		assert.Equal(t, 80.00, result.ExpectedSum)
+		assert.Equal(t, -40.00, result.Difference)
+		assert.Contains(t, result.Reason, "missing $40.00")
+	})
+
+	t.Run("no non-bank payments", func(t *testing.T) {
+		result := ValidatePayments([]float64{100.00}, 100.00, nil)
+
+		assert.True(t, result.Valid)
+		assert.Empty(t, result.NonBank)
+	})
+}
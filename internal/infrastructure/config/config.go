@@ -15,6 +15,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -27,6 +28,7 @@ type Config struct {
 	OpenAI        OpenAIConfig        `yaml:"openai"`
 	Anthropic     AnthropicConfig     `yaml:"anthropic"`
 	Categorizer   CategorizerConfig   `yaml:"categorizer"`
+	Tags          []TagRuleConfig     `yaml:"tags"`
 	Storage       StorageConfig       `yaml:"storage"`
 	Observability ObservabilityConfig `yaml:"observability"`
 }
@@ -39,6 +41,11 @@ type StorageConfig struct {
 // MonarchConfig holds Monarch API configuration
 type MonarchConfig struct {
 	APIKey string `yaml:"api_key"`
+
+	// CardAccounts maps a payment card's last four digits to the Monarch
+	// accounts its charges post to, by account ID, name or last four digits.
+	// Charges on other accounts are not matched to orders paid with the card.
+	CardAccounts map[string][]string `yaml:"card_accounts"`
 }
 
 // OpenAIConfig holds OpenAI API configuration
@@ -56,8 +63,82 @@ type AnthropicConfig struct {
 // CategorizerConfig selects which LLM backend the categorizer uses.
 // Provider may be "openai", "anthropic", or "" (auto-detect from which
 // API key is set).
+//
+// MinConfidence (0-1) flags items the LLM is unsure about. Those items are
+// moved to FallbackCategory (a Monarch category name or ID) when set, or
+// otherwise keep their category and the transaction is marked needs-review.
 type CategorizerConfig struct {
-	Provider string `yaml:"provider"`
+	Provider         string                   `yaml:"provider"`
+	MinConfidence    float64                  `yaml:"min_confidence"`
+	FallbackCategory string                   `yaml:"fallback_category"`
+	History          CategorizerHistoryConfig `yaml:"history"`
+	Prompt           CategorizerPromptConfig  `yaml:"prompt"`
+	Groups           CategorizerGroupsConfig  `yaml:"groups"`
+}
+
+// CategorizerGroupsConfig limits which Monarch category groups the
+// categorizer may assign. Entries are group names, IDs or types ("income",
+// "transfer"), case-insensitive. Allow (when set) keeps only those groups;
+// Deny removes groups, e.g. ["Income", "Transfers", "Business"].
+type CategorizerGroupsConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// CategorizerPromptConfig customizes the LLM categorization prompt.
+//
+// TemplateFile (or inline Template) is a Go text/template replacing the
+// built-in prompt. Guidance is household-specific instruction text rendered
+// into the prompt in place of the default US-centric rules; left unset the
+// default rules apply, while an explicit empty string renders none. Categories adds a
+// description and examples to individual Monarch categories, keyed by
+// category ID.
+type CategorizerPromptConfig struct {
+	TemplateFile string                          `yaml:"template_file"`
+	Template     string                          `yaml:"template"`
+	Guidance     *string                         `yaml:"guidance"`
+	Categories   map[string]CategoryPromptConfig `yaml:"categories"`
+}
+
+// CategoryPromptConfig describes what belongs in one Monarch category.
+type CategoryPromptConfig struct {
+	Description string   `yaml:"description"`
+	Examples    []string `yaml:"examples"`
+}
+
+// CategorizerHistoryConfig controls the offline categorizer trained on past
+// assignments stored in the database.
+//
+// Mode is "" or "off" (disabled), "first" (answer from history when its
+// confidence is at least MinConfidence, otherwise ask the LLM), or "only"
+// (history alone, no LLM calls).
+type CategorizerHistoryConfig struct {
+	Mode          string  `yaml:"mode"`
+	MinConfidence float64 `yaml:"min_confidence"`
+}
+
+// Enabled reports whether the history categorizer should be built.
+func (h CategorizerHistoryConfig) Enabled() bool {
+	mode := strings.ToLower(strings.TrimSpace(h.Mode))
+	return mode == "first" || mode == "only"
+}
+
+// Standalone reports whether history replaces the LLM entirely.
+func (h CategorizerHistoryConfig) Standalone() bool {
+	return strings.ToLower(strings.TrimSpace(h.Mode)) == "only"
+}
+
+// TagRuleConfig defines a Monarch tag (e.g. "HSA/FSA", "Reimbursable") and
+// which items get it: names containing any Match substring, names matching
+// Pattern (a regular expression), or items categorized into one of
+// Categories (Monarch category names or IDs). A Description offers the tag
+// to the LLM so it can also tag items no rule covers.
+type TagRuleConfig struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Match       []string `yaml:"match"`
+	Pattern     string   `yaml:"pattern"`
+	Categories  []string `yaml:"categories"`
 }
 
 // ProvidersConfig holds provider-specific configuration
@@ -67,17 +148,38 @@ type ProvidersConfig struct {
 	Amazon  AmazonConfig  `yaml:"amazon"`
 }
 
+// MerchantFilterConfig selects the Monarch transactions a provider's orders
+// are matched against, in addition to its built-in merchant names
+type MerchantFilterConfig struct {
+	// MerchantAliases are extra merchant names for the provider's charges,
+	// matched against the Monarch merchant and the bank's name: a substring,
+	// or a regular expression wrapped in slashes (e.g. "/^WM SUPERCENTER/")
+	MerchantAliases []string `yaml:"merchant_aliases"`
+	// MonarchAccounts limits matching to the Monarch accounts the provider's
+	// cards belong to, by account ID, name or last four digits
+	MonarchAccounts []string `yaml:"monarch_accounts"`
+}
+
 // WalmartConfig holds Walmart-specific settings
 type WalmartConfig struct {
+	MerchantFilterConfig `yaml:",inline"`
+
 	Enabled      bool   `yaml:"enabled"`
 	RateLimit    string `yaml:"rate_limit"`
 	LookbackDays int    `yaml:"lookback_days"`
 	MaxOrders    int    `yaml:"max_orders"`
 	Debug        bool   `yaml:"debug"`
+
+	// MultiChargeMode is how multi-delivery orders are applied: "consolidate"
+	// (default) merges the charges into one transaction; "split" keeps every
+	// charge and splits each by the items of its delivery.
+	MultiChargeMode string `yaml:"multi_charge_mode"`
 }
 
 // CostcoConfig holds Costco-specific settings
 type CostcoConfig struct {
+	MerchantFilterConfig `yaml:",inline"`
+
 	Enabled         bool   `yaml:"enabled"`
 	RateLimit       string `yaml:"rate_limit"`
 	LookbackDays    int    `yaml:"lookback_days"`
@@ -86,10 +188,16 @@ type CostcoConfig struct {
 	Email           string `yaml:"email"`
 	Password        string `yaml:"password"`
 	WarehouseNumber string `yaml:"warehouse_number"`
+
+	// GasCategory is the Monarch category (ID or name) for Costco gas
+	// station fuel, assigned without an LLM call. Defaults to "Gas".
+	GasCategory string `yaml:"gas_category"`
 }
 
 // AmazonConfig holds Amazon-specific settings
 type AmazonConfig struct {
+	MerchantFilterConfig `yaml:",inline"`
+
 	Enabled      bool   `yaml:"enabled"`
 	RateLimit    string `yaml:"rate_limit"`
 	LookbackDays int    `yaml:"lookback_days"`
@@ -97,6 +205,48 @@ type AmazonConfig struct {
 	Debug        bool   `yaml:"debug"`
 	AccountName  string `yaml:"account_name"` // For multi-account support (optional)
 	CookieFile   string `yaml:"cookie_file"`  // Optional amazon-go cookie file
+
+	// MultiChargeMode is how multi-shipment orders are applied: "consolidate"
+	// (default) merges the charges into one transaction; "split" keeps every
+	// charge and splits each by the items of its shipment.
+	MultiChargeMode string `yaml:"multi_charge_mode"`
+
+	// DigitalOrders also syncs digital orders (Kindle, Prime Video, apps,
+	// music, Audible) and membership and subscription charges.
+	DigitalOrders bool `yaml:"digital_orders"`
+	// DigitalCategories pins digital items to a Monarch category by kind
+	// (kindle, video, app, music, audible, digital, prime_membership,
+	// subscription), skipping the LLM for them.
+	DigitalCategories map[string]string `yaml:"digital_categories"`
+
+	// GroceryOrders also reads the itemized receipts of Amazon Fresh and
+	// Whole Foods Market orders, delivered or bought in store.
+	GroceryOrders bool `yaml:"grocery_orders"`
+}
+
+// MultiChargeMode returns the configured multi-charge mode for a provider,
+// or "" for providers that are always charged once per order.
+func (c ProvidersConfig) MultiChargeMode(provider string) string {
+	switch strings.ToLower(provider) {
+	case "walmart":
+		return c.Walmart.MultiChargeMode
+	case "amazon":
+		return c.Amazon.MultiChargeMode
+	}
+	return ""
+}
+
+// MerchantFilter returns the configured merchant filter for a provider
+func (c ProvidersConfig) MerchantFilter(provider string) MerchantFilterConfig {
+	switch strings.ToLower(provider) {
+	case "walmart":
+		return c.Walmart.MerchantFilterConfig
+	case "costco":
+		return c.Costco.MerchantFilterConfig
+	case "amazon":
+		return c.Amazon.MerchantFilterConfig
+	}
+	return MerchantFilterConfig{}
 }
 
 // ObservabilityConfig holds observability settings
@@ -182,7 +332,8 @@ func LoadFromEnv() *Config {
 			DatabasePath: getEnv("MONARCH_DB_PATH", "monarch_sync.db"),
 		},
 		Monarch: MonarchConfig{
-			APIKey: os.Getenv("MONARCH_TOKEN"),
+			APIKey:       os.Getenv("MONARCH_TOKEN"),
+			CardAccounts: getEnvCardAccounts("MONARCH_CARD_ACCOUNTS"),
 		},
 		OpenAI: OpenAIConfig{
 			APIKey: os.Getenv("OPENAI_API_KEY"),
@@ -193,25 +344,47 @@ func LoadFromEnv() *Config {
 			Model:  getEnv("ANTHROPIC_MODEL", "claude-haiku-4-5-20251001"),
 		},
 		Categorizer: CategorizerConfig{
-			Provider: os.Getenv("CATEGORIZER_PROVIDER"),
+			Provider:         os.Getenv("CATEGORIZER_PROVIDER"),
+			MinConfidence:    getEnvFloat("CATEGORIZER_MIN_CONFIDENCE", 0),
+			FallbackCategory: os.Getenv("CATEGORIZER_FALLBACK_CATEGORY"),
+			History: CategorizerHistoryConfig{
+				Mode:          os.Getenv("CATEGORIZER_HISTORY_MODE"),
+				MinConfidence: getEnvFloat("CATEGORIZER_HISTORY_MIN_CONFIDENCE", 0.9),
+			},
+			Prompt: CategorizerPromptConfig{
+				TemplateFile: os.Getenv("CATEGORIZER_PROMPT_TEMPLATE_FILE"),
+				Guidance:     getEnvOptional("CATEGORIZER_GUIDANCE"),
+			},
+			Groups: CategorizerGroupsConfig{
+				Allow: getEnvList("CATEGORIZER_ALLOW_GROUPS"),
+				Deny:  getEnvList("CATEGORIZER_DENY_GROUPS"),
+			},
 		},
 		Providers: ProvidersConfig{
 			Walmart: WalmartConfig{
-				Enabled:      true,
-				LookbackDays: getEnvInt("WALMART_LOOKBACK_DAYS", 14),
-				MaxOrders:    getEnvInt("WALMART_MAX_ORDERS", 0),
+				MerchantFilterConfig: getEnvMerchantFilter("WALMART"),
+				Enabled:              true,
+				LookbackDays:         getEnvInt("WALMART_LOOKBACK_DAYS", 14),
+				MaxOrders:            getEnvInt("WALMART_MAX_ORDERS", 0),
+				MultiChargeMode:      getEnv("WALMART_MULTI_CHARGE_MODE", "consolidate"),
 			},
 			Costco: CostcoConfig{
-				Enabled:      true,
-				LookbackDays: getEnvInt("COSTCO_LOOKBACK_DAYS", 14),
-				MaxOrders:    getEnvInt("COSTCO_MAX_ORDERS", 0),
+				MerchantFilterConfig: getEnvMerchantFilter("COSTCO"),
+				Enabled:              true,
+				LookbackDays:         getEnvInt("COSTCO_LOOKBACK_DAYS", 14),
+				MaxOrders:            getEnvInt("COSTCO_MAX_ORDERS", 0),
+				GasCategory:          getEnv("COSTCO_GAS_CATEGORY", "Gas"),
 			},
 			Amazon: AmazonConfig{
-				Enabled:      true,
-				LookbackDays: getEnvInt("AMAZON_LOOKBACK_DAYS", 14),
-				MaxOrders:    getEnvInt("AMAZON_MAX_ORDERS", 0),
-				AccountName:  getEnv("AMAZON_ACCOUNT_NAME", ""),
-				CookieFile:   getEnv("AMAZON_COOKIE_FILE", ""),
+				MerchantFilterConfig: getEnvMerchantFilter("AMAZON"),
+				Enabled:              true,
+				LookbackDays:         getEnvInt("AMAZON_LOOKBACK_DAYS", 14),
+				MaxOrders:            getEnvInt("AMAZON_MAX_ORDERS", 0),
+				AccountName:          getEnv("AMAZON_ACCOUNT_NAME", ""),
+				CookieFile:           getEnv("AMAZON_COOKIE_FILE", ""),
+				MultiChargeMode:      getEnv("AMAZON_MULTI_CHARGE_MODE", "consolidate"),
+				DigitalOrders:        getEnvBool("AMAZON_DIGITAL_ORDERS", false),
+				GroceryOrders:        getEnvBool("AMAZON_GROCERY_ORDERS", false),
 			},
 		},
 		Observability: ObservabilityConfig{
@@ -254,6 +427,15 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// getEnvOptional returns the environment variable's value, or nil when it is
+// unset (as opposed to set to an empty string)
+func getEnvOptional(key string) *string {
+	if val, ok := os.LookupEnv(key); ok {
+		return &val
+	}
+	return nil
+}
+
 // getEnvInt retrieves an integer environment variable with a fallback default
 func getEnvInt(key string, fallback int) int {
 	if val := os.Getenv(key); val != "" {
@@ -265,6 +447,66 @@ func getEnvInt(key string, fallback int) int {
 	return fallback
 }
 
+// getEnvFloat retrieves a float environment variable with a fallback default
+func getEnvFloat(key string, fallback float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		var result float64
+		if _, err := fmt.Sscanf(val, "%g", &result); err == nil {
+			return result
+		}
+	}
+	return fallback
+}
+
+// getEnvBool retrieves a boolean environment variable with a fallback default
+func getEnvBool(key string, fallback bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if result, err := strconv.ParseBool(val); err == nil {
+			return result
+		}
+	}
+	return fallback
+}
+
+// getEnvMerchantFilter reads a provider's <PREFIX>_MERCHANT_ALIASES and
+// <PREFIX>_MONARCH_ACCOUNTS lists
+func getEnvMerchantFilter(prefix string) MerchantFilterConfig {
+	return MerchantFilterConfig{
+		MerchantAliases: getEnvList(prefix + "_MERCHANT_ALIASES"),
+		MonarchAccounts: getEnvList(prefix + "_MONARCH_ACCOUNTS"),
+	}
+}
+
+// getEnvList retrieves a comma-separated environment variable as a list,
+// dropping empty entries
+func getEnvList(key string) []string {
+	var list []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
+
+// getEnvCardAccounts reads a comma-separated list of card=account pairs,
+// e.g. "1211=Chase Prime Visa,3005=Amex Gold". A card may repeat.
+func getEnvCardAccounts(key string) map[string][]string {
+	var accounts map[string][]string
+	for _, entry := range getEnvList(key) {
+		card, account, ok := strings.Cut(entry, "=")
+		card, account = strings.TrimSpace(card), strings.TrimSpace(account)
+		if !ok || card == "" || account == "" {
+			continue
+		}
+		if accounts == nil {
+			accounts = make(map[string][]string)
+		}
+		accounts[card] = append(accounts[card], account)
+	}
+	return accounts
+}
+
 // GetAPIKey retrieves an API key from config first, then tries multiple environment variable names
 // Usage: GetAPIKey(cfg.Monarch.APIKey, "MONARCH_TOKEN")
 //
@@ -116,6 +116,54 @@ monarch:
 	assert.Equal(t, "expanded-token", cfg.Monarch.APIKey)
 }
 
+func TestProvidersMerchantFilter(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+providers:
+  walmart:
+    merchant_aliases: ["WAL-MART", "/^WM SUPERCENTER #\\d+/"]
+    monarch_accounts: ["Walmart Card"]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0600))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	walmart := cfg.Providers.MerchantFilter("Walmart")
+	assert.Equal(t, []string{"WAL-MART", `/^WM SUPERCENTER #\d+/`}, walmart.MerchantAliases)
+	assert.Equal(t, []string{"Walmart Card"}, walmart.MonarchAccounts)
+	assert.Empty(t, cfg.Providers.MerchantFilter("costco").MerchantAliases)
+
+	t.Setenv("COSTCO_MERCHANT_ALIASES", "Costco Gas, COSTCO WHSE")
+	t.Setenv("COSTCO_MONARCH_ACCOUNTS", "1234")
+	costco := LoadFromEnv().Providers.MerchantFilter("costco")
+	assert.Equal(t, []string{"Costco Gas", "COSTCO WHSE"}, costco.MerchantAliases)
+	assert.Equal(t, []string{"1234"}, costco.MonarchAccounts)
+}
+
+func TestMonarchCardAccounts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `
+monarch:
+  card_accounts:
+    "1211": ["Chase Prime Visa"]
+    "3005": ["Amex Gold", "acc-123"]
+`
+	require.NoError(t, os.WriteFile(configPath, []byte(configContent), 0600))
+
+	cfg, err := Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"1211": {"Chase Prime Visa"},
+		"3005": {"Amex Gold", "acc-123"},
+	}, cfg.Monarch.CardAccounts)
+
+	t.Setenv("MONARCH_CARD_ACCOUNTS", "1211=Chase Prime Visa, 3005=Amex Gold,3005=acc-123,bogus")
+	assert.Equal(t, map[string][]string{
+		"1211": {"Chase Prime Visa"},
+		"3005": {"Amex Gold", "acc-123"},
+	}, LoadFromEnv().Monarch.CardAccounts)
+}
+
 func TestValidateConfigPathRejectsUnsafePaths(t *testing.T) {
 	tests := []string{
 		"",
@@ -171,3 +219,26 @@ func TestValidateConfigPathRejectsMissingFileAndDirectory(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "must be a file")
 }
+
+func TestCategorizerGuidance_UnsetVersusEmpty(t *testing.T) {
+	dir := t.TempDir()
+	unsetPath := filepath.Join(dir, "unset.yaml")
+	require.NoError(t, os.WriteFile(unsetPath, []byte("categorizer:\n  prompt:\n    template_file: \"\"\n"), 0600))
+	emptyPath := filepath.Join(dir, "empty.yaml")
+	require.NoError(t, os.WriteFile(emptyPath, []byte("categorizer:\n  prompt:\n    guidance: \"\"\n"), 0600))
+
+	cfg, err := Load(unsetPath)
+	require.NoError(t, err)
+	assert.Nil(t, cfg.Categorizer.Prompt.Guidance)
+
+	cfg, err = Load(emptyPath)
+	require.NoError(t, err)
+	require.NotNil(t, cfg.Categorizer.Prompt.Guidance)
+	assert.Equal(t, "", *cfg.Categorizer.Prompt.Guidance)
+
+	os.Unsetenv("CATEGORIZER_GUIDANCE")
+	assert.Nil(t, LoadFromEnv().Categorizer.Prompt.Guidance)
+	t.Setenv("CATEGORIZER_GUIDANCE", "")
+	require.NotNil(t, LoadFromEnv().Categorizer.Prompt.Guidance)
+	assert.Equal(t, "", *LoadFromEnv().Categorizer.Prompt.Guidance)
+}
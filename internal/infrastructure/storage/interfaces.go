@@ -8,6 +8,9 @@ type Repository interface {
 	SyncRunRepository
 	APICallRepository
 	LedgerRepository
+	EvalRunRepository
+	CardAccountRepository
+	SyncWatermarkRepository
 	Close() error
 }
 
@@ -53,6 +56,11 @@ type OrderFilters struct {
 	OrderDesc bool   // Sort descending (default: true)
 }
 
+// StatusLowConfidence is a pseudo-status accepted by OrderFilters.Status that
+// selects records with at least one item below the categorization confidence
+// threshold, regardless of their processing status.
+const StatusLowConfidence = "low_confidence"
+
 // OrderListResult contains paginated order results
 type OrderListResult struct {
 	Orders     []*ProcessingRecord `json:"orders"`
@@ -76,6 +84,12 @@ type SyncRunRepository interface {
 	// StartSyncRun records the start of a sync run and returns the run ID
 	StartSyncRun(provider string, lookbackDays int, dryRun bool) (int64, error)
 
+	// SetSyncRunAccount records the provider account(s) a sync run read
+	SetSyncRunAccount(runID int64, account string) error
+
+	// AddSyncRunWarning records a problem a sync run worked around
+	AddSyncRunWarning(runID int64, warning string) error
+
 	// CompleteSyncRun records the completion of a sync run
 	CompleteSyncRun(runID int64, ordersFound, processed, skipped, errors int) error
 
@@ -88,17 +102,19 @@ type SyncRunRepository interface {
 
 // SyncRun represents a sync run record
 type SyncRun struct {
-	ID              int64  `json:"id"`
-	Provider        string `json:"provider"`
-	StartedAt       string `json:"started_at"`
-	CompletedAt     string `json:"completed_at,omitempty"`
-	LookbackDays    int    `json:"lookback_days"`
-	DryRun          bool   `json:"dry_run"`
-	OrdersFound     int    `json:"orders_found"`
-	OrdersProcessed int    `json:"orders_processed"`
-	OrdersSkipped   int    `json:"orders_skipped"`
-	OrdersErrored   int    `json:"orders_errored"`
-	Status          string `json:"status"`
+	ID              int64    `json:"id"`
+	Provider        string   `json:"provider"`
+	StartedAt       string   `json:"started_at"`
+	CompletedAt     string   `json:"completed_at,omitempty"`
+	LookbackDays    int      `json:"lookback_days"`
+	DryRun          bool     `json:"dry_run"`
+	OrdersFound     int      `json:"orders_found"`
+	OrdersProcessed int      `json:"orders_processed"`
+	OrdersSkipped   int      `json:"orders_skipped"`
+	OrdersErrored   int      `json:"orders_errored"`
+	Status          string   `json:"status"`
+	Account         string   `json:"account,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
 }
 
 // APICallRepository handles API call logging
@@ -141,4 +157,40 @@ type LedgerRepository interface {
 
 	// GetUnmatchedCharges returns charges that haven't been matched to Monarch transactions
 	GetUnmatchedCharges(provider string, limit int) ([]LedgerCharge, error)
+
+	// GetLedgerEvents retrieves the changes between an order's ledger snapshots (oldest first)
+	GetLedgerEvents(orderID string) ([]LedgerEvent, error)
+}
+
+// EvalRunRepository stores categorization evaluation results
+type EvalRunRepository interface {
+	// SaveEvalRun stores an evaluation run and sets its ID
+	SaveEvalRun(run *EvalRun) error
+
+	// ListEvalRuns returns recent evaluation runs (newest first), optionally
+	// restricted to one dataset
+	ListEvalRuns(dataset string, limit int) ([]EvalRun, error)
+}
+
+// CardAccountRepository stores the Monarch accounts payment cards were
+// matched to
+type CardAccountRepository interface {
+	// RecordCardAccount records that an order's charge on a card posted to
+	// a Monarch account. MatchCount counts distinct orders, so recording the
+	// same order again only updates the last match time.
+	RecordCardAccount(account *CardAccount, orderID string) error
+
+	// ListCardAccounts returns every learned card-to-account pairing
+	ListCardAccounts() ([]CardAccount, error)
+}
+
+// SyncWatermarkRepository stores how far each provider account has been
+// synced
+type SyncWatermarkRepository interface {
+	// GetSyncWatermark returns a provider account's watermark, or nil when no
+	// sync of it has been recorded
+	GetSyncWatermark(provider, account string) (*SyncWatermark, error)
+
+	// SaveSyncWatermark creates or replaces a provider account's watermark
+	SaveSyncWatermark(watermark *SyncWatermark) error
 }
@@ -456,6 +456,176 @@ func TestStorage_GetUnmatchedCharges(t *testing.T) {
 	}
 }
 
+func TestStorage_GetUnmatchedCharges_LatestLedgerOnly(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ledger_unmatched_latest_test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	s, err := NewStorage(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	chargedAt := time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)
+	// Every sync saves a new snapshot of the same ledger
+	for i := 0; i < 2; i++ {
+		ledger := &OrderLedger{
+			OrderID:     "repeat-order",
+			Provider:    "walmart",
+			FetchedAt:   time.Now(),
+			LedgerState: LedgerStateCharged,
+			LedgerJSON:  `{}`,
+			Charges: []LedgerCharge{
+				{ChargeSequence: 1, ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234", ChargedAt: chargedAt},
+				{ChargeSequence: 2, ChargeAmount: 10.00, ChargeType: "payment", PaymentMethod: "GIFTCARD"},
+			},
+		}
+		if err := s.SaveLedger(ledger); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+	}
+
+	unmatched, err := s.GetUnmatchedCharges("", 50)
+	if err != nil {
+		t.Fatalf("failed to get unmatched: %v", err)
+	}
+	if len(unmatched) != 1 {
+		t.Fatalf("expected 1 unmatched charge from the latest snapshot, got %d", len(unmatched))
+	}
+	latest, _ := s.GetLatestLedger("repeat-order")
+	if unmatched[0].OrderLedgerID != latest.ID {
+		t.Errorf("expected charge from ledger %d, got %d", latest.ID, unmatched[0].OrderLedgerID)
+	}
+	if unmatched[0].Provider != "walmart" {
+		t.Errorf("expected provider 'walmart', got %q", unmatched[0].Provider)
+	}
+	if !unmatched[0].ChargedAt.Equal(chargedAt) {
+		t.Errorf("expected charged_at %v, got %v", chargedAt, unmatched[0].ChargedAt)
+	}
+}
+
+func TestDiffLedgers(t *testing.T) {
+	previous := &OrderLedger{
+		ID: 1, OrderID: "diff-order", LedgerState: LedgerStateCharged,
+		Charges: []LedgerCharge{
+			{ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "1234"},
+			{ChargeAmount: 10.00, ChargeType: "payment", PaymentMethod: "GIFTCARD"},
+		},
+	}
+
+	first := DiffLedgers(nil, previous)
+	if len(first) != 2 || first[0].EventType != LedgerEventChargeAdded || first[0].PreviousLedgerID != 0 {
+		t.Fatalf("expected the first snapshot's charges as added, got %+v", first)
+	}
+
+	if events := DiffLedgers(previous, &OrderLedger{ID: 2, LedgerState: LedgerStateCharged, Charges: previous.Charges}); len(events) != 0 {
+		t.Fatalf("expected no events for an unchanged ledger, got %+v", events)
+	}
+
+	// The card charge moved to another card and part of it was refunded
+	current := &OrderLedger{
+		ID: 2, OrderID: "diff-order", SyncRunID: 7, LedgerState: LedgerStatePartialRefund,
+		Charges: []LedgerCharge{
+			{ChargeAmount: 10.00, ChargeType: "payment", PaymentMethod: "GIFTCARD"},
+			{ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardLastFour: "9876"},
+			{ChargeAmount: -5.58, ChargeType: "refund", PaymentMethod: "CREDITCARD", CardLastFour: "9876"},
+		},
+	}
+	events := DiffLedgers(previous, current)
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d: %+v", len(events), events)
+	}
+	expected := []struct {
+		eventType LedgerEventType
+		card      string
+		amount    float64
+	}{
+		{LedgerEventChargeAdded, "9876", 40.00},
+		{LedgerEventRefundIssued, "9876", -5.58},
+		{LedgerEventChargeRemoved, "1234", 40.00},
+		{LedgerEventStateChanged, "", 0},
+	}
+	for i, want := range expected {
+		got := events[i]
+		if got.EventType != want.eventType || got.CardLastFour != want.card || got.ChargeAmount != want.amount {
+			t.Errorf("event %d: expected %s %s %.2f, got %s %s %.2f", i,
+				want.eventType, want.card, want.amount, got.EventType, got.CardLastFour, got.ChargeAmount)
+		}
+		if got.OrderID != "diff-order" || got.OrderLedgerID != 2 || got.PreviousLedgerID != 1 || got.SyncRunID != 7 {
+			t.Errorf("event %d: wrong ledger references %+v", i, got)
+		}
+	}
+	if events[3].PreviousState != LedgerStateCharged || events[3].NewState != LedgerStatePartialRefund {
+		t.Errorf("expected charged -> partial_refund, got %s -> %s", events[3].PreviousState, events[3].NewState)
+	}
+}
+
+func TestStorage_GetLedgerEvents(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "ledger_events_test_*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpFile.Name())
+	tmpFile.Close()
+
+	s, err := NewStorage(tmpFile.Name())
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	defer s.Close()
+
+	snapshots := []*OrderLedger{
+		{OrderID: "events-order", Provider: "walmart", FetchedAt: time.Now(), LedgerState: LedgerStatePending, LedgerJSON: `{}`},
+		{OrderID: "events-order", Provider: "walmart", FetchedAt: time.Now(), LedgerState: LedgerStateCharged, LedgerJSON: `{}`,
+			Charges: []LedgerCharge{{ChargeSequence: 1, ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardType: "VISA", CardLastFour: "1234"}}},
+		{OrderID: "events-order", Provider: "walmart", FetchedAt: time.Now(), LedgerState: LedgerStateCharged, LedgerJSON: `{}`,
+			Charges: []LedgerCharge{{ChargeSequence: 1, ChargeAmount: 40.00, ChargeType: "payment", PaymentMethod: "CREDITCARD", CardType: "VISA", CardLastFour: "1234"}}},
+	}
+	for _, ledger := range snapshots {
+		if err := s.SaveLedger(ledger); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+	}
+
+	events, err := s.GetLedgerEvents("events-order")
+	if err != nil {
+		t.Fatalf("failed to get events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d: %+v", len(events), events)
+	}
+	added, state := events[0], events[1]
+	if added.EventType != LedgerEventChargeAdded || added.ChargeAmount != 40.00 || added.CardType != "VISA" || added.CardLastFour != "1234" {
+		t.Errorf("unexpected charge event %+v", added)
+	}
+	if added.OrderLedgerID != snapshots[1].ID || added.PreviousLedgerID != snapshots[0].ID {
+		t.Errorf("expected event between ledgers %d and %d, got %d and %d",
+			snapshots[0].ID, snapshots[1].ID, added.PreviousLedgerID, added.OrderLedgerID)
+	}
+	if added.CreatedAt.IsZero() {
+		t.Error("expected created_at to be set")
+	}
+	if state.EventType != LedgerEventStateChanged || state.PreviousState != LedgerStatePending || state.NewState != LedgerStateCharged {
+		t.Errorf("unexpected state event %+v", state)
+	}
+
+	mock := NewMockRepository()
+	for _, ledger := range snapshots {
+		copied := *ledger
+		if err := mock.SaveLedger(&copied); err != nil {
+			t.Fatalf("failed to save to mock: %v", err)
+		}
+	}
+	mockEvents, _ := mock.GetLedgerEvents("events-order")
+	if len(mockEvents) != 2 || mockEvents[0].EventType != LedgerEventChargeAdded || mockEvents[1].EventType != LedgerEventStateChanged {
+		t.Errorf("expected the mock to record the same events, got %+v", mockEvents)
+	}
+}
+
 func TestMockRepository_Ledger(t *testing.T) {
 	mock := NewMockRepository()
 
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Record items whose categorization confidence fell below the configured
+-- threshold so they can be listed with /api/orders?status=low_confidence.
+
+-- +goose StatementBegin
+ALTER TABLE processing_records ADD COLUMN low_confidence_json TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE processing_attempts ADD COLUMN low_confidence_json TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Columns are nullable and left in place on downgrade.
@@ -0,0 +1,32 @@
+-- +goose Up
+-- Store categorization evaluation runs so model/prompt changes can be compared.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    dataset TEXT NOT NULL,
+    backend TEXT NOT NULL,
+    model TEXT NOT NULL,
+    prompt_hash TEXT,
+    label TEXT,
+    item_count INTEGER DEFAULT 0,
+    correct_count INTEGER DEFAULT 0,
+    accuracy REAL DEFAULT 0,
+    prompt_tokens INTEGER DEFAULT 0,
+    completion_tokens INTEGER DEFAULT 0,
+    cost_usd REAL DEFAULT 0,
+    duration_ms INTEGER DEFAULT 0,
+    report_json TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_eval_runs_created_at
+    ON eval_runs(created_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS eval_runs;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- Record which categorization prompt (template + guidance) produced each
+-- record so results can be traced back to prompt changes.
+
+-- +goose StatementBegin
+ALTER TABLE processing_records ADD COLUMN prompt_hash TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE processing_attempts ADD COLUMN prompt_hash TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Columns are nullable and left in place on downgrade.
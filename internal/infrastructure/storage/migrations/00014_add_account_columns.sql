@@ -0,0 +1,18 @@
+-- +goose Up
+-- Record which provider account (e.g. an Amazon cookie account) each order
+-- and sync run came from, so multi-account syncs can be told apart.
+
+-- +goose StatementBegin
+ALTER TABLE processing_records ADD COLUMN account TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE processing_attempts ADD COLUMN account TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE sync_runs ADD COLUMN account TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Columns are nullable and left in place on downgrade.
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Learn which Monarch account each payment card's charges post to, from
+-- orders matched while paid with a single card.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS card_accounts (
+    card_last_four TEXT NOT NULL,
+    monarch_account_id TEXT NOT NULL,
+    monarch_account_name TEXT,
+    card_type TEXT,
+    match_count INTEGER DEFAULT 0,
+    last_matched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (card_last_four, monarch_account_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_accounts;
+-- +goose StatementEnd
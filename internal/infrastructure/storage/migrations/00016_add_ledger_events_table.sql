@@ -0,0 +1,36 @@
+-- +goose Up
+-- ledger_events: What changed between consecutive ledger snapshots of an order
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS ledger_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id TEXT NOT NULL,
+    order_ledger_id INTEGER NOT NULL,
+    previous_ledger_id INTEGER,
+    sync_run_id INTEGER,
+    event_type TEXT NOT NULL,
+    charge_amount REAL,
+    charge_type TEXT,
+    payment_method TEXT,
+    card_type TEXT,
+    card_last_four TEXT,
+    previous_state TEXT,
+    new_state TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (order_ledger_id) REFERENCES order_ledgers(id),
+    FOREIGN KEY (previous_ledger_id) REFERENCES order_ledgers(id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS idx_ledger_events_order_id
+    ON ledger_events(order_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_ledger_events_order_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ledger_events;
+-- +goose StatementEnd
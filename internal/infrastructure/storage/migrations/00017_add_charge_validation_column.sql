@@ -0,0 +1,14 @@
+-- +goose Up
+-- Record whether an order's bank charges added up to its total, after
+-- gift cards, store credit and rewards, when it was processed.
+
+-- +goose StatementBegin
+ALTER TABLE processing_records ADD COLUMN charge_validation_json TEXT;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE processing_attempts ADD COLUMN charge_validation_json TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- Columns are nullable and left in place on downgrade.
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Remember how far each provider account has been synced, so incremental
+-- runs fetch from there instead of a fixed lookback.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS sync_watermarks (
+    provider TEXT NOT NULL,
+    account TEXT NOT NULL DEFAULT '',
+    settled_through TIMESTAMP NOT NULL,
+    open_orders_json TEXT,
+    run_id INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (provider, account)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS sync_watermarks;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Count the distinct orders behind each learned card-to-account pairing, so
+-- re-processing one order doesn't make its pairing look well established.
+-- Earlier counts were of matches, not orders, and can't be trusted: they are
+-- reset and the pairings are learned again.
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS card_account_orders (
+    card_last_four TEXT NOT NULL,
+    monarch_account_id TEXT NOT NULL,
+    order_id TEXT NOT NULL,
+    matched_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (card_last_four, monarch_account_id, order_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE card_accounts SET match_count = 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_account_orders;
+-- +goose StatementEnd
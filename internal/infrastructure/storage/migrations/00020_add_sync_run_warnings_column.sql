@@ -0,0 +1,10 @@
+-- +goose Up
+-- Problems a sync run worked around rather than failing on (such as a
+-- truncated Monarch transaction fetch), as a JSON array of messages.
+
+-- +goose StatementBegin
+ALTER TABLE sync_runs ADD COLUMN warnings_json TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- The column is nullable and left in place on downgrade.
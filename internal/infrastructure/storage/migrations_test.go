@@ -14,7 +14,7 @@ import (
 // expectedMigrationCount is the number of migrations we expect to have
 // Update this when adding new migrations
 // Note: goose adds a version 0 entry when initializing, so total count is migrations + 1
-const expectedMigrationCount = 10
+const expectedMigrationCount = 20
 const gooseVersionCount = expectedMigrationCount + 1 // includes goose's version 0 entry
 
 // TestMigrations_FreshDatabase tests running migrations on a fresh database
@@ -113,6 +113,24 @@ func TestMigrations_Schema(t *testing.T) {
 
 	err = store.db.QueryRow("SELECT COUNT(*) FROM provider_fetches").Scan(new(int))
 	assert.NoError(t, err, "provider_fetches table should exist")
+
+	err = store.db.QueryRow("SELECT COUNT(*) FROM eval_runs").Scan(new(int))
+	assert.NoError(t, err, "eval_runs table should exist")
+
+	err = store.db.QueryRow("SELECT COUNT(*) FROM card_accounts").Scan(new(int))
+	assert.NoError(t, err, "card_accounts table should exist")
+
+	err = store.db.QueryRow("SELECT COUNT(*) FROM ledger_events").Scan(new(int))
+	assert.NoError(t, err, "ledger_events table should exist")
+
+	err = store.db.QueryRow("SELECT charge_validation_json FROM processing_records LIMIT 0").Scan()
+	assert.True(t, err == nil || err == sql.ErrNoRows, "processing_records.charge_validation_json should exist")
+
+	err = store.db.QueryRow("SELECT COUNT(*) FROM sync_watermarks").Scan(new(int))
+	assert.NoError(t, err, "sync_watermarks table should exist")
+
+	err = store.db.QueryRow("SELECT COUNT(*) FROM card_account_orders").Scan(new(int))
+	assert.NoError(t, err, "card_account_orders table should exist")
 }
 
 // TestMigrations_ForeignKeyConstraints tests that foreign keys are enforced
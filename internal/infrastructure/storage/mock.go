@@ -1,5 +1,10 @@
 package storage
 
+import (
+	"sort"
+	"time"
+)
+
 // MockRepository is an in-memory implementation of Repository for testing.
 // It stores all data in maps and slices, making tests fast and isolated.
 type MockRepository struct {
@@ -11,9 +16,15 @@ type MockRepository struct {
 	providerFetches []ProviderFetchLog
 	ledgers         map[string][]*OrderLedger // Keyed by order_id
 	ledgerCharges   map[int64][]LedgerCharge  // Keyed by ledger_id
+	ledgerEvents    map[string][]LedgerEvent  // Keyed by order_id
+	evalRuns        []EvalRun
+	cardAccounts    []CardAccount
+	cardOrders      map[string]bool           // Keyed by card + "|" + account + "|" + order_id
+	watermarks      map[string]*SyncWatermark // Keyed by provider + "|" + account
 	nextRunID       int64
 	nextLedgerID    int64
 	nextChargeID    int64
+	nextEventID     int64
 
 	// Hooks for test assertions
 	SaveRecordCalled   bool
@@ -44,6 +55,8 @@ type mockSyncRun struct {
 	skipped      int
 	errors       int
 	completed    bool
+	account      string
+	warnings     []string
 }
 
 // NewMockRepository creates a new mock repository for testing
@@ -57,9 +70,12 @@ func NewMockRepository() *MockRepository {
 		providerFetches: make([]ProviderFetchLog, 0),
 		ledgers:         make(map[string][]*OrderLedger),
 		ledgerCharges:   make(map[int64][]LedgerCharge),
+		ledgerEvents:    make(map[string][]LedgerEvent),
+		cardOrders:      make(map[string]bool),
 		nextRunID:       1,
 		nextLedgerID:    1,
 		nextChargeID:    1,
+		nextEventID:     1,
 	}
 }
 
@@ -201,6 +217,22 @@ func (m *MockRepository) StartSyncRun(provider string, lookbackDays int, dryRun
 	return id, nil
 }
 
+// SetSyncRunAccount records the account(s) a sync run read
+func (m *MockRepository) SetSyncRunAccount(runID int64, account string) error {
+	if run, ok := m.syncRuns[runID]; ok {
+		run.account = account
+	}
+	return nil
+}
+
+// AddSyncRunWarning appends a warning to a sync run
+func (m *MockRepository) AddSyncRunWarning(runID int64, warning string) error {
+	if run, ok := m.syncRuns[runID]; ok {
+		run.warnings = append(run.warnings, warning)
+	}
+	return nil
+}
+
 // CompleteSyncRun marks a sync run as complete
 func (m *MockRepository) CompleteSyncRun(runID int64, ordersFound, processed, skipped, errors int) error {
 	if m.CompleteSyncRunErr != nil {
@@ -283,7 +315,11 @@ func (m *MockRepository) ListOrders(filters OrderFilters) (*OrderListResult, err
 			continue
 		}
 		// Apply status filter
-		if filters.Status != "" && r.Status != filters.Status {
+		if filters.Status == StatusLowConfidence {
+			if r.LowConfidenceJSON == "" {
+				continue
+			}
+		} else if filters.Status != "" && r.Status != filters.Status {
 			continue
 		}
 		matching = append(matching, r)
@@ -400,6 +436,8 @@ func (m *MockRepository) ListSyncRuns(limit int) ([]SyncRun, error) {
 			OrdersSkipped:   r.skipped,
 			OrdersErrored:   r.errors,
 			Status:          status,
+			Account:         r.account,
+			Warnings:        r.warnings,
 		})
 		if len(runs) >= limit {
 			break
@@ -428,6 +466,8 @@ func (m *MockRepository) GetSyncRun(runID int64) (*SyncRun, error) {
 		OrdersSkipped:   r.skipped,
 		OrdersErrored:   r.errors,
 		Status:          status,
+		Account:         r.account,
+		Warnings:        r.warnings,
 	}, nil
 }
 
@@ -465,9 +505,12 @@ func (m *MockRepository) Reset() {
 	m.providerFetches = make([]ProviderFetchLog, 0)
 	m.ledgers = make(map[string][]*OrderLedger)
 	m.ledgerCharges = make(map[int64][]LedgerCharge)
+	m.ledgerEvents = make(map[string][]LedgerEvent)
+	m.evalRuns = nil
 	m.nextRunID = 1
 	m.nextLedgerID = 1
 	m.nextChargeID = 1
+	m.nextEventID = 1
 	m.SaveRecordCalled = false
 	m.LastSavedRecord = nil
 	m.GetRecordCalled = false
@@ -504,6 +547,11 @@ func (m *MockRepository) SaveLedger(ledger *OrderLedger) error {
 	existingLedgers := m.ledgers[ledger.OrderID]
 	ledger.LedgerVersion = len(existingLedgers) + 1
 
+	var previous *OrderLedger
+	if len(existingLedgers) > 0 {
+		previous, _ = m.GetLatestLedger(ledger.OrderID)
+	}
+
 	// Deep copy the ledger
 	copied := *ledger
 
@@ -526,9 +574,21 @@ func (m *MockRepository) SaveLedger(ledger *OrderLedger) error {
 	// Store charges by ledger ID
 	m.ledgerCharges[copied.ID] = charges
 
+	for _, event := range DiffLedgers(previous, &copied) {
+		event.ID = m.nextEventID
+		m.nextEventID++
+		event.CreatedAt = time.Now()
+		m.ledgerEvents[ledger.OrderID] = append(m.ledgerEvents[ledger.OrderID], event)
+	}
+
 	return nil
 }
 
+// GetLedgerEvents retrieves the changes between an order's ledger snapshots (oldest first)
+func (m *MockRepository) GetLedgerEvents(orderID string) ([]LedgerEvent, error) {
+	return m.ledgerEvents[orderID], nil
+}
+
 // GetLatestLedger retrieves the most recent ledger for an order
 func (m *MockRepository) GetLatestLedger(orderID string) (*OrderLedger, error) {
 	ledgers := m.ledgers[orderID]
@@ -635,26 +695,31 @@ func (m *MockRepository) UpdateChargeMatch(chargeID int64, transactionID string,
 	return nil
 }
 
-// GetUnmatchedCharges returns charges that haven't been matched to Monarch transactions
+// GetUnmatchedCharges returns unmatched payment charges in each order's
+// latest ledger, leaving out gift cards
 func (m *MockRepository) GetUnmatchedCharges(provider string, limit int) ([]LedgerCharge, error) {
 	if limit == 0 {
 		limit = 50
 	}
 
+	orderIDs := make([]string, 0, len(m.ledgers))
+	for orderID := range m.ledgers {
+		orderIDs = append(orderIDs, orderID)
+	}
+	sort.Strings(orderIDs)
+
 	var result []LedgerCharge
-	for _, charges := range m.ledgerCharges {
-		for _, charge := range charges {
-			if charge.IsMatched {
-				continue
-			}
-			if charge.ChargeType != "payment" {
-				continue
-			}
-			// Check provider via the ledger
-			ledger, _ := m.GetLedgerByID(charge.OrderLedgerID)
-			if ledger != nil && provider != "" && ledger.Provider != provider {
+	for _, orderID := range orderIDs {
+		ledgers := m.ledgers[orderID]
+		latest := ledgers[len(ledgers)-1]
+		if provider != "" && latest.Provider != provider {
+			continue
+		}
+		for _, charge := range m.ledgerCharges[latest.ID] {
+			if charge.IsMatched || charge.ChargeType != "payment" || charge.PaymentMethod == "GIFTCARD" {
 				continue
 			}
+			charge.Provider = latest.Provider
 			result = append(result, charge)
 			if len(result) >= limit {
 				return result, nil
@@ -663,3 +728,91 @@ func (m *MockRepository) GetUnmatchedCharges(provider string, limit int) ([]Ledg
 	}
 	return result, nil
 }
+
+// ================================================================
+// EVAL RUN REPOSITORY METHODS
+// ================================================================
+
+// SaveEvalRun stores an evaluation run and sets its ID
+func (m *MockRepository) SaveEvalRun(run *EvalRun) error {
+	run.ID = int64(len(m.evalRuns) + 1)
+	m.evalRuns = append(m.evalRuns, *run)
+	return nil
+}
+
+// ListEvalRuns returns recent evaluation runs (newest first)
+func (m *MockRepository) ListEvalRuns(dataset string, limit int) ([]EvalRun, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var result []EvalRun
+	for i := len(m.evalRuns) - 1; i >= 0 && len(result) < limit; i-- {
+		if dataset != "" && m.evalRuns[i].Dataset != dataset {
+			continue
+		}
+		result = append(result, m.evalRuns[i])
+	}
+	return result, nil
+}
+
+// ================================================================
+// CARD ACCOUNT REPOSITORY METHODS
+// ================================================================
+
+// RecordCardAccount records that an order's charge on a card posted to a
+// Monarch account, counting each order once
+func (m *MockRepository) RecordCardAccount(account *CardAccount, orderID string) error {
+	orderKey := account.CardLastFour + "|" + account.MonarchAccountID + "|" + orderID
+	newOrder := !m.cardOrders[orderKey]
+	m.cardOrders[orderKey] = true
+	for i := range m.cardAccounts {
+		existing := &m.cardAccounts[i]
+		if existing.CardLastFour == account.CardLastFour && existing.MonarchAccountID == account.MonarchAccountID {
+			if newOrder {
+				existing.MatchCount++
+			}
+			if account.MonarchAccountName != "" {
+				existing.MonarchAccountName = account.MonarchAccountName
+			}
+			return nil
+		}
+	}
+	recorded := *account
+	recorded.MatchCount = 1
+	m.cardAccounts = append(m.cardAccounts, recorded)
+	return nil
+}
+
+// ListCardAccounts returns every learned card-to-account pairing
+func (m *MockRepository) ListCardAccounts() ([]CardAccount, error) {
+	return append([]CardAccount(nil), m.cardAccounts...), nil
+}
+
+// ================================================================
+// SYNC WATERMARK REPOSITORY METHODS
+// ================================================================
+
+// GetSyncWatermark returns a provider account's watermark, or nil when no
+// sync of it has been recorded
+func (m *MockRepository) GetSyncWatermark(provider, account string) (*SyncWatermark, error) {
+	watermark, ok := m.watermarks[provider+"|"+account]
+	if !ok {
+		return nil, nil
+	}
+	copied := *watermark
+	copied.OpenOrders = append([]OpenOrder(nil), watermark.OpenOrders...)
+	return &copied, nil
+}
+
+// SaveSyncWatermark creates or replaces a provider account's watermark
+func (m *MockRepository) SaveSyncWatermark(watermark *SyncWatermark) error {
+	if m.watermarks == nil {
+		m.watermarks = make(map[string]*SyncWatermark)
+	}
+	saved := *watermark
+	saved.OpenOrders = append([]OpenOrder(nil), watermark.OpenOrders...)
+	saved.UpdatedAt = time.Now()
+	m.watermarks[watermark.Provider+"|"+watermark.Account] = &saved
+	return nil
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -44,6 +46,35 @@ type ProcessingRecord struct {
 
 	// MatchDiagnosticsJSON captures why matching did or did not happen.
 	MatchDiagnosticsJSON string `json:"match_diagnostics_json,omitempty"`
+
+	// LowConfidenceJSON lists items categorized below the confidence threshold
+	// (see LowConfidenceItem). Empty when every item cleared the threshold.
+	LowConfidenceJSON string `json:"low_confidence_json,omitempty"`
+
+	// PromptHash identifies the LLM prompt template and guidance in effect
+	// when the order was categorized (see categorizer.Prompt.Hash). Empty when
+	// no item was categorized by the LLM.
+	PromptHash string `json:"prompt_hash,omitempty"`
+
+	// Account names the provider account the order was fetched from when a
+	// provider has several (see providers.OrderAccount).
+	Account string `json:"account,omitempty"`
+
+	// ChargeValidationJSON records whether the order's bank charges added up
+	// to its total after non-bank payments (see validator.ChargeValidation).
+	// Empty for providers that don't report how an order was paid.
+	ChargeValidationJSON string `json:"charge_validation_json,omitempty"`
+}
+
+// LowConfidenceItem records an item whose LLM categorization confidence fell
+// below the configured minimum.
+type LowConfidenceItem struct {
+	Name             string  `json:"name"`
+	CategoryID       string  `json:"category_id"`   // Category the LLM suggested
+	CategoryName     string  `json:"category_name"` // Category the LLM suggested
+	Confidence       float64 `json:"confidence"`
+	FallbackCategory string  `json:"fallback_category,omitempty"` // Category applied instead, if configured
+	FlaggedForReview bool    `json:"flagged_for_review"`          // Transaction marked needs-review in Monarch
 }
 
 // ProcessingAttempt is an append-only snapshot of each attempt to process an order.
@@ -69,10 +100,14 @@ type OrderTransaction struct {
 // OrderItem represents an item in the order
 type OrderItem struct {
 	Name       string  `json:"name"`
+	SKU        string  `json:"sku,omitempty"`
 	Quantity   float64 `json:"quantity"`
 	UnitPrice  float64 `json:"unit_price"`
 	TotalPrice float64 `json:"total_price"`
 	Category   string  `json:"category,omitempty"`
+
+	// Tags are the Monarch tags assigned to the item (e.g. "HSA/FSA")
+	Tags []string `json:"tags,omitempty"`
 }
 
 // SplitDetail represents how the transaction was split
@@ -82,6 +117,7 @@ type SplitDetail struct {
 	Amount       float64     `json:"amount"`
 	Items        []OrderItem `json:"items"`
 	Notes        string      `json:"notes"`
+	Tags         []string    `json:"tags,omitempty"` // Monarch tags applied to the split
 }
 
 // MultiDeliveryInfo tracks multi-delivery consolidation metadata
@@ -220,6 +256,110 @@ type LedgerCharge struct {
 	MatchConfidence      float64   `json:"match_confidence,omitempty"`
 	MatchedAt            time.Time `json:"matched_at,omitempty"`
 	SplitCount           int       `json:"split_count,omitempty"`
+	Provider             string    `json:"provider,omitempty"` // Set by GetUnmatchedCharges
+}
+
+// LedgerEventType names a change between consecutive ledger snapshots
+type LedgerEventType string
+
+const (
+	LedgerEventChargeAdded   LedgerEventType = "charge_added"
+	LedgerEventChargeRemoved LedgerEventType = "charge_removed"
+	LedgerEventRefundIssued  LedgerEventType = "refund_issued"
+	LedgerEventStateChanged  LedgerEventType = "state_changed"
+)
+
+// LedgerEvent records one change found when a ledger snapshot was saved
+type LedgerEvent struct {
+	ID               int64           `json:"id"`
+	OrderID          string          `json:"order_id"`
+	OrderLedgerID    int64           `json:"order_ledger_id"`              // Snapshot the change showed up in
+	PreviousLedgerID int64           `json:"previous_ledger_id,omitempty"` // Snapshot it was compared with (0 for the first)
+	SyncRunID        int64           `json:"sync_run_id,omitempty"`
+	EventType        LedgerEventType `json:"event_type"`
+
+	// Charge events
+	ChargeAmount  float64 `json:"charge_amount,omitempty"`
+	ChargeType    string  `json:"charge_type,omitempty"`
+	PaymentMethod string  `json:"payment_method,omitempty"`
+	CardType      string  `json:"card_type,omitempty"`
+	CardLastFour  string  `json:"card_last_four,omitempty"`
+
+	// State events
+	PreviousState LedgerState `json:"previous_state,omitempty"`
+	NewState      LedgerState `json:"new_state,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DiffLedgers lists the changes from previous to current, which may be nil
+// for an order's first snapshot. Charges are compared by type, payment
+// method, card and amount, so a charge moved to another card shows up as
+// removed and added. A new refund is reported as refund_issued.
+func DiffLedgers(previous, current *OrderLedger) []LedgerEvent {
+	seen := make(map[string]int)
+	if previous != nil {
+		for _, charge := range previous.Charges {
+			seen[chargeKey(charge)]++
+		}
+	}
+
+	var events []LedgerEvent
+	for _, charge := range current.Charges {
+		key := chargeKey(charge)
+		if seen[key] > 0 {
+			seen[key]--
+			continue
+		}
+		eventType := LedgerEventChargeAdded
+		if charge.ChargeType == "refund" {
+			eventType = LedgerEventRefundIssued
+		}
+		events = append(events, chargeEvent(eventType, charge))
+	}
+	if previous != nil {
+		for _, charge := range previous.Charges {
+			key := chargeKey(charge)
+			if seen[key] > 0 {
+				seen[key]--
+				events = append(events, chargeEvent(LedgerEventChargeRemoved, charge))
+			}
+		}
+		if previous.LedgerState != current.LedgerState {
+			events = append(events, LedgerEvent{
+				EventType:     LedgerEventStateChanged,
+				PreviousState: previous.LedgerState,
+				NewState:      current.LedgerState,
+			})
+		}
+	}
+
+	for i := range events {
+		events[i].OrderID = current.OrderID
+		events[i].OrderLedgerID = current.ID
+		events[i].SyncRunID = current.SyncRunID
+		if previous != nil {
+			events[i].PreviousLedgerID = previous.ID
+		}
+	}
+	return events
+}
+
+// chargeKey identifies a charge across ledger snapshots
+func chargeKey(charge LedgerCharge) string {
+	return fmt.Sprintf("%s|%s|%s|%d", charge.ChargeType, charge.PaymentMethod, charge.CardLastFour,
+		int64(math.Round(charge.ChargeAmount*100)))
+}
+
+func chargeEvent(eventType LedgerEventType, charge LedgerCharge) LedgerEvent {
+	return LedgerEvent{
+		EventType:     eventType,
+		ChargeAmount:  charge.ChargeAmount,
+		ChargeType:    charge.ChargeType,
+		PaymentMethod: charge.PaymentMethod,
+		CardType:      charge.CardType,
+		CardLastFour:  charge.CardLastFour,
+	}
 }
 
 // LedgerFilters defines filters for querying ledgers
@@ -238,3 +378,66 @@ type LedgerListResult struct {
 	Limit      int            `json:"limit"`
 	Offset     int            `json:"offset"`
 }
+
+// EvalRun is the stored summary of one `itemize eval` run. ReportJSON holds
+// the full report (confusion matrix, per-category stats, latency).
+type EvalRun struct {
+	ID               int64   `json:"id"`
+	Dataset          string  `json:"dataset"`
+	Backend          string  `json:"backend"`
+	Model            string  `json:"model"`
+	PromptHash       string  `json:"prompt_hash,omitempty"`
+	Label            string  `json:"label,omitempty"`
+	ItemCount        int     `json:"item_count"`
+	CorrectCount     int     `json:"correct_count"`
+	Accuracy         float64 `json:"accuracy"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+	DurationMs       int64   `json:"duration_ms"`
+	ReportJSON       string  `json:"report_json,omitempty"`
+	CreatedAt        string  `json:"created_at,omitempty"`
+}
+
+// CardAccount is a payment card (by last four digits) seen charged on a
+// Monarch account, and how many distinct matched orders paired them.
+type CardAccount struct {
+	CardLastFour       string `json:"card_last_four"`
+	MonarchAccountID   string `json:"monarch_account_id"`
+	MonarchAccountName string `json:"monarch_account_name,omitempty"`
+	CardType           string `json:"card_type,omitempty"`
+	MatchCount         int    `json:"match_count"`
+	LastMatchedAt      string `json:"last_matched_at,omitempty"`
+}
+
+// SyncWatermark is how far a provider account's orders have been synced.
+// Every order placed up to SettledThrough was settled by an applied sync,
+// except OpenOrders: those still pending, provisional or failed, which the
+// next incremental sync must fetch again.
+type SyncWatermark struct {
+	Provider       string      `json:"provider"`
+	Account        string      `json:"account,omitempty"`
+	SettledThrough time.Time   `json:"settled_through"`
+	OpenOrders     []OpenOrder `json:"open_orders,omitempty"`
+	RunID          int64       `json:"run_id,omitempty"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// OpenOrder is an order a sync left unsettled.
+type OpenOrder struct {
+	OrderID   string    `json:"order_id"`
+	OrderDate time.Time `json:"order_date"`
+	Status    string    `json:"status"`
+}
+
+// FetchFrom returns the date an incremental sync should fetch orders from:
+// the settled date, or the oldest open order's date when that is earlier.
+func (w *SyncWatermark) FetchFrom() time.Time {
+	from := w.SettledThrough
+	for _, order := range w.OpenOrders {
+		if !order.OrderDate.IsZero() && order.OrderDate.Before(from) {
+			from = order.OrderDate
+		}
+	}
+	return from
+}
@@ -209,8 +209,8 @@ func (s *Storage) SaveRecord(record *ProcessingRecord) error {
 	 split_count, status, error_message, item_count, match_confidence,
 	 dry_run, items_json, splits_json, multi_delivery_data,
 	 monarch_notes, category_id, category_name, order_fees_json, raw_order_json,
-	 match_diagnostics_json)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	 match_diagnostics_json, low_confidence_json, prompt_hash, account, charge_validation_json)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	if _, err := tx.Exec(attemptQuery,
@@ -240,6 +240,10 @@ func (s *Storage) SaveRecord(record *ProcessingRecord) error {
 		nullString(record.OrderFeesJSON),
 		nullString(record.RawOrderJSON),
 		nullString(record.MatchDiagnosticsJSON),
+		nullString(record.LowConfidenceJSON),
+		nullString(record.PromptHash),
+		nullString(record.Account),
+		nullString(record.ChargeValidationJSON),
 	); err != nil {
 		return err
 	}
@@ -251,8 +255,8 @@ func (s *Storage) SaveRecord(record *ProcessingRecord) error {
 	 split_count, status, error_message, item_count, match_confidence,
 	 dry_run, items_json, splits_json, multi_delivery_data,
 	 monarch_notes, category_id, category_name, order_fees_json, raw_order_json,
-	 match_diagnostics_json)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	 match_diagnostics_json, low_confidence_json, prompt_hash, account, charge_validation_json)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(order_id) DO UPDATE SET
 	 provider = excluded.provider,
 	 transaction_id = excluded.transaction_id,
@@ -277,7 +281,11 @@ func (s *Storage) SaveRecord(record *ProcessingRecord) error {
 	 category_name = excluded.category_name,
 	 order_fees_json = excluded.order_fees_json,
 	 raw_order_json = excluded.raw_order_json,
-	 match_diagnostics_json = excluded.match_diagnostics_json
+	 match_diagnostics_json = excluded.match_diagnostics_json,
+	 low_confidence_json = excluded.low_confidence_json,
+	 prompt_hash = excluded.prompt_hash,
+	 account = excluded.account,
+	 charge_validation_json = excluded.charge_validation_json
 	WHERE NOT (
 		processing_records.status = 'success'
 		AND processing_records.dry_run = 0
@@ -311,6 +319,10 @@ func (s *Storage) SaveRecord(record *ProcessingRecord) error {
 		nullString(record.OrderFeesJSON),
 		nullString(record.RawOrderJSON),
 		nullString(record.MatchDiagnosticsJSON),
+		nullString(record.LowConfidenceJSON),
+		nullString(record.PromptHash),
+		nullString(record.Account),
+		nullString(record.ChargeValidationJSON),
 	); err != nil {
 		return err
 	}
@@ -326,7 +338,7 @@ func (s *Storage) GetRecord(orderID string) (*ProcessingRecord, error) {
 	       split_count, status, error_message, item_count, match_confidence,
 	       dry_run, items_json, splits_json, multi_delivery_data,
 	       monarch_notes, category_id, category_name, order_fees_json, raw_order_json,
-	       match_diagnostics_json
+	       match_diagnostics_json, low_confidence_json, prompt_hash, account, charge_validation_json
 	FROM processing_records WHERE order_id = ?
 	`
 
@@ -343,6 +355,10 @@ func (s *Storage) GetRecord(orderID string) (*ProcessingRecord, error) {
 		orderFeesJSON     sql.NullString
 		rawOrderJSON      sql.NullString
 		matchDiagnostics  sql.NullString
+		lowConfidence     sql.NullString
+		promptHash        sql.NullString
+		account           sql.NullString
+		chargeValidation  sql.NullString
 	)
 	err := s.db.QueryRow(query, orderID).Scan(
 		&record.ID,
@@ -371,6 +387,10 @@ func (s *Storage) GetRecord(orderID string) (*ProcessingRecord, error) {
 		&orderFeesJSON,
 		&rawOrderJSON,
 		&matchDiagnostics,
+		&lowConfidence,
+		&promptHash,
+		&account,
+		&chargeValidation,
 	)
 
 	if err != nil {
@@ -414,6 +434,18 @@ func (s *Storage) GetRecord(orderID string) (*ProcessingRecord, error) {
 	if matchDiagnostics.Valid {
 		record.MatchDiagnosticsJSON = matchDiagnostics.String
 	}
+	if lowConfidence.Valid {
+		record.LowConfidenceJSON = lowConfidence.String
+	}
+	if promptHash.Valid {
+		record.PromptHash = promptHash.String
+	}
+	if account.Valid {
+		record.Account = account.String
+	}
+	if chargeValidation.Valid {
+		record.ChargeValidationJSON = chargeValidation.String
+	}
 
 	// Unmarshal JSON fields (errors ignored as these are optional enrichment fields)
 	if record.ItemsJSON != "" {
@@ -434,7 +466,7 @@ func (s *Storage) GetAttemptsByOrderID(orderID string) ([]ProcessingAttempt, err
 	       split_count, status, error_message, item_count, match_confidence,
 	       dry_run, items_json, splits_json, multi_delivery_data,
 	       monarch_notes, category_id, category_name, order_fees_json, raw_order_json,
-	       match_diagnostics_json, created_at
+	       match_diagnostics_json, low_confidence_json, prompt_hash, account, charge_validation_json, created_at
 	FROM processing_attempts
 	WHERE order_id = ?
 	ORDER BY id ASC
@@ -461,6 +493,10 @@ func (s *Storage) GetAttemptsByOrderID(orderID string) ([]ProcessingAttempt, err
 			orderFeesJSON     sql.NullString
 			rawOrderJSON      sql.NullString
 			matchDiagnostics  sql.NullString
+			lowConfidence     sql.NullString
+			promptHash        sql.NullString
+			account           sql.NullString
+			chargeValidation  sql.NullString
 		)
 
 		if err := rows.Scan(
@@ -491,6 +527,10 @@ func (s *Storage) GetAttemptsByOrderID(orderID string) ([]ProcessingAttempt, err
 			&orderFeesJSON,
 			&rawOrderJSON,
 			&matchDiagnostics,
+			&lowConfidence,
+			&promptHash,
+			&account,
+			&chargeValidation,
 			&attempt.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -531,6 +571,18 @@ func (s *Storage) GetAttemptsByOrderID(orderID string) ([]ProcessingAttempt, err
 		if matchDiagnostics.Valid {
 			attempt.MatchDiagnosticsJSON = matchDiagnostics.String
 		}
+		if lowConfidence.Valid {
+			attempt.LowConfidenceJSON = lowConfidence.String
+		}
+		if promptHash.Valid {
+			attempt.PromptHash = promptHash.String
+		}
+		if account.Valid {
+			attempt.Account = account.String
+		}
+		if chargeValidation.Valid {
+			attempt.ChargeValidationJSON = chargeValidation.String
+		}
 
 		attempts = append(attempts, attempt)
 	}
@@ -692,6 +744,18 @@ func (s *Storage) StartSyncRun(provider string, lookbackDays int, dryRun bool) (
 	return result.LastInsertId()
 }
 
+// SetSyncRunAccount records the provider account(s) a sync run read
+func (s *Storage) SetSyncRunAccount(runID int64, account string) error {
+	_, err := s.db.Exec(`UPDATE sync_runs SET account = ? WHERE id = ?`, nullString(account), runID)
+	return err
+}
+
+// AddSyncRunWarning appends a warning to a sync run
+func (s *Storage) AddSyncRunWarning(runID int64, warning string) error {
+	_, err := s.db.Exec(`UPDATE sync_runs SET warnings_json = json_insert(COALESCE(warnings_json, '[]'), '$[#]', ?) WHERE id = ?`, warning, runID)
+	return err
+}
+
 // CompleteSyncRun records the completion of a sync run
 func (s *Storage) CompleteSyncRun(runID int64, ordersFound, processed, skipped, errors int) error {
 	query := `
@@ -923,7 +987,11 @@ func (s *Storage) ListOrders(filters OrderFilters) (*OrderListResult, error) {
 		where += " AND provider = ?"
 		args = append(args, filters.Provider)
 	}
-	if filters.Status != "" {
+	switch filters.Status {
+	case "":
+	case StatusLowConfidence:
+		where += " AND low_confidence_json IS NOT NULL"
+	default:
 		where += " AND status = ?"
 		args = append(args, filters.Status)
 	}
@@ -965,7 +1033,7 @@ func (s *Storage) ListOrders(filters OrderFilters) (*OrderListResult, error) {
 		       split_count, status, error_message, item_count, match_confidence,
 		       dry_run, items_json, splits_json, multi_delivery_data,
 		       monarch_notes, category_id, category_name, order_fees_json, raw_order_json,
-		       match_diagnostics_json
+		       match_diagnostics_json, low_confidence_json, prompt_hash, account, charge_validation_json
 		FROM processing_records
 		%s
 		ORDER BY %s %s
@@ -995,6 +1063,10 @@ func (s *Storage) ListOrders(filters OrderFilters) (*OrderListResult, error) {
 			orderFeesJSON     sql.NullString
 			rawOrderJSON      sql.NullString
 			matchDiagnostics  sql.NullString
+			lowConfidence     sql.NullString
+			promptHash        sql.NullString
+			account           sql.NullString
+			chargeValidation  sql.NullString
 		)
 		err := rows.Scan(
 			&record.ID,
@@ -1023,6 +1095,10 @@ func (s *Storage) ListOrders(filters OrderFilters) (*OrderListResult, error) {
 			&orderFeesJSON,
 			&rawOrderJSON,
 			&matchDiagnostics,
+			&lowConfidence,
+			&promptHash,
+			&account,
+			&chargeValidation,
 		)
 		if err != nil {
 			return nil, err
@@ -1062,6 +1138,18 @@ func (s *Storage) ListOrders(filters OrderFilters) (*OrderListResult, error) {
 		if matchDiagnostics.Valid {
 			record.MatchDiagnosticsJSON = matchDiagnostics.String
 		}
+		if lowConfidence.Valid {
+			record.LowConfidenceJSON = lowConfidence.String
+		}
+		if promptHash.Valid {
+			record.PromptHash = promptHash.String
+		}
+		if account.Valid {
+			record.Account = account.String
+		}
+		if chargeValidation.Valid {
+			record.ChargeValidationJSON = chargeValidation.String
+		}
 
 		// Unmarshal JSON fields
 		if record.ItemsJSON != "" {
@@ -1154,7 +1242,7 @@ func (s *Storage) ListSyncRuns(limit int) ([]SyncRun, error) {
 
 	query := `
 		SELECT id, provider, started_at, completed_at, lookback_days, dry_run,
-		       orders_found, orders_processed, orders_skipped, orders_errored, status
+		       orders_found, orders_processed, orders_skipped, orders_errored, status, account, warnings_json
 		FROM sync_runs
 		ORDER BY started_at DESC
 		LIMIT ?
@@ -1169,7 +1257,7 @@ func (s *Storage) ListSyncRuns(limit int) ([]SyncRun, error) {
 	var runs []SyncRun
 	for rows.Next() {
 		var r SyncRun
-		var completedAt sql.NullString
+		var completedAt, account, warnings sql.NullString
 		err := rows.Scan(
 			&r.ID,
 			&r.Provider,
@@ -1182,6 +1270,8 @@ func (s *Storage) ListSyncRuns(limit int) ([]SyncRun, error) {
 			&r.OrdersSkipped,
 			&r.OrdersErrored,
 			&r.Status,
+			&account,
+			&warnings,
 		)
 		if err != nil {
 			return nil, err
@@ -1189,6 +1279,10 @@ func (s *Storage) ListSyncRuns(limit int) ([]SyncRun, error) {
 		if completedAt.Valid {
 			r.CompletedAt = completedAt.String
 		}
+		r.Account = account.String
+		if err := decodeSyncRunWarnings(&r, warnings); err != nil {
+			return nil, err
+		}
 		runs = append(runs, r)
 	}
 
@@ -1199,13 +1293,13 @@ func (s *Storage) ListSyncRuns(limit int) ([]SyncRun, error) {
 func (s *Storage) GetSyncRun(runID int64) (*SyncRun, error) {
 	query := `
 		SELECT id, provider, started_at, completed_at, lookback_days, dry_run,
-		       orders_found, orders_processed, orders_skipped, orders_errored, status
+		       orders_found, orders_processed, orders_skipped, orders_errored, status, account, warnings_json
 		FROM sync_runs
 		WHERE id = ?
 	`
 
 	var r SyncRun
-	var completedAt sql.NullString
+	var completedAt, account, warnings sql.NullString
 	err := s.db.QueryRow(query, runID).Scan(
 		&r.ID,
 		&r.Provider,
@@ -1218,6 +1312,8 @@ func (s *Storage) GetSyncRun(runID int64) (*SyncRun, error) {
 		&r.OrdersSkipped,
 		&r.OrdersErrored,
 		&r.Status,
+		&account,
+		&warnings,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1228,16 +1324,36 @@ func (s *Storage) GetSyncRun(runID int64) (*SyncRun, error) {
 	if completedAt.Valid {
 		r.CompletedAt = completedAt.String
 	}
+	r.Account = account.String
+	if err := decodeSyncRunWarnings(&r, warnings); err != nil {
+		return nil, err
+	}
 
 	return &r, nil
 }
 
+// decodeSyncRunWarnings reads a sync run's stored warnings
+func decodeSyncRunWarnings(run *SyncRun, warnings sql.NullString) error {
+	if !warnings.Valid || warnings.String == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(warnings.String), &run.Warnings); err != nil {
+		return fmt.Errorf("decode warnings of sync run %d: %w", run.ID, err)
+	}
+	return nil
+}
+
 // ================================================================
 // LEDGER REPOSITORY IMPLEMENTATION
 // ================================================================
 
 // SaveLedger saves a ledger snapshot with its charges in a transaction
 func (s *Storage) SaveLedger(ledger *OrderLedger) error {
+	previous, err := s.GetLatestLedger(ledger.OrderID)
+	if err != nil {
+		return fmt.Errorf("failed to get previous ledger: %w", err)
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -1325,6 +1441,33 @@ func (s *Storage) SaveLedger(ledger *OrderLedger) error {
 		charge.ID = chargeID
 	}
 
+	// Record what changed since the previous snapshot
+	for _, event := range DiffLedgers(previous, ledger) {
+		_, err := tx.Exec(`
+			INSERT INTO ledger_events
+			(order_id, order_ledger_id, previous_ledger_id, sync_run_id, event_type,
+			 charge_amount, charge_type, payment_method, card_type, card_last_four,
+			 previous_state, new_state)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			event.OrderID,
+			event.OrderLedgerID,
+			nullInt64(event.PreviousLedgerID),
+			nullInt64(event.SyncRunID),
+			event.EventType,
+			event.ChargeAmount,
+			nullString(event.ChargeType),
+			nullString(event.PaymentMethod),
+			nullString(event.CardType),
+			nullString(event.CardLastFour),
+			nullString(string(event.PreviousState)),
+			nullString(string(event.NewState)),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert ledger event: %w", err)
+		}
+	}
+
 	return tx.Commit()
 }
 
@@ -1618,45 +1761,32 @@ func (s *Storage) UpdateChargeMatch(chargeID int64, transactionID string, confid
 	return err
 }
 
-// GetUnmatchedCharges returns charges that haven't been matched to Monarch transactions
+// GetUnmatchedCharges returns payment charges in each order's latest ledger
+// that haven't been matched to Monarch transactions. Gift card payments never
+// reach a bank account and are left out. Older snapshots are ignored: every
+// sync saves a new one, so their charges would repeat the latest.
 func (s *Storage) GetUnmatchedCharges(provider string, limit int) ([]LedgerCharge, error) {
 	if limit <= 0 {
 		limit = 100
 	}
 
-	var query string
-	var args []interface{}
-
-	if provider != "" {
-		query = `
-			SELECT c.id, c.order_ledger_id, c.order_id, c.sync_run_id, c.charge_sequence,
-			       c.charge_amount, c.charge_type, c.payment_method, c.card_type, c.card_last_four,
-			       c.monarch_transaction_id, c.is_matched, c.match_confidence, c.matched_at, c.split_count
-			FROM ledger_charges c
-			JOIN order_ledgers l ON c.order_ledger_id = l.id
-			WHERE c.is_matched = 0
-			  AND c.charge_type = 'payment'
-			  AND l.provider = ?
-			ORDER BY l.fetched_at DESC
-			LIMIT ?
-		`
-		args = []interface{}{provider, limit}
-	} else {
-		query = `
-			SELECT c.id, c.order_ledger_id, c.order_id, c.sync_run_id, c.charge_sequence,
-			       c.charge_amount, c.charge_type, c.payment_method, c.card_type, c.card_last_four,
-			       c.monarch_transaction_id, c.is_matched, c.match_confidence, c.matched_at, c.split_count
-			FROM ledger_charges c
-			JOIN order_ledgers l ON c.order_ledger_id = l.id
-			WHERE c.is_matched = 0
-			  AND c.charge_type = 'payment'
-			ORDER BY l.fetched_at DESC
-			LIMIT ?
-		`
-		args = []interface{}{limit}
-	}
+	query := `
+		SELECT c.id, c.order_ledger_id, c.order_id, c.sync_run_id, c.charge_sequence,
+		       c.charge_amount, c.charge_type, c.payment_method, c.card_type, c.card_last_four,
+		       c.charged_at, c.monarch_transaction_id, c.is_matched, c.match_confidence, c.matched_at,
+		       c.split_count, l.provider
+		FROM ledger_charges c
+		JOIN order_ledgers l ON c.order_ledger_id = l.id
+		WHERE c.is_matched = 0
+		  AND c.charge_type = 'payment'
+		  AND c.payment_method != 'GIFTCARD'
+		  AND l.id = (SELECT MAX(id) FROM order_ledgers WHERE order_id = l.order_id)
+		  AND (? = '' OR l.provider = ?)
+		ORDER BY l.fetched_at DESC, c.charge_sequence
+		LIMIT ?
+	`
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.Query(query, provider, provider, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1668,7 +1798,7 @@ func (s *Storage) GetUnmatchedCharges(provider string, limit int) ([]LedgerCharg
 		var syncRunID sql.NullInt64
 		var txID sql.NullString
 		var cardType, cardLastFour sql.NullString
-		var matchedAt sql.NullTime
+		var chargedAt, matchedAt sql.NullTime
 
 		err := rows.Scan(
 			&charge.ID,
@@ -1681,11 +1811,13 @@ func (s *Storage) GetUnmatchedCharges(provider string, limit int) ([]LedgerCharg
 			&charge.PaymentMethod,
 			&cardType,
 			&cardLastFour,
+			&chargedAt,
 			&txID,
 			&charge.IsMatched,
 			&charge.MatchConfidence,
 			&matchedAt,
 			&charge.SplitCount,
+			&charge.Provider,
 		)
 		if err != nil {
 			return nil, err
@@ -1703,6 +1835,9 @@ func (s *Storage) GetUnmatchedCharges(provider string, limit int) ([]LedgerCharg
 		if cardLastFour.Valid {
 			charge.CardLastFour = cardLastFour.String
 		}
+		if chargedAt.Valid {
+			charge.ChargedAt = chargedAt.Time
+		}
 		if matchedAt.Valid {
 			charge.MatchedAt = matchedAt.Time
 		}
@@ -1785,6 +1920,297 @@ func (s *Storage) getChargesForLedger(ledgerID int64) ([]LedgerCharge, error) {
 	return charges, rows.Err()
 }
 
+// ================================================================
+// EVAL RUN METHODS
+// ================================================================
+
+// SaveEvalRun stores an evaluation run and sets its ID
+func (s *Storage) SaveEvalRun(run *EvalRun) error {
+	query := `
+		INSERT INTO eval_runs
+		(dataset, backend, model, prompt_hash, label, item_count, correct_count, accuracy,
+		 prompt_tokens, completion_tokens, cost_usd, duration_ms, report_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := s.db.Exec(query,
+		run.Dataset,
+		run.Backend,
+		run.Model,
+		nullString(run.PromptHash),
+		nullString(run.Label),
+		run.ItemCount,
+		run.CorrectCount,
+		run.Accuracy,
+		run.PromptTokens,
+		run.CompletionTokens,
+		run.CostUSD,
+		run.DurationMs,
+		nullString(run.ReportJSON),
+	)
+	if err != nil {
+		return err
+	}
+	run.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListEvalRuns returns recent evaluation runs (newest first), optionally
+// restricted to one dataset
+func (s *Storage) ListEvalRuns(dataset string, limit int) ([]EvalRun, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	query := `
+		SELECT id, dataset, backend, model, prompt_hash, label, item_count, correct_count,
+		       accuracy, prompt_tokens, completion_tokens, cost_usd, duration_ms, report_json, created_at
+		FROM eval_runs
+		WHERE (? = '' OR dataset = ?)
+		ORDER BY id DESC
+		LIMIT ?
+	`
+	rows, err := s.db.Query(query, dataset, dataset, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var runs []EvalRun
+	for rows.Next() {
+		var run EvalRun
+		var promptHash, label, reportJSON sql.NullString
+		if err := rows.Scan(
+			&run.ID,
+			&run.Dataset,
+			&run.Backend,
+			&run.Model,
+			&promptHash,
+			&label,
+			&run.ItemCount,
+			&run.CorrectCount,
+			&run.Accuracy,
+			&run.PromptTokens,
+			&run.CompletionTokens,
+			&run.CostUSD,
+			&run.DurationMs,
+			&reportJSON,
+			&run.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		run.PromptHash = promptHash.String
+		run.Label = label.String
+		run.ReportJSON = reportJSON.String
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// GetLedgerEvents retrieves the changes between an order's ledger snapshots (oldest first)
+func (s *Storage) GetLedgerEvents(orderID string) ([]LedgerEvent, error) {
+	query := `
+		SELECT id, order_id, order_ledger_id, previous_ledger_id, sync_run_id, event_type,
+		       charge_amount, charge_type, payment_method, card_type, card_last_four,
+		       previous_state, new_state, created_at
+		FROM ledger_events
+		WHERE order_id = ?
+		ORDER BY id
+	`
+	rows, err := s.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var events []LedgerEvent
+	for rows.Next() {
+		var event LedgerEvent
+		var previousLedgerID, syncRunID sql.NullInt64
+		var chargeAmount sql.NullFloat64
+		var chargeType, paymentMethod, cardType, cardLastFour, previousState, newState sql.NullString
+		if err := rows.Scan(
+			&event.ID,
+			&event.OrderID,
+			&event.OrderLedgerID,
+			&previousLedgerID,
+			&syncRunID,
+			&event.EventType,
+			&chargeAmount,
+			&chargeType,
+			&paymentMethod,
+			&cardType,
+			&cardLastFour,
+			&previousState,
+			&newState,
+			&event.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		event.PreviousLedgerID = previousLedgerID.Int64
+		event.SyncRunID = syncRunID.Int64
+		event.ChargeAmount = chargeAmount.Float64
+		event.ChargeType = chargeType.String
+		event.PaymentMethod = paymentMethod.String
+		event.CardType = cardType.String
+		event.CardLastFour = cardLastFour.String
+		event.PreviousState = LedgerState(previousState.String)
+		event.NewState = LedgerState(newState.String)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// ================================================================
+// CARD ACCOUNT METHODS
+// ================================================================
+
+// RecordCardAccount records that an order's charge on a card posted to a
+// Monarch account; match_count is the number of distinct orders recorded
+func (s *Storage) RecordCardAccount(account *CardAccount, orderID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec(`
+		INSERT INTO card_accounts
+		(card_last_four, monarch_account_id, monarch_account_name, card_type, match_count, last_matched_at)
+		VALUES (?, ?, ?, ?, 0, CURRENT_TIMESTAMP)
+		ON CONFLICT(card_last_four, monarch_account_id) DO UPDATE SET
+			monarch_account_name = COALESCE(excluded.monarch_account_name, monarch_account_name),
+			card_type = COALESCE(excluded.card_type, card_type),
+			last_matched_at = CURRENT_TIMESTAMP
+	`,
+		account.CardLastFour,
+		account.MonarchAccountID,
+		nullString(account.MonarchAccountName),
+		nullString(account.CardType),
+	); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT OR IGNORE INTO card_account_orders (card_last_four, monarch_account_id, order_id)
+		VALUES (?, ?, ?)
+	`, account.CardLastFour, account.MonarchAccountID, orderID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE card_accounts SET match_count = (
+			SELECT COUNT(*) FROM card_account_orders
+			WHERE card_last_four = ? AND monarch_account_id = ?
+		)
+		WHERE card_last_four = ? AND monarch_account_id = ?
+	`, account.CardLastFour, account.MonarchAccountID, account.CardLastFour, account.MonarchAccountID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListCardAccounts returns every learned card-to-account pairing, most
+// matched first
+func (s *Storage) ListCardAccounts() ([]CardAccount, error) {
+	query := `
+		SELECT card_last_four, monarch_account_id, monarch_account_name, card_type,
+		       match_count, last_matched_at
+		FROM card_accounts
+		ORDER BY card_last_four, match_count DESC
+	`
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var accounts []CardAccount
+	for rows.Next() {
+		var account CardAccount
+		var name, cardType sql.NullString
+		if err := rows.Scan(
+			&account.CardLastFour,
+			&account.MonarchAccountID,
+			&name,
+			&cardType,
+			&account.MatchCount,
+			&account.LastMatchedAt,
+		); err != nil {
+			return nil, err
+		}
+		account.MonarchAccountName = name.String
+		account.CardType = cardType.String
+		accounts = append(accounts, account)
+	}
+	return accounts, rows.Err()
+}
+
+// ================================================================
+// SYNC WATERMARK METHODS
+// ================================================================
+
+// GetSyncWatermark returns a provider account's watermark, or nil when no
+// sync of it has been recorded
+func (s *Storage) GetSyncWatermark(provider, account string) (*SyncWatermark, error) {
+	query := `
+		SELECT provider, account, settled_through, open_orders_json, run_id, updated_at
+		FROM sync_watermarks
+		WHERE provider = ? AND account = ?
+	`
+	watermark := &SyncWatermark{}
+	var openOrders sql.NullString
+	var runID sql.NullInt64
+	err := s.db.QueryRow(query, provider, account).Scan(
+		&watermark.Provider,
+		&watermark.Account,
+		&watermark.SettledThrough,
+		&openOrders,
+		&runID,
+		&watermark.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	watermark.RunID = runID.Int64
+	if openOrders.Valid && openOrders.String != "" {
+		if err := json.Unmarshal([]byte(openOrders.String), &watermark.OpenOrders); err != nil {
+			return nil, fmt.Errorf("failed to parse open orders: %w", err)
+		}
+	}
+	return watermark, nil
+}
+
+// SaveSyncWatermark creates or replaces a provider account's watermark
+func (s *Storage) SaveSyncWatermark(watermark *SyncWatermark) error {
+	var openOrders interface{}
+	if len(watermark.OpenOrders) > 0 {
+		data, err := json.Marshal(watermark.OpenOrders)
+		if err != nil {
+			return fmt.Errorf("failed to marshal open orders: %w", err)
+		}
+		openOrders = string(data)
+	}
+	query := `
+		INSERT INTO sync_watermarks
+		(provider, account, settled_through, open_orders_json, run_id, updated_at)
+		VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(provider, account) DO UPDATE SET
+			settled_through = excluded.settled_through,
+			open_orders_json = excluded.open_orders_json,
+			run_id = excluded.run_id,
+			updated_at = CURRENT_TIMESTAMP
+	`
+	_, err := s.db.Exec(query,
+		watermark.Provider,
+		watermark.Account,
+		watermark.SettledThrough,
+		openOrders,
+		nullInt64(watermark.RunID),
+	)
+	return err
+}
+
 // Helper functions for nullable values
 func nullInt64(v int64) interface{} {
 	if v == 0 {
@@ -1156,3 +1156,246 @@ func TestStorage_AuditColumns_UpdateExisting(t *testing.T) {
 	assert.Equal(t, "Personal Care:\n- Shampoo $8.99", retrieved.MonarchNotes)
 	assert.Equal(t, `{"orderId":"walmart-456"}`, retrieved.RawOrderJSON)
 }
+
+func TestStorage_ListOrders_LowConfidence(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Now()
+	lowConfidence := `[{"name":"Mystery Gadget","category_id":"cat_1","category_name":"Shopping","confidence":0.42,"flagged_for_review":true}]`
+	require.NoError(t, store.SaveRecord(&ProcessingRecord{OrderID: "ORDER-1", Provider: "walmart", Status: "success", OrderDate: now, ProcessedAt: now, LowConfidenceJSON: lowConfidence}))
+	require.NoError(t, store.SaveRecord(&ProcessingRecord{OrderID: "ORDER-2", Provider: "walmart", Status: "success", OrderDate: now, ProcessedAt: now}))
+
+	result, err := store.ListOrders(OrderFilters{Status: StatusLowConfidence})
+	require.NoError(t, err)
+	require.Equal(t, 1, result.TotalCount)
+	assert.Equal(t, "ORDER-1", result.Orders[0].OrderID)
+	assert.Equal(t, lowConfidence, result.Orders[0].LowConfidenceJSON)
+
+	record, err := store.GetRecord("ORDER-1")
+	require.NoError(t, err)
+	assert.Equal(t, lowConfidence, record.LowConfidenceJSON)
+
+	attempts, err := store.GetAttemptsByOrderID("ORDER-1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, lowConfidence, attempts[0].LowConfidenceJSON)
+}
+
+func TestStorage_EvalRuns(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	first := &EvalRun{Dataset: "groceries.yaml", Backend: "openai", Model: "gpt-5.4-nano", PromptHash: "abc123", ItemCount: 10, CorrectCount: 8, Accuracy: 0.8, ReportJSON: `{"items":10}`}
+	second := &EvalRun{Dataset: "groceries.yaml", Backend: "anthropic", Model: "claude-haiku-4-5-20251001", ItemCount: 10, CorrectCount: 9, Accuracy: 0.9, CostUSD: 0.0012}
+	other := &EvalRun{Dataset: "other.yaml", Backend: "openai", Model: "gpt-5.4-nano", ItemCount: 3}
+	require.NoError(t, store.SaveEvalRun(first))
+	require.NoError(t, store.SaveEvalRun(second))
+	require.NoError(t, store.SaveEvalRun(other))
+	assert.NotZero(t, first.ID)
+
+	runs, err := store.ListEvalRuns("groceries.yaml", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, "anthropic", runs[0].Backend, "newest first")
+	assert.Equal(t, 0.0012, runs[0].CostUSD)
+	assert.Equal(t, "abc123", runs[1].PromptHash)
+	assert.Equal(t, `{"items":10}`, runs[1].ReportJSON)
+	assert.NotEmpty(t, runs[1].CreatedAt)
+
+	all, err := store.ListEvalRuns("", 0)
+	require.NoError(t, err)
+	assert.Len(t, all, 3)
+}
+
+func TestStorage_SaveRecord_PromptHash(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Now()
+	require.NoError(t, store.SaveRecord(&ProcessingRecord{OrderID: "ORDER-1", Provider: "walmart", Status: "success", OrderDate: now, ProcessedAt: now, PromptHash: "3f2a9c1b7d4e"}))
+
+	record, err := store.GetRecord("ORDER-1")
+	require.NoError(t, err)
+	assert.Equal(t, "3f2a9c1b7d4e", record.PromptHash)
+
+	attempts, err := store.GetAttemptsByOrderID("ORDER-1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "3f2a9c1b7d4e", attempts[0].PromptHash)
+
+	list, err := store.ListOrders(OrderFilters{})
+	require.NoError(t, err)
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, "3f2a9c1b7d4e", list.Orders[0].PromptHash)
+}
+
+func TestStorage_SaveRecord_ChargeValidation(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	now := time.Now()
+	validation := `{"valid":false,"bank_charges_sum":40,"expected_sum":50}`
+	require.NoError(t, store.SaveRecord(&ProcessingRecord{OrderID: "ORDER-1", Provider: "costco", Status: "skipped", OrderDate: now, ProcessedAt: now, ChargeValidationJSON: validation}))
+
+	record, err := store.GetRecord("ORDER-1")
+	require.NoError(t, err)
+	assert.Equal(t, validation, record.ChargeValidationJSON)
+
+	attempts, err := store.GetAttemptsByOrderID("ORDER-1")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, validation, attempts[0].ChargeValidationJSON)
+
+	list, err := store.ListOrders(OrderFilters{})
+	require.NoError(t, err)
+	require.Len(t, list.Orders, 1)
+	assert.Equal(t, validation, list.Orders[0].ChargeValidationJSON)
+}
+
+func TestStorage_Account(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	runID, err := store.StartSyncRun("Amazon", 14, false)
+	require.NoError(t, err)
+	require.NoError(t, store.SetSyncRunAccount(runID, "mine,wife"))
+
+	now := time.Now()
+	require.NoError(t, store.SaveRecord(&ProcessingRecord{RunID: runID, OrderID: "111-0000000-0000001", Provider: "Amazon", Status: "success", OrderDate: now, ProcessedAt: now, Account: "wife"}))
+
+	run, err := store.GetSyncRun(runID)
+	require.NoError(t, err)
+	assert.Equal(t, "mine,wife", run.Account)
+
+	runs, err := store.ListSyncRuns(10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "mine,wife", runs[0].Account)
+
+	record, err := store.GetRecord("111-0000000-0000001")
+	require.NoError(t, err)
+	assert.Equal(t, "wife", record.Account)
+
+	attempts, err := store.GetAttemptsByOrderID("111-0000000-0000001")
+	require.NoError(t, err)
+	require.Len(t, attempts, 1)
+	assert.Equal(t, "wife", attempts[0].Account)
+}
+
+func TestStorage_SyncRunWarnings(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	runID, err := store.StartSyncRun("Walmart", 14, false)
+	require.NoError(t, err)
+
+	run, err := store.GetSyncRun(runID)
+	require.NoError(t, err)
+	assert.Empty(t, run.Warnings)
+
+	require.NoError(t, store.AddSyncRunWarning(runID, "first"))
+	require.NoError(t, store.AddSyncRunWarning(runID, "second"))
+
+	run, err = store.GetSyncRun(runID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, run.Warnings)
+
+	runs, err := store.ListSyncRuns(10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, []string{"first", "second"}, runs[0].Warnings)
+}
+
+func TestStorage_CardAccounts(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	require.NoError(t, store.RecordCardAccount(&CardAccount{CardLastFour: "1211", MonarchAccountID: "acc-prime", MonarchAccountName: "Chase Prime Visa", CardType: "VISA"}, "order-1"))
+	require.NoError(t, store.RecordCardAccount(&CardAccount{CardLastFour: "1211", MonarchAccountID: "acc-prime"}, "order-2"))
+	require.NoError(t, store.RecordCardAccount(&CardAccount{CardLastFour: "1211", MonarchAccountID: "acc-prime"}, "order-2"))
+	require.NoError(t, store.RecordCardAccount(&CardAccount{CardLastFour: "1211", MonarchAccountID: "acc-other"}, "order-3"))
+
+	accounts, err := store.ListCardAccounts()
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	assert.Equal(t, "acc-prime", accounts[0].MonarchAccountID, "most matched first")
+	assert.Equal(t, 2, accounts[0].MatchCount, "an order recorded twice counts once")
+	assert.Equal(t, "Chase Prime Visa", accounts[0].MonarchAccountName, "kept when a later match omits it")
+	assert.Equal(t, "VISA", accounts[0].CardType)
+	assert.NotEmpty(t, accounts[0].LastMatchedAt)
+	assert.Equal(t, 1, accounts[1].MatchCount)
+}
+
+func TestStorage_SyncWatermarks(t *testing.T) {
+	tmpDB := createTempDB(t)
+	defer os.Remove(tmpDB)
+
+	store, err := NewStorage(tmpDB)
+	require.NoError(t, err)
+	defer store.Close()
+
+	watermark, err := store.GetSyncWatermark("Amazon", "wife")
+	require.NoError(t, err)
+	assert.Nil(t, watermark, "no watermark before the first sync")
+
+	settled := time.Date(2026, time.July, 10, 0, 0, 0, 0, time.UTC)
+	openDate := time.Date(2026, time.July, 2, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, store.SaveSyncWatermark(&SyncWatermark{
+		Provider:       "Amazon",
+		Account:        "wife",
+		SettledThrough: settled,
+		OpenOrders:     []OpenOrder{{OrderID: "112-1", OrderDate: openDate, Status: "provisional"}},
+		RunID:          7,
+	}))
+	require.NoError(t, store.SaveSyncWatermark(&SyncWatermark{Provider: "Amazon", SettledThrough: settled.AddDate(0, 0, 3)}))
+
+	watermark, err = store.GetSyncWatermark("Amazon", "wife")
+	require.NoError(t, err)
+	require.NotNil(t, watermark)
+	assert.True(t, settled.Equal(watermark.SettledThrough))
+	require.Len(t, watermark.OpenOrders, 1)
+	assert.Equal(t, "112-1", watermark.OpenOrders[0].OrderID)
+	assert.Equal(t, int64(7), watermark.RunID)
+	assert.True(t, openDate.Equal(watermark.FetchFrom()), "open orders widen the fetch")
+
+	// Saving again replaces the account's watermark
+	require.NoError(t, store.SaveSyncWatermark(&SyncWatermark{Provider: "Amazon", Account: "wife", SettledThrough: settled.AddDate(0, 0, 1)}))
+	watermark, err = store.GetSyncWatermark("Amazon", "wife")
+	require.NoError(t, err)
+	assert.Empty(t, watermark.OpenOrders)
+	assert.True(t, settled.AddDate(0, 0, 1).Equal(watermark.FetchFrom()))
+
+	other, err := store.GetSyncWatermark("Amazon", "")
+	require.NoError(t, err)
+	require.NotNil(t, other)
+	assert.True(t, settled.AddDate(0, 0, 3).Equal(other.SettledThrough))
+}